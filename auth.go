@@ -69,24 +69,26 @@ func Authenticate(username, password, ipAddress string) (bool, error) {
 	// Verifica as credenciais
 	if validPassword, exists := ValidCredentials[username]; exists {
 		if password == validPassword {
+			RecordAttempt(Attempt{Addr: ipAddress, Username: username, Password: password, Success: true})
 			return true, nil // Sucesso no login
 		}
 	}
 
 	// Se falhou, registra a tentativa de login falha
-	recordFailedLogin(username, ipAddress)
+	recordFailedLogin(username, password, ipAddress)
 
 	// Retorna erro de credenciais inválidas
 	return false, errors.New("usuário ou senha inválidos")
 }
 
-// Função para registrar uma tentativa de login falha
-func recordFailedLogin(username, ipAddress string) {
+// Função para registrar uma tentativa de login falha; a senha vai para o store de credenciais
+func recordFailedLogin(username, password, ipAddress string) {
 	query := `INSERT INTO failed_logins (username, ip_address) VALUES (?, ?)`
 	_, err := db.Exec(query, username, ipAddress)
 	if err != nil {
 		log.Printf("Erro ao registrar falha de login: %v", err)
 	}
+	RecordAttempt(Attempt{Addr: ipAddress, Username: username, Password: password})
 }
 
 // Função para obter o número de tentativas falhas de login para um IP específico
@@ -133,10 +135,10 @@ package auth
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -175,9 +177,9 @@ func LoadUsersFromJSON(filePath string) ([]User, error) {
 	return usersData.Users, nil
 }
 
-// Função para verificar se as credenciais são válidas
+// Função para verificar se as credenciais são válidas no banco de usuários padrão
 func Authenticate(username, password string) (bool, error) {
-	users, err := LoadUsersFromJSON("./sers.json")
+	store, err := currentUsers()
 	if err != nil {
 		return false, err
 	}
@@ -186,106 +188,69 @@ func Authenticate(username, password string) (bool, error) {
 	username = strings.TrimSpace(username)
 	password = strings.TrimSpace(password)
 
-	for _, user := range users {
-		if user.Username == username {
-			if user.Status == "inactive" {
-				return false, errors.New("conta inativa")
-			}
-			if password == user.Password {
-				return true, nil
-			}
-			return false, errors.New("senha incorreta")
-		}
+	if err := store.Authenticate(username, password); err != nil {
+		return false, err
 	}
-
-	return false, errors.New("usuário não encontrado")
+	return true, nil
 }
 
 // Função para adicionar um novo usuário
 func AddUser(username, password, status string) error {
-	users, err := LoadUsersFromJSON("./sers.json")
+	store, err := currentUsers()
 	if err != nil {
 		return err
 	}
+	return store.Add(username, password, status)
+}
 
-	for _, user := range users {
-		if user.Username == username {
-			return errors.New("usuário já existe")
-		}
+// Função para salvar os usuários no arquivo JSON; grava um arquivo temporário e renomeia,
+// assim o arquivo nunca fica truncado ou com restos da versão anterior
+func saveUsersToJSON(filePath string, users []User) error {
+	if users == nil {
+		users = []User{}
 	}
-
-	newUser := User{
-		Username: username,
-		Password: password,
-		Status:   status,
+	data, err := json.MarshalIndent(UsersData{Users: users}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao salvar os usuários no arquivo JSON: %v", err)
 	}
 
-	users = append(users, newUser)
-	return saveUsersToJSON(users)
-}
-
-// Função para salvar os usuários no arquivo JSON
-func saveUsersToJSON(users []User) error {
-	file, err := os.OpenFile("./sers.json", os.O_RDWR|os.O_CREATE, 0666)
+	temp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*")
 	if err != nil {
-		return fmt.Errorf("erro ao abrir o arquivo %s: %v", "./sers.json", err)
+		return fmt.Errorf("erro ao abrir o arquivo %s: %v", filePath, err)
 	}
-	defer file.Close()
+	defer os.Remove(temp.Name())
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(UsersData{Users: users}); err != nil {
+	if _, err := temp.Write(append(data, '\n')); err != nil {
+		temp.Close()
 		return fmt.Errorf("erro ao salvar os usuários no arquivo JSON: %v", err)
 	}
-
+	if err := temp.Chmod(0600); err != nil {
+		temp.Close()
+		return fmt.Errorf("erro ao salvar os usuários no arquivo JSON: %v", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("erro ao salvar os usuários no arquivo JSON: %v", err)
+	}
+	if err := os.Rename(temp.Name(), filePath); err != nil {
+		return fmt.Errorf("erro ao substituir o arquivo %s: %v", filePath, err)
+	}
 	return nil
 }
 
 // Função para editar um usuário existente
 func EditUser(username, newPassword, newStatus string) error {
-	users, err := LoadUsersFromJSON("./sers.json")
+	store, err := currentUsers()
 	if err != nil {
 		return err
 	}
-
-	var found bool
-	for i, user := range users {
-		if user.Username == username {
-			users[i].Password = newPassword
-			users[i].Status = newStatus
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		return errors.New("usuário não encontrado")
-	}
-
-	return saveUsersToJSON(users)
+	return store.Edit(username, newPassword, newStatus)
 }
 
 // Função para remover um usuário
 func RemoveUser(username string) error {
-	users, err := LoadUsersFromJSON("./sers.json")
+	store, err := currentUsers()
 	if err != nil {
 		return err
 	}
-
-	var indexToRemove int
-	var found bool
-	for i, user := range users {
-		if user.Username == username {
-			users = append(users[:i], users[i+1:]...)
-			found = true
-			break
-		}
-	}
-
-	if !found {
-		return errors.New("usuário não encontrado")
-	}
-
-	return saveUsersToJSON(users)
+	return store.Remove(username)
 }
-
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+// Agrupamentos dos relatórios e wordlists de credenciais
+const (
+	CredentialUsername = "usernames"
+	CredentialPassword = "passwords"
+	CredentialPair     = "pairs"
+)
+
+// Attempt é uma tentativa de login vista por um dos serviços
+type Attempt struct {
+	Time     time.Time
+	Protocol string
+	Addr     string // IP ou IP:porta de origem
+	Username string
+	Password string
+	Client   string // Fingerprint do cliente (versão SSH, User-Agent, ...)
+	Success  bool
+}
+
+// CredentialFilter restringe as tentativas consideradas nos relatórios
+type CredentialFilter struct {
+	Since    time.Time // Inclusivo
+	Until    time.Time // Exclusivo
+	Protocol string
+}
+
+// CredentialStat resume um usuário, uma senha ou um par usuário:senha
+type CredentialStat struct {
+	Value     string `json:"value"`
+	Attempts  int    `json:"attempts"`
+	Sources   int    `json:"sources"`   // IPs distintos
+	Successes int    `json:"successes"` // Tentativas aceitas pela política
+	FirstSeen string `json:"first_seen"`
+	LastSeen  string `json:"last_seen"`
+}
+
+// CredentialStore guarda cada par usuário/senha tentado no banco de eventos
+type CredentialStore struct {
+	db      *sql.DB
+	closeDB bool // Banco aberto por OpenCredentialStore, fechado no Close
+}
+
+// OpenCredentialStore abre o banco de eventos e cria a tabela credentials
+func OpenCredentialStore(dbPath string) (*CredentialStore, error) {
+	db, err := logging.OpenDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewCredentialStore(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	store.closeDB = true
+	return store, nil
+}
+
+// NewCredentialStore cria a tabela credentials num banco já aberto, que continua sendo de quem o abriu
+func NewCredentialStore(db *sql.DB) (*CredentialStore, error) {
+	for _, statement := range []string{
+		`CREATE TABLE IF NOT EXISTS credentials (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			protocol TEXT,
+			username TEXT,
+			password TEXT,
+			client TEXT,
+			success INTEGER NOT NULL DEFAULT 0
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_credentials_timestamp ON credentials(timestamp)",
+		"CREATE INDEX IF NOT EXISTS idx_credentials_pair ON credentials(username, password)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			return nil, fmt.Errorf("erro ao criar tabela de credenciais: %v", err)
+		}
+	}
+	return &CredentialStore{db: db}, nil
+}
+
+// Record guarda uma tentativa de login
+func (s *CredentialStore) Record(attempt Attempt) error {
+	if attempt.Time.IsZero() {
+		attempt.Time = time.Now()
+	}
+	_, err := s.db.Exec("INSERT INTO credentials (timestamp, ip, protocol, username, password, client, success) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		attempt.Time.In(time.Local).Format(logging.TimestampLayout), sourceIP(attempt.Addr), strings.ToLower(attempt.Protocol),
+		attempt.Username, attempt.Password, attempt.Client, attempt.Success)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar credencial: %v", err)
+	}
+	return nil
+}
+
+// Top retorna os usuários, senhas ou pares mais tentados no período
+func (s *CredentialStore) Top(kind string, filter CredentialFilter, limit int) ([]CredentialStat, error) {
+	column, err := credentialColumn(kind)
+	if err != nil {
+		return nil, err
+	}
+	where, args := filter.sql()
+	query := fmt.Sprintf(`SELECT %s, COUNT(*), COUNT(DISTINCT ip), SUM(success), MIN(timestamp), MAX(timestamp)
+		FROM credentials%s GROUP BY 1 ORDER BY 2 DESC, 1 LIMIT ?`, column, where)
+	return s.stats(query, append(args, limit)...)
+}
+
+// New retorna os pares vistos pela primeira vez no período (nunca tentados antes de Since, em nenhum protocolo)
+func (s *CredentialStore) New(filter CredentialFilter, limit int) ([]CredentialStat, error) {
+	where, args := filter.sql()
+	if !filter.Since.IsZero() {
+		if where == "" {
+			where = " WHERE "
+		} else {
+			where += " AND "
+		}
+		where += `NOT EXISTS (SELECT 1 FROM credentials AS old
+			WHERE old.username = credentials.username AND old.password = credentials.password AND old.timestamp < ?)`
+		args = append(args, filter.Since.In(time.Local).Format(logging.TimestampLayout))
+	}
+	query := fmt.Sprintf(`SELECT username || ':' || password, COUNT(*), COUNT(DISTINCT ip), SUM(success), MIN(timestamp), MAX(timestamp)
+		FROM credentials%s GROUP BY username, password ORDER BY 5 DESC LIMIT ?`, where)
+	return s.stats(query, append(args, limit)...)
+}
+
+func (s *CredentialStore) stats(query string, args ...interface{}) ([]CredentialStat, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar credenciais: %v", err)
+	}
+	defer rows.Close()
+
+	var stats []CredentialStat
+	for rows.Next() {
+		var stat CredentialStat
+		if err := rows.Scan(&stat.Value, &stat.Attempts, &stat.Sources, &stat.Successes, &stat.FirstSeen, &stat.LastSeen); err != nil {
+			return nil, fmt.Errorf("erro ao ler credencial: %v", err)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// Wordlist percorre os valores distintos do tipo pedido, do mais tentado para o menos tentado
+func (s *CredentialStore) Wordlist(kind string, filter CredentialFilter, fn func(string) error) error {
+	column, err := credentialColumn(kind)
+	if err != nil {
+		return err
+	}
+	where, args := filter.sql()
+	rows, err := s.db.Query(fmt.Sprintf("SELECT %s FROM credentials%s GROUP BY 1 ORDER BY COUNT(*) DESC, 1", column, where), args...)
+	if err != nil {
+		return fmt.Errorf("erro ao consultar credenciais: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return fmt.Errorf("erro ao ler credencial: %v", err)
+		}
+		if value == "" {
+			continue
+		}
+		if err := fn(value); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Close fecha o banco se ele foi aberto por OpenCredentialStore
+func (s *CredentialStore) Close() error {
+	if s.closeDB {
+		return s.db.Close()
+	}
+	return nil
+}
+
+func credentialColumn(kind string) (string, error) {
+	switch kind {
+	case CredentialUsername:
+		return "username", nil
+	case CredentialPassword:
+		return "password", nil
+	case CredentialPair:
+		return "username || ':' || password", nil
+	}
+	return "", fmt.Errorf("agrupamento de credenciais desconhecido: %s", kind)
+}
+
+// sql monta a cláusula WHERE com os filtros preenchidos
+func (f CredentialFilter) sql() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, f.Since.In(time.Local).Format(logging.TimestampLayout))
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, f.Until.In(time.Local).Format(logging.TimestampLayout))
+	}
+	if f.Protocol != "" {
+		conditions = append(conditions, "protocol = ?")
+		args = append(args, strings.ToLower(f.Protocol))
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+var (
+	defaultStore   *CredentialStore
+	defaultStoreMu sync.RWMutex
+)
+
+// SetCredentialStore define onde Check e RecordAttempt guardam as tentativas
+func SetCredentialStore(store *CredentialStore) {
+	defaultStoreMu.Lock()
+	defer defaultStoreMu.Unlock()
+	defaultStore = store
+}
+
+// RecordAttempt guarda a tentativa no store padrão, se houver um
+func RecordAttempt(attempt Attempt) {
+	defaultStoreMu.RLock()
+	store := defaultStore
+	defaultStoreMu.RUnlock()
+	if store == nil {
+		return
+	}
+	if err := store.Record(attempt); err != nil {
+		log.Printf("Erro ao guardar tentativa de login: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCredentialFilterUsesLocalTime(t *testing.T) {
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.FixedZone("BRT", -3*60*60)
+
+	store, err := OpenCredentialStore(filepath.Join(t.TempDir(), "creds.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// 12:00 em BRT são 15:00 em UTC
+	attempt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	store.Record(Attempt{Time: attempt, Protocol: "ssh", Addr: "203.0.113.9:4000", Username: "root", Password: "admin"})
+	store.Record(Attempt{Time: attempt.Add(-48 * time.Hour), Protocol: "ssh", Addr: "203.0.113.9:4000", Username: "root", Password: "toor"})
+
+	for _, c := range []struct {
+		name   string
+		filter CredentialFilter
+		want   int
+	}{
+		{"since antes, em UTC", CredentialFilter{Since: time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)}, 1},
+		{"since depois, em UTC", CredentialFilter{Since: time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)}, 0},
+		{"until depois, em UTC", CredentialFilter{Until: time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)}, 2},
+		{"until antes, em UTC", CredentialFilter{Until: time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)}, 1},
+	} {
+		stats, err := store.Top(CredentialPair, c.filter, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != c.want {
+			t.Errorf("%s: Top = %v, esperado %d pares", c.name, stats, c.want)
+		}
+	}
+
+	fresh, err := store.New(CredentialFilter{Since: time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 1 || fresh[0].Value != "root:admin" {
+		t.Errorf("New = %v, esperado só root:admin", fresh)
+	}
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Padrões aceitos no campo password do banco de usuários
+const (
+	PasswordAny    = "*" // Aceita qualquer senha
+	PasswordExcept = "!" // Prefixo: aceita qualquer senha exceto a indicada
+)
+
+// HashPassword gera um hash bcrypt; * e hashes já prontos são mantidos e !senha vira !hash
+func HashPassword(password string) (string, error) {
+	if except, found := strings.CutPrefix(password, PasswordExcept); found && except != "" {
+		hashed, err := HashPassword(except)
+		return PasswordExcept + hashed, err
+	}
+	if isPasswordPattern(password) {
+		return password, nil
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar hash da senha: %v", err)
+	}
+	return string(hashed), nil
+}
+
+func isPasswordPattern(stored string) bool {
+	return stored == PasswordAny || strings.HasPrefix(stored, PasswordExcept) || isPasswordHash(stored)
+}
+
+// isPlaintext informa se o valor guardado expõe a senha (sozinha ou depois do prefixo !)
+func isPlaintext(stored string) bool {
+	stored = strings.TrimPrefix(stored, PasswordExcept)
+	return stored != "" && stored != PasswordAny && !isPasswordHash(stored)
+}
+
+func isPasswordHash(stored string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$", "$5$", "$6$"} {
+		if strings.HasPrefix(stored, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPassword compara a senha tentada com o valor guardado (texto, *, !senha, bcrypt, sha256-crypt ou sha512-crypt)
+func matchPassword(stored, password string) bool {
+	switch {
+	case stored == PasswordAny:
+		return true
+	case strings.HasPrefix(stored, PasswordExcept):
+		return !matchPassword(stored[len(PasswordExcept):], password)
+	case strings.HasPrefix(stored, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	case strings.HasPrefix(stored, "$5$"), strings.HasPrefix(stored, "$6$"):
+		computed, err := shaCrypt(password, stored)
+		return err == nil && subtle.ConstantTimeCompare([]byte(computed), []byte(stored)) == 1
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
+
+const (
+	shaCryptRounds    = 5000
+	shaCryptMinRounds = 1000
+	shaCryptMaxRounds = 999999999
+	shaCryptSaltLen   = 16
+	cryptAlphabet     = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// Ordem dos bytes do digest na codificação final de cada variante
+var (
+	sha256CryptOrder = [][]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29}, {31, 30},
+	}
+	sha512CryptOrder = [][]int{
+		{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+		{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+		{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+		{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+		{62, 20, 41}, {63},
+	}
+)
+
+// shaCrypt calcula o crypt(3) SHA-256 ($5$) ou SHA-512 ($6$) com o salt e os rounds de setting
+func shaCrypt(password, setting string) (string, error) {
+	var newHash func() hash.Hash
+	var order [][]int
+	if len(setting) < 3 {
+		return "", fmt.Errorf("hash inválido: %s", setting)
+	}
+	prefix := setting[:3]
+	switch prefix {
+	case "$5$":
+		newHash, order = sha256.New, sha256CryptOrder
+	case "$6$":
+		newHash, order = sha512.New, sha512CryptOrder
+	default:
+		return "", fmt.Errorf("formato de hash desconhecido: %s", prefix)
+	}
+
+	rest := setting[3:]
+	rounds, customRounds := shaCryptRounds, false
+	if strings.HasPrefix(rest, "rounds=") {
+		end := strings.IndexByte(rest, '$')
+		if end < 0 {
+			return "", fmt.Errorf("hash %s sem salt", prefix)
+		}
+		value, err := strconv.Atoi(rest[len("rounds="):end])
+		if err != nil {
+			return "", fmt.Errorf("rounds inválido: %v", err)
+		}
+		rounds, customRounds, rest = value, true, rest[end+1:]
+		if rounds < shaCryptMinRounds {
+			rounds = shaCryptMinRounds
+		}
+		if rounds > shaCryptMaxRounds {
+			rounds = shaCryptMaxRounds
+		}
+	}
+	salt := rest
+	if end := strings.IndexByte(salt, '$'); end >= 0 {
+		salt = salt[:end]
+	}
+	if len(salt) > shaCryptSaltLen {
+		salt = salt[:shaCryptSaltLen]
+	}
+	key, saltBytes := []byte(password), []byte(salt)
+
+	alternate := newHash()
+	alternate.Write(key)
+	alternate.Write(saltBytes)
+	alternate.Write(key)
+	alternateSum := alternate.Sum(nil)
+
+	digest := newHash()
+	digest.Write(key)
+	digest.Write(saltBytes)
+	digest.Write(repeatBytes(alternateSum, len(key)))
+	for length := len(key); length > 0; length >>= 1 {
+		if length&1 != 0 {
+			digest.Write(alternateSum)
+		} else {
+			digest.Write(key)
+		}
+	}
+	sum := digest.Sum(nil)
+
+	keyHash := newHash()
+	for i := 0; i < len(key); i++ {
+		keyHash.Write(key)
+	}
+	keySequence := repeatBytes(keyHash.Sum(nil), len(key))
+
+	saltHash := newHash()
+	for i := 0; i < 16+int(sum[0]); i++ {
+		saltHash.Write(saltBytes)
+	}
+	saltSequence := repeatBytes(saltHash.Sum(nil), len(saltBytes))
+
+	for i := 0; i < rounds; i++ {
+		round := newHash()
+		if i&1 != 0 {
+			round.Write(keySequence)
+		} else {
+			round.Write(sum)
+		}
+		if i%3 != 0 {
+			round.Write(saltSequence)
+		}
+		if i%7 != 0 {
+			round.Write(keySequence)
+		}
+		if i&1 != 0 {
+			round.Write(sum)
+		} else {
+			round.Write(keySequence)
+		}
+		sum = round.Sum(nil)
+	}
+
+	var out strings.Builder
+	out.WriteString(prefix)
+	if customRounds {
+		fmt.Fprintf(&out, "rounds=%d$", rounds)
+	}
+	out.WriteString(salt)
+	out.WriteByte('$')
+	for _, group := range order {
+		var value uint
+		for _, index := range group {
+			value = value<<8 | uint(sum[index])
+		}
+		for n := len(group) + 1; n > 0; n-- {
+			out.WriteByte(cryptAlphabet[value&0x3f])
+			value >>= 6
+		}
+	}
+	return out.String(), nil
+}
+
+func repeatBytes(source []byte, length int) []byte {
+	out := make([]byte, 0, length)
+	for len(out) < length {
+		remaining := length - len(out)
+		if remaining > len(source) {
+			remaining = len(source)
+		}
+		out = append(out, source[:remaining]...)
+	}
+	return out
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modos de aceitação de credenciais
+const (
+	PolicyList   = "list"    // Aceita apenas as credenciais listadas
+	PolicyAfterN = "after_n" // Aceita qualquer senha depois de N falhas do IP
+	PolicyRandom = "random"  // Aceita uma porcentagem aleatória das tentativas
+	PolicyMemory = "memory"  // Aceita a N-ésima senha distinta do IP e depois só ela (userdb + memória, como o Cowrie)
+	PolicyReject = "reject"  // Recusa tudo
+)
+
+// DefaultProtocol é a chave da política usada pelos protocolos sem política própria
+const DefaultProtocol = "default"
+
+// Credential é um par usuário/senha aceito pela política
+type Credential struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// PolicyConfig descreve a política de aceitação de um protocolo
+type PolicyConfig struct {
+	Mode        string        `yaml:"mode"`        // list, after_n, random, memory ou reject (padrão list)
+	Credentials []Credential  `yaml:"credentials"` // Sempre aceitas, exceto em reject (vazio usa o banco de usuários)
+	Attempts    int           `yaml:"attempts"`    // N de after_n e memory (padrão 3)
+	Percent     float64       `yaml:"percent"`     // Porcentagem aceita em random
+	Memory      time.Duration `yaml:"memory"`      // Tempo que o estado de cada IP é lembrado (padrão 24h)
+}
+
+// Policy decide se uma tentativa de login é aceita; o estado é mantido por IP
+type Policy struct {
+	config      PolicyConfig
+	credentials map[string]string
+	mutex       sync.Mutex
+	sources     map[string]*sourceState
+	lastSweep   time.Time
+	random      *rand.Rand
+}
+
+// sourceState é o que a política lembra de um IP
+type sourceState struct {
+	failures   int
+	passwords  map[string]bool
+	username   string // Credencial aceita pelo modo memory
+	password   string
+	remembered bool
+	seen       time.Time
+}
+
+// NewPolicy valida a configuração de uma política
+func NewPolicy(config PolicyConfig) (*Policy, error) {
+	if config.Mode == "" {
+		config.Mode = PolicyList
+	}
+	if config.Attempts <= 0 {
+		config.Attempts = 3
+	}
+	if config.Memory <= 0 {
+		config.Memory = 24 * time.Hour
+	}
+	switch config.Mode {
+	case PolicyList, PolicyAfterN, PolicyMemory, PolicyReject:
+	case PolicyRandom:
+		if config.Percent < 0 || config.Percent > 100 {
+			return nil, fmt.Errorf("percent inválido na política random: %v", config.Percent)
+		}
+	default:
+		return nil, fmt.Errorf("modo de política desconhecido: %s", config.Mode)
+	}
+
+	credentials := make(map[string]string)
+	for _, credential := range config.Credentials {
+		credentials[credential.Username] = credential.Password
+	}
+
+	return &Policy{
+		config:      config,
+		credentials: credentials,
+		sources:     make(map[string]*sourceState),
+		random:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Mode informa o modo configurado
+func (p *Policy) Mode() string {
+	return p.config.Mode
+}
+
+// Accept decide a tentativa de login de addr (IP ou IP:porta)
+func (p *Policy) Accept(addr, username, password string) bool {
+	return p.accept(addr, username, password, time.Now())
+}
+
+func (p *Policy) accept(addr, username, password string, now time.Time) bool {
+	if p.config.Mode == PolicyReject {
+		return false
+	}
+
+	// Fora do lock: hashes bcrypt são lentos de propósito
+	listed := p.listed(username, password)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.sweep(now)
+	state := p.source(sourceIP(addr), now)
+
+	if state.remembered {
+		// memory: depois de aceitar, o IP só entra com a mesma credencial
+		return username == state.username && password == state.password
+	}
+	if listed {
+		return true
+	}
+
+	switch p.config.Mode {
+	case PolicyAfterN:
+		if state.failures >= p.config.Attempts {
+			state.failures = 0
+			return true
+		}
+		state.failures++
+	case PolicyRandom:
+		return p.random.Float64()*100 < p.config.Percent
+	case PolicyMemory:
+		state.passwords[password] = true
+		if len(state.passwords) >= p.config.Attempts {
+			state.username, state.password, state.remembered = username, password, true
+			return true
+		}
+	}
+	return false
+}
+
+// listed consulta as credenciais da política ou, sem elas, o banco de usuários padrão
+func (p *Policy) listed(username, password string) bool {
+	if len(p.credentials) == 0 {
+		return validCredential(username, password)
+	}
+	expected, exists := p.credentials[username]
+	return exists && matchPassword(expected, password)
+}
+
+func (p *Policy) source(ip string, now time.Time) *sourceState {
+	state, exists := p.sources[ip]
+	if !exists {
+		state = &sourceState{passwords: make(map[string]bool)}
+		p.sources[ip] = state
+	}
+	state.seen = now
+	return state
+}
+
+// sweep esquece os IPs que não tentam login há mais de Memory
+func (p *Policy) sweep(now time.Time) {
+	if now.Sub(p.lastSweep) < time.Minute {
+		return
+	}
+	p.lastSweep = now
+	for ip, state := range p.sources {
+		if now.Sub(state.seen) > p.config.Memory {
+			delete(p.sources, ip)
+		}
+	}
+}
+
+func sourceIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// Policies agrupa as políticas por protocolo (ssh, telnet, ftp, http, ...)
+type Policies struct {
+	policies map[string]*Policy
+}
+
+// NewPolicies cria uma política por protocolo; a chave "default" vale para os demais
+func NewPolicies(configs map[string]PolicyConfig) (*Policies, error) {
+	policies := &Policies{policies: make(map[string]*Policy)}
+	for protocol, config := range configs {
+		policy, err := NewPolicy(config)
+		if err != nil {
+			return nil, fmt.Errorf("política %s: %v", protocol, err)
+		}
+		policies.policies[strings.ToLower(protocol)] = policy
+	}
+	if _, exists := policies.policies[DefaultProtocol]; !exists {
+		policy, _ := NewPolicy(PolicyConfig{Mode: PolicyList})
+		policies.policies[DefaultProtocol] = policy
+	}
+	return policies, nil
+}
+
+// Policy retorna a política do protocolo ou a padrão
+func (p *Policies) Policy(protocol string) *Policy {
+	if policy, exists := p.policies[strings.ToLower(protocol)]; exists {
+		return policy
+	}
+	return p.policies[DefaultProtocol]
+}
+
+// Accept decide a tentativa de login conforme a política do protocolo
+func (p *Policies) Accept(protocol, addr, username, password string) bool {
+	return p.Policy(protocol).Accept(addr, username, password)
+}
+
+var (
+	defaultPolicies   *Policies
+	defaultPoliciesMu sync.RWMutex
+)
+
+// SetDefaultPolicies define as políticas usadas por Accept
+func SetDefaultPolicies(policies *Policies) {
+	defaultPoliciesMu.Lock()
+	defer defaultPoliciesMu.Unlock()
+	defaultPolicies = policies
+}
+
+// Accept consulta as políticas padrão e guarda a tentativa; sem políticas definidas vale o banco de usuários
+func Accept(protocol, addr, username, password string) bool {
+	return Check(Attempt{Protocol: protocol, Addr: addr, Username: username, Password: password})
+}
+
+// Check é o Accept com os dados completos da tentativa (fingerprint do cliente)
+func Check(attempt Attempt) bool {
+	defaultPoliciesMu.RLock()
+	policies := defaultPolicies
+	defaultPoliciesMu.RUnlock()
+	if policies == nil {
+		attempt.Success = validCredential(attempt.Username, attempt.Password)
+	} else {
+		attempt.Success = policies.Accept(attempt.Protocol, attempt.Addr, attempt.Username, attempt.Password)
+	}
+	RecordAttempt(attempt)
+	return attempt.Success
+}
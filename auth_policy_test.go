@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyAccept(t *testing.T) {
+	const attacker, other = "203.0.113.9:4000", "198.51.100.2:5000"
+	listed := []Credential{{Username: "root", Password: "root"}}
+
+	type try struct {
+		at                 time.Duration // Desde o início do caso
+		addr               string
+		username, password string
+		accept             bool
+	}
+	tests := []struct {
+		name   string
+		config PolicyConfig
+		tries  []try
+	}{
+		{"after_n aceita depois de N falhas e recomeça", PolicyConfig{Mode: PolicyAfterN, Attempts: 2, Credentials: listed}, []try{
+			{0, attacker, "admin", "a", false},
+			{time.Second, attacker, "admin", "b", false},
+			{2 * time.Second, other, "admin", "c", false},
+			{3 * time.Second, attacker, "admin", "c", true},
+			{4 * time.Second, attacker, "admin", "d", false},
+			{5 * time.Second, attacker, "admin", "e", false},
+			{6 * time.Second, attacker, "admin", "f", true},
+			{7 * time.Second, attacker, "root", "root", true},
+		}},
+		{"memory trava na N-ésima senha distinta", PolicyConfig{Mode: PolicyMemory, Attempts: 3, Credentials: listed}, []try{
+			{0, attacker, "root", "123456", false},
+			{time.Second, attacker, "root", "123456", false},
+			{2 * time.Second, attacker, "root", "admin", false},
+			{3 * time.Second, attacker, "root", "toor", true},
+			{4 * time.Second, attacker, "root", "toor", true},
+			{5 * time.Second, attacker, "root", "123456", false},
+			{6 * time.Second, attacker, "admin", "toor", false},
+			// Depois de travar, nem a credencial listada entra
+			{7 * time.Second, attacker, "root", "root", false},
+			{8 * time.Second, other, "root", "toor", false},
+		}},
+		{"reject vence as credenciais listadas", PolicyConfig{Mode: PolicyReject, Credentials: listed}, []try{
+			{0, attacker, "root", "root", false},
+			{time.Second, attacker, "admin", "admin", false},
+		}},
+		{"list aceita só as listadas", PolicyConfig{Mode: PolicyList, Credentials: listed}, []try{
+			{0, attacker, "root", "root", true},
+			{time.Second, attacker, "root", "toor", false},
+		}},
+		{"random 0% só aceita as listadas", PolicyConfig{Mode: PolicyRandom, Percent: 0, Credentials: listed}, []try{
+			{0, attacker, "admin", "admin", false},
+			{time.Second, attacker, "root", "root", true},
+		}},
+		{"random 100% aceita tudo", PolicyConfig{Mode: PolicyRandom, Percent: 100, Credentials: listed}, []try{
+			{0, attacker, "admin", "admin", true},
+			{time.Second, other, "x", "y", true},
+		}},
+		{"sweep esquece as falhas de after_n", PolicyConfig{Mode: PolicyAfterN, Attempts: 2, Memory: time.Hour, Credentials: listed}, []try{
+			{0, attacker, "admin", "a", false},
+			{time.Second, attacker, "admin", "b", false},
+			// Sem o sweep esta seria a terceira tentativa, aceita
+			{2 * time.Hour, attacker, "admin", "c", false},
+			{2*time.Hour + time.Second, attacker, "admin", "d", false},
+			{2*time.Hour + 2*time.Second, attacker, "admin", "e", true},
+		}},
+		{"sweep esquece a senha do memory", PolicyConfig{Mode: PolicyMemory, Attempts: 1, Memory: time.Hour, Credentials: listed}, []try{
+			{0, attacker, "root", "toor", true},
+			{30 * time.Minute, attacker, "root", "123456", false},
+			{2 * time.Hour, attacker, "root", "123456", true},
+			{2*time.Hour + time.Second, attacker, "root", "toor", false},
+		}},
+		{"IP visto dentro de Memory não é esquecido", PolicyConfig{Mode: PolicyAfterN, Attempts: 1, Memory: time.Hour, Credentials: listed}, []try{
+			{0, attacker, "admin", "a", false},
+			{50 * time.Minute, other, "admin", "a", false},
+			{55 * time.Minute, attacker, "admin", "b", true},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := NewPolicy(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			for i, try := range test.tries {
+				if got := policy.accept(try.addr, try.username, try.password, start.Add(try.at)); got != try.accept {
+					t.Errorf("tentativa %d (%s %s/%s) = %v, esperado %v", i, try.addr, try.username, try.password, got, try.accept)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPolicyRejectsBadConfig(t *testing.T) {
+	for _, config := range []PolicyConfig{
+		{Mode: "sometimes"},
+		{Mode: PolicyRandom, Percent: 120},
+		{Mode: PolicyRandom, Percent: -1},
+	} {
+		if _, err := NewPolicy(config); err == nil {
+			t.Errorf("NewPolicy(%+v) aceitou a configuração", config)
+		}
+	}
+}
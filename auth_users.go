@@ -0,0 +1,477 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"myhoneypot/logging"
+)
+
+// Backends do banco de usuários
+const (
+	UsersJSON   = "json"
+	UsersSQLite = "sqlite"
+)
+
+// DefaultUsersFile é o arquivo usado quando auth.users.file não é informado
+const DefaultUsersFile = "users.json"
+
+// UserAny no campo username vale para qualquer usuário sem entrada própria
+const UserAny = "*"
+
+// usersReloadDelay agrupa as várias escritas de um editor em uma única recarga
+const usersReloadDelay = 500 * time.Millisecond
+
+var (
+	ErrUserNotFound = errors.New("usuário não encontrado")
+	ErrUserExists   = errors.New("usuário já existe")
+	ErrUserInactive = errors.New("conta inativa")
+	ErrBadPassword  = errors.New("senha incorreta")
+)
+
+// UsersConfig descreve o banco de usuários aceitos pelos serviços
+type UsersConfig struct {
+	Backend string `yaml:"backend"` // json ou sqlite (padrão json)
+	File    string `yaml:"file"`    // Arquivo JSON ou banco SQLite (padrão users.json)
+	Watch   bool   `yaml:"watch"`   // Recarrega automaticamente quando o arquivo muda
+}
+
+// UserBackend lê e grava a lista completa de usuários
+type UserBackend interface {
+	Load() ([]User, error)
+	Save(users []User) error
+	Path() string // Arquivo observado para recarga
+}
+
+// UserStore mantém os usuários em memória e os recarrega do backend
+type UserStore struct {
+	backend UserBackend
+	mutex   sync.RWMutex
+	users   []User
+	watcher *fsnotify.Watcher
+}
+
+// OpenUserStore abre o backend configurado e carrega os usuários
+func OpenUserStore(config UsersConfig) (*UserStore, error) {
+	if config.File == "" {
+		config.File = DefaultUsersFile
+	}
+
+	var backend UserBackend
+	switch strings.ToLower(config.Backend) {
+	case "", UsersJSON:
+		backend = &JSONUsers{File: config.File}
+	case UsersSQLite:
+		sqlite, err := OpenSQLiteUsers(config.File)
+		if err != nil {
+			return nil, err
+		}
+		backend = sqlite
+	default:
+		return nil, fmt.Errorf("backend de usuários desconhecido: %s", config.Backend)
+	}
+
+	store, err := NewUserStore(backend)
+	if err != nil {
+		closeBackend(backend)
+		return nil, err
+	}
+	if config.Watch {
+		if err := store.Watch(); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// NewUserStore carrega os usuários de backend
+func NewUserStore(backend UserBackend) (*UserStore, error) {
+	store := &UserStore{backend: backend}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload relê o backend; em caso de erro a lista anterior é mantida
+func (s *UserStore) Reload() error {
+	users, err := s.backend.Load()
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.users = users
+	s.mutex.Unlock()
+
+	plaintext := 0
+	for _, user := range users {
+		if isPlaintext(user.Password) {
+			plaintext++
+		}
+	}
+	if plaintext > 0 {
+		log.Printf("%d senhas em texto puro em %s; use \"honeypot users rehash\" para convertê-las em bcrypt", plaintext, s.backend.Path())
+	}
+	return nil
+}
+
+// Users retorna uma cópia da lista atual
+func (s *UserStore) Users() []User {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]User(nil), s.users...)
+}
+
+// Accept informa se o par usuário/senha é aceito
+func (s *UserStore) Accept(username, password string) bool {
+	return s.Authenticate(username, password) == nil
+}
+
+// Authenticate verifica o par usuário/senha; as entradas do usuário têm prioridade sobre as de "*".
+// Uma entrada "!senha" que casa com a senha recusa o login mesmo que outra entrada o aceite.
+func (s *UserStore) Authenticate(username, password string) error {
+	s.mutex.RLock()
+	entries := s.entries(username)
+	if len(entries) == 0 {
+		entries = s.entries(UserAny)
+	}
+	s.mutex.RUnlock()
+
+	if len(entries) == 0 {
+		return ErrUserNotFound
+	}
+	accepted := false
+	for _, user := range entries {
+		if user.Status == "inactive" {
+			return ErrUserInactive
+		}
+		// Uma comparação por entrada: o bcrypt é o custo de cada tentativa
+		matched := matchPassword(user.Password, password)
+		if !matched && strings.HasPrefix(user.Password, PasswordExcept) {
+			return ErrBadPassword
+		}
+		accepted = accepted || matched
+	}
+	if !accepted {
+		return ErrBadPassword
+	}
+	return nil
+}
+
+func (s *UserStore) entries(username string) []User {
+	var entries []User
+	for _, user := range s.users {
+		if user.Username == username {
+			entries = append(entries, user)
+		}
+	}
+	return entries
+}
+
+// Add cria um usuário; a senha é guardada como hash bcrypt
+func (s *UserStore) Add(username, password, status string) error {
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.update(func(users []User) ([]User, error) {
+		for _, user := range users {
+			if user.Username == username {
+				return nil, ErrUserExists
+			}
+		}
+		return append(users, User{Username: username, Password: hashed, Status: status}), nil
+	})
+}
+
+// Edit troca a senha e o status de todas as entradas do usuário
+func (s *UserStore) Edit(username, password, status string) error {
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.update(func(users []User) ([]User, error) {
+		found := false
+		for i := range users {
+			if users[i].Username == username {
+				users[i].Password = hashed
+				users[i].Status = status
+				found = true
+			}
+		}
+		if !found {
+			return nil, ErrUserNotFound
+		}
+		return users, nil
+	})
+}
+
+// Rehash troca as senhas em texto puro (inclusive as de !senha) por hashes bcrypt e retorna quantas mudaram
+func (s *UserStore) Rehash() (int, error) {
+	changed := 0
+	err := s.update(func(users []User) ([]User, error) {
+		for i := range users {
+			if !isPlaintext(users[i].Password) {
+				continue
+			}
+			hashed, err := HashPassword(users[i].Password)
+			if err != nil {
+				return nil, err
+			}
+			users[i].Password = hashed
+			changed++
+		}
+		return users, nil
+	})
+	return changed, err
+}
+
+// Remove apaga todas as entradas do usuário
+func (s *UserStore) Remove(username string) error {
+	return s.update(func(users []User) ([]User, error) {
+		kept := users[:0]
+		for _, user := range users {
+			if user.Username != username {
+				kept = append(kept, user)
+			}
+		}
+		if len(kept) == len(users) {
+			return nil, ErrUserNotFound
+		}
+		return kept, nil
+	})
+}
+
+// update relê o backend (o arquivo pode ter sido editado à mão), aplica a mudança e grava
+func (s *UserStore) update(change func([]User) ([]User, error)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	users, err := s.backend.Load()
+	if err != nil {
+		return err
+	}
+	if users, err = change(users); err != nil {
+		return err
+	}
+	if err := s.backend.Save(users); err != nil {
+		return err
+	}
+	s.users = users
+	return nil
+}
+
+// Watch recarrega os usuários sempre que o arquivo do backend muda
+func (s *UserStore) Watch() error {
+	path, err := filepath.Abs(s.backend.Path())
+	if err != nil {
+		return fmt.Errorf("erro ao resolver %s: %v", s.backend.Path(), err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("erro ao criar observador de arquivos: %v", err)
+	}
+	// Observa o diretório: editores costumam gravar um arquivo novo e renomeá-lo
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("erro ao observar %s: %v", filepath.Dir(path), err)
+	}
+	s.watcher = watcher
+	go s.watch(watcher, path)
+	return nil
+}
+
+func (s *UserStore) watch(watcher *fsnotify.Watcher, path string) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			// O banco SQLite também muda pelos arquivos -journal e -wal
+			name := filepath.Clean(event.Name)
+			if event.Op == fsnotify.Chmod || (name != path && !strings.HasPrefix(name, path+"-")) {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(usersReloadDelay, func() { s.reloadLogged(path) })
+			} else {
+				timer.Reset(usersReloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Erro ao observar %s: %v", path, err)
+		}
+	}
+}
+
+func (s *UserStore) reloadLogged(path string) {
+	if err := s.Reload(); err != nil {
+		log.Printf("Erro ao recarregar usuários de %s, mantendo a lista anterior: %v", path, err)
+		return
+	}
+	log.Printf("Usuários recarregados de %s: %d entradas", path, len(s.Users()))
+}
+
+// Close para o observador e fecha o backend
+func (s *UserStore) Close() error {
+	if s.watcher != nil {
+		s.watcher.Close()
+	}
+	return closeBackend(s.backend)
+}
+
+func closeBackend(backend UserBackend) error {
+	if closer, ok := backend.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// JSONUsers guarda os usuários em um arquivo {"users": [...]}
+type JSONUsers struct {
+	File string
+}
+
+// Load lê o arquivo; um arquivo inexistente é uma lista vazia
+func (b *JSONUsers) Load() ([]User, error) {
+	if _, err := os.Stat(b.File); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return LoadUsersFromJSON(b.File)
+}
+
+// Save grava a lista completa no arquivo
+func (b *JSONUsers) Save(users []User) error {
+	return saveUsersToJSON(b.File, users)
+}
+
+// Path retorna o arquivo JSON
+func (b *JSONUsers) Path() string {
+	return b.File
+}
+
+// SQLiteUsers guarda os usuários na tabela users de um banco SQLite
+type SQLiteUsers struct {
+	db   *sql.DB
+	file string
+}
+
+// OpenSQLiteUsers abre o banco e cria a tabela users
+func OpenSQLiteUsers(dbPath string) (*SQLiteUsers, error) {
+	db, err := logging.OpenDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL,
+		password TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao criar tabela de usuários: %v", err)
+	}
+	return &SQLiteUsers{db: db, file: dbPath}, nil
+}
+
+// Load lê os usuários na ordem de inserção
+func (b *SQLiteUsers) Load() ([]User, error) {
+	rows, err := b.db.Query("SELECT username, password, status FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar usuários: %v", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.Username, &user.Password, &user.Status); err != nil {
+			return nil, fmt.Errorf("erro ao ler usuário: %v", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Save substitui a tabela inteira em uma transação
+func (b *SQLiteUsers) Save(users []User) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM users"); err != nil {
+		return fmt.Errorf("erro ao limpar usuários: %v", err)
+	}
+	for _, user := range users {
+		if _, err := tx.Exec("INSERT INTO users (username, password, status) VALUES (?, ?, ?)", user.Username, user.Password, user.Status); err != nil {
+			return fmt.Errorf("erro ao salvar usuário %s: %v", user.Username, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao salvar usuários: %v", err)
+	}
+	return nil
+}
+
+// Path retorna o arquivo do banco
+func (b *SQLiteUsers) Path() string {
+	return b.file
+}
+
+// Close fecha o banco
+func (b *SQLiteUsers) Close() error {
+	return b.db.Close()
+}
+
+var (
+	defaultUsers   *UserStore
+	defaultUsersMu sync.RWMutex
+)
+
+// SetDefaultUsers define o banco de usuários consultado pelas políticas e por AddUser/EditUser/RemoveUser
+func SetDefaultUsers(store *UserStore) {
+	defaultUsersMu.Lock()
+	defer defaultUsersMu.Unlock()
+	defaultUsers = store
+}
+
+func currentUsers() (*UserStore, error) {
+	defaultUsersMu.RLock()
+	defer defaultUsersMu.RUnlock()
+	if defaultUsers == nil {
+		return nil, errors.New("banco de usuários não configurado")
+	}
+	return defaultUsers, nil
+}
+
+// validCredential consulta o banco de usuários padrão ou, sem ele, ValidCredentials
+func validCredential(username, password string) bool {
+	if store, err := currentUsers(); err == nil {
+		return store.Accept(username, password)
+	}
+	expected, exists := ValidCredentials[username]
+	return exists && expected == password
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRehash(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.json")
+	os.WriteFile(file, []byte(`{"users": [
+		{"username": "admin", "password": "admin", "status": "active"},
+		{"username": "root", "password": "!123456", "status": "active"},
+		{"username": "any", "password": "*", "status": "active"},
+		{"username": "pi", "password": "$2a$10$abcdefghijklmnopqrstuu5Ur0B3MXmRaXRkwPuGBWE8ekeVzU7G", "status": "active"}
+	]}`), 0644)
+	store, err := OpenUserStore(UsersConfig{File: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	changed, err := store.Rehash()
+	if err != nil || changed != 2 {
+		t.Fatalf("Rehash = %d, %v, esperado 2 senhas convertidas", changed, err)
+	}
+	users, err := (&JSONUsers{File: file}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range users {
+		if isPlaintext(user.Password) {
+			t.Errorf("%s ainda guarda a senha de %s em texto puro: %q", file, user.Username, user.Password)
+		}
+	}
+	if root := users[1].Password; !strings.HasPrefix(root, PasswordExcept+"$2") {
+		t.Errorf("senha de root = %q, esperado !hash", root)
+	}
+
+	// O arquivo regravado continua aceitando as mesmas credenciais
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		username, password string
+		accept             bool
+	}{
+		{"admin", "admin", true},
+		{"admin", "wrong", false},
+		{"root", "123456", false},
+		{"root", "toor", true},
+		{"any", "x", true},
+	} {
+		if got := store.Accept(c.username, c.password); got != c.accept {
+			t.Errorf("Accept(%s, %s) = %v, esperado %v", c.username, c.password, got, c.accept)
+		}
+	}
+	if changed, _ := store.Rehash(); changed != 0 {
+		t.Fatalf("segundo Rehash converteu %d senhas", changed)
+	}
+}
@@ -3,10 +3,16 @@ package handlers
 import (
 	"strings"
 	"time"
+
+	"myhoneypot/persona"
 )
 
 // ProcessCommand recebe um comando do usuário e retorna uma resposta realista.
 func ProcessCommand(cmd string) string {
+	// Identidade do sistema (uname, /etc/os-release, ip addr, ...) vem do perfil ativo
+	if response, ok := persona.Current().Command(strings.TrimSpace(cmd)); ok {
+		return response
+	}
 	cmd = strings.TrimSpace(strings.ToLower(cmd))
 
 	// Simula comportamento realista do Telnet/SSH
@@ -17,14 +23,14 @@ func ProcessCommand(cmd string) string {
 		return "/home/admin"
 	case "whoami":
 		return "admin"
-	case "uname -a":
-		return "Linux server01 5.15.0-84-generic #93-Ubuntu SMP x86_64 GNU/Linux"
-	case "uptime":
-		return "12:34:56 up 3 days,  4:55,  1 user,  load average: 0.10, 0.05, 0.01"
-	case "ps aux":
-		return "PID   USER      COMMAND\n1     root      /sbin/init\n2023  admin     /bin/bash"
-	case "cat /etc/passwd":
-		return "root:x:0:0:root:/root:/bin/bash\nadmin:x:1001:1001::/home/admin:/bin/bash"
+	case "cat /etc/shadow":
+		return "cat: /etc/shadow: Permission denied"
+	case "find / -perm -4000":
+		return "/usr/bin/passwd\n/usr/bin/sudo\n/usr/bin/chsh\n/usr/bin/newgrp"
+	case "sudo -l":
+		return "[sudo] password for admin: \nSorry, user admin may not run sudo on this system."
+	case "su", "sudo su":
+		return "Password: \nAuthentication failure"
 	case "exit":
 		return "Session closed."
 
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"myhoneypot/auth"
+)
+
+// credsReport é a saída JSON do subcomando "creds"
+type credsReport struct {
+	Usernames []auth.CredentialStat `json:"usernames"`
+	Passwords []auth.CredentialStat `json:"passwords"`
+	Pairs     []auth.CredentialStat `json:"pairs"`
+	New       []auth.CredentialStat `json:"new"`
+}
+
+// runCreds implementa o subcomando "creds": ranking de credenciais e exportação de wordlists
+func runCreds(args []string) int {
+	fs := flag.NewFlagSet("creds", flag.ContinueOnError)
+	configFile := fs.String("config", configPath, "Configuration file path")
+	dbPath := fs.String("db", "", "Event database (defaults to database.file from the config)")
+	format := fs.String("format", "text", "Report format: text or json")
+	output := fs.String("output", "-", "Output file ('-' for stdout)")
+	top := fs.Int("top", 20, "Entries in each ranking")
+	newWindow := fs.String("new", "24h", "Report credentials first seen after this point (RFC3339, YYYY-MM-DD or a duration)")
+	wordlist := fs.String("wordlist", "", "Export a deduplicated wordlist instead of the report: usernames, passwords or pairs")
+	since := fs.String("since", "", "Start of range: RFC3339, YYYY-MM-DD or a duration such as 24h")
+	until := fs.String("until", "", "End of range: RFC3339, YYYY-MM-DD or a duration such as 1h")
+	protocol := fs.String("protocol", "", "Only attempts against this protocol (ssh, telnet, ftp, http, ...)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	filter := auth.CredentialFilter{Protocol: *protocol}
+	var err error
+	if filter.Since, err = parseTimeFlag(*since); err != nil {
+		log.Printf("[ERROR] Invalid --since: %v", err)
+		return 2
+	}
+	if filter.Until, err = parseTimeFlag(*until); err != nil {
+		log.Printf("[ERROR] Invalid --until: %v", err)
+		return 2
+	}
+	newFilter := filter
+	if newFilter.Since, err = parseTimeFlag(*newWindow); err != nil {
+		log.Printf("[ERROR] Invalid --new: %v", err)
+		return 2
+	}
+
+	if *dbPath == "" {
+		config, err := loadHoneypotConfig(*configFile)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load configuration: %v", err)
+			return 1
+		}
+		*dbPath = config.Database.File
+	}
+
+	store, err := auth.OpenCredentialStore(*dbPath)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return 1
+	}
+	defer store.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Printf("[ERROR] Failed to create %s: %v", *output, err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if *wordlist != "" {
+		writer := bufio.NewWriter(out)
+		count := 0
+		err := store.Wordlist(strings.ToLower(*wordlist), filter, func(value string) error {
+			count++
+			_, err := fmt.Fprintln(writer, value)
+			return err
+		})
+		if err == nil {
+			err = writer.Flush()
+		}
+		if err != nil {
+			log.Printf("[ERROR] Wordlist export failed: %v", err)
+			return 1
+		}
+		log.Printf("[INFO] Exported %d %s", count, strings.ToLower(*wordlist))
+		return 0
+	}
+
+	var report credsReport
+	for _, ranking := range []struct {
+		kind  string
+		stats *[]auth.CredentialStat
+	}{
+		{auth.CredentialUsername, &report.Usernames},
+		{auth.CredentialPassword, &report.Passwords},
+		{auth.CredentialPair, &report.Pairs},
+	} {
+		if *ranking.stats, err = store.Top(ranking.kind, filter, *top); err != nil {
+			log.Printf("[ERROR] %v", err)
+			return 1
+		}
+	}
+	if report.New, err = store.New(newFilter, *top); err != nil {
+		log.Printf("[ERROR] %v", err)
+		return 1
+	}
+
+	switch strings.ToLower(*format) {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case "text":
+		err = writeCredsReport(out, report, newFilter.Since)
+	default:
+		log.Printf("[ERROR] Unknown format: %s", *format)
+		return 2
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to write report: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// writeCredsReport imprime os rankings em tabelas
+func writeCredsReport(out io.Writer, report credsReport, newSince time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	sections := []struct {
+		title string
+		stats []auth.CredentialStat
+	}{
+		{"Top usernames", report.Usernames},
+		{"Top passwords", report.Passwords},
+		{"Top username:password pairs", report.Pairs},
+		{"New credentials since " + newSince.Format("2006-01-02 15:04"), report.New},
+	}
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, section.title)
+		fmt.Fprintln(w, "ATTEMPTS\tSOURCES\tACCEPTED\tFIRST SEEN\tLAST SEEN\tVALUE")
+		for _, stat := range section.stats {
+			fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\t%q\n", stat.Attempts, stat.Sources, stat.Successes, stat.FirstSeen, stat.LastSeen, stat.Value)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventFilter restringe os eventos lidos do banco
+type EventFilter struct {
+	Since    time.Time // Inclusivo
+	Until    time.Time // Exclusivo
+	IP       string    // IP de origem, com ou sem porta
+	Protocol string
+	Type     string
+	Session  string
+}
+
+// OpenDB abre um banco SQLite para leitura e escrita, esperando até 5s pelos locks de outras conexões
+func OpenDB(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %v", err)
+	}
+	return db, nil
+}
+
+// OpenEventStore abre o banco de eventos somente para leitura
+func OpenEventStore(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao abrir %s: %v", dbPath, err)
+	}
+	return db, nil
+}
+
+// QueryEvents percorre os eventos que atendem ao filtro em ordem de inserção,
+// lendo uma linha por vez para não carregar o banco inteiro em memória
+func QueryEvents(db *sql.DB, filter EventFilter, fn func(LogEntry) error) error {
+	query, args := filter.sql()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("erro ao consultar eventos: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var timestamp, ip, event, level string
+		var eventType, protocol, session, data sql.NullString
+		if err := rows.Scan(&timestamp, &ip, &event, &level, &eventType, &protocol, &session, &data); err != nil {
+			return fmt.Errorf("erro ao ler evento: %v", err)
+		}
+
+		entry := LogEntry{
+			Timestamp: timestamp,
+			IP:        ip,
+			Event:     event,
+			Level:     LogLevel(level),
+			Type:      eventType.String,
+			Protocol:  protocol.String,
+			Session:   session.String,
+		}
+		// Linhas novas trazem o evento completo; linhas antigas só as colunas básicas
+		if data.Valid && data.String != "" {
+			var full LogEntry
+			if err := json.Unmarshal([]byte(data.String), &full); err == nil {
+				entry = full
+			}
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sql monta a consulta com os filtros preenchidos
+func (f EventFilter) sql() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, f.Since.In(time.Local).Format(TimestampLayout))
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, f.Until.In(time.Local).Format(TimestampLayout))
+	}
+	if f.IP != "" {
+		// A coluna ip guarda o RemoteAddr(), que pode incluir a porta
+		host, _ := splitAddr(f.IP)
+		conditions = append(conditions, "(ip = ? OR ip LIKE ? OR ip LIKE ?)")
+		args = append(args, host, host+":%", "["+host+"]:%")
+	}
+	if f.Protocol != "" {
+		conditions = append(conditions, "protocol = ?")
+		args = append(args, strings.ToLower(f.Protocol))
+	}
+	if f.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, strings.ToUpper(f.Type))
+	}
+	if f.Session != "" {
+		conditions = append(conditions, "session = ?")
+		args = append(args, f.Session)
+	}
+
+	query := "SELECT timestamp, ip, event, level, type, protocol, session, data FROM logs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return query + " ORDER BY id", args
+}
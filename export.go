@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatos aceitos pela exportação
+const (
+	ExportJSONL = "jsonl"
+	ExportCSV   = "csv"
+	ExportBulk  = "bulk" // NDJSON para a API _bulk do Elasticsearch/OpenSearch
+)
+
+// csvHeader define a ordem das colunas do CSV exportado
+var csvHeader = []string{
+	"timestamp", "ip", "level", "type", "protocol", "port", "session",
+	"username", "password", "command", "url", "sha256", "ssh_key", "payload", "user_agent", "request",
+	"mechanism", "path", "content", "file_type", "arch", "packer", "iocs", "strings", "event",
+}
+
+// ExportOptions ajusta a saída da exportação
+type ExportOptions struct {
+	Format string
+	Index  string // Índice do _bulk; "{date}" vira a data do evento (ex: honeypot-{date})
+}
+
+// ExportEvents grava os eventos filtrados em w, linha a linha, e retorna quantos foram exportados
+func ExportEvents(db *sql.DB, w io.Writer, filter EventFilter, options ExportOptions) (int, error) {
+	out := bufio.NewWriterSize(w, 64*1024)
+
+	var write func(LogEntry) error
+	switch strings.ToLower(options.Format) {
+	case ExportJSONL, "json", "":
+		encoder := json.NewEncoder(out)
+		write = func(entry LogEntry) error { return encoder.Encode(entry) }
+	case ExportCSV:
+		writer := csv.NewWriter(out)
+		if err := writer.Write(csvHeader); err != nil {
+			return 0, err
+		}
+		write = func(entry LogEntry) error {
+			if err := writer.Write(csvRecord(entry)); err != nil {
+				return err
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+	case ExportBulk:
+		index := options.Index
+		if index == "" {
+			index = "honeypot-{date}"
+		}
+		encoder := json.NewEncoder(out)
+		write = func(entry LogEntry) error { return writeBulk(encoder, index, entry) }
+	default:
+		return 0, fmt.Errorf("formato de exportação desconhecido: %s", options.Format)
+	}
+
+	count := 0
+	err := QueryEvents(db, filter, func(entry LogEntry) error {
+		if err := write(entry); err != nil {
+			return fmt.Errorf("erro ao exportar evento: %v", err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, out.Flush()
+}
+
+func csvRecord(entry LogEntry) []string {
+	port := ""
+	if entry.Port != 0 {
+		port = strconv.Itoa(entry.Port)
+	}
+	return []string{
+		entry.Timestamp, entry.IP, string(entry.Level), entry.Type, entry.Protocol, port, entry.Session,
+		entry.Username, entry.Password, entry.Command, entry.URL, entry.SHA256, entry.SSHKey, entry.Payload, entry.UserAgent, entry.Request,
+		entry.Mechanism, entry.Path, entry.Content, entry.FileType, entry.Arch, entry.Packer, entry.IOCs, entry.Strings, entry.Event,
+	}
+}
+
+// bulkDocument acrescenta os campos esperados pelo Elasticsearch ao evento
+type bulkDocument struct {
+	LogEntry
+	Time     string `json:"@timestamp"`
+	SourceIP string `json:"source_ip"`
+}
+
+// writeBulk grava o par ação/documento do formato _bulk
+func writeBulk(encoder *json.Encoder, index string, entry LogEntry) error {
+	t := entryTime(entry)
+	action := map[string]map[string]string{
+		"index": {"_index": strings.ReplaceAll(index, "{date}", t.Format("2006.01.02"))},
+	}
+	if err := encoder.Encode(action); err != nil {
+		return err
+	}
+
+	host, _ := splitAddr(entry.IP)
+	return encoder.Encode(bulkDocument{
+		LogEntry: entry,
+		Time:     t.Format(time.RFC3339),
+		SourceIP: host,
+	})
+}
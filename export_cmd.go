@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+// runExport implementa o subcomando "export" (e a flag --export-logs)
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	configFile := fs.String("config", configPath, "Configuration file path")
+	dbPath := fs.String("db", "", "Event database (defaults to database.file from the config)")
+	format := fs.String("format", logging.ExportJSONL, "Output format: jsonl, csv or bulk")
+	output := fs.String("output", "-", "Output file ('-' for stdout)")
+	index := fs.String("index", "honeypot-{date}", "Index name for bulk output ({date} = event date)")
+	since := fs.String("since", "", "Start of range: RFC3339, YYYY-MM-DD or a duration such as 24h")
+	until := fs.String("until", "", "End of range: RFC3339, YYYY-MM-DD or a duration such as 1h")
+	ip := fs.String("ip", "", "Only events from this source IP")
+	protocol := fs.String("protocol", "", "Only events from this protocol (ssh, telnet, ftp, ...)")
+	eventType := fs.String("type", "", "Only events of this type (FAILED_LOGIN, COMMAND_EXECUTED, ...)")
+	session := fs.String("session", "", "Only events from this session")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	filter := logging.EventFilter{
+		IP:       *ip,
+		Protocol: *protocol,
+		Type:     *eventType,
+		Session:  *session,
+	}
+	var err error
+	if filter.Since, err = parseTimeFlag(*since); err != nil {
+		log.Printf("[ERROR] Invalid --since: %v", err)
+		return 2
+	}
+	if filter.Until, err = parseTimeFlag(*until); err != nil {
+		log.Printf("[ERROR] Invalid --until: %v", err)
+		return 2
+	}
+
+	if *dbPath == "" {
+		config, err := loadHoneypotConfig(*configFile)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load configuration: %v", err)
+			return 1
+		}
+		*dbPath = config.Database.File
+	}
+
+	db, err := logging.OpenEventStore(*dbPath)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Printf("[ERROR] Failed to create %s: %v", *output, err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+
+	count, err := logging.ExportEvents(db, out, filter, logging.ExportOptions{Format: *format, Index: *index})
+	if err != nil {
+		log.Printf("[ERROR] Export failed after %d events: %v", count, err)
+		return 1
+	}
+	log.Printf("[INFO] Exported %d events", count)
+	return 0
+}
+
+// parseTimeFlag aceita RFC3339, uma data simples ou uma duração relativa a agora
+func parseTimeFlag(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("formato de data desconhecido: %s", value)
+}
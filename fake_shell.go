@@ -2,130 +2,608 @@ package handlers
 
 import (
 	"bufio"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
 	"net"
 	"strings"
 	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
+	"myhoneypot/session"
+)
+
+// Resultados possíveis de sudo, su e passwd
+const (
+	SudoGrant  = "grant"  // Qualquer senha vale: prompt # e uid 0
+	SudoDeny   = "deny"   // Toda senha é recusada
+	SudoPolicy = "policy" // Decide pela política de auth do protocolo sudo, su ou passwd
 )
 
+// SimulationConfig espelha a seção additional_simulations do config.yaml
+type SimulationConfig struct {
+	SimulateSudoAccess      bool   `yaml:"simulate_sudo_access"`     // Emula sudo, su e passwd de forma interativa
+	SimulateVulnerabilities bool   `yaml:"simulate_vulnerabilities"` // Reservado
+	SudoOutcome             string `yaml:"sudo_outcome"`             // grant, deny ou policy (padrão grant)
+	SudoAttempts            int    `yaml:"sudo_attempts"`            // Senhas pedidas pelo sudo antes de desistir (padrão 3)
+
+	DownloadFiles   bool          `yaml:"download_files"`    // wget e curl baixam de verdade para o sistema de arquivos falso
+	DownloadTimeout time.Duration `yaml:"download_timeout"`  // Padrão 30s
+	DownloadMaxSize int64         `yaml:"download_max_size"` // Bytes por arquivo baixado (padrão 5 MiB)
+	ScriptMaxDepth  int           `yaml:"script_max_depth"`  // Scripts e funções aninhados (padrão 8)
+	ScriptTimeout   time.Duration `yaml:"script_timeout"`    // Tempo máximo de um script (padrão 30s)
+}
+
+// Shell é o terminal falso servido depois de um login aceito
+type Shell struct {
+	config SimulationConfig
+	logger *logging.Logger
+	store  *session.Store // nil: cada conexão começa do zero
+}
+
+// NewShell valida a configuração das simulações
+func NewShell(config SimulationConfig, logger *logging.Logger) (*Shell, error) {
+	if config.SudoOutcome == "" {
+		config.SudoOutcome = SudoGrant
+	}
+	switch config.SudoOutcome {
+	case SudoGrant, SudoDeny, SudoPolicy:
+	default:
+		return nil, fmt.Errorf("sudo_outcome desconhecido: %s", config.SudoOutcome)
+	}
+	if config.SudoAttempts <= 0 {
+		config.SudoAttempts = 3
+	}
+	if config.DownloadTimeout <= 0 {
+		config.DownloadTimeout = 30 * time.Second
+	}
+	if config.DownloadMaxSize <= 0 {
+		config.DownloadMaxSize = 5 << 20
+	}
+	if config.ScriptMaxDepth <= 0 {
+		config.ScriptMaxDepth = 8
+	}
+	if config.ScriptTimeout <= 0 {
+		config.ScriptTimeout = 30 * time.Second
+	}
+	return &Shell{config: config, logger: logger}, nil
+}
+
+// SetStore guarda o estado de cada atacante entre conexões (session_persistence)
+func (s *Shell) SetStore(store *session.Store) {
+	s.store = store
+}
+
 // FakeShell inicia uma sessão simulada de terminal para enganar invasores.
 func FakeShell(conn net.Conn) {
 	defer conn.Close()
+	shell, _ := NewShell(SimulationConfig{}, nil)
+	shell.Serve(conn, "", "admin", "")
+}
 
-	username := "admin"
-	hostname := "server01"
-	prompt := fmt.Sprintf("%s@%s:~$ ", username, hostname)
-	history := []string{}
+// Serve roda o shell para username sobre conn, que já passou pelo login do protocolo
+func (s *Shell) Serve(conn net.Conn, protocol, username, password string) {
+	session := s.newSession(conn, protocol)
+	session.login(username, password)
+	session.run()
+}
 
-	// Mensagem inicial
-	conn.Write([]byte("Welcome to Ubuntu 22.04 LTS\n"))
-	conn.Write([]byte("Last login: " + time.Now().Format("Mon Jan 2 15:04:05 2006") + " from 192.168.1.100\n"))
+// shellSession é o estado de uma conexão no shell falso
+type shellSession struct {
+	shell     *Shell
+	conn      net.Conn
+	reader    *bufio.Reader
+	protocol  string
+	ip        string
+	id        string
+	hostname  string
+	users     []string // Pilha de usuários: su e sudo -i empilham, exit desempilha
+	history   []string
+	sudoUntil time.Time // sudo não pede senha de novo até este instante
+	loginAt   time.Time
+	tty       string
+	processes []persona.Process // Login, shell e jobs em segundo plano, mostrados pelo ps
+	shellPID  int
+	nextPID   int
+	jobs      []int          // PID de cada job; o job n é jobs[n-1]
+	state     *session.State // Sistema de arquivos, contas e visitas do atacante
+	cwd       string
+	oldpwd    string
+	histfile  bool                // Falso depois de unset HISTFILE: o histórico não é gravado na saída
+	line      string              // Linha em execução, anexada aos eventos gerados por ela
+	quiet     bool                // Saída de erro descartada com 2>/dev/null
+	vars      map[string]string   // Variáveis definidas pelo atacante (X=valor, export, for)
+	functions map[string][]string // Funções definidas pelo atacante, já quebradas em comandos
+	args      []string            // $0, $1, ... do script ou função em execução
+	depth     int                 // Scripts e funções aninhados em execução
+	deadline  time.Time           // Fim do prazo do script em execução
+	steps     int                 // Comandos já executados pelo script
+	stop      bool                // exit ou return dentro de um script
+}
+
+func (s *Shell) newSession(conn net.Conn, protocol string) *shellSession {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &shellSession{
+		shell:     s,
+		conn:      conn,
+		reader:    bufio.NewReader(conn),
+		protocol:  protocol,
+		ip:        conn.RemoteAddr().String(),
+		id:        hex.EncodeToString(id),
+		hostname:  persona.Current().Hostname,
+		tty:       "pts/0",
+		vars:      make(map[string]string),
+		functions: make(map[string][]string),
+	}
+}
+
+func (s *shellSession) login(username, password string) {
+	s.users = []string{username}
+	s.loginAt = time.Now()
+	s.restore(username, password)
+	s.cwd = "/"
+	if home, err := s.lookup(s.home()); err == nil && home.Dir {
+		s.cwd = s.home()
+	}
+	s.loadHistory()
+	s.startProcesses(username)
+
+	// Mensagem inicial; quem volta vê a própria visita anterior
+	p := persona.Current()
+	s.write(p.Welcome() + "\n\n")
+	last, exists := p.LastLogin(username)
+	if previous := s.previousLogins(); len(previous) > 0 {
+		last, exists = previous[0], true
+	}
+	if exists {
+		s.write("Last login: " + last.At.Format("Mon Jan _2 15:04:05 2006") + " from " + last.From + "\n")
+	}
+}
+
+func (s *shellSession) user() string {
+	return s.users[len(s.users)-1]
+}
 
-	scanner := bufio.NewScanner(conn)
+func (s *shellSession) isRoot() bool {
+	return s.user() == "root"
+}
+
+func (s *shellSession) home() string {
+	return s.profile().Account(s.user()).Home
+}
+
+func (s *shellSession) prompt() string {
+	// O bash mostra só o primeiro rótulo do hostname (\h) e a home como ~ (\w)
+	host, _, _ := strings.Cut(s.hostname, ".")
+	dir := s.cwd
+	if dir == s.home() || strings.HasPrefix(dir, s.home()+"/") {
+		dir = "~" + strings.TrimPrefix(dir, s.home())
+	}
+	if s.isRoot() {
+		return fmt.Sprintf("%s@%s:%s# ", s.user(), host, dir)
+	}
+	return fmt.Sprintf("%s@%s:%s$ ", s.user(), host, dir)
+}
+
+func (s *shellSession) run() {
+	defer s.save()
 	for {
 		// Exibe o prompt
-		conn.Write([]byte(prompt))
+		s.write(s.prompt())
 
 		// Lê entrada do usuário
-		if !scanner.Scan() {
+		command, err := s.readLine()
+		if err != nil {
 			break
 		}
-		command := strings.TrimSpace(scanner.Text())
-		history = append(history, command)
-
-		// Registra a atividade do invasor
-		logCommand(conn.RemoteAddr().String(), command)
+		command = strings.TrimSpace(command)
 
 		// Simula autocomplete (se pressionar "Tab")
 		if command == "" {
 			continue
 		}
-		if command == "history" {
-			conn.Write([]byte(strings.Join(history, "\n") + "\n"))
-			continue
+
+		// Um bloco aberto (for ...; do) continua nas próximas linhas, com o prompt "> "
+		for lines := 1; lines < maxEditorLines; lines++ {
+			if _, open := compound(command); !open {
+				break
+			}
+			s.write("> ")
+			next, err := s.readLine()
+			if err != nil {
+				break
+			}
+			command += "\n" + next
 		}
+		s.history = append(s.history, command)
+
+		// Registra a atividade do invasor
+		s.logCommand(command)
 
-		// Processa o comando e retorna resposta
-		response := ProcessCommand(command)
-		conn.Write([]byte(response + "\n"))
+		// Simula logout ao digitar "exit"; depois de su/sudo -i volta ao usuário anterior
+		if command == "exit" || command == "logout" {
+			if len(s.users) > 1 {
+				s.users = s.users[:len(s.users)-1]
+				s.write("logout\n")
+				continue
+			}
+			s.write("Connection closed.\n")
+			break
+		}
+
+		s.line = command
+		start := time.Now()
+		response := s.execute(command)
+		metrics.ObserveLatency(s.protocol, "command", start)
+		if response != "" {
+			s.write(response + "\n")
+		}
 
 		// Simula tempo de execução para comandos pesados
 		simulateExecutionTime(command)
+	}
+}
 
-		// Simula logout ao digitar "exit"
-		if command == "exit" {
-			conn.Write([]byte("Connection closed.\n"))
-			break
+// execute responde a um comando com o usuário atual da sessão
+func (s *shellSession) execute(command string) string {
+	command = strings.TrimSpace(command)
+	if isBlock, _ := compound(command); isBlock {
+		return strings.TrimSuffix(s.call(statements(command), "-bash", s.args[min(1, len(s.args)):]), "\n")
+	}
+	if commands, ops := splitList(command); len(commands) > 1 {
+		return s.list(commands, ops)
+	}
+	if strings.HasSuffix(command, "&") && !strings.HasSuffix(command, "&&") {
+		return s.background(strings.TrimSpace(strings.TrimSuffix(command, "&")), true)
+	}
+	if stages := splitPipeline(command); len(stages) > 1 {
+		return strings.TrimSuffix(s.pipeline(stages), "\n")
+	}
+	if rest, target, appendTo := splitRedirect(command); rest != command {
+		if quiet := s.quiet; strings.Contains(command, "2>/dev/null") || strings.Contains(command, "&>/dev/null") {
+			s.quiet = true
+			defer func() { s.quiet = quiet }()
+		}
+		out := ""
+		if rest != "" {
+			out = s.stdout(rest)
+		}
+		return strings.TrimSuffix(s.redirect(out, target, appendTo), "\n")
+	}
+	if inner, ok := subshell(command); ok {
+		return s.execute(inner)
+	}
+
+	p := persona.Current()
+	fields := s.words(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	// X=valor sozinho define a variável; antes de um comando vale só para ele e é ignorado
+	if name, value, found := strings.Cut(fields[0], "="); found && isName(name) {
+		if len(fields) == 1 {
+			s.vars[name] = value
+			return ""
+		}
+		fields = fields[1:]
+	}
+	if body, exists := s.functions[fields[0]]; exists {
+		return strings.TrimSuffix(s.call(body, fields[0], fields[1:]), "\n")
+	}
+	if strings.Contains(fields[0], "/") {
+		return strings.TrimSuffix(s.runFile(fields[0], fields[1:]), "\n")
+	}
+	switch fields[0] {
+	case "w":
+		return p.W(s.logins(command))
+	case "who":
+		return p.Who(s.logins(command))
+	case "last":
+		return p.Last(append(append(s.logins(command), s.previousLogins()...), p.History()...))
+	case "ps":
+		return p.PS(fields[1:], s.psProcesses(command))
+	case "netstat":
+		return p.Netstat(fields[1:], s.sockets(), s.isRoot())
+	case "ss":
+		return p.SS(fields[1:], s.sockets(), s.isRoot())
+	case "kill":
+		return s.kill(fields[1:])
+	case "nohup":
+		if len(fields) > 1 {
+			return s.background(command, false)
+		}
+	case "history":
+		return s.historyCommand(fields[1:])
+	case "unset":
+		for _, name := range fields[1:] {
+			if name == "HISTFILE" {
+				s.histfile = false
+			}
+			delete(s.vars, name)
+		}
+		return ""
+	case "export":
+		for _, assignment := range fields[1:] {
+			if name, value, found := strings.Cut(assignment, "="); found && isName(name) {
+				s.vars[name] = value
+				if name == "HISTFILE" {
+					s.histfile = false
+				}
+			}
+		}
+		return ""
+	case "true", "false", ":", "[", "[[", "test", "set", "shopt", "ulimit", "trap":
+		return ""
+	case "exit", "return":
+		// Fora de um script o exit já foi tratado pelo run
+		s.stop = s.depth > 0
+		return ""
+	case "sh", "bash", "dash", "ash":
+		return strings.TrimSuffix(s.shellCommand(fields[0], fields[1:], "", false), "\n")
+	case "source", ".":
+		return strings.TrimSuffix(s.source(fields[0], fields[1:]), "\n")
+	case "python", "python2", "python3", "perl":
+		return s.interpreter(fields[0], fields[1:], "", false)
+	case "wget":
+		return s.wget(fields[1:])
+	case "curl":
+		return s.curl(fields[1:])
+	case "busybox":
+		if len(fields) > 1 {
+			_, rest, _ := strings.Cut(command, "busybox ")
+			return s.execute(rest)
+		}
+	case "sleep":
+		return s.sleep(fields[1:])
+	case "which":
+		return s.which(fields[1:])
+	case "command", "type", "hash":
+		if len(fields) > 1 && fields[1] == "-v" {
+			return s.which(fields[2:])
+		}
+		if len(fields) > 1 && fields[0] == "command" {
+			return s.execute(strings.Join(fields[1:], " "))
+		}
+		return s.which(fields[1:])
+	case "whoami":
+		return s.user()
+	case "id":
+		return s.idOutput(fields[1:])
+	case "pwd":
+		return s.cwd
+	case "cd":
+		return s.cd(fields[1:])
+	case "ls":
+		return s.ls(fields[1:])
+	case "cat":
+		return s.cat(fields[1:])
+	case "echo":
+		return strings.TrimSuffix(echo(fields[1:]), "\n")
+	case "printf":
+		return strings.TrimSuffix(printf(fields[1:]), "\n")
+	case "touch":
+		return s.touch(fields[1:])
+	case "mkdir":
+		return s.mkdir(fields[1:])
+	case "rm":
+		return s.rm(fields[1:])
+	case "cp":
+		return s.copyFile("cp", fields[1:], false)
+	case "mv":
+		return s.copyFile("mv", fields[1:], true)
+	case "chmod":
+		return s.chmod(fields[1:])
+	case "chattr":
+		return s.chattr(fields[1:])
+	case "grep", "egrep", "head", "tail", "wc", "sort", "uniq":
+		if len(operands(fields[1:])) > 0 {
+			return s.text(fields, "")
+		}
+		return ""
+	case "tee":
+		return s.tee(fields[1:], "")
+	case "crontab":
+		return s.crontab(fields[1:], "", false)
+	case "systemctl":
+		return s.systemctl(fields[1:])
+	case "update-rc.d", "chkconfig":
+		return s.enableInit(fields[0], fields[1:])
+	case "useradd", "adduser":
+		return s.useradd(fields[0], fields[1:])
+	case "usermod":
+		return s.usermod(fields[1:])
+	case "sudo":
+		if s.shell.config.SimulateSudoAccess {
+			return s.sudo(fields[1:])
+		}
+	case "su":
+		if s.shell.config.SimulateSudoAccess {
+			return s.su(fields[1:])
+		}
+	case "passwd":
+		if s.shell.config.SimulateSudoAccess {
+			return s.passwd(fields[1:])
+		}
+	}
+	return ProcessCommand(command)
+}
+
+// redirect manda a saída out para target (> ou >>); sem target ela vai para o terminal
+func (s *shellSession) redirect(out, target string, appendTo bool) string {
+	switch target {
+	case "":
+		return out
+	case "/dev/null":
+		return ""
+	}
+	if err := s.writeFile(s.resolve(target), []byte(out), appendTo); err != nil {
+		return fmt.Sprintf("bash: %s: %v\n", target, err)
+	}
+	return ""
+}
+
+// words separa o comando em palavras expandindo variáveis e substituições de comando
+func (s *shellSession) words(command string) []string {
+	return splitWords(command, s.env, s.stdout)
+}
+
+// stderr escreve direto no terminal, sem passar por pipes nem por > arquivo
+func (s *shellSession) stderr(text string) {
+	if !s.quiet {
+		s.write(text + "\n")
+	}
+}
+
+// stdout é a saída exata de um comando, com a quebra de linha final que o terminal recebe
+func (s *shellSession) stdout(command string) string {
+	switch commandName(command) {
+	case "echo":
+		return echo(s.words(command)[1:])
+	case "printf":
+		return printf(s.words(command)[1:])
+	}
+	if out := s.execute(command); out != "" {
+		return out + "\n"
+	}
+	return ""
+}
+
+func (s *shellSession) idOutput(args []string) string {
+	p := s.profile()
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		user, exists := p.User(arg)
+		if !exists {
+			return fmt.Sprintf("id: '%s': no such user", arg)
 		}
+		return p.ID(user)
 	}
+	return p.ID(p.Account(s.user()))
+}
+
+// Bytes do protocolo Telnet usados para esconder a senha digitada
+const (
+	telnetIAC  = 255
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetSB   = 250
+	telnetSE   = 240
+	telnetECHO = 1
+)
+
+// maxLineLength limita uma linha lida do atacante
+const maxLineLength = 4096
+
+// readLine lê uma linha descartando comandos Telnet (IAC) e o \r final
+func (s *shellSession) readLine() (string, error) {
+	var line []byte
+	for {
+		b, err := s.reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case b == telnetIAC:
+			if err := s.skipTelnetCommand(); err != nil {
+				return "", err
+			}
+		case b == '\n':
+			return strings.TrimRight(string(line), "\r"), nil
+		case b == 0:
+			// Telnet envia CR NUL para um Enter sem LF
+		case len(line) < maxLineLength:
+			line = append(line, b)
+		}
+	}
+}
+
+func (s *shellSession) skipTelnetCommand() error {
+	command, err := s.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch command {
+	case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+		_, err = s.reader.ReadByte()
+	case telnetSB:
+		for err == nil {
+			var b byte
+			if b, err = s.reader.ReadByte(); err == nil && b == telnetIAC {
+				if b, err = s.reader.ReadByte(); err == nil && b == telnetSE {
+					return nil
+				}
+			}
+		}
+	}
+	return err
+}
+
+// readPassword lê uma linha sem eco; no Telnet o servidor assume o eco e não devolve nada
+func (s *shellSession) readPassword() (string, error) {
+	if s.protocol == "telnet" {
+		s.conn.Write([]byte{telnetIAC, telnetWILL, telnetECHO})
+		defer s.conn.Write([]byte{telnetIAC, telnetWONT, telnetECHO})
+	}
+	password, err := s.readLine()
+	s.write("\n")
+	return password, err
+}
+
+// write envia texto ao atacante; Telnet exige \r\n
+func (s *shellSession) write(text string) {
+	if s.protocol == "telnet" {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	s.conn.Write([]byte(text))
+}
+
+// record registra um evento da sessão; sem logger (FakeShell) vai para a saída padrão
+func (s *shellSession) record(entry logging.LogEntry) {
+	entry.IP = s.ip
+	entry.Protocol = s.protocol
+	entry.Session = s.id
+	if entry.Username == "" {
+		entry.Username = s.user()
+	}
+	if s.shell.logger == nil {
+		fmt.Printf("[%s] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), s.ip, entry.Event)
+		return
+	}
+	s.shell.logger.Record(entry)
 }
 
 // logCommand registra os comandos do invasor
-func logCommand(ip, command string) {
-	logEntry := fmt.Sprintf("[%s] %s executed: %s\n", time.Now().Format("2006-01-02 15:04:05"), ip, command)
-	fmt.Println(logEntry) // Pode ser salvo em arquivo também
+func (s *shellSession) logCommand(command string) {
+	metrics.Commands.Inc(s.protocol)
+	s.record(logging.LogEntry{
+		Event:   "executed: " + command,
+		Level:   logging.INFO,
+		Type:    logging.EventCommand,
+		Command: command,
+	})
 }
 
 // simulateExecutionTime adiciona delays para comandos pesados
 func simulateExecutionTime(cmd string) {
 	heavyCommands := map[string]time.Duration{
-		"find / -perm -4000":    5 * time.Second,
-		"ls -lah /":             2 * time.Second,
-		"cat /etc/passwd":       1 * time.Second,
-		"sudo -l":               3 * time.Second,
-		"netstat -tulnp":        2 * time.Second,
-		"ss -tulnp":             2 * time.Second,
-		"w":                     1 * time.Second,
-		"last":                  2 * time.Second,
-		"history":               1 * time.Second,
+		"find / -perm -4000": 5 * time.Second,
+		"ls -lah /":          2 * time.Second,
+		"cat /etc/passwd":    1 * time.Second,
+		"sudo -l":            3 * time.Second,
+		"netstat -tulnp":     2 * time.Second,
+		"ss -tulnp":          2 * time.Second,
+		"w":                  1 * time.Second,
+		"last":               2 * time.Second,
+		"history":            1 * time.Second,
 	}
 	if delay, exists := heavyCommands[cmd]; exists {
 		time.Sleep(delay)
 	}
 }
-
-// ProcessCommand simula respostas realistas para comandos de hackers
-func ProcessCommand(cmd string) string {
-	cmd = strings.TrimSpace(strings.ToLower(cmd))
-
-	// Respostas falsas realistas
-	switch cmd {
-	case "ls", "dir":
-		return "bin  boot  dev  etc  home  lib  lib64  media  mnt  opt  proc  root  run  sbin  srv  sys  tmp  usr  var"
-	case "pwd":
-		return "/home/admin"
-	case "whoami":
-		return "admin"
-	case "uname -a":
-		return "Linux server01 5.15.0-84-generic #93-Ubuntu SMP x86_64 GNU/Linux"
-	case "uptime":
-		return "12:34:56 up 3 days,  4:55,  1 user,  load average: 0.10, 0.05, 0.01"
-	case "ps aux":
-		return "PID   USER      COMMAND\n1     root      /sbin/init\n2023  admin     /bin/bash"
-	case "cat /etc/passwd":
-		return "root:x:0:0:root:/root:/bin/bash\nadmin:x:1001:1001::/home/admin:/bin/bash"
-	case "cat /etc/shadow":
-		return "Permission denied"
-	case "find / -perm -4000":
-		return "/usr/bin/passwd\n/usr/bin/sudo\n/usr/bin/chsh\n/usr/bin/newgrp"
-	case "sudo -l":
-		return "[sudo] password for admin: \nSorry, user admin may not run sudo on this system."
-	case "su", "sudo su":
-		return "Password: \nAuthentication failure"
-	case "netstat -tulnp", "ss -tulnp":
-		return "Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name\n" +
-			"tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN      1234/sshd\n" +
-			"tcp        0      0 127.0.0.1:3306          0.0.0.0:*               LISTEN      5678/mysqld"
-	case "w":
-		return "USER     TTY      FROM             LOGIN@   IDLE   JCPU   PCPU WHAT\n" +
-			"admin    pts/0    192.168.1.100    12:00    00:12   0.05s  0.05s -bash"
-	case "last":
-		return "admin    pts/0    192.168.1.100    Mon Mar 18 12:00 - 12:30  (00:30)\n" +
-			"admin    pts/1    192.168.1.105    Sun Mar 17 10:45 - 11:10  (00:25)"
-	case "exit":
-		return "Session closed."
-	default:
-		return "Command not found."
-	}
-}
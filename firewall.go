@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"sync"
 	"time"
 	"math/rand"
 	"sync/atomic"
@@ -15,21 +14,24 @@ import (
 type Config struct {
 	MaxAttempts    int           // Máximo de tentativas antes de banir
 	BanDuration    time.Duration // Duração do banimento
-	AllowedIPs     []string      // Lista de IPs permitidos
+	AllowedIPs     []string      // IPs ou CIDRs permitidos (v4 e v6)
 	LogFile        string        // Arquivo de log
 	CleanUpInterval time.Duration // Intervalo para limpeza dos banidos
+	Backend        Backend       // Driver que aplica os banimentos no sistema (padrão: DefaultBackend)
+	Store          *BanStore     // Registro compartilhado de banimentos (padrão: DefaultBanStore ou um em memória)
+	Rules          *RuleEngine   // Regras allow/deny por CIDR (padrão: vazio)
+	Guard          *Guard        // Limites de taxa e detecção de força bruta (padrão: banir após MaxAttempts falhas)
 }
 
 // Firewall gerencia as regras e controle de tráfego
 type Firewall struct {
-	allowedIPs    map[string]bool
-	bannedIPs     map[string]time.Time
+	rules         *RuleEngine
+	bans          *BanStore
+	guard         *Guard
 	maxAttempts   int
 	banDuration   time.Duration
 	cleanUpInterval time.Duration
 	allowedIPsCount int32 // Contador atômico de IPs permitidos
-	bannedIPsCount int32  // Contador atômico de IPs banidos
-	mu            sync.Mutex
 	logger        *log.Logger
 }
 
@@ -37,94 +39,100 @@ type Firewall struct {
 func NewFirewall(config *Config) *Firewall {
 	// Logger configurado
 	logger := log.New(log.Writer(), "FIREWALL: ", log.LstdFlags|log.Lshortfile)
-	return &Firewall{
-		allowedIPs:     make(map[string]bool),
-		bannedIPs:      make(map[string]time.Time),
+	bans := config.Store
+	if bans == nil {
+		bans = DefaultBanStore()
+	}
+	if bans == nil {
+		// Sem banco configurado os banimentos ficam apenas em memória
+		bans, _ = NewBanStore(nil, config.Backend, BanStoreConfig{
+			DefaultDuration: config.BanDuration,
+			JanitorInterval: config.CleanUpInterval,
+		})
+	}
+	rules := config.Rules
+	if rules == nil {
+		rules, _ = NewRuleEngine(nil)
+	}
+	guard := config.Guard
+	if guard == nil {
+		guard, _ = NewGuard(GuardConfig{
+			BruteForceDetection: true,
+			MaxAttempts:         config.MaxAttempts,
+			BruteForce:          BruteForceConfig{Action: BruteForceBan, BanDuration: config.BanDuration},
+		}, bans, nil)
+	}
+	fw := &Firewall{
+		rules:          rules,
+		bans:           bans,
+		guard:          guard,
 		maxAttempts:    config.MaxAttempts,
 		banDuration:    config.BanDuration,
 		cleanUpInterval: config.CleanUpInterval,
 		logger:         logger,
 	}
+	for _, ip := range config.AllowedIPs {
+		fw.allowConnection(ip)
+	}
+	return fw
 }
 
 // Verifica se o IP está banido
 func (fw *Firewall) isBanned(ip string) bool {
-	fw.mu.Lock()
-	defer fw.mu.Unlock()
-
-	if banTime, exists := fw.bannedIPs[ip]; exists {
-		if time.Since(banTime) > fw.banDuration {
-			delete(fw.bannedIPs, ip)
-			atomic.AddInt32(&fw.bannedIPsCount, -1)
-			return false
-		}
-		return true
-	}
-	return false
+	return fw.bans.IsBanned(ip)
 }
 
-// Adiciona IP à lista de banidos
-func (fw *Firewall) banIP(ip string) {
-	fw.mu.Lock()
-	defer fw.mu.Unlock()
-
-	fw.bannedIPs[ip] = time.Now()
-	atomic.AddInt32(&fw.bannedIPsCount, 1)
-	fw.logger.Printf("IP %s banido devido ao excesso de tentativas\n", ip)
+// Registra uma falha de login; o Guard bane o IP quando a janela de força bruta estoura
+func (fw *Firewall) failedLogin(ip, protocol, username string) {
+	if verdict := fw.guard.AuthFailed(ip, protocol, username); verdict.Action != ActionAllow {
+		fw.logger.Printf("IP %s: %s (%s)\n", ip, verdict.Action, verdict.Reason)
+	}
 }
 
 // Permite uma nova conexão para um IP
 func (fw *Firewall) allowConnection(ip string) {
-	fw.mu.Lock()
-	defer fw.mu.Unlock()
-
-	if _, exists := fw.allowedIPs[ip]; !exists {
-		fw.allowedIPs[ip] = true
-		atomic.AddInt32(&fw.allowedIPsCount, 1)
-		fw.logger.Printf("IP %s permitido para conexão\n", ip)
+	if fw.rules.Allowed(ip) {
+		return
+	}
+	if err := fw.rules.Add(Rule{Name: "allowed_ips", CIDR: ip, Action: ActionAllow}); err != nil {
+		fw.logger.Printf("Erro ao permitir %s: %v\n", ip, err)
+		return
 	}
+	atomic.AddInt32(&fw.allowedIPsCount, 1)
+	fw.logger.Printf("IP %s permitido para conexão\n", ip)
 }
 
 // HandleConnection simula o processo de verificar e permitir/rejeitar conexões
 func (fw *Firewall) handleConnection(ctx context.Context, ip string) {
+	switch fw.rules.Match(ip, 0, "").Action {
+	case ActionDeny:
+		fw.logger.Printf("Conexão rejeitada: IP %s negado por regra\n", ip)
+		return
+	case ActionAllow:
+		fw.logger.Printf("Conexão de %s liberada por regra\n", ip)
+		return
+	}
+
 	if fw.isBanned(ip) {
 		fw.logger.Printf("Conexão rejeitada: IP %s está banido\n", ip)
 		return
 	}
 
-	// Simula tentativas de login
-	attempts := 0
-	for attempts < fw.maxAttempts {
-		select {
-		case <-ctx.Done():
-			fw.logger.Println("Operação cancelada")
-			return
-		default:
-			attempts++
-			fw.logger.Printf("Tentativa %d de %d para %s\n", attempts, fw.maxAttempts, ip)
-
-			if attempts >= fw.maxAttempts {
-				fw.banIP(ip)
-			}
-		}
+	select {
+	case <-ctx.Done():
+		fw.logger.Println("Operação cancelada")
+		return
+	default:
 	}
-}
 
-// Limpeza periódica dos IPs banidos
-func (fw *Firewall) cleanUpBannedIPs() {
-	for {
-		select {
-		case <-time.After(fw.cleanUpInterval):
-			fw.mu.Lock()
-			for ip, banTime := range fw.bannedIPs {
-				if time.Since(banTime) > fw.banDuration {
-					delete(fw.bannedIPs, ip)
-					atomic.AddInt32(&fw.bannedIPsCount, -1)
-				}
-			}
-			fw.mu.Unlock()
-			fw.logger.Println("Limpeza de IPs banidos realizada")
-		}
+	// Limites de taxa por IP e por rede, sobre o tráfego real
+	switch verdict := fw.guard.CheckConnection(ip, ""); verdict.Action {
+	case ActionDeny:
+		fw.logger.Printf("Conexão rejeitada: %s (%s)\n", ip, verdict.Reason)
+	case ActionTarpit:
+		fw.logger.Printf("Conexão de %s enviada ao tarpit (%s)\n", ip, verdict.Reason)
+	default:
+		fw.logger.Printf("Conexão de %s permitida\n", ip)
 	}
 }
 
@@ -149,7 +157,7 @@ func (fw *Firewall) MonitorIP(ip string) {
 
 // StartFirewall inicia o servidor de firewall para gerenciar conexões
 func (fw *Firewall) startFirewall() {
-	go fw.cleanUpBannedIPs() // Inicia a limpeza periódica
+	// A limpeza dos banidos vencidos é feita pelo BanStore
 
 	// Simula alguns IPs para conexão
 	ips := []string{
@@ -0,0 +1,226 @@
+package firewall
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"myhoneypot/metrics"
+)
+
+// Backend aplica os banimentos no firewall do sistema operacional
+type Backend interface {
+	Name() string
+	Ban(ip net.IP) error
+	Unban(ip net.IP) error
+	List() ([]net.IP, error)
+	Flush() error
+}
+
+// Executor executa comandos externos; pode ser substituído para gerar regras sem root
+type Executor interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// CommandExecutor executa os comandos de verdade, opcionalmente via sudo
+type CommandExecutor struct {
+	Sudo bool
+}
+
+// Run executa o comando e devolve stdout e stderr combinados
+func (e CommandExecutor) Run(name string, args ...string) ([]byte, error) {
+	if e.Sudo {
+		args = append([]string{name}, args...)
+		name = "sudo"
+	}
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s %s: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// BackendConfig seleciona o driver de firewall
+type BackendConfig struct {
+	Driver  string `yaml:"driver"`   // iptables, nftables, ipset ou dryrun
+	UseSudo bool   `yaml:"use_sudo"` // Prefixa os comandos com sudo
+}
+
+// NewBackend cria o driver configurado e prepara as regras base
+func NewBackend(config BackendConfig, executor Executor) (Backend, error) {
+	if executor == nil {
+		executor = CommandExecutor{Sudo: config.UseSudo}
+	}
+
+	switch strings.ToLower(config.Driver) {
+	case "iptables":
+		return NewIPTablesBackend(executor)
+	case "nftables", "nft":
+		return NewNFTablesBackend(executor)
+	case "ipset":
+		return NewIPSetBackend(executor)
+	case "dryrun", "dry-run", "":
+		return NewDryRunBackend(), nil
+	default:
+		return nil, fmt.Errorf("driver de firewall desconhecido: %s", config.Driver)
+	}
+}
+
+// ParseSourceIP extrai o IP de endereços como os de RemoteAddr() ("1.2.3.4:5555", "[::1]:22")
+func ParseSourceIP(addr string) (net.IP, error) {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	if ip == nil {
+		return nil, fmt.Errorf("endereço IP inválido: %s", addr)
+	}
+	return normalizeIP(ip), nil
+}
+
+// Backend e registro de banimentos padrão, usados pelos serviços que não recebem um Firewall próprio
+var (
+	defaultBackend   Backend = NewDryRunBackend()
+	defaultBanStore  *BanStore
+	defaultBackendMu sync.RWMutex
+)
+
+// SetDefaultBackend troca o backend usado por BanAddr/UnbanAddr
+func SetDefaultBackend(backend Backend) {
+	defaultBackendMu.Lock()
+	defer defaultBackendMu.Unlock()
+	defaultBackend = backend
+}
+
+// DefaultBackend retorna o backend padrão
+func DefaultBackend() Backend {
+	defaultBackendMu.RLock()
+	defer defaultBackendMu.RUnlock()
+	return defaultBackend
+}
+
+// SetDefaultBanStore define o registro onde BanAddr grava os banimentos
+func SetDefaultBanStore(store *BanStore) {
+	defaultBackendMu.Lock()
+	defer defaultBackendMu.Unlock()
+	defaultBanStore = store
+}
+
+// DefaultBanStore retorna o registro de banimentos padrão (nil se não configurado)
+func DefaultBanStore() *BanStore {
+	defaultBackendMu.RLock()
+	defer defaultBackendMu.RUnlock()
+	return defaultBanStore
+}
+
+// BanAddr bane o IP de um endereço de conexão. Com um registro configurado o banimento
+// vale para todos os serviços e expira conforme security.ban_duration.
+func BanAddr(addr, reason, source string) error {
+	if store := DefaultBanStore(); store != nil {
+		return store.Ban(addr, reason, source, 0)
+	}
+
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return err
+	}
+	if err := DefaultBackend().Ban(ip); err != nil {
+		return err
+	}
+	metrics.FirewallBans.Inc()
+	return nil
+}
+
+// UnbanAddr remove o banimento de um endereço
+func UnbanAddr(addr string) error {
+	if store := DefaultBanStore(); store != nil {
+		return store.Unban(addr)
+	}
+
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return err
+	}
+	return DefaultBackend().Unban(ip)
+}
+
+// DryRunBackend guarda os banimentos em memória sem tocar no sistema (útil em testes)
+type DryRunBackend struct {
+	mu     sync.Mutex
+	banned map[string]net.IP
+	logger *log.Logger
+}
+
+// NewDryRunBackend cria o backend em memória
+func NewDryRunBackend() *DryRunBackend {
+	return &DryRunBackend{
+		banned: make(map[string]net.IP),
+		logger: log.New(log.Writer(), "FIREWALL (dry-run): ", log.LstdFlags),
+	}
+}
+
+// Name identifica o driver
+func (b *DryRunBackend) Name() string { return "dryrun" }
+
+// Ban registra o IP como banido
+func (b *DryRunBackend) Ban(ip net.IP) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.banned[ip.String()] = ip
+	b.logger.Printf("ban %s\n", ip)
+	return nil
+}
+
+// Unban remove o IP da lista
+func (b *DryRunBackend) Unban(ip net.IP) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.banned, ip.String())
+	b.logger.Printf("unban %s\n", ip)
+	return nil
+}
+
+// List retorna os IPs banidos em ordem
+func (b *DryRunBackend) List() ([]net.IP, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keys := make([]string, 0, len(b.banned))
+	for key := range b.banned {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ips := make([]net.IP, 0, len(keys))
+	for _, key := range keys {
+		ips = append(ips, b.banned[key])
+	}
+	return ips, nil
+}
+
+// Flush remove todos os banimentos
+func (b *DryRunBackend) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.banned = make(map[string]net.IP)
+	b.logger.Println("flush")
+	return nil
+}
+
+// normalizeIP usa a forma de 4 bytes para IPv4, para que comparações e chaves sejam estáveis
+func normalizeIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
+
+// isIPv6 indica se o IP precisa das variantes v6 dos comandos
+func isIPv6(ip net.IP) bool {
+	return ip.To4() == nil
+}
@@ -0,0 +1,272 @@
+package firewall
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingExecutor guarda cada comando em vez de executá-lo; argv listados em fail
+// retornam erro (regra ou elemento ausente) e outputs simula a saída dos comandos de listagem
+type recordingExecutor struct {
+	calls   []string
+	fail    map[string]bool
+	outputs map[string]string
+}
+
+func (e *recordingExecutor) Run(name string, args ...string) ([]byte, error) {
+	argv := strings.Join(append([]string{name}, args...), " ")
+	e.calls = append(e.calls, argv)
+	if e.fail[argv] {
+		return nil, errors.New("exit status 1")
+	}
+	return []byte(e.outputs[argv]), nil
+}
+
+func newBackend(t *testing.T, driver string, executor Executor) Backend {
+	t.Helper()
+	backend, err := NewBackend(BackendConfig{Driver: driver}, executor)
+	if err != nil {
+		t.Fatalf("NewBackend(%s): %v", driver, err)
+	}
+	return backend
+}
+
+func set(argvs ...string) map[string]bool {
+	m := make(map[string]bool)
+	for _, argv := range argvs {
+		m[argv] = true
+	}
+	return m
+}
+
+func TestBackendSetup(t *testing.T) {
+	tests := []struct {
+		driver string
+		fail   map[string]bool
+		want   []string
+	}{
+		{
+			driver: "iptables",
+			fail: set("iptables -n -L HONEYPOT", "iptables -C INPUT -j HONEYPOT",
+				"ip6tables -n -L HONEYPOT", "ip6tables -C INPUT -j HONEYPOT"),
+			want: []string{
+				"iptables -n -L HONEYPOT", "iptables -N HONEYPOT", "iptables -C INPUT -j HONEYPOT", "iptables -I INPUT -j HONEYPOT",
+				"ip6tables -n -L HONEYPOT", "ip6tables -N HONEYPOT", "ip6tables -C INPUT -j HONEYPOT", "ip6tables -I INPUT -j HONEYPOT",
+			},
+		},
+		{
+			// Chain já criada e ligada ao INPUT: nada é alterado
+			driver: "iptables",
+			want: []string{
+				"iptables -n -L HONEYPOT", "iptables -C INPUT -j HONEYPOT",
+				"ip6tables -n -L HONEYPOT", "ip6tables -C INPUT -j HONEYPOT",
+			},
+		},
+		{
+			driver: "nftables",
+			fail:   set("nft list table inet honeypot"),
+			want: []string{
+				"nft list table inet honeypot",
+				"nft add table inet honeypot",
+				"nft add set inet honeypot banned4 { type ipv4_addr; }",
+				"nft add set inet honeypot banned6 { type ipv6_addr; }",
+				"nft add chain inet honeypot input { type filter hook input priority -10; policy accept; }",
+				"nft add rule inet honeypot input ip saddr @banned4 drop",
+				"nft add rule inet honeypot input ip6 saddr @banned6 drop",
+			},
+		},
+		{
+			driver: "nftables",
+			want:   []string{"nft list table inet honeypot"},
+		},
+		{
+			driver: "ipset",
+			fail: set("iptables -C INPUT -m set --match-set honeypot4 src -j DROP",
+				"ip6tables -C INPUT -m set --match-set honeypot6 src -j DROP"),
+			want: []string{
+				"ipset create honeypot4 hash:ip family inet -exist",
+				"iptables -C INPUT -m set --match-set honeypot4 src -j DROP",
+				"iptables -I INPUT -m set --match-set honeypot4 src -j DROP",
+				"ipset create honeypot6 hash:ip family inet6 -exist",
+				"ip6tables -C INPUT -m set --match-set honeypot6 src -j DROP",
+				"ip6tables -I INPUT -m set --match-set honeypot6 src -j DROP",
+			},
+		},
+	}
+	for _, tt := range tests {
+		executor := &recordingExecutor{fail: tt.fail}
+		newBackend(t, tt.driver, executor)
+		if !reflect.DeepEqual(executor.calls, tt.want) {
+			t.Errorf("%s: comandos de preparação\n got %q\nwant %q", tt.driver, executor.calls, tt.want)
+		}
+	}
+}
+
+func TestBackendCommands(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		op     string // ban, unban ou flush
+		ip     string
+		fail   map[string]bool
+		want   []string
+	}{
+		// iptables: -C antes de -A/-D evita regras duplicadas e erros de regra ausente
+		{"iptables ban v4", "iptables", "ban", "203.0.113.9",
+			set("iptables -C HONEYPOT -s 203.0.113.9 -j DROP"),
+			[]string{"iptables -C HONEYPOT -s 203.0.113.9 -j DROP", "iptables -A HONEYPOT -s 203.0.113.9 -j DROP"}},
+		{"iptables ban v4 existente", "iptables", "ban", "203.0.113.9", nil,
+			[]string{"iptables -C HONEYPOT -s 203.0.113.9 -j DROP"}},
+		{"iptables ban v6", "iptables", "ban", "2001:db8::1",
+			set("ip6tables -C HONEYPOT -s 2001:db8::1 -j DROP"),
+			[]string{"ip6tables -C HONEYPOT -s 2001:db8::1 -j DROP", "ip6tables -A HONEYPOT -s 2001:db8::1 -j DROP"}},
+		{"iptables unban v4", "iptables", "unban", "203.0.113.9", nil,
+			[]string{"iptables -C HONEYPOT -s 203.0.113.9 -j DROP", "iptables -D HONEYPOT -s 203.0.113.9 -j DROP"}},
+		{"iptables unban v6 ausente", "iptables", "unban", "2001:db8::1",
+			set("ip6tables -C HONEYPOT -s 2001:db8::1 -j DROP"),
+			[]string{"ip6tables -C HONEYPOT -s 2001:db8::1 -j DROP"}},
+		{"iptables flush", "iptables", "flush", "", nil,
+			[]string{"iptables -F HONEYPOT", "ip6tables -F HONEYPOT"}},
+
+		{"nftables ban v4", "nftables", "ban", "203.0.113.9", nil,
+			[]string{"nft add element inet honeypot banned4 { 203.0.113.9 }"}},
+		{"nftables ban v6", "nftables", "ban", "2001:db8::1", nil,
+			[]string{"nft add element inet honeypot banned6 { 2001:db8::1 }"}},
+		{"nftables unban v4", "nftables", "unban", "203.0.113.9", nil,
+			[]string{"nft get element inet honeypot banned4 { 203.0.113.9 }", "nft delete element inet honeypot banned4 { 203.0.113.9 }"}},
+		{"nftables unban v6 ausente", "nftables", "unban", "2001:db8::1",
+			set("nft get element inet honeypot banned6 { 2001:db8::1 }"),
+			[]string{"nft get element inet honeypot banned6 { 2001:db8::1 }"}},
+		{"nftables flush", "nftables", "flush", "", nil,
+			[]string{"nft flush set inet honeypot banned4", "nft flush set inet honeypot banned6"}},
+
+		// ipset: -exist torna add e del idempotentes
+		{"ipset ban v4", "ipset", "ban", "203.0.113.9", nil,
+			[]string{"ipset add honeypot4 203.0.113.9 -exist"}},
+		{"ipset ban v6", "ipset", "ban", "2001:db8::1", nil,
+			[]string{"ipset add honeypot6 2001:db8::1 -exist"}},
+		{"ipset unban v4 ausente", "ipset", "unban", "203.0.113.9", nil,
+			[]string{"ipset del honeypot4 203.0.113.9 -exist"}},
+		{"ipset unban v6", "ipset", "unban", "2001:db8::1", nil,
+			[]string{"ipset del honeypot6 2001:db8::1 -exist"}},
+		{"ipset flush", "ipset", "flush", "", nil,
+			[]string{"ipset flush honeypot4", "ipset flush honeypot6"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &recordingExecutor{}
+			backend := newBackend(t, tt.driver, executor)
+			executor.calls, executor.fail = nil, tt.fail
+
+			var err error
+			ip := net.ParseIP(tt.ip)
+			switch tt.op {
+			case "ban":
+				err = backend.Ban(normalizeIP(ip))
+			case "unban":
+				err = backend.Unban(normalizeIP(ip))
+			case "flush":
+				err = backend.Flush()
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", tt.op, err)
+			}
+			if !reflect.DeepEqual(executor.calls, tt.want) {
+				t.Errorf("comandos\n got %q\nwant %q", executor.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackendList(t *testing.T) {
+	tests := []struct {
+		driver  string
+		outputs map[string]string
+		want    []string
+		calls   []string
+	}{
+		{
+			driver: "iptables",
+			outputs: map[string]string{
+				"iptables -S HONEYPOT":  "-N HONEYPOT\n-A HONEYPOT -s 203.0.113.9/32 -j DROP\n-A HONEYPOT -s 198.51.100.7/32 -j DROP\n",
+				"ip6tables -S HONEYPOT": "-N HONEYPOT\n-A HONEYPOT -s 2001:db8::1/128 -j DROP\n",
+			},
+			want:  []string{"203.0.113.9", "198.51.100.7", "2001:db8::1"},
+			calls: []string{"iptables -S HONEYPOT", "ip6tables -S HONEYPOT"},
+		},
+		{
+			driver: "nftables",
+			outputs: map[string]string{
+				"nft -j list set inet honeypot banned4": `{"nftables":[{"metainfo":{"version":"1.0.2"}},{"set":{"family":"inet","name":"banned4","elem":["203.0.113.9",{"elem":{"val":"198.51.100.7","timeout":3600}}]}}]}`,
+				"nft -j list set inet honeypot banned6": `{"nftables":[{"set":{"family":"inet","name":"banned6","elem":["2001:db8::1"]}}]}`,
+			},
+			want:  []string{"203.0.113.9", "198.51.100.7", "2001:db8::1"},
+			calls: []string{"nft -j list set inet honeypot banned4", "nft -j list set inet honeypot banned6"},
+		},
+		{
+			driver: "ipset",
+			outputs: map[string]string{
+				"ipset save honeypot4": "create honeypot4 hash:ip family inet hashsize 1024 maxelem 65536\nadd honeypot4 203.0.113.9\nadd honeypot4 198.51.100.7\n",
+				"ipset save honeypot6": "create honeypot6 hash:ip family inet6 hashsize 1024 maxelem 65536\nadd honeypot6 2001:db8::1\n",
+			},
+			want:  []string{"203.0.113.9", "198.51.100.7", "2001:db8::1"},
+			calls: []string{"ipset save honeypot4", "ipset save honeypot6"},
+		},
+	}
+	for _, tt := range tests {
+		executor := &recordingExecutor{}
+		backend := newBackend(t, tt.driver, executor)
+		executor.calls, executor.outputs = nil, tt.outputs
+
+		ips, err := backend.List()
+		if err != nil {
+			t.Fatalf("%s: List: %v", tt.driver, err)
+		}
+		var got []string
+		for _, ip := range ips {
+			got = append(got, ip.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: List = %q, esperado %q", tt.driver, got, tt.want)
+		}
+		if !reflect.DeepEqual(executor.calls, tt.calls) {
+			t.Errorf("%s: comandos\n got %q\nwant %q", tt.driver, executor.calls, tt.calls)
+		}
+	}
+}
+
+func TestBackendErrors(t *testing.T) {
+	executor := &recordingExecutor{}
+	backend := newBackend(t, "nftables", executor)
+	executor.fail = set("nft add element inet honeypot banned4 { 203.0.113.9 }")
+	if err := backend.Ban(net.ParseIP("203.0.113.9").To4()); err == nil {
+		t.Error("Ban deveria propagar a falha do nft")
+	}
+
+	if _, err := NewBackend(BackendConfig{Driver: "pf"}, executor); err == nil {
+		t.Error("driver desconhecido deveria falhar")
+	}
+}
+
+func TestDryRunBackend(t *testing.T) {
+	backend := newBackend(t, "dryrun", nil)
+	v4, v6 := net.ParseIP("203.0.113.9").To4(), net.ParseIP("2001:db8::1")
+	backend.Ban(v6)
+	backend.Ban(v4)
+	backend.Ban(v4)
+	backend.Unban(net.ParseIP("198.51.100.7"))
+
+	// Ordem das chaves em texto: "2001:db8::1" vem antes de "203.0.113.9"
+	ips, _ := backend.List()
+	if len(ips) != 2 || !ips[0].Equal(v6) || !ips[1].Equal(v4) {
+		t.Fatalf("List = %v", ips)
+	}
+	backend.Unban(v4)
+	backend.Flush()
+	if ips, _ := backend.List(); len(ips) != 0 {
+		t.Fatalf("List depois do Flush = %v", ips)
+	}
+}
@@ -0,0 +1,408 @@
+package firewall
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+)
+
+// Origem dos banimentos carregados do config.yaml
+const BanSourceConfig = "config"
+
+// Ban é uma entrada do registro de banimentos
+type Ban struct {
+	IP        string
+	Reason    string
+	Source    string // Evento que originou o banimento (ex: SUSPICIOUS_COMMAND, FAILED_LOGIN, config)
+	BannedAt  time.Time
+	ExpiresAt time.Time // Zero para banimentos permanentes
+}
+
+// Expired indica se o banimento já venceu
+func (b *Ban) Expired(now time.Time) bool {
+	return !b.ExpiresAt.IsZero() && !now.Before(b.ExpiresAt)
+}
+
+// BanStoreConfig ajusta o comportamento do registro de banimentos
+type BanStoreConfig struct {
+	DefaultDuration time.Duration // Validade padrão dos banimentos automáticos (0 = permanente)
+	Persistent      bool          // Mantém os banimentos automáticos entre reinícios
+	JanitorInterval time.Duration // Intervalo da limpeza dos banimentos vencidos
+}
+
+// BanStore é o registro único de IPs banidos, compartilhado por todos os serviços.
+// As entradas ficam em memória para a checagem no accept e são gravadas na tabela banned_ips.
+type BanStore struct {
+	db       *sql.DB // nil mantém o registro apenas em memória
+	backend  Backend
+	rules    *RuleEngine // IPs com regra allow nunca são banidos
+	config   BanStoreConfig
+	bans     map[string]*Ban
+	mu       sync.RWMutex
+	stop     chan struct{}
+	stopOnce sync.Once
+	logger   *log.Logger
+}
+
+// NewBanStore carrega os banimentos do banco, reaplica-os no backend e inicia a limpeza periódica
+func NewBanStore(db *sql.DB, backend Backend, config BanStoreConfig) (*BanStore, error) {
+	if backend == nil {
+		backend = DefaultBackend()
+	}
+	if config.JanitorInterval <= 0 {
+		config.JanitorInterval = time.Minute
+	}
+
+	s := &BanStore{
+		db:      db,
+		backend: backend,
+		config:  config,
+		bans:    make(map[string]*Ban),
+		stop:    make(chan struct{}),
+		logger:  log.New(log.Writer(), "BANS: ", log.LstdFlags|log.Lshortfile),
+	}
+
+	if db != nil {
+		if err := s.migrate(); err != nil {
+			return nil, err
+		}
+		if err := s.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	go s.janitor()
+	return s, nil
+}
+
+// migrate cria a tabela banned_ips e adiciona as colunas novas em bancos antigos
+func (s *BanStore) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS banned_ips (
+			ip TEXT PRIMARY KEY,
+			banned_at TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela de banimentos: %v", err)
+	}
+
+	rows, err := s.db.Query("PRAGMA table_info(banned_ips)")
+	if err != nil {
+		return fmt.Errorf("erro ao ler estrutura da tabela de banimentos: %v", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("erro ao ler estrutura da tabela de banimentos: %v", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, column := range []string{"expires_at", "reason", "source"} {
+		if existing[column] {
+			continue
+		}
+		if _, err := s.db.Exec("ALTER TABLE banned_ips ADD COLUMN " + column + " TEXT"); err != nil {
+			return fmt.Errorf("erro ao adicionar coluna %s: %v", column, err)
+		}
+	}
+
+	if _, err := s.db.Exec("CREATE INDEX IF NOT EXISTS idx_banned_ips_expires_at ON banned_ips(expires_at)"); err != nil {
+		return fmt.Errorf("erro ao criar índice: %v", err)
+	}
+	return nil
+}
+
+// load lê os banimentos válidos do banco e os aplica no backend
+func (s *BanStore) load() error {
+	// Sem persistência, só os banimentos do config sobrevivem ao reinício
+	if !s.config.Persistent {
+		if _, err := s.db.Exec("DELETE FROM banned_ips WHERE COALESCE(source, '') != ?", BanSourceConfig); err != nil {
+			return fmt.Errorf("erro ao limpar banimentos antigos: %v", err)
+		}
+	}
+
+	rows, err := s.db.Query("SELECT ip, banned_at, COALESCE(expires_at, ''), COALESCE(reason, ''), COALESCE(source, '') FROM banned_ips")
+	if err != nil {
+		return fmt.Errorf("erro ao ler banimentos: %v", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var expired []string
+	s.mu.Lock()
+	for rows.Next() {
+		var ip, bannedAt, expiresAt string
+		ban := &Ban{}
+		if err := rows.Scan(&ip, &bannedAt, &expiresAt, &ban.Reason, &ban.Source); err != nil {
+			s.mu.Unlock()
+			return fmt.Errorf("erro ao ler banimentos: %v", err)
+		}
+		parsed, err := ParseSourceIP(ip)
+		if err != nil {
+			s.logger.Printf("Ignorando banimento inválido %q: %v\n", ip, err)
+			continue
+		}
+		ban.IP = parsed.String()
+		ban.BannedAt, _ = time.ParseInLocation(logging.TimestampLayout, bannedAt, time.Local)
+		if expiresAt != "" {
+			ban.ExpiresAt, _ = time.ParseInLocation(logging.TimestampLayout, expiresAt, time.Local)
+		}
+		if ban.Expired(now) {
+			expired = append(expired, ban.IP)
+			continue
+		}
+		s.bans[ban.IP] = ban
+	}
+	s.mu.Unlock()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao ler banimentos: %v", err)
+	}
+
+	for _, ip := range expired {
+		_, _ = s.db.Exec("DELETE FROM banned_ips WHERE ip = ?", ip)
+	}
+	for _, ban := range s.List() {
+		if err := s.backend.Ban(net.ParseIP(ban.IP)); err != nil {
+			s.logger.Printf("Erro ao reaplicar banimento de %s no %s: %v\n", ban.IP, s.backend.Name(), err)
+		}
+	}
+	s.updateMetrics()
+	return nil
+}
+
+// SetRules define as regras de acesso consultadas antes de banir
+func (s *BanStore) SetRules(rules *RuleEngine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = rules
+}
+
+// Seed registra os IPs do config.yaml como banimentos permanentes e remove os que
+// saíram da lista desde a última execução
+func (s *BanStore) Seed(ips []string) {
+	listed := make(map[string]bool)
+	for _, addr := range ips {
+		ip, err := ParseSourceIP(addr)
+		if err != nil {
+			s.logger.Printf("Ignorando banned_ips inválido %q: %v\n", addr, err)
+			continue
+		}
+		listed[ip.String()] = true
+		if err := s.Ban(ip.String(), "listado em banned_ips", BanSourceConfig, -1); err != nil {
+			s.logger.Printf("Erro ao banir %s do config: %v\n", ip, err)
+		}
+	}
+
+	for _, ban := range s.List() {
+		if ban.Source == BanSourceConfig && !listed[ban.IP] {
+			if err := s.Unban(ban.IP); err != nil {
+				s.logger.Printf("Erro ao remover banimento de %s: %v\n", ban.IP, err)
+			}
+		}
+	}
+}
+
+// Ban bane o IP de addr (aceita "ip" ou "ip:porta"). duration 0 usa a validade padrão
+// e um valor negativo cria um banimento permanente.
+func (s *BanStore) Ban(addr, reason, source string, duration time.Duration) error {
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return err
+	}
+	if duration == 0 {
+		duration = s.config.DefaultDuration
+	}
+
+	now := time.Now()
+	ban := &Ban{IP: ip.String(), Reason: reason, Source: source, BannedAt: now}
+	if duration > 0 {
+		ban.ExpiresAt = now.Add(duration)
+	}
+
+	s.mu.Lock()
+	if s.rules != nil && s.rules.Allowed(ban.IP) {
+		s.mu.Unlock()
+		s.logger.Printf("IP %s não banido: liberado por regra allow (%s)\n", ban.IP, reason)
+		return nil
+	}
+	if current, exists := s.bans[ban.IP]; exists && !current.Expired(now) {
+		// Um banimento permanente (ex: do config) não é rebaixado por um temporário
+		if current.ExpiresAt.IsZero() || (!ban.ExpiresAt.IsZero() && current.ExpiresAt.After(ban.ExpiresAt)) {
+			s.mu.Unlock()
+			return nil
+		}
+	}
+	s.bans[ban.IP] = ban
+	s.mu.Unlock()
+
+	if err := s.save(ban); err != nil {
+		return err
+	}
+	if err := s.backend.Ban(ip); err != nil {
+		return err
+	}
+
+	metrics.FirewallBans.Inc()
+	s.updateMetrics()
+	s.logger.Printf("IP %s banido (%s, origem %s, expira %s)\n", ban.IP, reason, source, expiryString(ban))
+	return nil
+}
+
+// Unban remove o banimento do IP de addr
+func (s *BanStore) Unban(addr string) error {
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.bans, ip.String())
+	s.mu.Unlock()
+
+	if s.db != nil {
+		if _, err := s.db.Exec("DELETE FROM banned_ips WHERE ip = ?", ip.String()); err != nil {
+			return fmt.Errorf("erro ao remover banimento: %v", err)
+		}
+	}
+	s.updateMetrics()
+	return s.backend.Unban(ip)
+}
+
+// Lookup retorna o banimento válido do IP de addr, se houver
+func (s *BanStore) Lookup(addr string) (Ban, bool) {
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return Ban{}, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ban, exists := s.bans[ip.String()]
+	if !exists || ban.Expired(time.Now()) {
+		return Ban{}, false
+	}
+	return *ban, true
+}
+
+// IsBanned verifica se o IP de addr está banido; usado no accept de todos os serviços
+func (s *BanStore) IsBanned(addr string) bool {
+	_, banned := s.Lookup(addr)
+	return banned
+}
+
+// List retorna os banimentos válidos ordenados por IP
+func (s *BanStore) List() []Ban {
+	now := time.Now()
+	s.mu.RLock()
+	list := make([]Ban, 0, len(s.bans))
+	for _, ban := range s.bans {
+		if !ban.Expired(now) {
+			list = append(list, *ban)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].IP < list[j].IP })
+	return list
+}
+
+// Close interrompe a limpeza periódica; o banco continua aberto para quem o passou a NewBanStore
+func (s *BanStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// save grava (ou substitui) a entrada na tabela banned_ips
+func (s *BanStore) save(ban *Ban) error {
+	if s.db == nil {
+		return nil
+	}
+	var expiresAt interface{}
+	if !ban.ExpiresAt.IsZero() {
+		expiresAt = ban.ExpiresAt.Format(logging.TimestampLayout)
+	}
+	_, err := s.db.Exec("INSERT OR REPLACE INTO banned_ips (ip, banned_at, expires_at, reason, source) VALUES (?, ?, ?, ?, ?)",
+		ban.IP, ban.BannedAt.Format(logging.TimestampLayout), expiresAt, ban.Reason, ban.Source)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar banimento: %v", err)
+	}
+	return nil
+}
+
+// janitor remove periodicamente os banimentos vencidos
+func (s *BanStore) janitor() {
+	ticker := time.NewTicker(s.config.JanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.expire(time.Now())
+		}
+	}
+}
+
+// expire retira do registro, do banco e do backend os banimentos vencidos até now
+func (s *BanStore) expire(now time.Time) {
+	var expired []*Ban
+	s.mu.Lock()
+	for ip, ban := range s.bans {
+		if ban.Expired(now) {
+			expired = append(expired, ban)
+			delete(s.bans, ip)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+
+	for _, ban := range expired {
+		if s.db != nil {
+			if _, err := s.db.Exec("DELETE FROM banned_ips WHERE ip = ? AND expires_at = ?", ban.IP, ban.ExpiresAt.Format(logging.TimestampLayout)); err != nil {
+				s.logger.Printf("Erro ao remover banimento vencido de %s: %v\n", ban.IP, err)
+			}
+		}
+		// Um Ban do mesmo IP desde a remoção do mapa já instalou a regra nova: não a remove. O lock fica
+		// até o fim do Unban para que um Ban concorrente só chegue ao backend depois dele
+		s.mu.Lock()
+		if _, rebanned := s.bans[ban.IP]; !rebanned {
+			if err := s.backend.Unban(net.ParseIP(ban.IP)); err != nil {
+				s.logger.Printf("Erro ao remover banimento de %s no %s: %v\n", ban.IP, s.backend.Name(), err)
+			}
+		}
+		s.mu.Unlock()
+	}
+	s.updateMetrics()
+	s.logger.Printf("%d banimentos vencidos removidos\n", len(expired))
+}
+
+func (s *BanStore) updateMetrics() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	metrics.BannedIPs.Set(float64(len(s.bans)))
+}
+
+func expiryString(ban *Ban) string {
+	if ban.ExpiresAt.IsZero() {
+		return "nunca"
+	}
+	return ban.ExpiresAt.Format(logging.TimestampLayout)
+}
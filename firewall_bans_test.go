@@ -0,0 +1,84 @@
+package firewall
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowBackend guarda os IPs banidos em memória; onUnban roda no início de cada Unban,
+// antes da remoção, que só acontece depois de delay
+type slowBackend struct {
+	mu      sync.Mutex
+	banned  map[string]bool
+	delay   time.Duration
+	onUnban func()
+}
+
+func (b *slowBackend) Name() string            { return "slow" }
+func (b *slowBackend) List() ([]net.IP, error) { return nil, nil }
+func (b *slowBackend) Flush() error            { return nil }
+
+func (b *slowBackend) has(ip string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.banned[ip]
+}
+
+func (b *slowBackend) set(ip string, banned bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.banned[ip] = banned
+}
+
+func (b *slowBackend) Ban(ip net.IP) error {
+	b.set(ip.String(), true)
+	return nil
+}
+
+func (b *slowBackend) Unban(ip net.IP) error {
+	if b.onUnban != nil {
+		b.onUnban()
+	}
+	time.Sleep(b.delay)
+	b.set(ip.String(), false)
+	return nil
+}
+
+func TestExpireKeepsRebannedIP(t *testing.T) {
+	const ip = "203.0.113.9"
+	backend := &slowBackend{banned: make(map[string]bool), delay: 100 * time.Millisecond}
+	store, err := NewBanStore(nil, backend, BanStoreConfig{JanitorInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if err := store.Ban(ip, "brute force", "BRUTE_FORCE", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// O atacante volta e é banido de novo enquanto o banimento vencido está sendo removido
+	var rebanned sync.WaitGroup
+	backend.onUnban = func() {
+		backend.onUnban = nil
+		rebanned.Add(1)
+		go func() {
+			defer rebanned.Done()
+			if err := store.Ban(ip, "brute force", "BRUTE_FORCE", time.Hour); err != nil {
+				t.Error(err)
+			}
+		}()
+		time.Sleep(20 * time.Millisecond)
+	}
+	store.expire(time.Now().Add(time.Second))
+	rebanned.Wait()
+
+	if !store.IsBanned(ip) {
+		t.Fatal("novo banimento não registrado")
+	}
+	if !backend.has(ip) {
+		t.Fatal("registro diz banido, mas a regra do novo banimento foi removida do backend")
+	}
+}
@@ -0,0 +1,294 @@
+package firewall
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+)
+
+// ProtocolUnknown é o resultado da classificação quando nenhum sniffer reconhece o payload
+const ProtocolUnknown = "unknown"
+
+// CatchAllConfig ajusta o serviço genérico das portas sem emulador dedicado
+type CatchAllConfig struct {
+	MaxBytes    int           `yaml:"max_bytes"`    // Bytes do primeiro payload guardados (padrão 4096)
+	WaitClient  time.Duration `yaml:"wait_client"`  // Espera o cliente falar antes de enviar o banner (padrão 3s)
+	ReadTimeout time.Duration `yaml:"read_timeout"` // Tempo máximo da conexão (padrão 15s)
+}
+
+// withDefaults preenche os valores não configurados
+func (c CatchAllConfig) withDefaults() CatchAllConfig {
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = 4096
+	}
+	if c.WaitClient <= 0 {
+		c.WaitClient = 3 * time.Second
+	}
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = 15 * time.Second
+	}
+	return c
+}
+
+// sniffer reconhece um protocolo pelos primeiros bytes; reply é a resposta usada quando a porta não tem banner
+type sniffer struct {
+	name  string
+	match func(data []byte) bool
+	reply string
+}
+
+// sniffers em ordem de avaliação: os mais específicos antes dos genéricos
+var sniffers = []sniffer{
+	{name: "tls", match: sniffTLS},
+	{name: "ssh", match: hasPrefix("SSH-")},
+	{name: "sip", match: firstLineContains(" SIP/2.0")},
+	{name: "rtsp", match: firstLineContains(" RTSP/1.0")},
+	{name: "http", match: hasPrefix("GET ", "POST ", "HEAD ", "PUT ", "DELETE ", "OPTIONS ", "PATCH ", "TRACE ", "CONNECT ", "PRI * HTTP/2"),
+		reply: "HTTP/1.1 404 Not Found\r\nServer: nginx\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"},
+	{name: "rdp", match: sniffRDP},
+	{name: "smb", match: sniffSMB},
+	{name: "mssql", match: sniffTDS},
+	{name: "postgresql", match: sniffPostgres},
+	{name: "mongodb", match: sniffMongo},
+	{name: "mysql", match: sniffMySQL},
+	{name: "redis", match: sniffRedis, reply: "-NOAUTH Authentication required.\r\n"},
+	{name: "memcached", match: hasPrefix("stats", "version", "get ", "gets ", "set "), reply: "ERROR\r\n"},
+	{name: "vnc", match: hasPrefix("RFB ")},
+	{name: "mqtt", match: sniffMQTT},
+	{name: "smtp", match: hasPrefix("EHLO", "HELO", "ehlo", "helo")},
+	{name: "ftp", match: hasPrefix("USER ", "user ")},
+	{name: "bittorrent", match: hasPrefix("\x13BitTorrent protocol")},
+	{name: "java-rmi", match: hasPrefix("JRMI")},
+	{name: "telnet", match: sniffTelnet},
+	{name: "socks", match: sniffSOCKS},
+	{name: "modbus", match: sniffModbus},
+}
+
+// SniffProtocol classifica o primeiro payload de uma conexão (http, tls, rdp, smb, redis, ...)
+func SniffProtocol(data []byte) string {
+	if s := findSniffer(data); s != nil {
+		return s.name
+	}
+	return ProtocolUnknown
+}
+
+func findSniffer(data []byte) *sniffer {
+	if len(data) == 0 {
+		return nil
+	}
+	for i := range sniffers {
+		if sniffers[i].match(data) {
+			return &sniffers[i]
+		}
+	}
+	return nil
+}
+
+func hasPrefix(prefixes ...string) func([]byte) bool {
+	return func(data []byte) bool {
+		for _, prefix := range prefixes {
+			if bytes.HasPrefix(data, []byte(prefix)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func firstLineContains(value string) func([]byte) bool {
+	return func(data []byte) bool {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[:i]
+		}
+		return bytes.Contains(data, []byte(value))
+	}
+}
+
+// sniffTLS reconhece o registro handshake (TLS 1.0 a 1.3) e o ClientHello do SSLv2
+func sniffTLS(data []byte) bool {
+	if len(data) >= 3 && data[0] == 0x16 && data[1] == 0x03 && data[2] <= 0x04 {
+		return true
+	}
+	return len(data) >= 3 && data[0]&0x80 != 0 && data[2] == 0x01
+}
+
+// sniffRDP reconhece o TPKT com X.224 Connection Request (com ou sem "Cookie: mstshash=")
+func sniffRDP(data []byte) bool {
+	return len(data) >= 11 && data[0] == 0x03 && data[1] == 0x00 && data[5] == 0xe0
+}
+
+// sniffSMB reconhece SMB1/SMB2 sobre NetBIOS session service
+func sniffSMB(data []byte) bool {
+	if len(data) < 8 || data[0] != 0x00 {
+		return false
+	}
+	return bytes.Equal(data[4:8], []byte("\xffSMB")) || bytes.Equal(data[4:8], []byte("\xfeSMB"))
+}
+
+// sniffTDS reconhece o PRELOGIN do Microsoft SQL Server
+func sniffTDS(data []byte) bool {
+	return len(data) >= 8 && data[0] == 0x12 && data[1] <= 0x01 && int(binary.BigEndian.Uint16(data[2:4])) >= 8
+}
+
+// sniffPostgres reconhece StartupMessage, SSLRequest e GSSENCRequest
+func sniffPostgres(data []byte) bool {
+	if len(data) < 8 {
+		return false
+	}
+	length := binary.BigEndian.Uint32(data[0:4])
+	code := binary.BigEndian.Uint32(data[4:8])
+	return length >= 8 && length <= 10000 && (code == 0x00030000 || code == 80877103 || code == 80877104)
+}
+
+// sniffMongo reconhece OP_QUERY e OP_MSG
+func sniffMongo(data []byte) bool {
+	if len(data) < 16 {
+		return false
+	}
+	length := binary.LittleEndian.Uint32(data[0:4])
+	opcode := binary.LittleEndian.Uint32(data[12:16])
+	return length >= 16 && length <= 48*1024*1024 && (opcode == 2004 || opcode == 2013)
+}
+
+// sniffMySQL reconhece a resposta do cliente ao handshake (pacote com sequência 1)
+func sniffMySQL(data []byte) bool {
+	if len(data) < 36 {
+		return false
+	}
+	length := int(data[0]) | int(data[1])<<8 | int(data[2])<<16
+	return data[3] == 1 && length >= 32 && length <= len(data)-4+1024
+}
+
+// sniffRedis reconhece comandos RESP ("*1\r\n$4\r\nPING") e inline
+func sniffRedis(data []byte) bool {
+	if len(data) >= 2 && data[0] == '*' && data[1] >= '0' && data[1] <= '9' {
+		return true
+	}
+	return hasPrefix("PING", "ping", "INFO", "info", "AUTH ", "CONFIG ", "config ", "SLAVEOF ", "FLUSHALL")(data)
+}
+
+// sniffMQTT reconhece o pacote CONNECT
+func sniffMQTT(data []byte) bool {
+	return len(data) >= 8 && data[0] == 0x10 &&
+		(bytes.Contains(data[:min(len(data), 16)], []byte("MQTT")) || bytes.Contains(data[:min(len(data), 16)], []byte("MQIsdp")))
+}
+
+// sniffTelnet reconhece a negociação IAC (WILL/WONT/DO/DONT)
+func sniffTelnet(data []byte) bool {
+	return len(data) >= 3 && data[0] == 0xff && data[1] >= 0xfb && data[1] <= 0xfe
+}
+
+// sniffSOCKS reconhece o CONNECT do SOCKS4 e a saudação do SOCKS5
+func sniffSOCKS(data []byte) bool {
+	if len(data) >= 9 && data[0] == 0x04 && (data[1] == 0x01 || data[1] == 0x02) {
+		return true
+	}
+	return len(data) >= 3 && data[0] == 0x05 && data[1] >= 1 && len(data) == 2+int(data[1])
+}
+
+// sniffModbus reconhece o cabeçalho MBAP (protocol id 0 e tamanho coerente)
+func sniffModbus(data []byte) bool {
+	return len(data) >= 8 && data[2] == 0 && data[3] == 0 && int(binary.BigEndian.Uint16(data[4:6])) == len(data)-6
+}
+
+// catchAll coleta o primeiro payload: espera o cliente falar (HTTP, TLS, RDP, ...) e, se ele ficar
+// calado, envia o banner da porta primeiro (SSH, FTP, MySQL, ...). O payload é classificado
+// pelos sniffers e registrado como FIRST_PAYLOAD.
+func (pm *PortManager) catchAll(service, banner string) ServiceHandler {
+	return func(conn net.Conn) {
+		defer conn.Close()
+		defer metrics.TrackConnection(service, conn)()
+
+		pm.mutex.RLock()
+		config := pm.catchAllConfig.withDefaults()
+		pm.mutex.RUnlock()
+
+		port := 0
+		if tcpAddr, ok := conn.LocalAddr().(*net.TCPAddr); ok {
+			port = tcpAddr.Port
+		}
+		start := time.Now()
+		buf := make([]byte, config.MaxBytes)
+
+		n := capture(conn, buf, start.Add(config.WaitClient))
+		bannerSent := false
+		if n == 0 && banner != "" {
+			conn.Write([]byte(banner))
+			bannerSent = true
+			n = capture(conn, buf, start.Add(config.ReadTimeout))
+		}
+
+		data := buf[:n]
+		protocol := ProtocolUnknown
+		if s := findSniffer(data); s != nil {
+			protocol = s.name
+			// Quem fala primeiro espera resposta; sem banner configurado usa a resposta do protocolo
+			reply := banner
+			if reply == "" {
+				reply = s.reply
+			}
+			if !bannerSent && reply != "" {
+				conn.Write([]byte(reply))
+			}
+		} else if !bannerSent && banner != "" {
+			conn.Write([]byte(banner))
+		}
+		metrics.ScanPayloads.Inc(protocol)
+
+		event := fmt.Sprintf("Porta %d: nenhum dado recebido", port)
+		if n > 0 {
+			event = fmt.Sprintf("Porta %d: payload %s (%d bytes): %q", port, protocol, n, preview(data))
+		}
+
+		pm.mutex.RLock()
+		recorder := pm.recorder
+		pm.mutex.RUnlock()
+		if recorder == nil {
+			pm.logger.Printf("%s: %s\n", conn.RemoteAddr(), event)
+			return
+		}
+		entry := logging.LogEntry{
+			IP:       conn.RemoteAddr().String(),
+			Event:    event,
+			Level:    logging.INFO,
+			Type:     logging.EventFirstPayload,
+			Protocol: protocol,
+			Port:     port,
+		}
+		if n > 0 {
+			entry.Payload = hex.EncodeToString(data)
+		}
+		recorder.Record(entry)
+	}
+}
+
+// capture lê até encher buf ou até o prazo; após o primeiro bloco espera só um pouco por mais dados
+func capture(conn net.Conn, buf []byte, deadline time.Time) int {
+	n := 0
+	for n < len(buf) {
+		conn.SetReadDeadline(deadline)
+		read, err := conn.Read(buf[n:])
+		n += read
+		if err != nil {
+			break
+		}
+		if next := time.Now().Add(500 * time.Millisecond); next.Before(deadline) {
+			deadline = next
+		}
+	}
+	return n
+}
+
+// preview limita o trecho do payload incluído na mensagem do evento
+func preview(data []byte) []byte {
+	if len(data) > 128 {
+		return data[:128]
+	}
+	return data
+}
@@ -0,0 +1,460 @@
+package firewall
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+// Ação adicional do Guard: manter o atacante preso no tarpit em vez de recusar
+const ActionTarpit = "tarpit"
+
+// Ações possíveis quando a detecção de força bruta dispara
+const (
+	BruteForceLog    = "log"
+	BruteForceTarpit = "tarpit"
+	BruteForceBan    = "ban"
+)
+
+// RateLimit define um token bucket: Limit eventos por Window, com rajadas de até Burst
+type RateLimit struct {
+	Limit  int           `yaml:"limit"` // 0 desativa o limite
+	Window time.Duration `yaml:"window"`
+	Burst  int           `yaml:"burst"` // Padrão: Limit
+}
+
+// RateLimitScope aplica limites por IP de origem e por rede (/24 no IPv4, /64 no IPv6)
+type RateLimitScope struct {
+	PerIP     RateLimit `yaml:"per_ip"`
+	PerSubnet RateLimit `yaml:"per_subnet"`
+}
+
+// RateLimitConfig agrupa os limites de conexões e de tentativas de autenticação
+type RateLimitConfig struct {
+	Connections RateLimitScope `yaml:"connections"`
+	Auth        RateLimitScope `yaml:"auth"`
+	// A primeira recusa de uma origem gera um RATE_LIMITED na hora; as seguintes são somadas
+	// e saem num RATE_LIMITED de resumo a cada SummaryInterval (padrão 1m)
+	SummaryInterval time.Duration `yaml:"summary_interval"`
+}
+
+// BruteForceConfig ajusta a janela deslizante de falhas de login (todos os protocolos somados)
+type BruteForceConfig struct {
+	Window      time.Duration `yaml:"window"`
+	Action      string        `yaml:"action"`       // log, tarpit ou ban
+	BanDuration time.Duration `yaml:"ban_duration"` // Duração do ban/tarpit; 0 usa a do BanStore
+}
+
+// GuardConfig reúne as configurações do Guard
+type GuardConfig struct {
+	BruteForceDetection bool
+	MaxAttempts         int // Falhas dentro da janela que disparam a ação
+	BruteForce          BruteForceConfig
+	RateLimits          RateLimitConfig
+}
+
+// Recorder recebe os eventos de decisão do Guard (normalmente o *logging.Logger)
+type Recorder interface {
+	Record(entry logging.LogEntry)
+}
+
+// Verdict é a decisão do Guard para uma conexão ou tentativa de login
+type Verdict struct {
+	Action string // ActionAllow, ActionDeny ou ActionTarpit
+	Reason string
+}
+
+// Guard aplica limites de taxa e detecta força bruta a partir do tráfego real
+type Guard struct {
+	connIP, connSubnet *rateLimiter
+	authIP, authSubnet *rateLimiter
+
+	config     GuardConfig
+	failures   map[string][]time.Time  // Falhas de login por IP na janela
+	tarpitted  map[string]time.Time    // IPs no tarpit e até quando
+	suppressed map[string]*suppression // Recusas por rate limit ainda não resumidas, por IP e motivo
+	mu         sync.Mutex
+
+	bans     *BanStore
+	recorder Recorder
+	stop     chan struct{}
+	stopOnce sync.Once
+	logger   *log.Logger
+}
+
+// NewGuard cria o Guard; bans e recorder podem ser nil
+func NewGuard(config GuardConfig, bans *BanStore, recorder Recorder) (*Guard, error) {
+	switch config.BruteForce.Action {
+	case "":
+		config.BruteForce.Action = BruteForceBan
+	case BruteForceLog, BruteForceTarpit, BruteForceBan:
+	default:
+		return nil, fmt.Errorf("ação de força bruta inválida: %s (use log, tarpit ou ban)", config.BruteForce.Action)
+	}
+	if config.BruteForce.Window <= 0 {
+		config.BruteForce.Window = 10 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 10
+	}
+	if config.RateLimits.SummaryInterval <= 0 {
+		config.RateLimits.SummaryInterval = time.Minute
+	}
+
+	g := &Guard{
+		connIP:     newRateLimiter(config.RateLimits.Connections.PerIP),
+		connSubnet: newRateLimiter(config.RateLimits.Connections.PerSubnet),
+		authIP:     newRateLimiter(config.RateLimits.Auth.PerIP),
+		authSubnet: newRateLimiter(config.RateLimits.Auth.PerSubnet),
+		config:     config,
+		failures:   make(map[string][]time.Time),
+		tarpitted:  make(map[string]time.Time),
+		suppressed: make(map[string]*suppression),
+		bans:       bans,
+		recorder:   recorder,
+		stop:       make(chan struct{}),
+		logger:     log.New(log.Writer(), "GUARD: ", log.LstdFlags|log.Lshortfile),
+	}
+	go g.cleanUp()
+	return g, nil
+}
+
+// CheckConnection aplica os limites de conexão por IP e por rede
+func (g *Guard) CheckConnection(addr, protocol string) Verdict {
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return Verdict{Action: ActionAllow}
+	}
+	if verdict, limited := g.limit(g.connIP, g.connSubnet, ip, protocol, "conexões"); limited {
+		return verdict
+	}
+	if g.isTarpitted(ip) {
+		return Verdict{Action: ActionTarpit, Reason: "força bruta"}
+	}
+	return Verdict{Action: ActionAllow}
+}
+
+// CheckAuth aplica os limites de tentativas de autenticação por IP e por rede
+func (g *Guard) CheckAuth(addr, protocol string) Verdict {
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return Verdict{Action: ActionAllow}
+	}
+	if verdict, limited := g.limit(g.authIP, g.authSubnet, ip, protocol, "autenticações"); limited {
+		return verdict
+	}
+	return Verdict{Action: ActionAllow}
+}
+
+// AuthFailed registra uma falha de login e aplica a ação configurada ao atingir o limite
+func (g *Guard) AuthFailed(addr, protocol, username string) Verdict {
+	if !g.config.BruteForceDetection {
+		return Verdict{Action: ActionAllow}
+	}
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return Verdict{Action: ActionAllow}
+	}
+
+	now := time.Now()
+	key := ip.String()
+	window := g.config.BruteForce.Window
+
+	g.mu.Lock()
+	recent := g.failures[key][:0]
+	for _, t := range g.failures[key] {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	count := len(recent)
+	if count < g.config.MaxAttempts {
+		g.failures[key] = recent
+		g.mu.Unlock()
+		return Verdict{Action: ActionAllow}
+	}
+	// A janela recomeça após a ação, para não repetir o alerta a cada nova falha
+	delete(g.failures, key)
+	g.mu.Unlock()
+
+	reason := fmt.Sprintf("força bruta: %d falhas de login em %s (último usuário %q)", count, window, username)
+	verdict := Verdict{Action: ActionAllow, Reason: reason}
+
+	switch g.config.BruteForce.Action {
+	case BruteForceTarpit:
+		duration := g.config.BruteForce.BanDuration
+		if duration <= 0 {
+			duration = time.Hour
+		}
+		g.mu.Lock()
+		g.tarpitted[key] = now.Add(duration)
+		g.mu.Unlock()
+		verdict.Action = ActionTarpit
+	case BruteForceBan:
+		if g.bans != nil {
+			if err := g.bans.Ban(key, reason, logging.EventFailedLogin, g.config.BruteForce.BanDuration); err != nil {
+				g.logger.Printf("Erro ao banir %s: %v\n", key, err)
+			}
+		}
+		verdict.Action = ActionDeny
+	}
+
+	g.emit(logging.LogEntry{
+		IP:       addr,
+		Event:    fmt.Sprintf("%s (ação: %s)", reason, g.config.BruteForce.Action),
+		Level:    logging.CRITICAL,
+		Type:     logging.EventBruteForce,
+		Protocol: protocol,
+		Username: username,
+	})
+	return verdict
+}
+
+// Close interrompe a limpeza periódica e emite o resumo das recusas ainda não registradas
+func (g *Guard) Close() {
+	g.stopOnce.Do(func() {
+		close(g.stop)
+		g.flushSuppressed()
+	})
+}
+
+// suppression conta as recusas de uma origem que ainda não saíram em evento
+type suppression struct {
+	ip, protocol, reason string
+	count                int
+}
+
+// limit consome um token do IP e da rede. Toda recusa é registrada: a primeira de uma sequência
+// gera um evento na hora e as demais entram no resumo periódico
+func (g *Guard) limit(perIP, perSubnet *rateLimiter, ip net.IP, protocol, what string) (Verdict, bool) {
+	key := ip.String()
+	if allowed, first := perIP.take(key); !allowed {
+		reason := fmt.Sprintf("limite de %s por IP excedido (%s)", what, perIP.limit)
+		g.refused(key, protocol, reason, first)
+		return Verdict{Action: ActionDeny, Reason: reason}, true
+	}
+
+	subnet := subnetKey(ip)
+	if allowed, first := perSubnet.take(subnet); !allowed {
+		reason := fmt.Sprintf("limite de %s da rede %s excedido (%s)", what, subnet, perSubnet.limit)
+		g.refused(key, protocol, reason, first)
+		return Verdict{Action: ActionDeny, Reason: reason}, true
+	}
+	return Verdict{}, false
+}
+
+func (g *Guard) refused(ip, protocol, reason string, first bool) {
+	if first {
+		g.emitRateLimited(ip, protocol, reason)
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := ip + "|" + reason
+	s, exists := g.suppressed[key]
+	if !exists {
+		s = &suppression{ip: ip, protocol: protocol, reason: reason}
+		g.suppressed[key] = s
+	}
+	s.count++
+}
+
+// flushSuppressed emite um RATE_LIMITED por origem com o total de recusas desde o último resumo
+func (g *Guard) flushSuppressed() {
+	g.mu.Lock()
+	pending := g.suppressed
+	g.suppressed = make(map[string]*suppression)
+	g.mu.Unlock()
+
+	for _, s := range pending {
+		g.emitRateLimited(s.ip, s.protocol, fmt.Sprintf("%s: mais %d recusas desde o último aviso", s.reason, s.count))
+	}
+}
+
+func (g *Guard) emitRateLimited(ip, protocol, reason string) {
+	g.emit(logging.LogEntry{
+		IP:       ip,
+		Event:    reason,
+		Level:    logging.WARNING,
+		Type:     logging.EventRateLimited,
+		Protocol: protocol,
+	})
+}
+
+func (g *Guard) emit(entry logging.LogEntry) {
+	if g.recorder != nil {
+		g.recorder.Record(entry)
+		return
+	}
+	g.logger.Printf("%s: %s %s\n", entry.Type, entry.IP, entry.Event)
+}
+
+func (g *Guard) isTarpitted(ip net.IP) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	until, exists := g.tarpitted[ip.String()]
+	return exists && time.Now().Before(until)
+}
+
+// cleanUp descarta buckets cheios, janelas vencidas e tarpits expirados
+func (g *Guard) cleanUp() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	summary := time.NewTicker(g.config.RateLimits.SummaryInterval)
+	defer summary.Stop()
+
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-summary.C:
+			g.flushSuppressed()
+		case now := <-ticker.C:
+			for _, limiter := range []*rateLimiter{g.connIP, g.connSubnet, g.authIP, g.authSubnet} {
+				limiter.cleanUp(now)
+			}
+
+			g.mu.Lock()
+			for key, times := range g.failures {
+				if len(times) == 0 || now.Sub(times[len(times)-1]) >= g.config.BruteForce.Window {
+					delete(g.failures, key)
+				}
+			}
+			for key, until := range g.tarpitted {
+				if !now.Before(until) {
+					delete(g.tarpitted, key)
+				}
+			}
+			g.mu.Unlock()
+		}
+	}
+}
+
+// subnetKey agrupa IPs em /24 (IPv4) ou /64 (IPv6)
+func subnetKey(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// String descreve o limite (ex: "30/1m0s")
+func (r RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Window)
+}
+
+// tokenBucket guarda os tokens disponíveis de uma origem
+type tokenBucket struct {
+	tokens  float64
+	last    time.Time
+	limited bool // Já foi limitado desde o último token concedido
+}
+
+// rateLimiter mantém um token bucket por chave (IP ou rede)
+type rateLimiter struct {
+	limit   RateLimit
+	rate    float64 // Tokens por segundo
+	burst   float64
+	buckets map[string]*tokenBucket
+	mu      sync.Mutex
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.Window <= 0 {
+		limit.Window = time.Minute
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Limit
+	}
+	return &rateLimiter{
+		limit:   limit,
+		rate:    float64(limit.Limit) / limit.Window.Seconds(),
+		burst:   float64(limit.Burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// take consome um token; first indica a primeira recusa desde o último token concedido
+func (l *rateLimiter) take(key string) (allowed bool, first bool) {
+	if l.limit.Limit <= 0 {
+		return true, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.limited = false
+		return true, false
+	}
+
+	first = !bucket.limited
+	bucket.limited = true
+	return false, first
+}
+
+// cleanUp remove buckets que já se recompuseram por completo
+func (l *rateLimiter) cleanUp(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Guard padrão, usado pelos serviços que não recebem um Guard próprio
+var (
+	defaultGuard   *Guard
+	defaultGuardMu sync.RWMutex
+)
+
+// SetDefaultGuard define o Guard usado por CheckAuth/AuthFailed
+func SetDefaultGuard(guard *Guard) {
+	defaultGuardMu.Lock()
+	defer defaultGuardMu.Unlock()
+	defaultGuard = guard
+}
+
+// CheckAuth consulta o Guard padrão antes de uma tentativa de login; sem Guard tudo é permitido
+func CheckAuth(addr, protocol string) Verdict {
+	defaultGuardMu.RLock()
+	guard := defaultGuard
+	defaultGuardMu.RUnlock()
+	if guard == nil {
+		return Verdict{Action: ActionAllow}
+	}
+	return guard.CheckAuth(addr, strings.ToLower(protocol))
+}
+
+// AuthFailed registra uma falha de login no Guard padrão
+func AuthFailed(addr, protocol, username string) Verdict {
+	defaultGuardMu.RLock()
+	guard := defaultGuard
+	defaultGuardMu.RUnlock()
+	if guard == nil {
+		return Verdict{Action: ActionAllow}
+	}
+	return guard.AuthFailed(addr, strings.ToLower(protocol), username)
+}
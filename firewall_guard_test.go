@@ -0,0 +1,50 @@
+package firewall
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+type recordingRecorder struct {
+	mu      sync.Mutex
+	entries []logging.LogEntry
+}
+
+func (r *recordingRecorder) Record(entry logging.LogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+func TestGuardSummarizesSuppressedRefusals(t *testing.T) {
+	recorder := &recordingRecorder{}
+	guard, err := NewGuard(GuardConfig{RateLimits: RateLimitConfig{
+		Connections:     RateLimitScope{PerIP: RateLimit{Limit: 1, Window: time.Hour}},
+		SummaryInterval: time.Hour,
+	}}, nil, recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 5 {
+		guard.CheckConnection("203.0.113.9:1234", "ssh")
+	}
+	// 1 conexão aceita, 4 recusadas: a primeira recusa sai na hora e as outras 3 ficam no resumo
+	if len(recorder.entries) != 1 || recorder.entries[0].Type != logging.EventRateLimited {
+		t.Fatalf("eventos antes do resumo = %+v, esperado um RATE_LIMITED", recorder.entries)
+	}
+
+	guard.Close()
+	if len(recorder.entries) != 2 {
+		t.Fatalf("eventos após Close = %d, esperado o resumo", len(recorder.entries))
+	}
+	summary := recorder.entries[1]
+	if summary.Type != logging.EventRateLimited || summary.IP != "203.0.113.9" || summary.Protocol != "ssh" ||
+		!strings.Contains(summary.Event, "mais 3 recusas") {
+		t.Fatalf("resumo = %+v", summary)
+	}
+}
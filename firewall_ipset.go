@@ -0,0 +1,95 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Sets do ipset usados pelo driver (um por família)
+const (
+	ipsetSet4 = "honeypot4"
+	ipsetSet6 = "honeypot6"
+)
+
+// IPSetBackend bane IPs adicionando-os a sets do ipset referenciados por uma regra do iptables
+type IPSetBackend struct {
+	exec Executor
+}
+
+// NewIPSetBackend cria os sets e as regras de DROP que os consultam
+func NewIPSetBackend(executor Executor) (*IPSetBackend, error) {
+	b := &IPSetBackend{exec: executor}
+	sets := []struct{ name, family, iptables string }{
+		{ipsetSet4, "inet", "iptables"},
+		{ipsetSet6, "inet6", "ip6tables"},
+	}
+	for _, s := range sets {
+		if _, err := b.exec.Run("ipset", "create", s.name, "hash:ip", "family", s.family, "-exist"); err != nil {
+			return nil, fmt.Errorf("erro ao criar set %s: %v", s.name, err)
+		}
+		rule := []string{"INPUT", "-m", "set", "--match-set", s.name, "src", "-j", "DROP"}
+		if _, err := b.exec.Run(s.iptables, append([]string{"-C"}, rule...)...); err != nil {
+			if _, err := b.exec.Run(s.iptables, append([]string{"-I"}, rule...)...); err != nil {
+				return nil, fmt.Errorf("erro ao criar regra do set %s: %v", s.name, err)
+			}
+		}
+	}
+	return b, nil
+}
+
+// Name identifica o driver
+func (b *IPSetBackend) Name() string { return "ipset" }
+
+// Ban adiciona o IP ao set
+func (b *IPSetBackend) Ban(ip net.IP) error {
+	if _, err := b.exec.Run("ipset", "add", ipsetSetFor(ip), ip.String(), "-exist"); err != nil {
+		return fmt.Errorf("erro ao banir %s: %v", ip, err)
+	}
+	return nil
+}
+
+// Unban remove o IP do set
+func (b *IPSetBackend) Unban(ip net.IP) error {
+	if _, err := b.exec.Run("ipset", "del", ipsetSetFor(ip), ip.String(), "-exist"); err != nil {
+		return fmt.Errorf("erro ao remover banimento de %s: %v", ip, err)
+	}
+	return nil
+}
+
+// List lê os sets no formato do "ipset save" ("add honeypot4 1.2.3.4")
+func (b *IPSetBackend) List() ([]net.IP, error) {
+	var ips []net.IP
+	for _, set := range []string{ipsetSet4, ipsetSet6} {
+		out, err := b.exec.Run("ipset", "save", set)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar set %s: %v", set, err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && fields[0] == "add" && fields[1] == set {
+				if ip := net.ParseIP(fields[2]); ip != nil {
+					ips = append(ips, normalizeIP(ip))
+				}
+			}
+		}
+	}
+	return ips, nil
+}
+
+// Flush esvazia os sets
+func (b *IPSetBackend) Flush() error {
+	for _, set := range []string{ipsetSet4, ipsetSet6} {
+		if _, err := b.exec.Run("ipset", "flush", set); err != nil {
+			return fmt.Errorf("erro ao limpar set %s: %v", set, err)
+		}
+	}
+	return nil
+}
+
+func ipsetSetFor(ip net.IP) string {
+	if isIPv6(ip) {
+		return ipsetSet6
+	}
+	return ipsetSet4
+}
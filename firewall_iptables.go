@@ -0,0 +1,102 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Chain dedicada, para que List/Flush não mexam em regras de terceiros
+const iptablesChain = "HONEYPOT"
+
+// IPTablesBackend bane IPs com regras DROP em uma chain própria (iptables/ip6tables)
+type IPTablesBackend struct {
+	exec Executor
+}
+
+// NewIPTablesBackend cria a chain HONEYPOT e a liga ao INPUT (v4 e v6)
+func NewIPTablesBackend(executor Executor) (*IPTablesBackend, error) {
+	b := &IPTablesBackend{exec: executor}
+	for _, bin := range []string{"iptables", "ip6tables"} {
+		if _, err := b.exec.Run(bin, "-n", "-L", iptablesChain); err != nil {
+			if _, err := b.exec.Run(bin, "-N", iptablesChain); err != nil {
+				return nil, fmt.Errorf("erro ao criar chain %s: %v", iptablesChain, err)
+			}
+		}
+		if _, err := b.exec.Run(bin, "-C", "INPUT", "-j", iptablesChain); err != nil {
+			if _, err := b.exec.Run(bin, "-I", "INPUT", "-j", iptablesChain); err != nil {
+				return nil, fmt.Errorf("erro ao ligar chain %s ao INPUT: %v", iptablesChain, err)
+			}
+		}
+	}
+	return b, nil
+}
+
+// Name identifica o driver
+func (b *IPTablesBackend) Name() string { return "iptables" }
+
+// Ban adiciona a regra DROP, sem duplicar se ela já existir
+func (b *IPTablesBackend) Ban(ip net.IP) error {
+	bin := iptablesBinary(ip)
+	if _, err := b.exec.Run(bin, "-C", iptablesChain, "-s", ip.String(), "-j", "DROP"); err == nil {
+		return nil
+	}
+	if _, err := b.exec.Run(bin, "-A", iptablesChain, "-s", ip.String(), "-j", "DROP"); err != nil {
+		return fmt.Errorf("erro ao banir %s: %v", ip, err)
+	}
+	return nil
+}
+
+// Unban remove a regra DROP do IP
+func (b *IPTablesBackend) Unban(ip net.IP) error {
+	bin := iptablesBinary(ip)
+	if _, err := b.exec.Run(bin, "-C", iptablesChain, "-s", ip.String(), "-j", "DROP"); err != nil {
+		return nil
+	}
+	if _, err := b.exec.Run(bin, "-D", iptablesChain, "-s", ip.String(), "-j", "DROP"); err != nil {
+		return fmt.Errorf("erro ao remover banimento de %s: %v", ip, err)
+	}
+	return nil
+}
+
+// List lê as regras da chain ("-A HONEYPOT -s 1.2.3.4/32 -j DROP")
+func (b *IPTablesBackend) List() ([]net.IP, error) {
+	var ips []net.IP
+	for _, bin := range []string{"iptables", "ip6tables"} {
+		out, err := b.exec.Run(bin, "-S", iptablesChain)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar chain %s: %v", iptablesChain, err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			for i := 0; i+1 < len(fields); i++ {
+				if fields[i] != "-s" {
+					continue
+				}
+				if ip, _, err := net.ParseCIDR(fields[i+1]); err == nil {
+					ips = append(ips, normalizeIP(ip))
+				} else if ip := net.ParseIP(fields[i+1]); ip != nil {
+					ips = append(ips, normalizeIP(ip))
+				}
+			}
+		}
+	}
+	return ips, nil
+}
+
+// Flush esvazia a chain HONEYPOT
+func (b *IPTablesBackend) Flush() error {
+	for _, bin := range []string{"iptables", "ip6tables"} {
+		if _, err := b.exec.Run(bin, "-F", iptablesChain); err != nil {
+			return fmt.Errorf("erro ao limpar chain %s: %v", iptablesChain, err)
+		}
+	}
+	return nil
+}
+
+func iptablesBinary(ip net.IP) string {
+	if isIPv6(ip) {
+		return "ip6tables"
+	}
+	return "iptables"
+}
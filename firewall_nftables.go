@@ -0,0 +1,142 @@
+package firewall
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// Tabela e sets usados pelo driver nftables
+const (
+	nftFamily = "inet"
+	nftTable  = "honeypot"
+	nftSet4   = "banned4"
+	nftSet6   = "banned6"
+)
+
+// NFTablesBackend bane IPs adicionando-os a sets do nftables
+type NFTablesBackend struct {
+	exec Executor
+}
+
+// NewNFTablesBackend cria a tabela inet honeypot, os sets e a chain de input
+func NewNFTablesBackend(executor Executor) (*NFTablesBackend, error) {
+	b := &NFTablesBackend{exec: executor}
+
+	// A tabela já existe: regras criadas numa execução anterior
+	if _, err := b.exec.Run("nft", "list", "table", nftFamily, nftTable); err == nil {
+		return b, nil
+	}
+
+	commands := [][]string{
+		{"add", "table", nftFamily, nftTable},
+		{"add", "set", nftFamily, nftTable, nftSet4, "{ type ipv4_addr; }"},
+		{"add", "set", nftFamily, nftTable, nftSet6, "{ type ipv6_addr; }"},
+		{"add", "chain", nftFamily, nftTable, "input", "{ type filter hook input priority -10; policy accept; }"},
+		{"add", "rule", nftFamily, nftTable, "input", "ip", "saddr", "@" + nftSet4, "drop"},
+		{"add", "rule", nftFamily, nftTable, "input", "ip6", "saddr", "@" + nftSet6, "drop"},
+	}
+	for _, args := range commands {
+		if _, err := b.exec.Run("nft", args...); err != nil {
+			return nil, fmt.Errorf("erro ao preparar nftables: %v", err)
+		}
+	}
+	return b, nil
+}
+
+// Name identifica o driver
+func (b *NFTablesBackend) Name() string { return "nftables" }
+
+// Ban adiciona o IP ao set (elementos repetidos são ignorados pelo nft)
+func (b *NFTablesBackend) Ban(ip net.IP) error {
+	if _, err := b.exec.Run("nft", "add", "element", nftFamily, nftTable, nftSetFor(ip), "{ "+ip.String()+" }"); err != nil {
+		return fmt.Errorf("erro ao banir %s: %v", ip, err)
+	}
+	return nil
+}
+
+// Unban remove o IP do set; o delete do nft falha com elementos ausentes, então consulta antes
+func (b *NFTablesBackend) Unban(ip net.IP) error {
+	element := "{ " + ip.String() + " }"
+	if _, err := b.exec.Run("nft", "get", "element", nftFamily, nftTable, nftSetFor(ip), element); err != nil {
+		return nil
+	}
+	if _, err := b.exec.Run("nft", "delete", "element", nftFamily, nftTable, nftSetFor(ip), element); err != nil {
+		return fmt.Errorf("erro ao remover banimento de %s: %v", ip, err)
+	}
+	return nil
+}
+
+// List lê os elementos dos dois sets pela saída JSON do nft
+func (b *NFTablesBackend) List() ([]net.IP, error) {
+	var ips []net.IP
+	for _, set := range []string{nftSet4, nftSet6} {
+		out, err := b.exec.Run("nft", "-j", "list", "set", nftFamily, nftTable, set)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar set %s: %v", set, err)
+		}
+		elements, err := parseNFTSet(out)
+		if err != nil {
+			return nil, err
+		}
+		ips = append(ips, elements...)
+	}
+	return ips, nil
+}
+
+// Flush esvazia os sets
+func (b *NFTablesBackend) Flush() error {
+	for _, set := range []string{nftSet4, nftSet6} {
+		if _, err := b.exec.Run("nft", "flush", "set", nftFamily, nftTable, set); err != nil {
+			return fmt.Errorf("erro ao limpar set %s: %v", set, err)
+		}
+	}
+	return nil
+}
+
+func nftSetFor(ip net.IP) string {
+	if isIPv6(ip) {
+		return nftSet6
+	}
+	return nftSet4
+}
+
+// parseNFTSet extrai os IPs de {"nftables":[{"set":{"elem":[...]}}]}; elementos com
+// timeout aparecem como {"elem":{"val":"1.2.3.4","timeout":...}}
+func parseNFTSet(data []byte) ([]net.IP, error) {
+	var doc struct {
+		Nftables []struct {
+			Set *struct {
+				Elem []json.RawMessage `json:"elem"`
+			} `json:"set"`
+		} `json:"nftables"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar saída do nft: %v", err)
+	}
+
+	var ips []net.IP
+	for _, item := range doc.Nftables {
+		if item.Set == nil {
+			continue
+		}
+		for _, raw := range item.Set.Elem {
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				var wrapped struct {
+					Elem struct {
+						Val string `json:"val"`
+					} `json:"elem"`
+				}
+				if err := json.Unmarshal(raw, &wrapped); err != nil {
+					continue
+				}
+				value = wrapped.Elem.Val
+			}
+			if ip := net.ParseIP(value); ip != nil {
+				ips = append(ips, normalizeIP(ip))
+			}
+		}
+	}
+	return ips, nil
+}
@@ -0,0 +1,337 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Ações das regras de acesso
+const (
+	ActionAllow = "allow" // IP confiável (scanners e monitoramento internos): nunca é banido
+	ActionDeny  = "deny"  // Conexão recusada no accept
+	ActionNone  = ""      // Nenhuma regra casou; segue o fluxo normal do honeypot
+)
+
+// Rule é uma regra de acesso por prefixo CIDR ou ASN, com escopo opcional de porta e protocolo
+type Rule struct {
+	Name      string   `yaml:"name"`
+	CIDR      string   `yaml:"cidr"`      // "10.20.0.0/16", "2001:db8::/32" ou um IP único
+	ASN       uint32   `yaml:"asn"`       // Alternativa ao CIDR; exige asn_database
+	Action    string   `yaml:"action"`    // allow ou deny
+	Priority  int      `yaml:"priority"`  // Maior prioridade vence; no empate vence o prefixo mais específico
+	Ports     []int    `yaml:"ports"`     // Vazio = todas as portas
+	Protocols []string `yaml:"protocols"` // Vazio = todos os protocolos (ssh, telnet, ftp, ...)
+}
+
+// Decision é o resultado da avaliação de um IP
+type Decision struct {
+	Action string
+	Rule   *Rule // nil quando nenhuma regra casou
+}
+
+// compiledRule guarda a regra com o prefixo já interpretado
+type compiledRule struct {
+	rule      Rule
+	bits      int
+	order     int
+	ports     map[int]bool
+	protocols map[string]bool
+}
+
+// appliesTo verifica o escopo de porta/protocolo. Consultas sem porta ou protocolo
+// (ex: decidir se um IP pode ser banido) só casam com regras sem esse escopo.
+func (r *compiledRule) appliesTo(port int, protocol string) bool {
+	if len(r.ports) > 0 && !r.ports[port] {
+		return false
+	}
+	if len(r.protocols) > 0 && !r.protocols[strings.ToLower(protocol)] {
+		return false
+	}
+	return true
+}
+
+// better indica se r tem precedência sobre other
+func (r *compiledRule) better(other *compiledRule) bool {
+	if other == nil {
+		return true
+	}
+	if r.rule.Priority != other.rule.Priority {
+		return r.rule.Priority > other.rule.Priority
+	}
+	if r.bits != other.bits {
+		return r.bits > other.bits
+	}
+	if r.rule.Action != other.rule.Action {
+		return r.rule.Action == ActionDeny
+	}
+	return r.order < other.order
+}
+
+// ASNResolver traduz um IP para o número do sistema autônomo
+type ASNResolver interface {
+	LookupASN(ip net.IP) (uint32, bool)
+}
+
+// RuleEngine avalia as regras de acesso com busca em trie de prefixos (v4 e v6)
+type RuleEngine struct {
+	mu       sync.RWMutex
+	trie     prefixTrie[*compiledRule]
+	asnRules map[uint32][]*compiledRule
+	rules    []*compiledRule
+	resolver ASNResolver
+}
+
+// NewRuleEngine cria o motor de regras a partir da configuração
+func NewRuleEngine(rules []Rule) (*RuleEngine, error) {
+	e := &RuleEngine{asnRules: make(map[uint32][]*compiledRule)}
+	for _, rule := range rules {
+		if err := e.Add(rule); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// SetASNResolver habilita as regras por ASN
+func (e *RuleEngine) SetASNResolver(resolver ASNResolver) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resolver = resolver
+}
+
+// Add valida e insere uma regra
+func (e *RuleEngine) Add(rule Rule) error {
+	rule.Action = strings.ToLower(rule.Action)
+	if rule.Action != ActionAllow && rule.Action != ActionDeny {
+		return fmt.Errorf("regra %q: ação inválida %q (use allow ou deny)", rule.Name, rule.Action)
+	}
+
+	compiled := &compiledRule{rule: rule}
+	if len(rule.Ports) > 0 {
+		compiled.ports = make(map[int]bool)
+		for _, port := range rule.Ports {
+			compiled.ports[port] = true
+		}
+	}
+	if len(rule.Protocols) > 0 {
+		compiled.protocols = make(map[string]bool)
+		for _, protocol := range rule.Protocols {
+			compiled.protocols[strings.ToLower(protocol)] = true
+		}
+	}
+
+	var prefix *net.IPNet
+	switch {
+	case rule.CIDR != "" && rule.ASN != 0:
+		return fmt.Errorf("regra %q: use cidr ou asn, não ambos", rule.Name)
+	case rule.CIDR != "":
+		var err error
+		if prefix, err = parsePrefix(rule.CIDR); err != nil {
+			return fmt.Errorf("regra %q: %v", rule.Name, err)
+		}
+		compiled.bits, _ = prefix.Mask.Size()
+	case rule.ASN == 0:
+		return fmt.Errorf("regra %q: informe cidr ou asn", rule.Name)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	compiled.order = len(e.rules)
+	e.rules = append(e.rules, compiled)
+	if prefix != nil {
+		e.trie.insert(prefix, compiled)
+	} else {
+		e.asnRules[rule.ASN] = append(e.asnRules[rule.ASN], compiled)
+	}
+	return nil
+}
+
+// Match avalia o IP de addr para a porta e o protocolo informados
+func (e *RuleEngine) Match(addr string, port int, protocol string) Decision {
+	ip, err := ParseSourceIP(addr)
+	if err != nil {
+		return Decision{}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var best *compiledRule
+	e.trie.walk(ip, func(rule *compiledRule) {
+		if rule.appliesTo(port, protocol) && rule.better(best) {
+			best = rule
+		}
+	})
+
+	if e.resolver != nil && len(e.asnRules) > 0 {
+		if asn, ok := e.resolver.LookupASN(ip); ok {
+			for _, rule := range e.asnRules[asn] {
+				if rule.appliesTo(port, protocol) && rule.better(best) {
+					best = rule
+				}
+			}
+		}
+	}
+
+	if best == nil {
+		return Decision{}
+	}
+	return Decision{Action: best.rule.Action, Rule: &best.rule}
+}
+
+// Allowed indica se o IP tem uma regra allow global (usado para nunca banir scanners próprios)
+func (e *RuleEngine) Allowed(addr string) bool {
+	return e.Match(addr, 0, "").Action == ActionAllow
+}
+
+// Rules retorna as regras na ordem em que foram adicionadas
+func (e *RuleEngine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	rules := make([]Rule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		rules = append(rules, rule.rule)
+	}
+	return rules
+}
+
+// parsePrefix aceita CIDR ou IP único (vira /32 ou /128)
+func parsePrefix(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, prefix, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("CIDR inválido: %s", value)
+		}
+		return prefix, nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("endereço IP inválido: %s", value)
+	}
+	ip = normalizeIP(ip)
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}, nil
+}
+
+// prefixTrie é uma trie binária por bit do endereço, uma raiz por família
+type prefixTrie[T any] struct {
+	v4, v6 *trieNode[T]
+}
+
+type trieNode[T any] struct {
+	children [2]*trieNode[T]
+	values   []T
+}
+
+// insert associa value ao prefixo
+func (t *prefixTrie[T]) insert(prefix *net.IPNet, value T) {
+	ip := prefix.IP.To16()
+	bits, size := prefix.Mask.Size()
+	root := &t.v6
+	switch {
+	case size == 32:
+		root, ip = &t.v4, prefix.IP.To4()
+	case prefix.IP.To4() != nil && bits >= 96:
+		// Prefixo IPv4 mapeado em IPv6 (::ffff:a.b.c.d/N); os IPs são normalizados para v4
+		root, ip, bits = &t.v4, prefix.IP.To4(), bits-96
+	}
+	if *root == nil {
+		*root = &trieNode[T]{}
+	}
+
+	node := *root
+	for i := 0; i < bits; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode[T]{}
+		}
+		node = node.children[bit]
+	}
+	node.values = append(node.values, value)
+}
+
+// walk chama fn para os valores de todos os prefixos que contêm ip, do menos ao mais específico
+func (t *prefixTrie[T]) walk(ip net.IP, fn func(T)) {
+	node := t.v6
+	if v4 := ip.To4(); v4 != nil {
+		node, ip = t.v4, v4
+	} else {
+		ip = ip.To16()
+	}
+	for i := 0; node != nil; i++ {
+		for _, value := range node.values {
+			fn(value)
+		}
+		if i >= len(ip)*8 {
+			return
+		}
+		node = node.children[(ip[i/8]>>(7-uint(i%8)))&1]
+	}
+}
+
+// ASNTable resolve ASNs a partir de uma tabela local de prefixos ("CIDR ASN" por linha)
+type ASNTable struct {
+	trie    prefixTrie[uint32]
+	entries int
+}
+
+// LoadASNTable lê uma tabela de prefixos e ASNs, ignorando linhas vazias e comentários (#).
+// Aceita "10.0.0.0/8 64512", "10.0.0.0/8,AS64512" e variações com tabulação.
+func LoadASNTable(path string) (*ASNTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir tabela de ASN: %v", err)
+	}
+	defer file.Close()
+
+	table := &ASNTable{}
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("tabela de ASN, linha %d: formato inválido", line)
+		}
+		prefix, err := parsePrefix(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("tabela de ASN, linha %d: %v", line, err)
+		}
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(fields[1]), "AS"), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("tabela de ASN, linha %d: ASN inválido %q", line, fields[1])
+		}
+
+		table.trie.insert(prefix, uint32(asn))
+		table.entries++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler tabela de ASN: %v", err)
+	}
+	return table, nil
+}
+
+// LookupASN retorna o ASN do prefixo mais específico que contém o IP
+func (t *ASNTable) LookupASN(ip net.IP) (uint32, bool) {
+	var asn uint32
+	found := false
+	// walk visita do prefixo menos ao mais específico; fica o último
+	t.trie.walk(ip, func(value uint32) { asn, found = value, true })
+	return asn, found
+}
+
+// Len retorna o número de prefixos carregados
+func (t *ASNTable) Len() int {
+	return t.entries
+}
@@ -0,0 +1,232 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+)
+
+// TarpitConfig ajusta o tarpit, usado como alternativa ao banimento
+type TarpitConfig struct {
+	MaxConnections int           `yaml:"max_connections"` // Conexões presas ao mesmo tempo (padrão 64)
+	MaxDuration    time.Duration `yaml:"max_duration"`    // Tempo máximo preso (padrão 1h)
+	DripInterval   time.Duration `yaml:"drip_interval"`   // Intervalo entre bytes do banner SSH (padrão 10s)
+	InitialDelay   time.Duration `yaml:"initial_delay"`   // Primeiro atraso de Telnet/FTP (padrão 1s)
+	MaxDelay       time.Duration `yaml:"max_delay"`       // Atraso máximo de Telnet/FTP (padrão 60s)
+	RateLimited    bool          `yaml:"rate_limited"`    // Prende em vez de recusar conexões acima do rate limit
+	Banned         bool          `yaml:"banned"`          // Prende IPs banidos que ainda chegam ao accept (ex: driver dryrun)
+}
+
+// Tarpit segura atacantes o máximo possível sem encerrar a coleta de dados
+type Tarpit struct {
+	config   TarpitConfig
+	active   int32
+	recorder Recorder
+	logger   *log.Logger
+}
+
+// NewTarpit cria o tarpit; recorder pode ser nil
+func NewTarpit(config TarpitConfig, recorder Recorder) *Tarpit {
+	if config.MaxConnections <= 0 {
+		config.MaxConnections = 64
+	}
+	if config.MaxDuration <= 0 {
+		config.MaxDuration = time.Hour
+	}
+	if config.DripInterval <= 0 {
+		config.DripInterval = 10 * time.Second
+	}
+	if config.InitialDelay <= 0 {
+		config.InitialDelay = time.Second
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = time.Minute
+	}
+
+	return &Tarpit{
+		config:   config,
+		recorder: recorder,
+		logger:   log.New(log.Writer(), "TARPIT: ", log.LstdFlags|log.Lshortfile),
+	}
+}
+
+// Active retorna quantas conexões estão presas agora
+func (t *Tarpit) Active() int {
+	return int(atomic.LoadInt32(&t.active))
+}
+
+// Hold prende a conexão até o atacante desistir ou MaxDuration vencer, e a fecha no fim.
+// Retorna false sem tocar na conexão quando o limite de conexões simultâneas foi atingido.
+func (t *Tarpit) Hold(conn net.Conn, protocol string) bool {
+	protocol = strings.ToLower(protocol)
+	if atomic.AddInt32(&t.active, 1) > int32(t.config.MaxConnections) {
+		atomic.AddInt32(&t.active, -1)
+		metrics.TarpitRejected.Inc(protocol)
+		return false
+	}
+	defer atomic.AddInt32(&t.active, -1)
+	defer conn.Close()
+
+	metrics.TarpitConnections.Inc(protocol)
+	defer metrics.TarpitConnections.Dec(protocol)
+
+	addr := conn.RemoteAddr().String()
+	start := time.Now()
+	conn.SetDeadline(start.Add(t.config.MaxDuration))
+
+	t.emit(addr, protocol, fmt.Sprintf("Conexão %s presa no tarpit (%d ativas)", protocol, t.Active()))
+
+	var sent int
+	var err error
+	switch protocol {
+	case "ssh":
+		sent, err = t.dripSSH(conn)
+	case "telnet":
+		sent, err = t.slowTelnet(conn)
+	case "ftp":
+		sent, err = t.slowFTP(conn)
+	default:
+		err = t.zeroWindow(conn)
+	}
+
+	t.emit(addr, protocol, fmt.Sprintf("Conexão %s liberada do tarpit após %s (%d bytes enviados, %v)",
+		protocol, time.Since(start).Round(time.Second), sent, err))
+	return true
+}
+
+// dripSSH imita o endlessh: antes da linha "SSH-" o cliente aceita linhas arbitrárias,
+// então enviamos linhas aleatórias um byte por vez, sem nunca chegar ao banner
+func (t *Tarpit) dripSSH(conn net.Conn) (int, error) {
+	sent := 0
+	for {
+		line := randomLine()
+		for i := 0; i < len(line); i++ {
+			time.Sleep(t.config.DripInterval)
+			if _, err := conn.Write([]byte{line[i]}); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+}
+
+// slowTelnet simula o login, com atrasos que dobram a cada resposta
+func (t *Tarpit) slowTelnet(conn net.Conn) (int, error) {
+	delay := t.config.InitialDelay
+	reader := bufio.NewReader(conn)
+	prompts := []string{"login: ", "Password: "}
+	sent := 0
+
+	for i := 0; ; i++ {
+		n, err := t.slowWrite(conn, prompts[i%2], &delay)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+		if _, err := reader.ReadString('\n'); err != nil {
+			return sent, err
+		}
+		if i%2 == 1 {
+			n, err := t.slowWrite(conn, "\r\nLogin incorrect\r\n", &delay)
+			sent += n
+			if err != nil {
+				return sent, err
+			}
+		}
+	}
+}
+
+// slowFTP envia uma saudação multilinha lenta e responde 530 a tudo, cada vez mais devagar
+func (t *Tarpit) slowFTP(conn net.Conn) (int, error) {
+	delay := t.config.InitialDelay
+	sent := 0
+	for i := 0; i < 5; i++ {
+		n, err := t.slowWrite(conn, fmt.Sprintf("220-Welcome, please wait (%d)\r\n", i+1), &delay)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	n, err := t.slowWrite(conn, "220 FTP server ready\r\n", &delay)
+	sent += n
+	if err != nil {
+		return sent, err
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return sent, err
+		}
+		reply := "530 Please login with USER and PASS.\r\n"
+		if strings.HasPrefix(strings.ToUpper(line), "USER") {
+			reply = "331 Please specify the password.\r\n"
+		}
+		n, err := t.slowWrite(conn, reply, &delay)
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+}
+
+// zeroWindow quase não lê a conexão: com o buffer de recepção mínimo, o kernel anuncia
+// janela zero e o cliente fica preso tentando enviar. Um byte é lido a cada intervalo
+// só para perceber quando o cliente desiste e liberar a vaga.
+func (t *Tarpit) zeroWindow(conn net.Conn) error {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetReadBuffer(1)
+	}
+	buf := make([]byte, 1)
+	for {
+		time.Sleep(t.config.DripInterval)
+		if _, err := conn.Read(buf); err != nil {
+			return err
+		}
+	}
+}
+
+// slowWrite espera o atraso atual, escreve a mensagem e dobra o atraso até MaxDelay
+func (t *Tarpit) slowWrite(conn net.Conn, message string, delay *time.Duration) (int, error) {
+	time.Sleep(*delay)
+	if *delay *= 2; *delay > t.config.MaxDelay {
+		*delay = t.config.MaxDelay
+	}
+	return conn.Write([]byte(message))
+}
+
+func (t *Tarpit) emit(addr, protocol, event string) {
+	if t.recorder == nil {
+		t.logger.Printf("%s: %s\n", addr, event)
+		return
+	}
+	t.recorder.Record(logging.LogEntry{
+		IP:       addr,
+		Event:    event,
+		Level:    logging.INFO,
+		Type:     logging.EventTarpit,
+		Protocol: protocol,
+	})
+}
+
+// randomLine gera uma linha que não começa com "SSH-", para o cliente continuar esperando o banner
+func randomLine() string {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 "
+	b := make([]byte, 8+rand.Intn(24))
+	for i := range b {
+		b[i] = chars[rand.Intn(len(chars))]
+	}
+	if b[0] == 'S' {
+		b[0] = 'x'
+	}
+	return string(b) + "\r\n"
+}
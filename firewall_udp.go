@@ -0,0 +1,567 @@
+package firewall
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+)
+
+// UDPConfig limita os responders UDP para o sensor nunca servir de amplificador
+type UDPConfig struct {
+	MaxAmplification float64   `yaml:"max_amplification"` // Resposta máxima em relação ao pedido (padrão 2.0)
+	MaxResponse      int       `yaml:"max_response"`      // Tamanho máximo absoluto da resposta (padrão 512)
+	RateLimit        RateLimit `yaml:"rate_limit"`        // Pacotes processados por IP; o excesso é descartado sem log (padrão 60/1m)
+	DNSAnswer        string    `yaml:"dns_answer"`        // IPv4 devolvido nas consultas A (padrão 127.0.0.1)
+	SNMPCommunities  []string  `yaml:"snmp_communities"`  // Communities aceitas pelo agente SNMP (padrão public)
+}
+
+// withDefaults preenche os valores não configurados
+func (c UDPConfig) withDefaults() UDPConfig {
+	if c.MaxAmplification <= 0 {
+		c.MaxAmplification = 2.0
+	}
+	if c.MaxResponse <= 0 {
+		c.MaxResponse = 512
+	}
+	if c.RateLimit.Limit == 0 {
+		c.RateLimit = RateLimit{Limit: 60, Window: time.Minute, Burst: 20}
+	}
+	if net.ParseIP(c.DNSAnswer).To4() == nil {
+		c.DNSAnswer = "127.0.0.1"
+	}
+	if len(c.SNMPCommunities) == 0 {
+		c.SNMPCommunities = []string{"public"}
+	}
+	return c
+}
+
+// udpRequest é o que o responder entendeu do pacote
+type udpRequest struct {
+	summary   string // Resumo para o evento (ex: "A example.com", "monlist")
+	community string // Community SNMP, registrada como credencial
+	reply     []byte // nil = sem resposta
+}
+
+// udpResponder reconhece e responde um protocolo UDP
+type udpResponder struct {
+	name   string
+	match  func(packet []byte) bool
+	handle func(packet []byte, config UDPConfig) udpRequest
+}
+
+// udpResponders em ordem de avaliação no serviço genérico; sip e tftp só são registrados
+var udpResponders = []udpResponder{
+	{name: "dns", match: matchDNS, handle: handleDNS},
+	{name: "ntp", match: matchNTP, handle: handleNTP},
+	{name: "ssdp", match: hasPrefix("M-SEARCH ", "NOTIFY "), handle: handleSSDP},
+	{name: "snmp", match: matchSNMP, handle: handleSNMP},
+	{name: "memcached", match: matchMemcached, handle: handleMemcached},
+	{name: "sip", match: firstLineContains(" SIP/2.0"), handle: handleSIP},
+	{name: "tftp", match: matchTFTP, handle: handleTFTP},
+}
+
+// udpResponderFor retorna o responder do serviço; o serviço genérico escolhe pelo conteúdo e nunca responde
+func udpResponderFor(service string, packet []byte) (*udpResponder, bool) {
+	for i := range udpResponders {
+		if udpResponders[i].name == service {
+			return &udpResponders[i], true
+		}
+	}
+	for i := range udpResponders {
+		if udpResponders[i].match(packet) {
+			return &udpResponders[i], false
+		}
+	}
+	return nil, false
+}
+
+// BindUDP abre a porta UDP com o responder do serviço (dns, ntp, ssdp, snmp, memcached, sip, tftp ou banner)
+func (pm *PortManager) BindUDP(portNumber int, service string) error {
+	if portNumber < 1 || portNumber > 65535 {
+		return fmt.Errorf("porta inválida: %d", portNumber)
+	}
+	service = strings.ToLower(service)
+	if service == "" {
+		service = ServiceBanner
+	}
+	if _, known := udpResponderFor(service, nil); !known && service != ServiceBanner {
+		return fmt.Errorf("porta %d/udp: serviço desconhecido %q", portNumber, service)
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if _, exists := pm.packetConns[portNumber]; exists {
+		return fmt.Errorf("porta %d/udp já está aberta", portNumber)
+	}
+	port, exists := pm.udpPorts[portNumber]
+	if !exists {
+		port = &Port{PortNumber: portNumber, Protocol: "UDP"}
+		pm.udpPorts[portNumber] = port
+	}
+	port.ServiceName = service
+
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(pm.bindAddr, strconv.Itoa(portNumber)))
+	if err != nil {
+		port.IsOpen = false
+		port.LastError = err.Error()
+		return fmt.Errorf("erro ao abrir porta %d/udp: %v", portNumber, err)
+	}
+
+	port.IsOpen = true
+	port.OpenedAt = time.Now()
+	port.Connections = 0
+	port.LastError = ""
+	pm.packetConns[portNumber] = conn
+	pm.logger.Printf("Porta %d/udp aberta para o serviço %s.\n", portNumber, service)
+
+	go pm.serveUDP(portNumber, service, conn, newRateLimiter(pm.udpConfig.withDefaults().RateLimit))
+	return nil
+}
+
+// UnbindUDP fecha a porta UDP
+func (pm *PortManager) UnbindUDP(portNumber int) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	conn, exists := pm.packetConns[portNumber]
+	if !exists {
+		return fmt.Errorf("porta %d/udp não está aberta", portNumber)
+	}
+	delete(pm.packetConns, portNumber)
+	pm.udpPorts[portNumber].IsOpen = false
+	pm.logger.Printf("Porta %d/udp fechada para o serviço %s.\n", portNumber, pm.udpPorts[portNumber].ServiceName)
+	return conn.Close()
+}
+
+// SetUDP ajusta os limites dos responders UDP
+func (pm *PortManager) SetUDP(config UDPConfig) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.udpConfig = config
+}
+
+// serveUDP lê pacotes até a porta ser fechada
+func (pm *PortManager) serveUDP(portNumber int, service string, conn net.PacketConn, limiter *rateLimiter) {
+	buf := make([]byte, 65535)
+	lastCleanUp := time.Now()
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			pm.mutex.Lock()
+			pm.udpPorts[portNumber].LastError = err.Error()
+			pm.mutex.Unlock()
+			pm.logger.Printf("Erro na leitura da porta %d/udp: %v\n", portNumber, err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		if now := time.Now(); now.Sub(lastCleanUp) > time.Minute {
+			limiter.cleanUp(now)
+			lastCleanUp = now
+		}
+		pm.handlePacket(conn, addr, portNumber, service, buf[:n], limiter)
+	}
+}
+
+// handlePacket registra o pacote e envia a resposta, respeitando o limite de amplificação.
+// Origens UDP podem ser forjadas, então aqui nada é banido: o excesso só é descartado.
+func (pm *PortManager) handlePacket(conn net.PacketConn, addr net.Addr, portNumber int, service string, packet []byte, limiter *rateLimiter) {
+	ip, err := ParseSourceIP(addr.String())
+	if err != nil {
+		return
+	}
+
+	pm.mutex.Lock()
+	config := pm.udpConfig.withDefaults()
+	recorder, rules := pm.recorder, pm.rules
+	pm.udpPorts[portNumber].Connections++
+	pm.mutex.Unlock()
+
+	if rules.Match(addr.String(), portNumber, service).Action == ActionDeny {
+		metrics.UDPDropped.Inc(service, "rule")
+		return
+	}
+	if bans := DefaultBanStore(); bans != nil && bans.IsBanned(addr.String()) {
+		metrics.UDPDropped.Inc(service, "banned")
+		return
+	}
+	if allowed, _ := limiter.take(ip.String()); !allowed {
+		metrics.UDPDropped.Inc(service, "rate_limit")
+		return
+	}
+
+	protocol := ProtocolUnknown
+	var request udpRequest
+	if responder, named := udpResponderFor(service, packet); responder != nil {
+		protocol = responder.name
+		request = responder.handle(packet, config)
+		if !named {
+			// O serviço genérico só registra, nunca responde
+			request.reply = nil
+		}
+	}
+	metrics.UDPPackets.Inc(protocol)
+
+	replied := ""
+	if request.reply != nil {
+		limit := int(float64(len(packet)) * config.MaxAmplification)
+		if limit > config.MaxResponse {
+			limit = config.MaxResponse
+		}
+		if len(request.reply) > limit {
+			metrics.UDPDropped.Inc(service, "amplification")
+			replied = fmt.Sprintf(", resposta de %d bytes suprimida (limite %d)", len(request.reply), limit)
+		} else if _, err := conn.WriteTo(request.reply, addr); err == nil {
+			replied = fmt.Sprintf(", resposta de %d bytes", len(request.reply))
+		}
+	}
+
+	event := fmt.Sprintf("Porta %d/udp: pacote %s (%d bytes)%s", portNumber, protocol, len(packet), replied)
+	if request.summary != "" {
+		event = fmt.Sprintf("Porta %d/udp: %s %s (%d bytes)%s", portNumber, protocol, request.summary, len(packet), replied)
+	}
+	if recorder == nil {
+		pm.logger.Printf("%s: %s\n", addr, event)
+		return
+	}
+	recorder.Record(logging.LogEntry{
+		IP:       addr.String(),
+		Event:    event,
+		Level:    logging.INFO,
+		Type:     logging.EventUDPPacket,
+		Protocol: protocol,
+		Port:     portNumber,
+		Command:  request.summary,
+		Password: request.community,
+		Payload:  hex.EncodeToString(preview(packet)),
+	})
+}
+
+// DNS
+
+var dnsTypes = map[uint16]string{1: "A", 2: "NS", 5: "CNAME", 6: "SOA", 12: "PTR", 15: "MX", 16: "TXT", 28: "AAAA", 33: "SRV", 255: "ANY"}
+
+func matchDNS(packet []byte) bool {
+	// Consulta padrão (QR=0, opcode 0) com ao menos uma pergunta
+	return len(packet) >= 17 && packet[2]&0xf8 == 0 && binary.BigEndian.Uint16(packet[4:6]) >= 1
+}
+
+// parseDNSQuestion lê o nome e o tipo da primeira pergunta; end é o fim da pergunta no pacote
+func parseDNSQuestion(packet []byte) (name string, qtype uint16, end int, ok bool) {
+	var labels []string
+	i := 12
+	for i < len(packet) {
+		length := int(packet[i])
+		if length == 0 {
+			i++
+			if i+4 > len(packet) {
+				return "", 0, 0, false
+			}
+			return strings.Join(labels, "."), binary.BigEndian.Uint16(packet[i : i+2]), i + 4, true
+		}
+		if length > 63 || i+1+length > len(packet) {
+			return "", 0, 0, false
+		}
+		labels = append(labels, string(packet[i+1:i+1+length]))
+		i += 1 + length
+	}
+	return "", 0, 0, false
+}
+
+// handleDNS responde A com o endereço configurado, recusa ANY e devolve NXDOMAIN para o resto
+func handleDNS(packet []byte, config UDPConfig) udpRequest {
+	if !matchDNS(packet) {
+		return udpRequest{}
+	}
+	name, qtype, end, ok := parseDNSQuestion(packet)
+	if !ok {
+		return udpRequest{summary: "consulta malformada"}
+	}
+	typeName, known := dnsTypes[qtype]
+	if !known {
+		typeName = "TYPE" + strconv.Itoa(int(qtype))
+	}
+
+	// Cabeçalho com a pergunta original, sem as seções adicionais (EDNS)
+	reply := make([]byte, end, end+16)
+	copy(reply, packet[:end])
+	reply[2] = 0x80 | packet[2]&0x01 // QR=1, mantém RD
+	binary.BigEndian.PutUint16(reply[4:6], 1)
+	binary.BigEndian.PutUint16(reply[6:8], 0)
+	binary.BigEndian.PutUint16(reply[8:10], 0)
+	binary.BigEndian.PutUint16(reply[10:12], 0)
+
+	switch qtype {
+	case 1:
+		reply[3] = 0x80 // RA, NOERROR
+		binary.BigEndian.PutUint16(reply[6:8], 1)
+		reply = append(reply, 0xc0, 0x0c, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x2c, 0x00, 0x04)
+		reply = append(reply, net.ParseIP(config.DNSAnswer).To4()...)
+	case 255:
+		reply[3] = 0x85 // RA, REFUSED
+	default:
+		reply[3] = 0x83 // RA, NXDOMAIN
+	}
+	return udpRequest{summary: typeName + " " + name, reply: reply}
+}
+
+// NTP
+
+var ntpModes = map[byte]string{1: "symmetric-active", 3: "client", 6: "control", 7: "private"}
+
+func matchNTP(packet []byte) bool {
+	if len(packet) < 4 {
+		return false
+	}
+	version, mode := (packet[0]>>3)&0x07, packet[0]&0x07
+	switch mode {
+	case 3:
+		return version >= 1 && version <= 4 && len(packet) >= 48
+	case 6, 7:
+		return version >= 1 && version <= 4
+	}
+	return false
+}
+
+// handleNTP responde apenas ao modo cliente (48 bytes, mesmo tamanho do pedido); monlist e
+// consultas de controle, usadas em amplificação, só são registradas
+func handleNTP(packet []byte, config UDPConfig) udpRequest {
+	if !matchNTP(packet) {
+		return udpRequest{}
+	}
+	mode := packet[0] & 0x07
+	summary := ntpModes[mode]
+	if mode == 7 && len(packet) >= 4 && packet[3] == 42 {
+		summary = "monlist"
+	}
+	if mode != 3 {
+		return udpRequest{summary: summary}
+	}
+
+	reply := make([]byte, 48)
+	reply[0] = packet[0]&0x38 | 0x04 // LI=0, versão do cliente, modo servidor
+	reply[1] = 2                     // Stratum
+	reply[2] = packet[2]             // Poll
+	reply[3] = 0xe9                  // Precision
+	copy(reply[12:16], "GPS\x00")
+	now := ntpTime(time.Now())
+	copy(reply[24:32], packet[40:48]) // Originate = transmit do cliente
+	binary.BigEndian.PutUint64(reply[16:24], ntpTime(time.Now().Add(-64*time.Second)))
+	binary.BigEndian.PutUint64(reply[32:40], now)
+	binary.BigEndian.PutUint64(reply[40:48], now)
+	return udpRequest{summary: summary, reply: reply}
+}
+
+// ntpTime converte para o formato de 64 bits do NTP (segundos desde 1900)
+func ntpTime(t time.Time) uint64 {
+	seconds := uint64(t.Unix() + 2208988800)
+	fraction := uint64(t.Nanosecond()) << 32 / 1e9
+	return seconds<<32 | fraction
+}
+
+// SSDP
+
+// handleSSDP responde ao M-SEARCH como um roteador doméstico com miniupnpd
+func handleSSDP(packet []byte, config UDPConfig) udpRequest {
+	text := string(packet)
+	if !strings.HasPrefix(text, "M-SEARCH ") {
+		return udpRequest{summary: "NOTIFY"}
+	}
+	st := "upnp:rootdevice"
+	for _, line := range strings.Split(text, "\r\n") {
+		if i := strings.Index(line, ":"); i > 0 && strings.EqualFold(strings.TrimSpace(line[:i]), "ST") {
+			if value := strings.TrimSpace(line[i+1:]); value != "" && value != "ssdp:all" {
+				st = value
+			}
+		}
+	}
+	reply := "HTTP/1.1 200 OK\r\nST: " + st + "\r\nUSN: uuid:3d7b2a61-1dd2-11b2-a4c5-c0a8010100fe::" + st +
+		"\r\nLOCATION: http://192.168.1.1:5000/rootDesc.xml\r\nSERVER: Linux UPnP/1.0 miniupnpd/2.1\r\nCACHE-CONTROL: max-age=120\r\n\r\n"
+	return udpRequest{summary: "M-SEARCH " + st, reply: []byte(reply)}
+}
+
+// SNMP
+
+func matchSNMP(packet []byte) bool {
+	// SEQUENCE seguido de INTEGER (versão 0, 1 ou 3)
+	_, body, ok := berRead(packet, 0x30)
+	if !ok {
+		return false
+	}
+	_, version, ok := berRead(body, 0x02)
+	return ok && len(version) == 1 && (version[0] <= 1 || version[0] == 3)
+}
+
+// handleSNMP responde v1/v2c para communities aceitas com noSuchName nas mesmas variáveis
+// pedidas; GetBulk nunca é expandido, então a resposta tem o tamanho do pedido
+func handleSNMP(packet []byte, config UDPConfig) udpRequest {
+	_, message, ok := berRead(packet, 0x30)
+	if !ok {
+		return udpRequest{}
+	}
+	rest, version, ok := berRead(message, 0x02)
+	if !ok || len(version) != 1 {
+		return udpRequest{}
+	}
+	if version[0] == 3 {
+		return udpRequest{summary: "v3"}
+	}
+	rest, community, ok := berRead(rest, 0x04)
+	if !ok || len(rest) < 2 {
+		return udpRequest{summary: "malformado"}
+	}
+
+	pduType := rest[0]
+	names := map[byte]string{0xa0: "get", 0xa1: "get-next", 0xa3: "set", 0xa5: "get-bulk"}
+	summary := fmt.Sprintf("v%d %s", map[byte]int{0: 1, 1: 2}[version[0]], names[pduType])
+	request := udpRequest{summary: strings.TrimSpace(summary), community: string(community)}
+
+	accepted := false
+	for _, c := range config.SNMPCommunities {
+		accepted = accepted || c == string(community)
+	}
+	if _, known := names[pduType]; !known || !accepted {
+		return request
+	}
+
+	_, pdu, ok := berRead(rest, pduType)
+	if !ok {
+		return request
+	}
+	pduRest, requestID, ok := berRead(pdu, 0x02)
+	if !ok {
+		return request
+	}
+	// error-status e error-index (ou non-repeaters e max-repetitions no GetBulk)
+	if pduRest, _, ok = berRead(pduRest, 0x02); !ok {
+		return request
+	}
+	if pduRest, _, ok = berRead(pduRest, 0x02); !ok {
+		return request
+	}
+	_, varbinds, ok := berRead(pduRest, 0x30)
+	if !ok {
+		return request
+	}
+
+	response := berWrite(0x02, requestID)
+	response = append(response, berWrite(0x02, []byte{2})...) // noSuchName
+	response = append(response, berWrite(0x02, []byte{1})...)
+	response = append(response, berWrite(0x30, varbinds)...)
+
+	body := berWrite(0x02, version)
+	body = append(body, berWrite(0x04, community)...)
+	body = append(body, berWrite(0xa2, response)...)
+	request.reply = berWrite(0x30, body)
+	return request
+}
+
+// berRead lê um TLV com a tag esperada e retorna o restante e o conteúdo
+func berRead(data []byte, tag byte) (rest, value []byte, ok bool) {
+	if len(data) < 2 || data[0] != tag {
+		return nil, nil, false
+	}
+	length, i := int(data[1]), 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 2 || len(data) < 2+n {
+			return nil, nil, false
+		}
+		length = 0
+		for _, b := range data[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+		i += n
+	}
+	if len(data) < i+length {
+		return nil, nil, false
+	}
+	return data[i+length:], data[i : i+length], true
+}
+
+// berWrite codifica um TLV
+func berWrite(tag byte, value []byte) []byte {
+	var out []byte
+	switch n := len(value); {
+	case n < 0x80:
+		out = []byte{tag, byte(n)}
+	case n < 0x100:
+		out = []byte{tag, 0x81, byte(n)}
+	default:
+		out = []byte{tag, 0x82, byte(n >> 8), byte(n)}
+	}
+	return append(out, value...)
+}
+
+// memcached
+
+func matchMemcached(packet []byte) bool {
+	// Cabeçalho UDP de 8 bytes (reservado = 0) seguido de um comando de texto
+	if len(packet) < 10 || packet[6] != 0 || packet[7] != 0 {
+		return false
+	}
+	return hasPrefix("stats", "get", "gets", "set", "version", "flush_all", "delete")(packet[8:])
+}
+
+// handleMemcached responde com respostas curtas: stats, o vetor clássico, nunca devolve estatísticas
+func handleMemcached(packet []byte, config UDPConfig) udpRequest {
+	if !matchMemcached(packet) {
+		return udpRequest{}
+	}
+	command := string(packet[8:])
+	if i := strings.IndexAny(command, "\r\n"); i >= 0 {
+		command = command[:i]
+	}
+
+	answer := "ERROR\r\n"
+	switch strings.Fields(command + " ")[0] {
+	case "version":
+		answer = "VERSION 1.5.6\r\n"
+	case "get", "gets":
+		answer = "END\r\n"
+	}
+	reply := make([]byte, 8, 8+len(answer))
+	copy(reply, packet[:2]) // Request ID
+	reply[5] = 1            // Total de datagramas
+	return udpRequest{summary: command, reply: append(reply, answer...)}
+}
+
+// SIP e TFTP: só registrados
+
+func handleSIP(packet []byte, config UDPConfig) udpRequest {
+	line := packet
+	if i := bytes.IndexAny(line, "\r\n"); i >= 0 {
+		line = line[:i]
+	}
+	return udpRequest{summary: string(line)}
+}
+
+func matchTFTP(packet []byte) bool {
+	// RRQ ou WRQ: opcode, nome do arquivo e modo terminados em zero
+	return len(packet) >= 4 && packet[0] == 0 && (packet[1] == 1 || packet[1] == 2) &&
+		bytes.Count(packet[2:], []byte{0}) >= 2
+}
+
+func handleTFTP(packet []byte, config UDPConfig) udpRequest {
+	if !matchTFTP(packet) {
+		return udpRequest{}
+	}
+	fields := bytes.Split(packet[2:], []byte{0})
+	op := "RRQ"
+	if packet[1] == 2 {
+		op = "WRQ"
+	}
+	return udpRequest{summary: fmt.Sprintf("%s %s (%s)", op, fields[0], fields[1])}
+}
@@ -9,18 +9,19 @@ import (
 	"math/rand"
 	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // Para salvar logs no SQLite
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 	"yourproject/internal/logs"
 )
 
 const (
 	ftpPort        = "0.0.0.0:21"
-	fakeUsername   = "admin"
-	fakePassword   = "admin"
 	timeoutSeconds = 120
 )
 
@@ -60,22 +61,31 @@ func StartFTPServer() {
 
 func handleFTPConnection(conn net.Conn) {
 	defer conn.Close()
+	defer metrics.TrackConnection("ftp", conn)()
 	clientAddr := conn.RemoteAddr().String()
 	logs.Info(fmt.Sprintf("New FTP connection from %s", clientAddr))
 
-	conn.Write([]byte("220 FTP Server Ready\r\n"))
+	conn.Write([]byte(persona.Current().Banners.FTP + "\r\n"))
+
+	if verdict := firewall.CheckAuth(clientAddr, "ftp"); verdict.Action == firewall.ActionDeny {
+		logs.Warn(fmt.Sprintf("Login from %s refused: %s", clientAddr, verdict.Reason))
+		return
+	}
 
 	username, password := fakeFTPLogin(conn)
+	metrics.AuthAttempts.Inc("ftp")
 	if username == "" || password == "" {
 		logs.Warn(fmt.Sprintf("Failed FTP login attempt from %s", clientAddr))
 		logToFile(fmt.Sprintf("Failed login from %s", clientAddr))
 		saveToDatabase(clientAddr, "FAILED_LOGIN", "")
+		firewall.AuthFailed(clientAddr, "ftp", "")
 		return
 	}
 
 	logs.Info(fmt.Sprintf("Successful FTP login from %s with user: %s", clientAddr, username))
 	logToFile(fmt.Sprintf("Successful login from %s - Username: %s", clientAddr, username))
 	saveToDatabase(clientAddr, "SUCCESSFUL_LOGIN", username)
+	metrics.AuthSuccesses.Inc("ftp")
 
 	handleFakeFTPCommands(conn, clientAddr)
 }
@@ -87,7 +97,7 @@ func fakeFTPLogin(conn net.Conn) (string, string) {
 	conn.Write([]byte("331 Password required.\r\n"))
 	password := readLine(conn)
 
-	if username == fakeUsername && password == fakePassword {
+	if auth.Accept("ftp", conn.RemoteAddr().String(), username, password) {
 		conn.Write([]byte("230 User logged in, proceed.\r\n"))
 		return username, password
 	}
@@ -106,6 +116,7 @@ func handleFakeFTPCommands(conn net.Conn, clientAddr string) {
 			continue
 		}
 
+		metrics.Commands.Inc("ftp")
 		logs.Info(fmt.Sprintf("FTP command from %s: %s", clientAddr, command))
 		logToFile(fmt.Sprintf("FTP command from %s: %s", clientAddr, command))
 		saveToDatabase(clientAddr, "COMMAND_EXECUTED", command)
@@ -113,7 +124,9 @@ func handleFakeFTPCommands(conn net.Conn, clientAddr string) {
 		simulateCommandLatency(command)
 		detectSuspiciousCommand(command, clientAddr)
 
-		if response, exists := fakeFTPResponses[command]; exists {
+		if command == "SYST" {
+			conn.Write([]byte(persona.Current().Banners.FTPSyst + "\r\n"))
+		} else if response, exists := fakeFTPResponses[command]; exists {
 			conn.Write([]byte(response + "\r\n"))
 			if command == "QUIT" {
 				return
@@ -163,15 +176,14 @@ func detectSuspiciousCommand(command, clientAddr string) {
 	}
 }
 
-func blockSuspiciousIP(ip string) {
-	cmd := exec.Command("sudo", "iptables", "-A", "INPUT", "-s", ip, "-j", "DROP")
-	err := cmd.Run()
+func blockSuspiciousIP(addr string) {
+	err := firewall.BanAddr(addr, "suspicious command", "SUSPICIOUS_COMMAND")
 	if err != nil {
-		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
-		logToFile(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
+		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
+		logToFile(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
 	} else {
-		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", ip))
-		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", ip))
+		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", addr))
+		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", addr))
 	}
 }
 
@@ -3,21 +3,25 @@ package handlers
 import (
 	"net"
 	"myhoneypot/logging"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 )
 
 func HandleFTPConnection(conn net.Conn, logger *logging.Logger) {
 	defer conn.Close()
+	defer metrics.TrackConnection("ftp", conn)()
 
 	ip := conn.RemoteAddr().String()
 
-	if logger.IsIPBanned(ip) {
-		logger.Log(ip, "Conexão FTP recusada (IP banido)", WARNING)
-		return
-	}
-
-	logger.Log(ip, "Tentativa de login via FTP", INFO)
+	logger.Record(logging.LogEntry{
+		IP:       ip,
+		Event:    "Tentativa de login via FTP",
+		Level:    logging.INFO,
+		Type:     logging.EventConnection,
+		Protocol: "ftp",
+	})
 
-	conn.Write([]byte("220 Fake FTP Server Ready\r\n"))
+	conn.Write([]byte(persona.Current().Banners.FTP + "\r\n"))
 }
 
 package cmd
@@ -29,25 +33,25 @@ import (
 	"log"
 	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 	"yourproject/internal/logs"
 )
 
 const (
 	ftpPort        = "0.0.0.0:21"
-	fakeUsername   = "admin"
-	fakePassword   = "admin"
 	timeoutSeconds = 120
 )
 
 var fakeFTPResponses = map[string]string{
 	"USER admin": "331 User admin okay, need password.",
 	"PASS admin": "230 Login successful.",
-	"SYST":       "215 UNIX Type: L8",
 	"PWD":        `257 "/" is the current directory`,
 	"LIST":       "drwxr-xr-x  5 root root  4096 Mar 20 12:00 home\n-rw-r--r--  1 root root  1234 Mar 20 12:05 README.txt",
 	"QUIT":       "221 Goodbye.",
@@ -77,22 +81,31 @@ func StartFTPServer() {
 
 func handleFTPConnection(conn net.Conn) {
 	defer conn.Close()
+	defer metrics.TrackConnection("ftp", conn)()
 	clientAddr := conn.RemoteAddr().String()
 	logs.Info(fmt.Sprintf("New FTP connection from %s", clientAddr))
 
-	conn.Write([]byte("220 FTP Server ready.\r\n"))
+	conn.Write([]byte(persona.Current().Banners.FTP + "\r\n"))
+
+	if verdict := firewall.CheckAuth(clientAddr, "ftp"); verdict.Action == firewall.ActionDeny {
+		logs.Warn(fmt.Sprintf("Login from %s refused: %s", clientAddr, verdict.Reason))
+		return
+	}
 
 	username, password := fakeFTPLogin(conn)
+	metrics.AuthAttempts.Inc("ftp")
 	if username == "" || password == "" {
 		logs.Warn(fmt.Sprintf("Failed FTP login attempt from %s", clientAddr))
 		logToFile(fmt.Sprintf("Failed login from %s", clientAddr))
 		saveToDatabase(clientAddr, "FAILED_LOGIN", "")
+		firewall.AuthFailed(clientAddr, "ftp", "")
 		return
 	}
 
 	logs.Info(fmt.Sprintf("Successful FTP login from %s with user: %s", clientAddr, username))
 	logToFile(fmt.Sprintf("Successful login from %s - Username: %s", clientAddr, username))
 	saveToDatabase(clientAddr, "SUCCESSFUL_LOGIN", username)
+	metrics.AuthSuccesses.Inc("ftp")
 
 	handleFakeFTPCommands(conn, clientAddr)
 }
@@ -108,7 +121,7 @@ func fakeFTPLogin(conn net.Conn) (string, string) {
 	scanner.Scan()
 	password := scanner.Text()
 
-	if username == "admin" && password == "admin" {
+	if auth.Accept("ftp", conn.RemoteAddr().String(), username, password) {
 		conn.Write([]byte("230 Login successful.\r\n"))
 		return username, password
 	}
@@ -127,13 +140,16 @@ func handleFakeFTPCommands(conn net.Conn, clientAddr string) {
 			continue
 		}
 
+		metrics.Commands.Inc("ftp")
 		logs.Info(fmt.Sprintf("FTP command from %s: %s", clientAddr, command))
 		logToFile(fmt.Sprintf("FTP command from %s: %s", clientAddr, command))
 		saveToDatabase(clientAddr, "COMMAND_EXECUTED", command)
 
 		detectSuspiciousCommand(command, clientAddr)
 
-		if response, exists := fakeFTPResponses[command]; exists {
+		if command == "SYST" {
+			conn.Write([]byte(persona.Current().Banners.FTPSyst + "\r\n"))
+		} else if response, exists := fakeFTPResponses[command]; exists {
 			conn.Write([]byte(response + "\r\n"))
 		} else {
 			conn.Write([]byte("500 Unknown command.\r\n"))
@@ -165,15 +181,14 @@ func detectSuspiciousCommand(command, clientAddr string) {
 	}
 }
 
-func blockSuspiciousIP(ip string) {
-	cmd := exec.Command("sudo", "iptables", "-A", "INPUT", "-s", ip, "-j", "DROP")
-	err := cmd.Run()
+func blockSuspiciousIP(addr string) {
+	err := firewall.BanAddr(addr, "suspicious command", "SUSPICIOUS_COMMAND")
 	if err != nil {
-		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
-		logToFile(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
+		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
+		logToFile(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
 	} else {
-		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", ip))
-		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", ip))
+		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", addr))
+		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", addr))
 	}
 }
 
@@ -8,4 +8,6 @@ require (
 	github.com/armon/go-socks5 v0.0.0-20210120193318-cfd40e799cf5 // Proxy SOCKS5
 	github.com/spf13/viper v1.16.0    // Leitura de configurações em YAML
 	github.com/mattn/go-sqlite3 v1.14.16 // Banco de dados SQLite para logs
+	github.com/fsnotify/fsnotify v1.10.1 // Recarga do banco de usuários
+	gopkg.in/yaml.v3 v3.0.1           // Configuração e perfis de persona em YAML
 )
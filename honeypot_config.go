@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+	"myhoneypot/alerting"
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/internal/handlers"
+	"myhoneypot/logging"
+	"myhoneypot/persona"
+	"myhoneypot/session"
+)
+
+// HoneypotConfig espelha as seções do config.yaml usadas pelo servidor
+type HoneypotConfig struct {
+	Honeypot struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Version     string `yaml:"version"`
+	} `yaml:"honeypot"`
+
+	Ports struct {
+		SSH         int                       `yaml:"ssh"`
+		Telnet      int                       `yaml:"telnet"`
+		FTP         int                       `yaml:"ftp"`
+		BindAddress string                    `yaml:"bind_address"`
+		Listeners   []firewall.ListenerConfig `yaml:"listeners"`
+		CatchAll    firewall.CatchAllConfig   `yaml:"catch_all"`
+		UDP         firewall.UDPConfig        `yaml:"udp"`
+		API         struct {
+			Enabled bool   `yaml:"enabled"`
+			Listen  string `yaml:"listen"`
+			Token   string `yaml:"token"`
+		} `yaml:"api"`
+	} `yaml:"ports"`
+
+	HTTP  handlers.HTTPConfig  `yaml:"http"`
+	Proxy handlers.ProxyConfig `yaml:"proxy"`
+
+	Simulations handlers.SimulationConfig `yaml:"additional_simulations"`
+
+	Persona persona.Config `yaml:"persona"`
+
+	BannedIPs []string `yaml:"banned_ips"`
+
+	SessionPersistence bool           `yaml:"session_persistence"` // Restaura o shell de quem volta
+	SessionStore       session.Config `yaml:"session_store"`
+
+	Security struct {
+		MaxAttempts         int                       `yaml:"max_attempts"`
+		BanDuration         int                       `yaml:"ban_duration"` // Segundos; 0 = permanente
+		PersistentBan       bool                      `yaml:"persistent_ban"`
+		BruteForceDetection bool                      `yaml:"brute_force_detection"`
+		BruteForce          firewall.BruteForceConfig `yaml:"brute_force"`
+		RateLimits          firewall.RateLimitConfig  `yaml:"rate_limits"`
+		Tarpit              firewall.TarpitConfig     `yaml:"tarpit"`
+	} `yaml:"security"`
+
+	Auth struct {
+		Users    auth.UsersConfig             `yaml:"users"`
+		Policies map[string]auth.PolicyConfig `yaml:"policies"` // Por protocolo; "default" vale para os demais
+	} `yaml:"auth"`
+
+	Firewall struct {
+		firewall.BackendConfig `yaml:",inline"`
+		Rules                  []firewall.Rule `yaml:"rules"`
+		ASNDatabase            string          `yaml:"asn_database"`
+	} `yaml:"firewall"`
+
+	Database struct {
+		Type string `yaml:"type"`
+		File string `yaml:"file"`
+	} `yaml:"database"`
+
+	Logging struct {
+		Level     string               `yaml:"level"`
+		LogToFile bool                 `yaml:"log_to_file"`
+		LogFile   string               `yaml:"log_file"`
+		Syslog    logging.SyslogConfig `yaml:"syslog"`
+	} `yaml:"logging"`
+
+	Alerting alerting.Config `yaml:"alerting"`
+
+	Metrics struct {
+		Enabled bool   `yaml:"enabled"`
+		Listen  string `yaml:"listen"`
+	} `yaml:"metrics"`
+}
+
+// loadHoneypotConfig lê o config.yaml completo
+func loadHoneypotConfig(path string) (*HoneypotConfig, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler %s: %v", path, err)
+	}
+
+	var config HoneypotConfig
+	if err := yaml.Unmarshal(file, &config); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar %s: %v", path, err)
+	}
+
+	if config.Honeypot.Name == "" {
+		config.Honeypot.Name = "honeypot"
+	}
+	if config.Ports.SSH == 0 {
+		config.Ports.SSH = 2222
+	}
+	if config.Ports.Telnet == 0 {
+		config.Ports.Telnet = 23
+	}
+	if config.Ports.FTP == 0 {
+		config.Ports.FTP = 21
+	}
+	if config.Ports.API.Listen == "" {
+		config.Ports.API.Listen = "127.0.0.1:9102"
+	}
+	if config.Database.File == "" {
+		config.Database.File = "honeypot_logs.db"
+	}
+	if config.Auth.Users.File == "" {
+		config.Auth.Users.File = auth.DefaultUsersFile
+	}
+	if config.Persona.Profile == "" {
+		config.Persona.Profile = persona.DefaultProfile
+	}
+	if config.Logging.LogFile == "" {
+		config.Logging.LogFile = "honeypot_debug.log"
+	}
+	return &config, nil
+}
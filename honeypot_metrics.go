@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net"
+	"time"
+)
+
+// Métricas do honeypot expostas em /metrics
+var (
+	Connections = NewCounterVec("honeypot_connections_total",
+		"Conexões recebidas por protocolo e porta.", "protocol", "port")
+	AuthAttempts = NewCounterVec("honeypot_auth_attempts_total",
+		"Tentativas de autenticação por protocolo.", "protocol")
+	AuthSuccesses = NewCounterVec("honeypot_auth_successes_total",
+		"Autenticações aceitas por protocolo.", "protocol")
+	ActiveSessions = NewGaugeVec("honeypot_active_sessions",
+		"Sessões abertas no momento por protocolo.", "protocol")
+	Commands = NewCounterVec("honeypot_commands_total",
+		"Comandos executados pelos atacantes por protocolo.", "protocol")
+	Downloads = NewCounterVec("honeypot_downloads_total",
+		"Arquivos capturados por protocolo.", "protocol")
+	FirewallBans = NewCounterVec("honeypot_firewall_bans_total",
+		"IPs banidos pelo firewall.")
+	BannedIPs = NewGaugeVec("honeypot_firewall_banned_ips",
+		"IPs banidos no momento.")
+	TarpitConnections = NewGaugeVec("honeypot_tarpit_connections",
+		"Conexões presas no tarpit no momento por protocolo.", "protocol")
+	ScanPayloads = NewCounterVec("honeypot_scan_payloads_total",
+		"Primeiros payloads capturados nas portas genéricas por protocolo detectado.", "protocol")
+	UDPPackets = NewCounterVec("honeypot_udp_packets_total",
+		"Pacotes UDP processados por protocolo.", "protocol")
+	UDPDropped = NewCounterVec("honeypot_udp_dropped_total",
+		"Pacotes UDP descartados ou respostas suprimidas por serviço e motivo.", "service", "reason")
+	ProxyRequests = NewCounterVec("honeypot_proxy_requests_total",
+		"Destinos pedidos ao proxy aberto por protocolo e modo (canned ou relay).", "protocol", "mode")
+	TarpitRejected = NewCounterVec("honeypot_tarpit_rejected_total",
+		"Conexões fechadas por falta de vaga no tarpit.", "protocol")
+	SessionDuration = NewHistogramVec("honeypot_session_duration_seconds",
+		"Tempo de vida das sessões, da conexão ao encerramento, por protocolo.",
+		[]float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800}, "protocol")
+	HandlerLatency = NewHistogramVec("honeypot_handler_latency_seconds",
+		"Tempo de resposta dos handlers por protocolo e etapa (auth ou command), sem os atrasos simulados.",
+		[]float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 30}, "protocol", "stage")
+)
+
+var startTime = time.Now()
+
+func init() {
+	NewGaugeFunc("honeypot_start_time_seconds", "Horário de início do processo (epoch).", func() float64 {
+		return float64(startTime.Unix())
+	})
+}
+
+// ObserveLatency registra no HandlerLatency o tempo desde start:
+//
+//	defer metrics.ObserveLatency("telnet", "auth", time.Now())
+func ObserveLatency(protocol, stage string, start time.Time) {
+	HandlerLatency.Observe(time.Since(start).Seconds(), protocol, stage)
+}
+
+// TrackConnection contabiliza uma nova conexão e devolve a função que a encerra:
+//
+//	defer metrics.TrackConnection("ssh", conn)()
+func TrackConnection(protocol string, conn net.Conn) func() {
+	port := "unknown"
+	if _, p, err := net.SplitHostPort(conn.LocalAddr().String()); err == nil {
+		port = p
+	}
+
+	Connections.Inc(protocol, port)
+	ActiveSessions.Inc(protocol)
+	start := time.Now()
+
+	return func() {
+		ActiveSessions.Dec(protocol)
+		SessionDuration.Observe(time.Since(start).Seconds(), protocol)
+	}
+}
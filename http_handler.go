@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"myhoneypot/auth"
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
+)
+
+// HTTPConfig configura o honeypot HTTP/HTTPS
+type HTTPConfig struct {
+	Template  string         `yaml:"template"`  // apache, router, phpmyadmin, wordpress ou jenkins
+	Templates map[int]string `yaml:"templates"` // Template por porta local (sobrepõe template)
+	MaxBody   int64          `yaml:"max_body"`  // Bytes do corpo guardados no evento (padrão 64 KiB)
+	TLSCert   string         `yaml:"tls_cert"`  // Certificado do HTTPS; vazio gera um autoassinado
+	TLSKey    string         `yaml:"tls_key"`
+	TLSCommon string         `yaml:"tls_common"` // CN do certificado autoassinado (padrão localhost)
+}
+
+// HTTPHoneypot atende conexões HTTP e HTTPS entregues pelo PortManager
+type HTTPHoneypot struct {
+	config    HTTPConfig
+	logger    *logging.Logger
+	server    *http.Server
+	plain     *connListener
+	secure    *connListener
+	tlsConfig *tls.Config
+}
+
+// NewHTTPHoneypot valida os templates e prepara o certificado do HTTPS
+func NewHTTPHoneypot(config HTTPConfig, logger *logging.Logger) (*HTTPHoneypot, error) {
+	if config.Template == "" {
+		config.Template = "apache"
+	}
+	if config.MaxBody <= 0 {
+		config.MaxBody = 64 * 1024
+	}
+	if config.TLSCommon == "" {
+		config.TLSCommon = "localhost"
+	}
+	for _, name := range append([]string{config.Template}, mapValues(config.Templates)...) {
+		if _, exists := httpTemplates[name]; !exists {
+			return nil, fmt.Errorf("template HTTP desconhecido: %s", name)
+		}
+	}
+
+	var cert tls.Certificate
+	var err error
+	if config.TLSCert != "" {
+		cert, err = tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+	} else {
+		cert, err = selfSignedCertificate(config.TLSCommon)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao preparar certificado TLS: %v", err)
+	}
+
+	h := &HTTPHoneypot{
+		config:    config,
+		logger:    logger,
+		plain:     newConnListener(),
+		secure:    newConnListener(),
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	h.server = &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 15 * time.Second,
+		IdleTimeout:       30 * time.Second,
+		ErrorLog:          log.New(io.Discard, "", 0), // Handshakes TLS quebrados de scanners são rotina
+	}
+	go h.server.Serve(h.plain)
+	go h.server.Serve(tls.NewListener(h.secure, h.tlsConfig))
+	return h, nil
+}
+
+// HandleHTTPConnection entrega uma conexão HTTP ao servidor e espera ela ser encerrada
+func (h *HTTPHoneypot) HandleHTTPConnection(conn net.Conn) {
+	tracked := trackedConn(conn, "http")
+	h.plain.push(tracked)
+	<-tracked.closed
+}
+
+// HandleHTTPSConnection entrega uma conexão HTTPS ao servidor (o handshake TLS é feito pelo servidor)
+func (h *HTTPHoneypot) HandleHTTPSConnection(conn net.Conn) {
+	tracked := trackedConn(conn, "https")
+	h.secure.push(tracked)
+	<-tracked.closed
+}
+
+// Close encerra o servidor e as conexões abertas
+func (h *HTTPHoneypot) Close() error {
+	h.plain.Close()
+	h.secure.Close()
+	return h.server.Close()
+}
+
+// ServeHTTP registra a requisição, procura credenciais e exploits e responde com o template
+func (h *HTTPHoneypot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	protocol := "http"
+	if r.TLS != nil {
+		protocol = "https"
+	}
+	port := 0
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+			port = tcpAddr.Port
+		}
+	}
+	template := httpTemplates[h.config.Template]
+	if name, exists := h.config.Templates[port]; exists {
+		template = httpTemplates[name]
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(r.Body, h.config.MaxBody))
+	raw := dumpRequest(r, body)
+	base := logging.LogEntry{
+		IP:        r.RemoteAddr,
+		Protocol:  protocol,
+		Port:      port,
+		UserAgent: r.UserAgent(),
+	}
+
+	entry := base
+	entry.Event = fmt.Sprintf("%s %s (%s, %d bytes)", r.Method, r.URL.RequestURI(), template.name, len(body))
+	entry.Level = logging.INFO
+	entry.Type = logging.EventHTTPRequest
+	entry.Request = raw
+	h.logger.Record(entry)
+
+	for _, exploit := range detectExploits(r, body) {
+		entry := base
+		entry.Event = fmt.Sprintf("%s: %s %s", exploit.name, r.Method, r.URL.RequestURI())
+		entry.Level = exploit.level
+		entry.Type = logging.EventExploitAttempt
+		entry.Command = exploit.indicator
+		entry.Request = raw
+		h.logger.Record(entry)
+	}
+
+	accepted := false
+	username, password, found := credentials(r, body)
+	if found {
+		metrics.AuthAttempts.Inc(protocol)
+		// HTTP e HTTPS compartilham a política "http"
+		start := time.Now()
+		accepted = auth.Check(auth.Attempt{Protocol: "http", Addr: r.RemoteAddr, Username: username, Password: password, Client: r.UserAgent()})
+		metrics.ObserveLatency(protocol, "auth", start)
+		entry := base
+		entry.Event = fmt.Sprintf("Tentativa de login via %s (%s) em %s", strings.ToUpper(protocol), template.name, r.URL.Path)
+		entry.Level = logging.WARNING
+		entry.Type = logging.EventFailedLogin
+		if accepted {
+			metrics.AuthSuccesses.Inc(protocol)
+			entry.Event = fmt.Sprintf("Login aceito via %s (%s) em %s", strings.ToUpper(protocol), template.name, r.URL.Path)
+			entry.Type = logging.EventSuccessfulLogin
+		}
+		entry.Username = username
+		entry.Password = password
+		h.logger.Record(entry)
+	}
+
+	for name, value := range template.headers {
+		w.Header().Set(name, value)
+	}
+	if template.osServer {
+		w.Header().Set("Server", persona.Current().Banners.HTTP)
+	}
+
+	// A política de autenticação decide: o formulário recusado volta com erro e o painel protegido
+	// por Basic pede de novo; aceito, o atacante vê a página de sucesso do template
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == template.loginPath && accepted:
+		writePage(w, template.success, template.failure)
+	case r.Method == http.MethodPost && r.URL.Path == template.loginPath:
+		writePage(w, template.failure, template.notFound)
+	case template.basicAuth != "" && r.URL.Path != "/" && accepted:
+		writePage(w, template.success, template.notFound)
+	case template.basicAuth != "" && r.URL.Path != "/":
+		h.challenge(w, template)
+	default:
+		page, exists := template.pages[r.URL.Path]
+		if !exists {
+			page = template.notFound
+		}
+		writePage(w, page, template.notFound)
+	}
+}
+
+// challenge pede HTTP Basic, como os roteadores que protegem todo o painel
+func (h *HTTPHoneypot) challenge(w http.ResponseWriter, template *httpTemplate) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", template.basicAuth))
+	writePage(w, httpPage{status: http.StatusUnauthorized, contentType: htmlType, body: "<html><body><h1>401 Unauthorized</h1></body></html>\n"}, template.notFound)
+}
+
+func writePage(w http.ResponseWriter, page, fallback httpPage) {
+	if page.status == 0 {
+		page = fallback
+	}
+	if page.location != "" {
+		w.Header().Set("Location", page.location)
+	}
+	if page.contentType != "" {
+		w.Header().Set("Content-Type", page.contentType)
+	}
+	w.WriteHeader(page.status)
+	// {{server}} é a assinatura do servidor, como no <address> das páginas de erro do Apache;
+	// {{db_*}} descrevem o banco do perfil, o mesmo que dpkg -l, rpm -qa e ps mostram no shell
+	dbHost := "Localhost via UNIX socket"
+	dbType, dbVersion, local := persona.Current().Database()
+	if !local {
+		// Sem banco no perfil o painel aponta para um servidor remoto, que não aparece no shell
+		dbHost, dbType, dbVersion = "db via TCP/IP", "MySQL", "8.0.34"
+	}
+	io.WriteString(w, strings.NewReplacer(
+		"{{server}}", w.Header().Get("Server"),
+		"{{db_host}}", dbHost,
+		"{{db_type}}", dbType,
+		"{{db_version}}", dbVersion,
+	).Replace(page.body))
+}
+
+// dumpRequest monta a requisição completa no formato do protocolo, com o corpo já lido
+func dumpRequest(r *http.Request, body []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\r\n", r.Method, r.URL.RequestURI(), r.Proto)
+	fmt.Fprintf(&b, "Host: %s\r\n", r.Host)
+	r.Header.Write(&b)
+	b.WriteString("\r\n")
+	b.Write(body)
+	return b.String()
+}
+
+// Campos de formulário que os templates (e os bots) usam para usuário e senha
+var (
+	userFields     = []string{"username", "user", "log", "login", "email", "pma_username", "j_username", "uname", "usr"}
+	passwordFields = []string{"password", "pass", "pwd", "passwd", "pma_password", "j_password", "pswd", "pw"}
+)
+
+// credentials procura usuário e senha no HTTP Basic, no formulário ou na query string
+func credentials(r *http.Request, body []byte) (string, string, bool) {
+	if username, password, ok := r.BasicAuth(); ok {
+		return username, password, true
+	}
+
+	values := r.URL.Query()
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for key, list := range form {
+				values[key] = append(list, values[key]...)
+			}
+		}
+	}
+
+	lower := make(map[string]string)
+	for key := range values {
+		lower[strings.ToLower(key)] = values.Get(key)
+	}
+	username, password := "", ""
+	for _, field := range userFields {
+		if value, exists := lower[field]; exists {
+			username = value
+			break
+		}
+	}
+	for _, field := range passwordFields {
+		if value, exists := lower[field]; exists {
+			password = value
+			break
+		}
+	}
+	return username, password, password != ""
+}
+
+// exploitSignature identifica uma classe de ataque conhecida
+type exploitSignature struct {
+	name    string
+	level   logging.LogLevel
+	path    *regexp.Regexp      // Aplicado ao caminho decodificado
+	content *regexp.Regexp      // Aplicado à URL, aos headers e ao corpo
+	detect  func(string) string // Alternativa ao content para casos que regex não cobre
+}
+
+// exploitMatch é uma assinatura encontrada na requisição
+type exploitMatch struct {
+	name      string
+	level     logging.LogLevel
+	indicator string
+}
+
+var exploitSignatures = []exploitSignature{
+	{name: "Log4Shell (CVE-2021-44228)", level: logging.CRITICAL, detect: log4shell},
+	{name: "Shellshock (CVE-2014-6271)", level: logging.CRITICAL, content: regexp.MustCompile(`\(\)\s*\{\s*:?\s*;\s*\}\s*;`)},
+	{name: "Injeção de comando", level: logging.CRITICAL,
+		content: regexp.MustCompile(`(?i)(;|\||\$\(|` + "`" + `)\s*(wget|curl|tftp|busybox|chmod|sh|bash|nc)\b`)},
+	{name: "Arquivo de credenciais exposto", level: logging.WARNING,
+		path: regexp.MustCompile(`(?i)/(\.env|\.git/config|\.aws/credentials|\.ssh/id_rsa|wp-config\.php\.bak|config\.json|\.htpasswd)$`)},
+	{name: "Varredura WordPress", level: logging.WARNING,
+		path: regexp.MustCompile(`(?i)^/(wp-admin|wp-login\.php|xmlrpc\.php|wp-content/plugins|wp-includes)`)},
+	{name: "CGI", level: logging.WARNING, path: regexp.MustCompile(`(?i)/cgi-bin/`)},
+	{name: "Path traversal", level: logging.WARNING,
+		content: regexp.MustCompile(`(?i)(\.\./|\.\.\\|%2e%2e(%2f|/|%5c)|/etc/passwd|win\.ini)`)},
+	{name: "PHPUnit RCE (CVE-2017-9841)", level: logging.CRITICAL, path: regexp.MustCompile(`(?i)eval-stdin\.php`)},
+	{name: "Exploit de roteador/IoT", level: logging.CRITICAL,
+		path: regexp.MustCompile(`(?i)^/(boaform/|GponForm/|HNAP1|setup\.cgi|shell|tmUnblock\.cgi|picsdesc\.xml|ctrlt/DeviceUpgrade|goform/)`)},
+	{name: "ThinkPHP RCE", level: logging.CRITICAL, content: regexp.MustCompile(`(?i)invokefunction|call_user_func_array`)},
+	{name: "Spring/Actuator", level: logging.WARNING, path: regexp.MustCompile(`(?i)^/(actuator|env|heapdump|jolokia)(/|$)`)},
+	{name: "Painel administrativo", level: logging.INFO,
+		path: regexp.MustCompile(`(?i)^/(phpmyadmin|pma|myadmin|manager/html|solr/admin|druid|admin|administrator)(/|$)`)},
+}
+
+// detectExploits aplica as assinaturas; cada assinatura gera no máximo um evento por requisição
+func detectExploits(r *http.Request, body []byte) []exploitMatch {
+	path := r.URL.Path
+	if decoded, err := url.PathUnescape(r.URL.EscapedPath()); err == nil {
+		path = decoded
+	}
+
+	var content bytes.Buffer
+	uri := r.URL.RequestURI()
+	if decoded, err := url.QueryUnescape(uri); err == nil {
+		uri = decoded
+	}
+	content.WriteString(uri + "\n")
+	for name, values := range r.Header {
+		content.WriteString(name + ": " + strings.Join(values, ", ") + "\n")
+	}
+	content.Write(body)
+	if decoded, err := url.QueryUnescape(string(body)); err == nil {
+		content.WriteString("\n" + decoded)
+	}
+
+	var matches []exploitMatch
+	for _, signature := range exploitSignatures {
+		indicator := ""
+		if signature.path != nil {
+			indicator = signature.path.FindString(path)
+		}
+		if indicator == "" && signature.content != nil {
+			indicator = signature.content.FindString(content.String())
+		}
+		if indicator == "" && signature.detect != nil {
+			indicator = signature.detect(content.String())
+		}
+		if indicator != "" {
+			matches = append(matches, exploitMatch{name: signature.name, level: signature.level, indicator: indicator})
+		}
+	}
+	return matches
+}
+
+var lookupPattern = regexp.MustCompile(`\$\{([^{}$]*)\}`)
+
+// log4shell resolve os lookups aninhados usados para ofuscar o payload (${${lower:j}ndi:...},
+// ${${::-j}${::-n}di:...}) de dentro para fora e retorna o primeiro ${jndi:...} encontrado
+func log4shell(content string) string {
+	for round := 0; round < 10 && strings.Contains(content, "${"); round++ {
+		found := ""
+		resolved := lookupPattern.ReplaceAllStringFunc(content, func(lookup string) string {
+			inner := lookup[2 : len(lookup)-1]
+			if strings.HasPrefix(strings.ToLower(inner), "jndi:") {
+				if found == "" {
+					found = lookup
+				}
+				return ""
+			}
+			if i := strings.LastIndex(inner, ":-"); i >= 0 {
+				return inner[i+2:]
+			}
+			if i := strings.Index(inner, ":"); i >= 0 {
+				switch strings.ToLower(inner[:i]) {
+				case "lower", "upper":
+					return inner[i+1:]
+				}
+			}
+			return ""
+		})
+		if found != "" {
+			return found
+		}
+		if resolved == content {
+			break
+		}
+		content = resolved
+	}
+	return ""
+}
+
+// connListener é um net.Listener alimentado pelas conexões aceitas no PortManager
+type connListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newConnListener() *connListener {
+	return &connListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+func (l *connListener) push(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+// metricsConn encerra a contagem de sessão e libera o handler quando o servidor HTTP fecha a conexão
+type metricsConn struct {
+	net.Conn
+	done   func()
+	closed chan struct{}
+	once   sync.Once
+}
+
+func trackedConn(conn net.Conn, protocol string) *metricsConn {
+	return &metricsConn{Conn: conn, done: metrics.TrackConnection(protocol, conn), closed: make(chan struct{})}
+}
+
+func (c *metricsConn) Close() error {
+	c.once.Do(func() {
+		c.done()
+		close(c.closed)
+	})
+	return c.Conn.Close()
+}
+
+// selfSignedCertificate gera um certificado ECDSA válido por um ano
+func selfSignedCertificate(commonName string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"Default Company Ltd"}},
+		NotBefore:             time.Now().Add(-30 * 24 * time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{commonName},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func mapValues(m map[int]string) []string {
+	values := make([]string, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	return values
+}
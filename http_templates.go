@@ -0,0 +1,236 @@
+package handlers
+
+// httpPage é uma resposta fixa do template
+type httpPage struct {
+	status      int
+	contentType string
+	body        string
+	location    string // Redirecionamento (status 302)
+}
+
+// httpTemplate imita um produto web conhecido: cabeçalhos, páginas e a resposta ao login
+type httpTemplate struct {
+	name      string
+	headers   map[string]string
+	pages     map[string]httpPage // Caminho exato -> página
+	loginPath string              // Destino do POST do formulário
+	failure   httpPage            // Resposta ao login recusado pela política
+	success   httpPage            // Resposta ao login aceito; vazio usa failure
+	basicAuth string              // Realm do HTTP Basic; vazio desativa
+	notFound  httpPage
+	osServer  bool // Server vem do perfil (persona) ativo, como um servidor web instalado no sistema
+}
+
+const htmlType = "text/html; charset=UTF-8"
+
+var apacheNotFound = httpPage{status: 404, contentType: "text/html; charset=iso-8859-1", body: `<!DOCTYPE HTML PUBLIC "-//IETF//DTD HTML 2.0//EN">
+<html><head>
+<title>404 Not Found</title>
+</head><body>
+<h1>Not Found</h1>
+<p>The requested URL was not found on this server.</p>
+<hr>
+<address>{{server}} Server Port 80</address>
+</body></html>
+`}
+
+// httpTemplates são os templates disponíveis em http.template
+var httpTemplates = map[string]*httpTemplate{
+	"apache": {
+		name:     "apache",
+		osServer: true,
+		pages: map[string]httpPage{
+			"/": {status: 200, contentType: htmlType, body: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <head>
+    <meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+    <title>Apache2 Ubuntu Default Page: It works</title>
+  </head>
+  <body>
+    <div class="main_page">
+      <div class="page_header floating_element">
+        <span class="floating_element">Apache2 Ubuntu Default Page</span>
+      </div>
+      <div class="section_header"><div id="about"></div>It works!</div>
+      <p>This is the default welcome page used to test the correct operation of the Apache2 server after
+      installation on Ubuntu systems. If you can read this page, it means that the Apache HTTP server installed at
+      this site is working properly. You should <b>replace this file</b> (located at <tt>/var/www/html/index.html</tt>)
+      before continuing to operate your HTTP server.</p>
+    </div>
+  </body>
+</html>
+`},
+		},
+		notFound: apacheNotFound,
+	},
+
+	"router": {
+		name:      "router",
+		headers:   map[string]string{"Server": "lighttpd/1.4.35", "X-Frame-Options": "SAMEORIGIN"},
+		loginPath: "/cgi-bin/luci",
+		basicAuth: "TP-LINK Wireless N Router WR840N",
+		pages: map[string]httpPage{
+			"/": {status: 200, contentType: htmlType, body: `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Router Admin Login</title></head>
+<body style="font-family:Arial;background:#eef2f5">
+<div style="width:340px;margin:120px auto;background:#fff;padding:24px;border-radius:4px">
+<h2>Wireless N Router WR840N</h2>
+<form method="post" action="/cgi-bin/luci">
+<p><input type="text" name="username" placeholder="Username" value="admin"></p>
+<p><input type="password" name="password" placeholder="Password"></p>
+<p><input type="submit" value="Login"></p>
+</form>
+<p style="font-size:11px;color:#888">Firmware Version: 0.9.1 4.16 v0001.0 Build 171211 Rel.58800n</p>
+</div></body></html>
+`},
+		},
+		failure: httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Router Admin Login</title></head>
+<body><script>alert("The username or password is incorrect, please input again.");location.href="/";</script></body></html>
+`},
+		success: httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Status</title></head>
+<body style="font-family:Arial">
+<h2>Wireless N Router WR840N - Status</h2>
+<table>
+<tr><td>Firmware Version:</td><td>0.9.1 4.16 v0001.0 Build 171211 Rel.58800n</td></tr>
+<tr><td>Hardware Version:</td><td>WR840N v4 00000004</td></tr>
+<tr><td>WAN IP Address:</td><td>100.64.12.87</td></tr>
+<tr><td>LAN MAC Address:</td><td>50-C7-BF-3A-21-9E</td></tr>
+<tr><td>System Up Time:</td><td>12 days 03:41:17</td></tr>
+</table>
+<p><a href="/cgi-bin/luci/admin/system">System Tools</a> | <a href="/cgi-bin/luci/admin/network">Network</a></p>
+</body></html>
+`},
+		notFound: httpPage{status: 404, contentType: htmlType, body: "<html><body><h1>404 - Not Found</h1></body></html>\n"},
+	},
+
+	"phpmyadmin": {
+		name:      "phpmyadmin",
+		osServer:  true,
+		headers:   map[string]string{"X-Powered-By": "PHP/7.4.3", "Set-Cookie": "phpMyAdmin=3b1f5c0e8d2a4f6b9c7e1a2d3f4b5c6d; path=/phpmyadmin/; HttpOnly"},
+		loginPath: "/phpmyadmin/index.php",
+		pages: map[string]httpPage{
+			"/":                     {status: 302, location: "/phpmyadmin/"},
+			"/phpmyadmin":           {status: 301, location: "/phpmyadmin/"},
+			"/phpmyadmin/":          phpMyAdminLogin,
+			"/phpmyadmin/index.php": phpMyAdminLogin,
+		},
+		failure: httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE HTML>
+<html lang="en" dir="ltr"><head><meta charset="utf-8"><title>phpMyAdmin</title></head>
+<body class="loginform">
+<div class="container"><h1>Welcome to <bdo dir="ltr" lang="en">phpMyAdmin</bdo></h1>
+<div class="alert alert-danger" role="alert">mysqli::real_connect(): (HY000/1045): Access denied for user</div>
+<form method="post" action="index.php" name="login_form" class="disableAjax login hide js-show">
+<input type="text" name="pma_username" id="input_username" value="" size="24" class="textfield">
+<input type="password" name="pma_password" id="input_password" value="" size="24" class="textfield">
+<input value="Go" type="submit" id="input_go">
+</form></div></body></html>
+`},
+		success: httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE HTML>
+<html lang="en" dir="ltr"><head><meta charset="utf-8"><title>localhost / localhost | phpMyAdmin 5.0.1</title></head>
+<body>
+<div id="pma_navigation"><div id="pma_navigation_tree"><ul><li><a href="index.php?route=/database/structure&db=information_schema">information_schema</a></li><li><a href="index.php?route=/database/structure&db=mysql">mysql</a></li><li><a href="index.php?route=/database/structure&db=performance_schema">performance_schema</a></li><li><a href="index.php?route=/database/structure&db=wordpress">wordpress</a></li></ul></div></div>
+<div id="maincontainer"><h2>Database server</h2><ul>
+<li>Server: {{db_host}}</li><li>Server type: {{db_type}}</li><li>Server version: {{db_version}}</li>
+<li>User: root@localhost</li><li>Server charset: UTF-8 Unicode (utf8mb4)</li></ul></div>
+</body></html>
+`},
+		notFound: apacheNotFound,
+	},
+
+	"wordpress": {
+		name:      "wordpress",
+		osServer:  true,
+		headers:   map[string]string{"X-Powered-By": "PHP/8.0.30", "Link": `<https://blog.local/wp-json/>; rel="https://api.w.org/"`},
+		loginPath: "/wp-login.php",
+		pages: map[string]httpPage{
+			"/": {status: 200, contentType: htmlType, body: `<!DOCTYPE html>
+<html lang="en-US"><head><meta charset="UTF-8"><title>My Blog &#8211; Just another WordPress site</title>
+<meta name="generator" content="WordPress 6.2.2" />
+<link rel='stylesheet' id='wp-block-library-css' href='/wp-includes/css/dist/block-library/style.min.css?ver=6.2.2' media='all' />
+</head><body class="home blog">
+<header><h1><a href="/">My Blog</a></h1><p>Just another WordPress site</p></header>
+<main><article><h2><a href="/?p=1">Hello world!</a></h2><p>Welcome to WordPress. This is your first post. Edit or delete it, then start writing!</p></article></main>
+<footer><a href="/wp-login.php">Log in</a></footer>
+</body></html>
+`},
+			"/wp-login.php": wordpressLogin,
+			"/wp-admin":     {status: 302, location: "/wp-login.php?redirect_to=%2Fwp-admin%2F&reauth=1"},
+			"/wp-admin/":    {status: 302, location: "/wp-login.php?redirect_to=%2Fwp-admin%2F&reauth=1"},
+		},
+		failure:  wordpressFailure,
+		success:  httpPage{status: 302, location: "/wp-admin/"},
+		notFound: httpPage{status: 404, contentType: htmlType, body: "<!DOCTYPE html><html><head><title>Page not found &#8211; My Blog</title></head><body><h1>Oops! That page can&rsquo;t be found.</h1></body></html>\n"},
+	},
+
+	"jenkins": {
+		name:      "jenkins",
+		headers:   map[string]string{"Server": "Jetty(9.4.43.v20210629)", "X-Jenkins": "2.303.1", "X-Hudson": "1.395", "X-Content-Type-Options": "nosniff"},
+		loginPath: "/j_spring_security_check",
+		pages: map[string]httpPage{
+			"/":           {status: 403, contentType: htmlType, body: "<html><head><meta http-equiv='refresh' content='1;url=/login?from=%2F'/></head><body>Authentication required</body></html>\n"},
+			"/login":      jenkinsLogin,
+			"/loginError": {status: 401, contentType: htmlType, body: "<!DOCTYPE html><html><head><title>Sign in [Jenkins]</title></head><body><div class=\"app-sign-in-register__error\">Invalid username or password</div><a href=\"/login\">Try again</a></body></html>\n"},
+		},
+		failure:  httpPage{status: 302, location: "/loginError"},
+		success:  httpPage{status: 302, location: "/"},
+		notFound: httpPage{status: 404, contentType: htmlType, body: "<html><head><title>Error 404 Not Found</title></head><body><h2>HTTP ERROR 404 Not Found</h2></body></html>\n"},
+	},
+}
+
+var phpMyAdminLogin = httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE HTML>
+<html lang="en" dir="ltr"><head><meta charset="utf-8"><meta name="robots" content="noindex,nofollow">
+<title>phpMyAdmin</title></head>
+<body class="loginform">
+<div class="container"><h1>Welcome to <bdo dir="ltr" lang="en">phpMyAdmin</bdo></h1>
+<form method="post" action="index.php" name="login_form" class="disableAjax login hide js-show">
+<fieldset><legend>Log in</legend>
+<div class="item"><label for="input_username">Username:</label>
+<input type="text" name="pma_username" id="input_username" value="" size="24" class="textfield"></div>
+<div class="item"><label for="input_password">Password:</label>
+<input type="password" name="pma_password" id="input_password" value="" size="24" class="textfield"></div>
+<input type="hidden" name="server" value="1">
+</fieldset>
+<fieldset class="tblFooters"><input value="Go" type="submit" id="input_go"></fieldset>
+</form></div></body></html>
+`}
+
+var wordpressLogin = httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE html>
+<html lang="en-US"><head><meta charset="UTF-8"><title>Log In &lsaquo; My Blog &#8212; WordPress</title>
+<meta name='robots' content='max-image-preview:large, noindex, noarchive' /></head>
+<body class="login no-js login-action-login wp-core-ui locale-en-us">
+<div id="login"><h1><a href="https://wordpress.org/">Powered by WordPress</a></h1>
+<form name="loginform" id="loginform" action="/wp-login.php" method="post">
+<p><label for="user_login">Username or Email Address</label>
+<input type="text" name="log" id="user_login" class="input" value="" size="20" autocapitalize="off" autocomplete="username" /></p>
+<div class="user-pass-wrap"><label for="user_pass">Password</label>
+<input type="password" name="pwd" id="user_pass" class="input password-input" value="" size="20" autocomplete="current-password" /></div>
+<p class="forgetmenot"><input name="rememberme" type="checkbox" id="rememberme" value="forever" /> <label for="rememberme">Remember Me</label></p>
+<p class="submit"><input type="submit" name="wp-submit" id="wp-submit" class="button button-primary button-large" value="Log In" />
+<input type="hidden" name="redirect_to" value="/wp-admin/" /><input type="hidden" name="testcookie" value="1" /></p>
+</form></div></body></html>
+`}
+
+var wordpressFailure = httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE html>
+<html lang="en-US"><head><meta charset="UTF-8"><title>Log In &lsaquo; My Blog &#8212; WordPress</title></head>
+<body class="login no-js login-action-login wp-core-ui locale-en-us">
+<div id="login"><h1><a href="https://wordpress.org/">Powered by WordPress</a></h1>
+<div id="login_error"><strong>Error:</strong> The password you entered for the username is incorrect. <a href="/wp-login.php?action=lostpassword">Lost your password?</a><br /></div>
+<form name="loginform" id="loginform" action="/wp-login.php" method="post">
+<p><input type="text" name="log" id="user_login" class="input" value="" size="20" /></p>
+<div class="user-pass-wrap"><input type="password" name="pwd" id="user_pass" class="input password-input" value="" size="20" /></div>
+<p class="submit"><input type="submit" name="wp-submit" id="wp-submit" class="button button-primary button-large" value="Log In" /></p>
+</form></div></body></html>
+`}
+
+var jenkinsLogin = httpPage{status: 200, contentType: htmlType, body: `<!DOCTYPE html><html><head resURL="/static/3e8f1a2b" data-rooturl="" data-resurl="/static/3e8f1a2b">
+<title>Sign in [Jenkins]</title></head>
+<body><div id="main-panel"><div class="simple-page" role="main">
+<h1>Welcome to Jenkins!</h1>
+<form method="post" name="login" action="j_spring_security_check">
+<div class="formRow"><input autocorrect="off" autocomplete="off" name="j_username" id="j_username" placeholder="Username" type="text" class="normal" autocapitalize="off" aria-label="Username"></div>
+<div class="formRow"><input name="j_password" placeholder="Password" type="password" class="normal" aria-label="Password"></div>
+<input name="from" type="hidden"><div class="submit formRow"><button type="submit" name="Submit" class="submit-button primary ">Sign in</button></div>
+</form></div></div></body></html>
+`}
@@ -0,0 +1,179 @@
+package intel
+
+import (
+	"crypto/sha1"
+	"database/sql"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+// Tipos de observáveis extraídos dos eventos
+const (
+	KindIP         = "ip"
+	KindC2         = "c2" // IP embutido num executável baixado
+	KindFile       = "file"
+	KindURL        = "url"
+	KindSSHKey     = "ssh-key"
+	KindCredential = "credential"
+)
+
+// Observable é um IOC agregado com contagem e primeira/última ocorrência
+type Observable struct {
+	Kind      string
+	Value     string // IP, SHA-256, URL ou fingerprint; para credenciais, "usuário:senha"
+	Username  string
+	Password  string
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Collection agrupa os observáveis de um período
+type Collection struct {
+	Sensor      string
+	observables map[string]*Observable
+}
+
+// NewCollection cria uma coleção vazia para o sensor informado
+func NewCollection(sensor string) *Collection {
+	return &Collection{Sensor: sensor, observables: make(map[string]*Observable)}
+}
+
+// Collect lê os eventos do banco e agrega os IOCs
+func Collect(db *sql.DB, sensor string, filter logging.EventFilter) (*Collection, error) {
+	collection := NewCollection(sensor)
+	err := logging.QueryEvents(db, filter, func(entry logging.LogEntry) error {
+		collection.Add(entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// Add extrai os observáveis de um evento
+func (c *Collection) Add(entry logging.LogEntry) {
+	seen, err := time.ParseInLocation(logging.TimestampLayout, entry.Timestamp, time.Local)
+	if err != nil {
+		return
+	}
+
+	// A origem de um pacote UDP é forjável (as sondas de reflexão usam o IP da vítima), então
+	// não vira um IOC de atacante
+	if ip := sourceIP(entry.IP); ip != "" && entry.Type != logging.EventUDPPacket {
+		c.observe(KindIP, ip, seen)
+	}
+	if entry.SHA256 != "" {
+		c.observe(KindFile, strings.ToLower(entry.SHA256), seen)
+	}
+	if entry.URL != "" {
+		c.observe(KindURL, entry.URL, seen)
+	}
+	if entry.SSHKey != "" {
+		c.observe(KindSSHKey, entry.SSHKey, seen)
+	}
+	// URLs e IPs achados dentro de executáveis baixados (servidores de C2, mirrors do dropper)
+	for _, ioc := range strings.Split(entry.IOCs, ",") {
+		switch {
+		case ioc == "":
+		case net.ParseIP(ioc) != nil:
+			c.observe(KindC2, ioc, seen)
+		default:
+			c.observe(KindURL, ioc, seen)
+		}
+	}
+	if entry.Password != "" && (entry.Type == logging.EventFailedLogin || entry.Type == logging.EventSuccessfulLogin) {
+		o := c.observe(KindCredential, entry.Username+":"+entry.Password, seen)
+		o.Username = entry.Username
+		o.Password = entry.Password
+	}
+}
+
+func (c *Collection) observe(kind, value string, seen time.Time) *Observable {
+	key := kind + "|" + value
+	o, exists := c.observables[key]
+	if !exists {
+		o = &Observable{Kind: kind, Value: value, FirstSeen: seen, LastSeen: seen}
+		c.observables[key] = o
+	}
+	o.Count++
+	if seen.Before(o.FirstSeen) {
+		o.FirstSeen = seen
+	}
+	if seen.After(o.LastSeen) {
+		o.LastSeen = seen
+	}
+	return o
+}
+
+// Observables devolve os IOCs ordenados por tipo e primeira ocorrência
+func (c *Collection) Observables() []*Observable {
+	list := make([]*Observable, 0, len(c.observables))
+	for _, o := range c.observables {
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind < list[j].Kind
+		}
+		if !list[i].FirstSeen.Equal(list[j].FirstSeen) {
+			return list[i].FirstSeen.Before(list[j].FirstSeen)
+		}
+		return list[i].Value < list[j].Value
+	})
+	return list
+}
+
+// Period retorna a menor e a maior data entre os observáveis
+func (c *Collection) Period() (time.Time, time.Time) {
+	var first, last time.Time
+	for _, o := range c.observables {
+		if first.IsZero() || o.FirstSeen.Before(first) {
+			first = o.FirstSeen
+		}
+		if o.LastSeen.After(last) {
+			last = o.LastSeen
+		}
+	}
+	return first, last
+}
+
+// sourceIP remove a porta de endereços no formato de RemoteAddr()
+func sourceIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if net.ParseIP(addr) == nil {
+		return ""
+	}
+	return addr
+}
+
+// uuid5 gera um UUID determinístico (RFC 4122, versão 5), para que exportações
+// repetidas produzam os mesmos IDs e a plataforma de inteligência deduplique os objetos
+func uuid5(namespace [16]byte, name string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+	sum := h.Sum(nil)
+
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// parseUUID converte a forma textual de um UUID em bytes
+func parseUUID(s string) [16]byte {
+	var u [16]byte
+	s = strings.ReplaceAll(s, "-", "")
+	for i := 0; i < 16; i++ {
+		fmt.Sscanf(s[i*2:i*2+2], "%02x", &u[i])
+	}
+	return u
+}
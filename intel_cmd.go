@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"myhoneypot/intel"
+	"myhoneypot/logging"
+)
+
+// runIntel implementa o subcomando "intel": exporta os IOCs como STIX 2.1 ou MISP
+func runIntel(args []string) int {
+	fs := flag.NewFlagSet("intel", flag.ContinueOnError)
+	configFile := fs.String("config", configPath, "Configuration file path")
+	dbPath := fs.String("db", "", "Event database (defaults to database.file from the config)")
+	format := fs.String("format", "stix", "Output format: stix or misp")
+	output := fs.String("output", "-", "Output file ('-' for stdout)")
+	sensor := fs.String("sensor", "", "Sensor name used as STIX identity / MISP source (defaults to honeypot.name)")
+	since := fs.String("since", "24h", "Start of range: RFC3339, YYYY-MM-DD or a duration such as 24h")
+	until := fs.String("until", "", "End of range: RFC3339, YYYY-MM-DD or a duration such as 1h")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var filter logging.EventFilter
+	var err error
+	if filter.Since, err = parseTimeFlag(*since); err != nil {
+		log.Printf("[ERROR] Invalid --since: %v", err)
+		return 2
+	}
+	if filter.Until, err = parseTimeFlag(*until); err != nil {
+		log.Printf("[ERROR] Invalid --until: %v", err)
+		return 2
+	}
+
+	if *dbPath == "" || *sensor == "" {
+		config, err := loadHoneypotConfig(*configFile)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load configuration: %v", err)
+			return 1
+		}
+		if *dbPath == "" {
+			*dbPath = config.Database.File
+		}
+		if *sensor == "" {
+			*sensor = config.Honeypot.Name
+		}
+	}
+
+	db, err := logging.OpenEventStore(*dbPath)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	start := time.Now()
+	collection, err := intel.Collect(db, *sensor, filter)
+	if err != nil {
+		log.Printf("[ERROR] Failed to collect indicators: %v", err)
+		return 1
+	}
+
+	var data []byte
+	switch strings.ToLower(*format) {
+	case "stix":
+		data, err = intel.STIXBundle(collection)
+	case "misp":
+		data, err = intel.MISPEvent(collection)
+	default:
+		log.Printf("[ERROR] Unknown format: %s", *format)
+		return 2
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to build %s output: %v", *format, err)
+		return 1
+	}
+
+	data = append(data, '\n')
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0644)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to write output: %v", err)
+		return 1
+	}
+
+	log.Printf("[INFO] Exported %d indicators in %s", len(collection.Observables()), time.Since(start).Round(time.Millisecond))
+	return 0
+}
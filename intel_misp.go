@@ -0,0 +1,119 @@
+package intel
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// mispAttribute segue o formato de atributo do MISP
+type mispAttribute struct {
+	UUID           string `json:"uuid,omitempty"`
+	Type           string `json:"type"`
+	Category       string `json:"category,omitempty"`
+	Value          string `json:"value"`
+	ToIDS          bool   `json:"to_ids"`
+	ObjectRelation string `json:"object_relation,omitempty"`
+	FirstSeen      string `json:"first_seen,omitempty"`
+	LastSeen       string `json:"last_seen,omitempty"`
+	Comment        string `json:"comment,omitempty"`
+}
+
+// mispObject agrupa atributos relacionados (ex: objeto "credential")
+type mispObject struct {
+	UUID         string          `json:"uuid"`
+	Name         string          `json:"name"`
+	MetaCategory string          `json:"meta-category"`
+	FirstSeen    string          `json:"first_seen,omitempty"`
+	LastSeen     string          `json:"last_seen,omitempty"`
+	Comment      string          `json:"comment,omitempty"`
+	Attribute    []mispAttribute `json:"Attribute"`
+}
+
+type mispTag struct {
+	Name string `json:"name"`
+}
+
+type mispEvent struct {
+	UUID          string          `json:"uuid"`
+	Info          string          `json:"info"`
+	Date          string          `json:"date"`
+	ThreatLevelID string          `json:"threat_level_id"`
+	Analysis      string          `json:"analysis"`
+	Distribution  string          `json:"distribution"`
+	Published     bool            `json:"published"`
+	Tag           []mispTag       `json:"Tag"`
+	Attribute     []mispAttribute `json:"Attribute"`
+	Object        []mispObject    `json:"Object"`
+}
+
+// MISPEvent converte a coleção em um evento MISP pronto para importação
+func MISPEvent(c *Collection) ([]byte, error) {
+	first, last := c.Period()
+	if first.IsZero() {
+		first, last = time.Now(), time.Now()
+	}
+
+	event := mispEvent{
+		UUID:          uuid5(honeypotNamespace, fmt.Sprintf("misp-event|%s|%s|%s", c.Sensor, stixTime(first), stixTime(last))),
+		Info:          fmt.Sprintf("Honeypot %s findings %s - %s", c.Sensor, first.Format("2006-01-02 15:04"), last.Format("2006-01-02 15:04")),
+		Date:          first.Format("2006-01-02"),
+		ThreatLevelID: "3", // Low
+		Analysis:      "2", // Completed
+		Distribution:  "0", // Your organisation only
+		Tag:           []mispTag{{Name: "tlp:amber"}, {Name: "source:honeypot"}},
+		Attribute:     []mispAttribute{},
+		Object:        []mispObject{},
+	}
+
+	for _, o := range c.Observables() {
+		key := o.Kind + "|" + o.Value
+		comment := fmt.Sprintf("Seen %d times by %s", o.Count, c.Sensor)
+
+		if o.Kind == KindCredential {
+			event.Object = append(event.Object, mispObject{
+				UUID:         uuid5(honeypotNamespace, "misp-object|"+key),
+				Name:         "credential",
+				MetaCategory: "misc",
+				FirstSeen:    o.FirstSeen.UTC().Format(time.RFC3339),
+				LastSeen:     o.LastSeen.UTC().Format(time.RFC3339),
+				Comment:      comment,
+				Attribute: []mispAttribute{
+					{Type: "text", ObjectRelation: "username", Value: o.Username},
+					{Type: "text", ObjectRelation: "password", Value: o.Password},
+				},
+			})
+			continue
+		}
+
+		attrType, category := mispType(o.Kind)
+		event.Attribute = append(event.Attribute, mispAttribute{
+			UUID:      uuid5(honeypotNamespace, "misp-attribute|"+key),
+			Type:      attrType,
+			Category:  category,
+			Value:     o.Value,
+			ToIDS:     true,
+			FirstSeen: o.FirstSeen.UTC().Format(time.RFC3339),
+			LastSeen:  o.LastSeen.UTC().Format(time.RFC3339),
+			Comment:   comment,
+		})
+	}
+
+	return json.MarshalIndent(map[string]mispEvent{"Event": event}, "", "  ")
+}
+
+// mispType mapeia o tipo do observável para tipo e categoria do MISP
+func mispType(kind string) (string, string) {
+	switch kind {
+	case KindIP:
+		return "ip-src", "Network activity"
+	case KindC2:
+		return "ip-dst", "Network activity"
+	case KindFile:
+		return "sha256", "Payload delivery"
+	case KindURL:
+		return "url", "Payload delivery"
+	default:
+		return "ssh-fingerprint", "Network activity"
+	}
+}
@@ -0,0 +1,175 @@
+package intel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	// Namespace definido pela especificação STIX 2.1 para IDs determinísticos de SCOs
+	stixSCONamespace = parseUUID("00abedb4-aa42-572c-96a9-a9b1d6c7ad7d")
+	// Namespace do honeypot para indicadores, sightings e demais objetos
+	honeypotNamespace = parseUUID("5b0d8a0e-3f57-5c11-9a34-7e2c6b1f4d90")
+)
+
+// stixObject é um objeto STIX genérico; cada tipo tem campos próprios
+type stixObject map[string]interface{}
+
+// STIXBundle converte a coleção em um bundle STIX 2.1
+func STIXBundle(c *Collection) ([]byte, error) {
+	now := stixTime(time.Now())
+	identityID := "identity--" + uuid5(honeypotNamespace, "identity|"+c.Sensor)
+
+	objects := []stixObject{{
+		"type":           "identity",
+		"spec_version":   "2.1",
+		"id":             identityID,
+		"created":        now,
+		"modified":       now,
+		"name":           c.Sensor,
+		"identity_class": "system",
+		"description":    "Honeypot sensor",
+	}}
+
+	for _, o := range c.Observables() {
+		key := o.Kind + "|" + o.Value
+
+		var observedID string
+		if sco := stixSCO(o); sco != nil {
+			observedID = "observed-data--" + uuid5(honeypotNamespace, "observed-data|"+key)
+			objects = append(objects, sco, stixObject{
+				"type":            "observed-data",
+				"spec_version":    "2.1",
+				"id":              observedID,
+				"created":         now,
+				"modified":        now,
+				"created_by_ref":  identityID,
+				"first_observed":  stixTime(o.FirstSeen),
+				"last_observed":   stixTime(o.LastSeen),
+				"number_observed": o.Count,
+				"object_refs":     []string{sco["id"].(string)},
+			})
+		}
+
+		// Pares de credenciais são observações, não padrões de detecção
+		if o.Kind == KindCredential {
+			continue
+		}
+
+		indicatorID := "indicator--" + uuid5(honeypotNamespace, "indicator|"+key)
+		objects = append(objects, stixObject{
+			"type":            "indicator",
+			"spec_version":    "2.1",
+			"id":              indicatorID,
+			"created":         now,
+			"modified":        now,
+			"created_by_ref":  identityID,
+			"name":            indicatorName(o),
+			"indicator_types": []string{"malicious-activity"},
+			"pattern":         stixPattern(o),
+			"pattern_type":    "stix",
+			"valid_from":      stixTime(o.FirstSeen),
+		})
+
+		sighting := stixObject{
+			"type":               "sighting",
+			"spec_version":       "2.1",
+			"id":                 "sighting--" + uuid5(honeypotNamespace, "sighting|"+key),
+			"created":            now,
+			"modified":           now,
+			"created_by_ref":     identityID,
+			"sighting_of_ref":    indicatorID,
+			"count":              o.Count,
+			"first_seen":         stixTime(o.FirstSeen),
+			"last_seen":          stixTime(o.LastSeen),
+			"where_sighted_refs": []string{identityID},
+		}
+		if observedID != "" {
+			sighting["observed_data_refs"] = []string{observedID}
+		}
+		objects = append(objects, sighting)
+	}
+
+	first, last := c.Period()
+	bundle := stixObject{
+		"type":    "bundle",
+		"id":      "bundle--" + uuid5(honeypotNamespace, fmt.Sprintf("bundle|%s|%s|%s", c.Sensor, stixTime(first), stixTime(last))),
+		"objects": objects,
+	}
+	return json.MarshalIndent(bundle, "", "  ")
+}
+
+// stixSCO monta o Cyber-observable Object do IOC (nil quando não há tipo padrão)
+func stixSCO(o *Observable) stixObject {
+	var sco stixObject
+	var idName string
+
+	switch o.Kind {
+	case KindIP, KindC2:
+		kind := "ipv4-addr"
+		if ip := net.ParseIP(o.Value); ip != nil && ip.To4() == nil {
+			kind = "ipv6-addr"
+		}
+		sco = stixObject{"type": kind, "value": o.Value}
+		idName = fmt.Sprintf(`{"value":%q}`, o.Value)
+	case KindURL:
+		sco = stixObject{"type": "url", "value": o.Value}
+		idName = fmt.Sprintf(`{"value":%q}`, o.Value)
+	case KindFile:
+		sco = stixObject{"type": "file", "hashes": map[string]string{"SHA-256": o.Value}}
+		idName = fmt.Sprintf(`{"hashes":{"SHA-256":%q}}`, o.Value)
+	case KindCredential:
+		sco = stixObject{"type": "user-account", "account_login": o.Username, "credential": o.Password}
+		idName = fmt.Sprintf(`{"account_login":%q,"credential":%q}`, o.Username, o.Password)
+	default:
+		return nil
+	}
+
+	sco["spec_version"] = "2.1"
+	sco["id"] = sco["type"].(string) + "--" + uuid5(stixSCONamespace, idName)
+	return sco
+}
+
+// stixPattern monta o padrão STIX que identifica o IOC
+func stixPattern(o *Observable) string {
+	value := patternEscaper.Replace(o.Value)
+	switch o.Kind {
+	case KindIP, KindC2:
+		if ip := net.ParseIP(o.Value); ip != nil && ip.To4() == nil {
+			return fmt.Sprintf("[ipv6-addr:value = '%s']", value)
+		}
+		return fmt.Sprintf("[ipv4-addr:value = '%s']", value)
+	case KindFile:
+		return fmt.Sprintf("[file:hashes.'SHA-256' = '%s']", value)
+	case KindURL:
+		return fmt.Sprintf("[url:value = '%s']", value)
+	default:
+		// Não existe SCO padrão para chaves SSH; usamos um objeto customizado
+		return fmt.Sprintf("[x-ssh-key:fingerprint = '%s']", value)
+	}
+}
+
+func indicatorName(o *Observable) string {
+	switch o.Kind {
+	case KindIP:
+		return "Honeypot attacker IP " + o.Value
+	case KindC2:
+		return "Address embedded in dropped file " + o.Value
+	case KindFile:
+		return "File dropped on honeypot " + o.Value
+	case KindURL:
+		return "Payload URL " + o.Value
+	default:
+		return "SSH public key planted on honeypot " + o.Value
+	}
+}
+
+var patternEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// stixTime formata datas no padrão exigido pelo STIX (UTC, milissegundos)
+func stixTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
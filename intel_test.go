@@ -0,0 +1,43 @@
+package intel
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+func TestUDPSourceIsNotAnIndicator(t *testing.T) {
+	now := time.Now().Format(logging.TimestampLayout)
+	c := NewCollection("sensor-1")
+	c.Add(logging.LogEntry{Timestamp: now, IP: "192.0.2.10:53", Type: logging.EventUDPPacket, Protocol: "dns", Password: "public"})
+	c.Add(logging.LogEntry{Timestamp: now, IP: "198.51.100.20:40022", Type: logging.EventFailedLogin, Protocol: "ssh", Username: "root", Password: "123456"})
+
+	var ips []string
+	for _, o := range c.Observables() {
+		if o.Kind == KindIP {
+			ips = append(ips, o.Value)
+		}
+	}
+	if len(ips) != 1 || ips[0] != "198.51.100.20" {
+		t.Fatalf("IPs observados = %v, esperado só o do login SSH", ips)
+	}
+
+	stix, err := STIXBundle(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	misp, err := MISPEvent(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, export := range map[string][]byte{"STIX": stix, "MISP": misp} {
+		if bytes.Contains(export, []byte("192.0.2.10")) {
+			t.Errorf("exportação %s contém a origem do pacote UDP:\n%s", name, export)
+		}
+		if !bytes.Contains(export, []byte("198.51.100.20")) {
+			t.Errorf("exportação %s sem o IP do login SSH", name)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // Driver SQLite
@@ -21,18 +22,72 @@ const (
 	CRITICAL LogLevel = "CRITICAL"
 )
 
+// Tipos de evento registrados pelos serviços
+const (
+	EventConnection          = "CONNECTION"
+	EventFailedLogin         = "FAILED_LOGIN"
+	EventSuccessfulLogin     = "SUCCESSFUL_LOGIN"
+	EventCommand             = "COMMAND_EXECUTED"
+	EventSuspiciousCommand   = "SUSPICIOUS_COMMAND"
+	EventDownload            = "FILE_DOWNLOAD"
+	EventRateLimited         = "RATE_LIMITED"
+	EventBruteForce          = "BRUTE_FORCE"
+	EventTarpit              = "TARPIT"
+	EventFirstPayload        = "FIRST_PAYLOAD"
+	EventUDPPacket           = "UDP_PACKET"
+	EventHTTPRequest         = "HTTP_REQUEST"
+	EventExploitAttempt      = "EXPLOIT_ATTEMPT"
+	EventProxyRequest        = "PROXY_REQUEST"
+	EventPrivilegeEscalation = "PRIVILEGE_ESCALATION"
+	EventPersistence         = "PERSISTENCE"
+	EventScript              = "SCRIPT_EXECUTED"
+	EventBinary              = "BINARY_EXECUTED"
+)
+
+// TimestampLayout é o formato do campo Timestamp e de todas as datas gravadas no banco de eventos
+const TimestampLayout = "2006-01-02 15:04:05"
+
 // LogEntry representa um evento de log
 type LogEntry struct {
 	Timestamp string   `json:"timestamp"`
 	IP        string   `json:"ip"`
 	Event     string   `json:"event"`
 	Level     LogLevel `json:"level"`
+	Type      string   `json:"type,omitempty"`     // Tipo do evento (ex: FAILED_LOGIN)
+	Protocol  string   `json:"protocol,omitempty"` // Serviço que gerou o evento (ssh, telnet, ftp)
+	Port      int      `json:"port,omitempty"`     // Porta local do serviço
+	Session   string   `json:"session,omitempty"`
+	Username  string   `json:"username,omitempty"`
+	Password  string   `json:"password,omitempty"`
+	Command   string   `json:"command,omitempty"`
+	URL       string   `json:"url,omitempty"`     // URL de download
+	SHA256    string   `json:"sha256,omitempty"`  // Hash do arquivo baixado
+	SSHKey    string   `json:"ssh_key,omitempty"` // Fingerprint SHA256 da chave SSH gravada em authorized_keys (PERSISTENCE)
+	Payload   string   `json:"payload,omitempty"` // Primeiros bytes enviados pelo cliente (hex)
+	UserAgent string   `json:"user_agent,omitempty"`
+	Request   string   `json:"request,omitempty"`   // Requisição HTTP completa (linha, headers e corpo)
+	Mechanism string   `json:"mechanism,omitempty"` // Persistência instalada: cron, systemd, ssh_key, shell_rc, init, preload
+	Path      string   `json:"path,omitempty"`      // Arquivo alterado no shell falso
+	Content   string   `json:"content,omitempty"`   // Conteúdo exato instalado (linha do crontab, unit, chave pública)
+	FileType  string   `json:"file_type,omitempty"` // Tipo do executável no formato do file(1)
+	Arch      string   `json:"arch,omitempty"`      // Arquitetura do executável (x86_64, mips, armv7l...)
+	Packer    string   `json:"packer,omitempty"`    // Compactador detectado (UPX)
+	IOCs      string   `json:"iocs,omitempty"`      // URLs e IPs embutidos no executável, separados por vírgula
+	Strings   string   `json:"strings,omitempty"`   // Strings legíveis do executável, uma por linha
+}
+
+// Sink recebe uma cópia de cada evento registrado (syslog, alertas, etc.)
+type Sink interface {
+	Send(entry LogEntry) error
+	Close() error
 }
 
 // Logger gerencia logs no sistema
 type Logger struct {
 	logFile *os.File
 	db      *sql.DB
+	sinks   []Sink
+	sinksMu sync.RWMutex
 }
 
 // NewLogger cria um novo logger
@@ -44,9 +99,9 @@ func NewLogger(logPath, dbPath string) (*Logger, error) {
 	}
 
 	// Conectar ao banco de dados SQLite
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := OpenDB(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %v", err)
+		return nil, err
 	}
 
 	// Criar tabela de logs se não existir
@@ -63,19 +118,74 @@ func NewLogger(logPath, dbPath string) (*Logger, error) {
 		return nil, fmt.Errorf("erro ao criar tabela de logs: %v", err)
 	}
 
+	if err := migrateLogsTable(db); err != nil {
+		return nil, err
+	}
+
 	return &Logger{logFile: file, db: db}, nil
 }
 
+// migrateLogsTable adiciona as colunas de eventos estruturados em bancos antigos
+func migrateLogsTable(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(logs)")
+	if err != nil {
+		return fmt.Errorf("erro ao ler estrutura da tabela de logs: %v", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("erro ao ler estrutura da tabela de logs: %v", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, column := range []string{"type", "protocol", "session", "data"} {
+		if existing[column] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE logs ADD COLUMN " + column + " TEXT"); err != nil {
+			return fmt.Errorf("erro ao adicionar coluna %s: %v", column, err)
+		}
+	}
+
+	for _, index := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_logs_ip ON logs(ip)",
+		"CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON logs(timestamp)",
+		"CREATE INDEX IF NOT EXISTS idx_logs_type ON logs(type)",
+		"CREATE INDEX IF NOT EXISTS idx_logs_session ON logs(session)",
+	} {
+		if _, err := db.Exec(index); err != nil {
+			return fmt.Errorf("erro ao criar índice: %v", err)
+		}
+	}
+	return nil
+}
+
+// AddSink registra um destino adicional para os eventos
+func (l *Logger) AddSink(sink Sink) {
+	l.sinksMu.Lock()
+	defer l.sinksMu.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
 // Log registra eventos com nível de severidade
 func (l *Logger) Log(ip, event string, level LogLevel) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-
-	// Criar entrada de log
-	entry := LogEntry{
-		Timestamp: timestamp,
-		IP:        ip,
-		Event:     event,
-		Level:     level,
+	l.Record(LogEntry{
+		IP:    ip,
+		Event: event,
+		Level: level,
+	})
+}
+
+// Record registra um evento estruturado e o repassa para os sinks
+func (l *Logger) Record(entry LogEntry) {
+	if entry.Timestamp == "" {
+		entry.Timestamp = time.Now().Format(TimestampLayout)
 	}
 
 	// Transformar em JSON para logs estruturados
@@ -87,25 +197,63 @@ func (l *Logger) Log(ip, event string, level LogLevel) {
 	// Escrever no arquivo
 	_, _ = l.logFile.WriteString(string(jsonLog) + "\n")
 
-	// Inserir no banco de dados
-	_, _ = l.db.Exec("INSERT INTO logs (timestamp, ip, event, level) VALUES (?, ?, ?, ?)", timestamp, ip, event, level)
+	// Inserir no banco de dados (data guarda o evento completo para exportação)
+	_, _ = l.db.Exec("INSERT INTO logs (timestamp, ip, event, level, type, protocol, session, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.Timestamp, entry.IP, entry.Event, entry.Level, entry.Type, entry.Protocol, entry.Session, string(jsonLog))
+
+	// Repassar para os sinks (syslog, SIEM, ...)
+	l.sinksMu.RLock()
+	defer l.sinksMu.RUnlock()
+	for _, sink := range l.sinks {
+		if err := sink.Send(entry); err != nil {
+			log.Printf("Erro ao enviar evento para o sink: %v", err)
+		}
+	}
 }
 
-// Close fecha os recursos do logger
-func (l *Logger) Close() {
-	l.logFile.Close()
-	l.db.Close()
+// QueueDepth soma os eventos aguardando envio nos sinks
+func (l *Logger) QueueDepth() int {
+	l.sinksMu.RLock()
+	defer l.sinksMu.RUnlock()
+
+	depth := 0
+	for _, sink := range l.sinks {
+		if q, ok := sink.(interface{ QueueDepth() int }); ok {
+			depth += q.QueueDepth()
+		}
+	}
+	return depth
 }
 
-// BanIP adiciona um IP à lista de banidos
-func (l *Logger) BanIP(ip string) {
-	_, _ = l.db.Exec("INSERT INTO banned_ips (ip) VALUES (?)", ip)
-	log.Printf("⚠️ IP BANIDO: %s\n", ip)
+// Dropped soma os eventos descartados pelos sinks
+func (l *Logger) Dropped() uint64 {
+	l.sinksMu.RLock()
+	defer l.sinksMu.RUnlock()
+
+	var dropped uint64
+	for _, sink := range l.sinks {
+		if d, ok := sink.(interface{ Dropped() uint64 }); ok {
+			dropped += d.Dropped()
+		}
+	}
+	return dropped
+}
+
+// DB é a conexão com o banco de eventos, compartilhada com os registros gravados nele
+// (credenciais, banimentos, estados do shell); é fechada pelo Close do logger
+func (l *Logger) DB() *sql.DB {
+	return l.db
 }
 
-// IsIPBanned verifica se um IP está banido
-func (l *Logger) IsIPBanned(ip string) bool {
-	var count int
-	_ = l.db.QueryRow("SELECT COUNT(*) FROM banned_ips WHERE ip = ?", ip).Scan(&count)
-	return count > 0
+// Close fecha os recursos do logger
+func (l *Logger) Close() {
+	l.sinksMu.Lock()
+	for _, sink := range l.sinks {
+		sink.Close()
+	}
+	l.sinks = nil
+	l.sinksMu.Unlock()
+
+	l.logFile.Close()
+	l.db.Close()
 }
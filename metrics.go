@@ -0,0 +1,343 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collector é qualquer métrica capaz de se escrever no formato texto do Prometheus
+type collector interface {
+	metricName() string
+	write(w io.Writer)
+}
+
+// Registry guarda as métricas expostas pelo endpoint
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// DefaultRegistry é usado pelos construtores do pacote
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry cria um registro vazio
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// register adiciona a métrica, substituindo outra de mesmo nome
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors[c.metricName()] = c
+}
+
+// Write escreve todas as métricas em ordem alfabética
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]collector, 0, len(names))
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler expõe as métricas do DefaultRegistry
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		DefaultRegistry.Write(w)
+	})
+}
+
+// Serve inicia o endpoint HTTP com /metrics e /healthz
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("[INFO] Metrics endpoint listening on %s", addr)
+	return server.ListenAndServe()
+}
+
+// series guarda os valores de labels de uma série
+type series struct {
+	labels []string
+	value  float64
+}
+
+// vec é a base comum de contadores e gauges com labels
+type vec struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	mu         sync.Mutex
+	series     map[string]*series
+}
+
+func newVec(name, help, kind string, labelNames []string) *vec {
+	return &vec{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		series:     make(map[string]*series),
+	}
+}
+
+func (v *vec) metricName() string { return v.name }
+
+// get devolve a série das labels, criando-a se necessário (chamar com mu travado)
+func (v *vec) get(labelValues []string) *series {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s espera %d labels, recebeu %d", v.name, len(v.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, exists := v.series[key]
+	if !exists {
+		s = &series{labels: append([]string(nil), labelValues...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *vec) add(delta float64, labelValues []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.get(labelValues).value += delta
+}
+
+func (v *vec) set(value float64, labelValues []string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.get(labelValues).value = value
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	writeHeader(w, v.name, v.help, v.kind)
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labelNames, s.labels, "", ""), formatValue(s.value))
+	}
+}
+
+// CounterVec é um contador monotônico com labels
+type CounterVec struct{ *vec }
+
+// NewCounterVec cria e registra um contador
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labelNames)}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// Inc soma 1 à série das labels
+func (c *CounterVec) Inc(labelValues ...string) { c.add(1, labelValues) }
+
+// Add soma um valor positivo à série das labels
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.add(delta, labelValues)
+}
+
+// GaugeVec é um valor que sobe e desce, com labels
+type GaugeVec struct{ *vec }
+
+// NewGaugeVec cria e registra um gauge
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, "gauge", labelNames)}
+	DefaultRegistry.register(g)
+	return g
+}
+
+// Set define o valor da série
+func (g *GaugeVec) Set(value float64, labelValues ...string) { g.set(value, labelValues) }
+
+// Inc soma 1 à série
+func (g *GaugeVec) Inc(labelValues ...string) { g.add(1, labelValues) }
+
+// Dec subtrai 1 da série
+func (g *GaugeVec) Dec(labelValues ...string) { g.add(-1, labelValues) }
+
+// funcMetric lê o valor de uma função a cada coleta
+type funcMetric struct {
+	name string
+	help string
+	kind string
+	fn   func() float64
+}
+
+func (f *funcMetric) metricName() string { return f.name }
+
+func (f *funcMetric) write(w io.Writer) {
+	writeHeader(w, f.name, f.help, f.kind)
+	fmt.Fprintf(w, "%s %s\n", f.name, formatValue(f.fn()))
+}
+
+// NewGaugeFunc registra um gauge calculado no momento da coleta
+func NewGaugeFunc(name, help string, fn func() float64) {
+	DefaultRegistry.register(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc registra um contador lido de outro componente
+func NewCounterFunc(name, help string, fn func() float64) {
+	DefaultRegistry.register(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+// histogramSeries acumula as observações de uma combinação de labels
+type histogramSeries struct {
+	labels []string
+	counts []uint64 // Uma posição por bucket (não cumulativo)
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec distribui observações em buckets, com labels
+type HistogramVec struct {
+	name       string
+	help       string
+	buckets    []float64
+	labelNames []string
+	mu         sync.Mutex
+	series     map[string]*histogramSeries
+}
+
+// NewHistogramVec cria e registra um histograma
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		name:       name,
+		help:       help,
+		buckets:    sorted,
+		labelNames: labelNames,
+		series:     make(map[string]*histogramSeries),
+	}
+	DefaultRegistry.register(h)
+	return h
+}
+
+func (h *HistogramVec) metricName() string { return h.name }
+
+// Observe registra um valor na série das labels
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labelNames) {
+		panic(fmt.Sprintf("metrics: %s espera %d labels, recebeu %d", h.name, len(h.labelNames), len(labelValues)))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := strings.Join(labelValues, "\xff")
+	s, exists := h.series[key]
+	if !exists {
+		s = &histogramSeries{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if value <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labelNames, s.labels, "le", formatValue(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labelNames, s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labelNames, s.labels, "", ""), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labelNames, s.labels, "", ""), s.count)
+	}
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, helpEscaper.Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// formatLabels monta {a="x",b="y"}, com uma label extra opcional (usada pelo "le")
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(values[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
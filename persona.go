@@ -0,0 +1,308 @@
+package persona
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile é o perfil embutido usado quando persona.profile não é informado
+const DefaultProfile = "ubuntu-22.04"
+
+// Config escolhe o perfil do sistema simulado
+type Config struct {
+	Profile string `yaml:"profile"` // Perfil embutido (ubuntu-22.04, debian-11, centos-7, raspbian-10)
+	File    string `yaml:"file"`    // YAML com um perfil próprio; campos ausentes vêm de Profile
+}
+
+// Profile descreve o sistema que todos os emuladores fingem ser
+type Profile struct {
+	Name       string        `yaml:"name"`
+	Hostname   string        `yaml:"hostname"`
+	Uptime     time.Duration `yaml:"uptime"` // Tempo ligado no momento em que o honeypot inicia
+	OS         OS            `yaml:"os"`
+	Kernel     Kernel        `yaml:"kernel"`
+	CPU        CPU           `yaml:"cpu"`
+	Memory     Memory        `yaml:"memory"`
+	Disks      []Disk        `yaml:"disks"`
+	Users      []User        `yaml:"users"`
+	Groups     []Group       `yaml:"groups"`
+	Interfaces []Interface   `yaml:"interfaces"`
+	Packages   []Package     `yaml:"packages"`
+	Processes  []Process     `yaml:"processes"`
+	Banners    Banners       `yaml:"banners"`
+}
+
+// OS são os campos de /etc/os-release
+type OS struct {
+	ID             string `yaml:"id"` // ubuntu, debian, centos, raspbian
+	IDLike         string `yaml:"id_like"`
+	Name           string `yaml:"name"`        // Ubuntu
+	Version        string `yaml:"version"`     // 22.04.3 LTS (Jammy Jellyfish)
+	VersionID      string `yaml:"version_id"`  // 22.04
+	Codename       string `yaml:"codename"`    // jammy
+	PrettyName     string `yaml:"pretty_name"` // Ubuntu 22.04.3 LTS
+	HomeURL        string `yaml:"home_url"`
+	PackageManager string `yaml:"package_manager"` // dpkg ou rpm
+}
+
+// Kernel é o que uname e /proc/version mostram
+type Kernel struct {
+	Release  string `yaml:"release"`  // 5.15.0-84-generic
+	Version  string `yaml:"version"`  // #93-Ubuntu SMP Tue Sep 5 17:16:10 UTC 2023
+	Machine  string `yaml:"machine"`  // x86_64, aarch64, armv7l, mips
+	Compiler string `yaml:"compiler"` // Trecho "(buildd@...) (gcc ...)" de /proc/version
+}
+
+// CPU alimenta lscpu, nproc e /proc/cpuinfo
+type CPU struct {
+	Vendor   string  `yaml:"vendor"` // GenuineIntel, AuthenticAMD ou ARM
+	Model    string  `yaml:"model"`
+	Family   int     `yaml:"family"`
+	ModelID  int     `yaml:"model_id"`
+	Stepping int     `yaml:"stepping"`
+	Cores    int     `yaml:"cores"`
+	MHz      float64 `yaml:"mhz"`
+	CacheKB  int     `yaml:"cache_kb"`
+	BogoMIPS float64 `yaml:"bogomips"`
+	Flags    string  `yaml:"flags"`
+}
+
+// Memory alimenta free e /proc/meminfo
+type Memory struct {
+	TotalMB int `yaml:"total_mb"`
+	UsedMB  int `yaml:"used_mb"`
+	SwapMB  int `yaml:"swap_mb"`
+}
+
+// Disk é uma linha do df
+type Disk struct {
+	Device string  `yaml:"device"`
+	Type   string  `yaml:"type"`
+	Mount  string  `yaml:"mount"`
+	SizeGB float64 `yaml:"size_gb"`
+	UsedGB float64 `yaml:"used_gb"`
+}
+
+// User é uma linha de /etc/passwd (e /etc/shadow)
+type User struct {
+	Name     string `yaml:"name"`
+	UID      int    `yaml:"uid"`
+	GID      int    `yaml:"gid"`
+	Gecos    string `yaml:"gecos"`
+	Home     string `yaml:"home"`
+	Shell    string `yaml:"shell"`
+	Password string `yaml:"password"` // Hash de /etc/shadow; vazio vira "*"
+}
+
+// Group é uma linha de /etc/group
+type Group struct {
+	Name    string   `yaml:"name"`
+	GID     int      `yaml:"gid"`
+	Members []string `yaml:"members"`
+}
+
+// Interface é uma placa de rede de ip addr e ifconfig
+type Interface struct {
+	Name     string `yaml:"name"`
+	MAC      string `yaml:"mac"`
+	Address  string `yaml:"address"`  // IPv4 em CIDR
+	Address6 string `yaml:"address6"` // IPv6 em CIDR
+	MTU      int    `yaml:"mtu"`
+}
+
+// Package é um pacote instalado (dpkg -l ou rpm -qa)
+type Package struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Arch        string `yaml:"arch"`
+	Description string `yaml:"description"`
+}
+
+// Process é um processo do sistema mostrado pelo ps
+type Process struct {
+	PID     int       `yaml:"pid"`
+	PPID    int       `yaml:"ppid"` // Vazio vale 1 (filho do init)
+	User    string    `yaml:"user"`
+	CPU     float64   `yaml:"cpu"`
+	Mem     float64   `yaml:"mem"`
+	VSZ     int       `yaml:"vsz"`
+	RSS     int       `yaml:"rss"`
+	TTY     string    `yaml:"tty"`
+	Stat    string    `yaml:"stat"`
+	Time    string    `yaml:"time"`
+	Start   time.Time `yaml:"-"` // Só para processos da sessão; os do perfil começam no boot
+	Command string    `yaml:"command"`
+	Listen  []string  `yaml:"listen"` // Sockets do netstat/ss, ex.: "tcp 0.0.0.0:22", "udp 127.0.0.53:53"
+}
+
+// Banners são as identificações dos serviços de rede
+type Banners struct {
+	SSH     string `yaml:"ssh"`      // Versão do servidor SSH, sem \r\n
+	Telnet  string `yaml:"telnet"`   // /etc/issue.net mostrado antes do login
+	MOTD    string `yaml:"motd"`     // Mensagem depois do login
+	FTP     string `yaml:"ftp"`      // Resposta 220 do FTP
+	FTPSyst string `yaml:"ftp_syst"` // Resposta ao SYST
+	HTTP    string `yaml:"http"`     // Cabeçalho Server do servidor web do sistema
+}
+
+// Builtins lista os perfis embutidos
+func Builtins() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Builtin carrega um perfil embutido
+func Builtin(name string) (*Profile, error) {
+	source, exists := builtinProfiles[name]
+	if !exists {
+		return nil, fmt.Errorf("perfil desconhecido: %s (disponíveis: %v)", name, Builtins())
+	}
+	var profile Profile
+	if err := yaml.Unmarshal([]byte(source), &profile); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar o perfil %s: %v", name, err)
+	}
+	profile.Name = name
+	return &profile, nil
+}
+
+// Load carrega o perfil embutido e aplica o arquivo do operador por cima dele
+func Load(config Config) (*Profile, error) {
+	if config.Profile == "" {
+		config.Profile = DefaultProfile
+	}
+	profile, err := Builtin(config.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if config.File == "" {
+		return profile, nil
+	}
+
+	data, err := os.ReadFile(config.File)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler %s: %v", config.File, err)
+	}
+	// Listas presentes no arquivo substituem as do perfil base; as ausentes são mantidas
+	if err := yaml.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar %s: %v", config.File, err)
+	}
+	if profile.Hostname == "" {
+		return nil, fmt.Errorf("perfil %s sem hostname", config.File)
+	}
+	return profile, nil
+}
+
+// BootTime é o instante de boot simulado: o início do honeypot menos Uptime
+func (p *Profile) BootTime() time.Time {
+	return startTime.Add(-p.Uptime)
+}
+
+// User procura um usuário de /etc/passwd
+func (p *Profile) User(name string) (User, bool) {
+	for _, user := range p.Users {
+		if user.Name == name {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+// Account retorna o usuário do perfil ou um usuário comum criado para o login aceito
+func (p *Profile) Account(name string) User {
+	if user, exists := p.User(name); exists {
+		return user
+	}
+	return User{Name: name, UID: 1001, GID: 1001, Home: "/home/" + name, Shell: "/bin/bash"}
+}
+
+// GroupsOf retorna o grupo primário e os suplementares do usuário, como o id
+func (p *Profile) GroupsOf(user User) []Group {
+	primary := Group{Name: user.Name, GID: user.GID}
+	for _, group := range p.Groups {
+		if group.GID == user.GID {
+			primary = group
+		}
+	}
+	groups := []Group{primary}
+	for _, group := range p.Groups {
+		if group.GID == user.GID {
+			continue
+		}
+		for _, member := range group.Members {
+			if member == user.Name {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return groups
+}
+
+// WithAccounts retorna uma cópia do perfil com usuários e membros de grupo extras, como os
+// criados pelo atacante com useradd e usermod
+func (p *Profile) WithAccounts(users []User, members map[string][]string) *Profile {
+	if len(users) == 0 && len(members) == 0 {
+		return p
+	}
+	profile := *p
+	profile.Users = append(append([]User(nil), p.Users...), users...)
+	profile.Groups = make([]Group, 0, len(p.Groups)+len(users))
+	for _, group := range p.Groups {
+		group.Members = append(append([]string(nil), group.Members...), members[group.Name]...)
+		profile.Groups = append(profile.Groups, group)
+	}
+	for _, user := range users {
+		if _, exists := profile.group(user.GID); !exists {
+			profile.Groups = append(profile.Groups, Group{Name: user.Name, GID: user.GID, Members: members[user.Name]})
+		}
+	}
+	return &profile
+}
+
+func (p *Profile) group(gid int) (Group, bool) {
+	for _, group := range p.Groups {
+		if group.GID == gid {
+			return group, true
+		}
+	}
+	return Group{}, false
+}
+
+var (
+	startTime = time.Now()
+
+	current   *Profile
+	currentMu sync.RWMutex
+)
+
+// SetDefault define o perfil lido pelos emuladores
+func SetDefault(profile *Profile) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = profile
+}
+
+// Current retorna o perfil ativo; antes de SetDefault vale o perfil padrão
+func Current() *Profile {
+	currentMu.RLock()
+	profile := current
+	currentMu.RUnlock()
+	if profile != nil {
+		return profile
+	}
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if current == nil {
+		current, _ = Builtin(DefaultProfile)
+	}
+	return current
+}
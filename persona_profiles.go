@@ -0,0 +1,441 @@
+package persona
+
+// builtinProfiles são os perfis embutidos, no mesmo formato YAML aceito em persona.file
+var builtinProfiles = map[string]string{
+	"ubuntu-22.04": `
+hostname: server01
+uptime: 1015h
+os:
+  id: ubuntu
+  id_like: debian
+  name: Ubuntu
+  version: "22.04.3 LTS (Jammy Jellyfish)"
+  version_id: "22.04"
+  codename: jammy
+  pretty_name: "Ubuntu 22.04.3 LTS"
+  home_url: "https://www.ubuntu.com/"
+  package_manager: dpkg
+kernel:
+  release: 5.15.0-84-generic
+  version: "#93-Ubuntu SMP Tue Sep 5 17:16:10 UTC 2023"
+  machine: x86_64
+  compiler: "(buildd@lcy02-amd64-045) (x86_64-linux-gnu-gcc-11 (Ubuntu 11.4.0-1ubuntu1~22.04) 11.4.0, GNU ld (GNU Binutils for Ubuntu) 2.38)"
+cpu:
+  vendor: GenuineIntel
+  model: "Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz"
+  family: 6
+  model_id: 79
+  stepping: 1
+  cores: 4
+  mhz: 2399.998
+  cache_kb: 35840
+  bogomips: 4799.99
+  flags: "fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush mmx fxsr sse sse2 ss syscall nx pdpe1gb rdtscp lm constant_tsc rep_good nopl xtopology cpuid tsc_known_freq pni pclmulqdq ssse3 fma cx16 pcid sse4_1 sse4_2 x2apic movbe popcnt tsc_deadline_timer aes xsave avx f16c rdrand hypervisor lahf_lm abm 3dnowprefetch invpcid_single pti fsgsbase bmi1 hle avx2 smep bmi2 erms invpcid rtm rdseed adx smap xsaveopt arat"
+memory: {total_mb: 7953, used_mb: 2314, swap_mb: 2047}
+disks:
+  - {device: /dev/sda1, type: ext4, mount: /, size_gb: 78.6, used_gb: 23.4}
+  - {device: /dev/sda15, type: vfat, mount: /boot/efi, size_gb: 0.1, used_gb: 0.01}
+users:
+  - {name: root, uid: 0, gid: 0, gecos: root, home: /root, shell: /bin/bash, password: "$6$xf1H0Oo2yEnAYK8k$WtAu0n6/r2Q88.WbIxQ57HeuASL8crt0F/0Ro9uzBTTv.oMlWnJ.aJM9g8/XQTk.3bsP6ZNnJMiHBTtC89Jpp."}
+  - {name: daemon, uid: 1, gid: 1, gecos: daemon, home: /usr/sbin, shell: /usr/sbin/nologin}
+  - {name: bin, uid: 2, gid: 2, gecos: bin, home: /bin, shell: /usr/sbin/nologin}
+  - {name: sys, uid: 3, gid: 3, gecos: sys, home: /dev, shell: /usr/sbin/nologin}
+  - {name: sync, uid: 4, gid: 65534, gecos: sync, home: /bin, shell: /bin/sync}
+  - {name: man, uid: 6, gid: 12, gecos: man, home: /var/cache/man, shell: /usr/sbin/nologin}
+  - {name: mail, uid: 8, gid: 8, gecos: mail, home: /var/mail, shell: /usr/sbin/nologin}
+  - {name: www-data, uid: 33, gid: 33, gecos: www-data, home: /var/www, shell: /usr/sbin/nologin}
+  - {name: backup, uid: 34, gid: 34, gecos: backup, home: /var/backups, shell: /usr/sbin/nologin}
+  - {name: nobody, uid: 65534, gid: 65534, gecos: nobody, home: /nonexistent, shell: /usr/sbin/nologin}
+  - {name: systemd-network, uid: 100, gid: 102, gecos: "systemd Network Management,,,", home: /run/systemd, shell: /usr/sbin/nologin}
+  - {name: systemd-resolve, uid: 101, gid: 103, gecos: "systemd Resolver,,,", home: /run/systemd, shell: /usr/sbin/nologin}
+  - {name: messagebus, uid: 102, gid: 105, home: /nonexistent, shell: /usr/sbin/nologin}
+  - {name: syslog, uid: 104, gid: 111, home: /home/syslog, shell: /usr/sbin/nologin}
+  - {name: sshd, uid: 105, gid: 65534, home: /run/sshd, shell: /usr/sbin/nologin}
+  - {name: mysql, uid: 110, gid: 118, gecos: "MySQL Server,,,", home: /nonexistent, shell: /bin/false}
+  - {name: ftp, uid: 111, gid: 119, gecos: "ftp daemon,,,", home: /srv/ftp, shell: /usr/sbin/nologin}
+  - {name: admin, uid: 1000, gid: 1000, gecos: "Admin,,,", home: /home/admin, shell: /bin/bash, password: "$6$jqvAnQwXKaJtOWw4$.XpVOQhrcsNFnuZswSIiz/I4GMoBzE3ZF4PEzhgqV9umrebvqc94MyyFSzpH4uka7fCW8I.D4sut26CGsdqxG1"}
+groups:
+  - {name: root, gid: 0}
+  - {name: daemon, gid: 1}
+  - {name: bin, gid: 2}
+  - {name: sys, gid: 3}
+  - {name: adm, gid: 4, members: [syslog, admin]}
+  - {name: mail, gid: 8}
+  - {name: man, gid: 12}
+  - {name: sudo, gid: 27, members: [admin]}
+  - {name: www-data, gid: 33}
+  - {name: backup, gid: 34}
+  - {name: systemd-network, gid: 102}
+  - {name: systemd-resolve, gid: 103}
+  - {name: messagebus, gid: 105}
+  - {name: syslog, gid: 111}
+  - {name: mysql, gid: 118}
+  - {name: ftp, gid: 119}
+  - {name: admin, gid: 1000}
+  - {name: nogroup, gid: 65534}
+interfaces:
+  - {name: eth0, mac: "52:54:00:4b:8e:1a", address: 10.0.2.15/24, address6: "fe80::5054:ff:fe4b:8e1a/64"}
+packages:
+  - {name: apache2, version: 2.4.52-1ubuntu4.6, arch: amd64, description: Apache HTTP Server}
+  - {name: bash, version: 5.1-6ubuntu1, arch: amd64, description: GNU Bourne Again SHell}
+  - {name: coreutils, version: 8.32-4.1ubuntu1, arch: amd64, description: GNU core utilities}
+  - {name: cron, version: 3.0pl1-137ubuntu3, arch: amd64, description: process scheduling daemon}
+  - {name: curl, version: 7.81.0-1ubuntu1.13, arch: amd64, description: command line tool for transferring data with URL syntax}
+  - {name: mysql-server-8.0, version: 8.0.34-0ubuntu0.22.04.1, arch: amd64, description: MySQL database server binaries and system database setup}
+  - {name: openssh-server, version: "1:8.9p1-3ubuntu0.4", arch: amd64, description: "secure shell (SSH) server, for secure access from remote machines"}
+  - {name: perl, version: 5.34.0-3ubuntu1.3, arch: amd64, description: Larry Wall's Practical Extraction and Report Language}
+  - {name: php8.1, version: 8.1.2-1ubuntu2.14, arch: all, description: "server-side, HTML-embedded scripting language (metapackage)"}
+  - {name: python3, version: 3.10.6-1~22.04, arch: amd64, description: interactive high-level object-oriented language (default python3 version)}
+  - {name: sudo, version: 1.9.9-1ubuntu2.4, arch: amd64, description: Provide limited super user privileges to specific users}
+  - {name: systemd, version: 249.11-0ubuntu3.10, arch: amd64, description: system and service manager}
+  - {name: vsftpd, version: 3.0.5-0ubuntu1, arch: amd64, description: "lightweight, efficient FTP server written for security"}
+  - {name: wget, version: 1.21.2-2ubuntu1, arch: amd64, description: retrieves files from the web}
+processes:
+  - {pid: 1, user: root, cpu: 0.0, mem: 0.1, vsz: 167744, rss: 11372, tty: "?", stat: Ss, time: "1:47", command: "/sbin/init"}
+  - {pid: 2, user: root, tty: "?", stat: S, time: "0:00", command: "[kthreadd]"}
+  - {pid: 392, user: root, mem: 0.2, vsz: 64248, rss: 20512, tty: "?", stat: S<s, time: "0:38", command: "/lib/systemd/systemd-journald"}
+  - {pid: 431, user: root, vsz: 25532, rss: 6284, tty: "?", stat: Ss, time: "0:02", command: "/lib/systemd/systemd-udevd"}
+  - {pid: 612, user: systemd+, vsz: 16120, rss: 8008, tty: "?", stat: Ss, time: "0:03", command: "/lib/systemd/systemd-networkd"}
+  - {pid: 614, user: systemd+, vsz: 25536, rss: 12628, tty: "?", stat: Ss, time: "0:05", command: "/lib/systemd/systemd-resolved", listen: ["tcp 127.0.0.53:53", "udp 127.0.0.53:53"]}
+  - {pid: 688, user: root, vsz: 9492, rss: 2836, tty: "?", stat: Ss, time: "0:04", command: "/usr/sbin/cron -f -P"}
+  - {pid: 689, user: message+, vsz: 8792, rss: 4940, tty: "?", stat: Ss, time: "0:01", command: "@dbus-daemon --system --address=systemd: --nofork --nopidfile --systemd-activation --syslog-only"}
+  - {pid: 701, user: syslog, vsz: 222404, rss: 5628, tty: "?", stat: Ssl, time: "0:09", command: "/usr/sbin/rsyslogd -n -iNONE"}
+  - {pid: 744, user: root, vsz: 15432, rss: 9096, tty: "?", stat: Ss, time: "0:00", command: "sshd: /usr/sbin/sshd -D [listener] 0 of 10-100 startups", listen: ["tcp 0.0.0.0:22", "tcp6 :::22"]}
+  - {pid: 751, user: root, vsz: 6172, rss: 1092, tty: tty1, stat: Ss+, time: "0:00", command: "/sbin/agetty -o -p -- \\u --noclear tty1 linux"}
+  - {pid: 802, user: root, vsz: 7568, rss: 3040, tty: "?", stat: Ss, time: "0:00", command: "/usr/sbin/vsftpd /etc/vsftpd.conf", listen: ["tcp6 :::21"]}
+  - {pid: 860, user: mysql, cpu: 0.4, mem: 5.2, vsz: 2414856, rss: 421728, tty: "?", stat: Ssl, time: "271:12", command: "/usr/sbin/mysqld", listen: ["tcp 127.0.0.1:3306", "tcp 127.0.0.1:33060"]}
+  - {pid: 1012, user: root, mem: 0.3, vsz: 201560, rss: 24876, tty: "?", stat: Ss, time: "0:58", command: "/usr/sbin/apache2 -k start", listen: ["tcp6 :::80"]}
+  - {pid: 1015, user: www-data, mem: 0.2, vsz: 202112, rss: 17720, tty: "?", stat: S, time: "0:00", command: "/usr/sbin/apache2 -k start", ppid: 1012}
+  - {pid: 1016, user: www-data, mem: 0.2, vsz: 202112, rss: 17720, tty: "?", stat: S, time: "0:00", command: "/usr/sbin/apache2 -k start", ppid: 1012}
+banners:
+  ssh: SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.4
+  telnet: "Ubuntu 22.04.3 LTS"
+  motd: |
+    Welcome to Ubuntu 22.04.3 LTS (GNU/Linux 5.15.0-84-generic x86_64)
+
+     * Documentation:  https://help.ubuntu.com
+     * Management:     https://landscape.canonical.com
+     * Support:        https://ubuntu.com/advantage
+
+    Expanded Security Maintenance for Applications is not enabled.
+
+    12 updates can be applied immediately.
+    To see these additional updates run: apt list --upgradable
+  ftp: "220 (vsFTPd 3.0.5)"
+  ftp_syst: "215 UNIX Type: L8"
+  http: "Apache/2.4.52 (Ubuntu)"
+`,
+
+	"debian-11": `
+hostname: db-backup
+uptime: 2211h
+os:
+  id: debian
+  name: Debian GNU/Linux
+  version: "11 (bullseye)"
+  version_id: "11"
+  codename: bullseye
+  pretty_name: "Debian GNU/Linux 11 (bullseye)"
+  home_url: "https://www.debian.org/"
+  package_manager: dpkg
+kernel:
+  release: 5.10.0-26-amd64
+  version: "#1 SMP Debian 5.10.197-1 (2023-09-29)"
+  machine: x86_64
+  compiler: "(debian-kernel@lists.debian.org) (gcc-10 (Debian 10.2.1-6) 10.2.1 20210110, GNU ld (GNU Binutils for Debian) 2.35.2)"
+cpu:
+  vendor: AuthenticAMD
+  model: "AMD EPYC 7571"
+  family: 23
+  model_id: 1
+  stepping: 2
+  cores: 2
+  mhz: 2199.960
+  cache_kb: 512
+  bogomips: 4399.92
+  flags: "fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush mmx fxsr sse sse2 ht syscall nx mmxext fxsr_opt pdpe1gb rdtscp lm constant_tsc rep_good nopl nonstop_tsc cpuid extd_apicid tsc_known_freq pni pclmulqdq ssse3 fma cx16 sse4_1 sse4_2 movbe popcnt aes xsave avx f16c rdrand hypervisor lahf_lm cmp_legacy cr8_legacy abm sse4a misalignsse 3dnowprefetch topoext perfctr_core vmmcall fsgsbase bmi1 avx2 smep bmi2 rdseed adx smap clflushopt sha_ni xsaveopt xsavec xgetbv1 clzero xsaveerptr arat npt nrip_save"
+memory: {total_mb: 3920, used_mb: 1190, swap_mb: 0}
+disks:
+  - {device: /dev/nvme0n1p1, type: ext4, mount: /, size_gb: 49.1, used_gb: 31.7}
+  - {device: /dev/nvme1n1, type: ext4, mount: /var/backups, size_gb: 196.7, used_gb: 142.3}
+users:
+  - {name: root, uid: 0, gid: 0, gecos: root, home: /root, shell: /bin/bash, password: "$6$l93Ql490lGjjdhsM$HzhPQ57J4n1OaZ8I.ZoxT5mxQsQRVjTQoRiVMsoGS/lG1xeiaHzsyNOFqp5G9iqUpMyHotM7O2i9/MFnXIx0o/"}
+  - {name: daemon, uid: 1, gid: 1, gecos: daemon, home: /usr/sbin, shell: /usr/sbin/nologin}
+  - {name: bin, uid: 2, gid: 2, gecos: bin, home: /bin, shell: /usr/sbin/nologin}
+  - {name: sys, uid: 3, gid: 3, gecos: sys, home: /dev, shell: /usr/sbin/nologin}
+  - {name: sync, uid: 4, gid: 65534, gecos: sync, home: /bin, shell: /bin/sync}
+  - {name: www-data, uid: 33, gid: 33, gecos: www-data, home: /var/www, shell: /usr/sbin/nologin}
+  - {name: backup, uid: 34, gid: 34, gecos: backup, home: /var/backups, shell: /usr/sbin/nologin}
+  - {name: nobody, uid: 65534, gid: 65534, gecos: nobody, home: /nonexistent, shell: /usr/sbin/nologin}
+  - {name: _apt, uid: 100, gid: 65534, home: /nonexistent, shell: /usr/sbin/nologin}
+  - {name: systemd-timesync, uid: 101, gid: 101, gecos: "systemd Time Synchronization,,,", home: /run/systemd, shell: /usr/sbin/nologin}
+  - {name: messagebus, uid: 103, gid: 109, home: /nonexistent, shell: /usr/sbin/nologin}
+  - {name: sshd, uid: 104, gid: 65534, home: /run/sshd, shell: /usr/sbin/nologin}
+  - {name: postgres, uid: 105, gid: 111, gecos: "PostgreSQL administrator,,,", home: /var/lib/postgresql, shell: /bin/bash}
+  - {name: admin, uid: 1000, gid: 1000, gecos: "Debian,,,", home: /home/admin, shell: /bin/bash, password: "$6$jqvAnQwXKaJtOWw4$.XpVOQhrcsNFnuZswSIiz/I4GMoBzE3ZF4PEzhgqV9umrebvqc94MyyFSzpH4uka7fCW8I.D4sut26CGsdqxG1"}
+groups:
+  - {name: root, gid: 0}
+  - {name: daemon, gid: 1}
+  - {name: bin, gid: 2}
+  - {name: sys, gid: 3}
+  - {name: adm, gid: 4, members: [admin]}
+  - {name: sudo, gid: 27, members: [admin]}
+  - {name: www-data, gid: 33}
+  - {name: backup, gid: 34}
+  - {name: systemd-timesync, gid: 101}
+  - {name: messagebus, gid: 109}
+  - {name: postgres, gid: 111}
+  - {name: admin, gid: 1000}
+  - {name: nogroup, gid: 65534}
+interfaces:
+  - {name: ens5, mac: "0a:3f:91:c2:7d:05", address: 172.31.24.117/20, address6: "fe80::83f:91ff:fec2:7d05/64", mtu: 9001}
+packages:
+  - {name: bash, version: 5.1-2+deb11u1, arch: amd64, description: GNU Bourne Again SHell}
+  - {name: coreutils, version: 8.32-4+b1, arch: amd64, description: GNU core utilities}
+  - {name: cron, version: 3.0pl1-137, arch: amd64, description: process scheduling daemon}
+  - {name: curl, version: 7.74.0-1.3+deb11u10, arch: amd64, description: command line tool for transferring data with URL syntax}
+  - {name: nginx, version: 1.18.0-6.1+deb11u3, arch: all, description: "small, powerful, scalable web/proxy server"}
+  - {name: openssh-server, version: "1:8.4p1-5+deb11u2", arch: amd64, description: "secure shell (SSH) server, for secure access from remote machines"}
+  - {name: perl, version: 5.32.1-4+deb11u3, arch: amd64, description: Larry Wall's Practical Extraction and Report Language}
+  - {name: postgresql-13, version: 13.13-0+deb11u1, arch: amd64, description: The World's Most Advanced Open Source Relational Database}
+  - {name: proftpd-basic, version: 1.3.7a+dfsg-12+deb11u2, arch: amd64, description: "Versatile, virtual-hosting FTP daemon - binaries"}
+  - {name: python3, version: 3.9.2-3, arch: amd64, description: interactive high-level object-oriented language (default python3 version)}
+  - {name: rsync, version: 3.2.3-4+deb11u1, arch: amd64, description: "fast, versatile, remote (and local) file-copying tool"}
+  - {name: sudo, version: 1.9.5p2-3+deb11u1, arch: amd64, description: Provide limited super user privileges to specific users}
+  - {name: wget, version: 1.21-1+deb11u1, arch: amd64, description: retrieves files from the web}
+processes:
+  - {pid: 1, user: root, mem: 0.2, vsz: 163844, rss: 10236, tty: "?", stat: Ss, time: "3:12", command: "/sbin/init"}
+  - {pid: 2, user: root, tty: "?", stat: S, time: "0:00", command: "[kthreadd]"}
+  - {pid: 238, user: root, mem: 0.9, vsz: 56420, rss: 36104, tty: "?", stat: Ss, time: "1:20", command: "/lib/systemd/systemd-journald"}
+  - {pid: 262, user: root, vsz: 21668, rss: 5364, tty: "?", stat: Ss, time: "0:01", command: "/lib/systemd/systemd-udevd"}
+  - {pid: 351, user: systemd+, vsz: 88440, rss: 6380, tty: "?", stat: Ssl, time: "0:07", command: "/lib/systemd/systemd-timesyncd"}
+  - {pid: 402, user: root, vsz: 6684, rss: 2684, tty: "?", stat: Ss, time: "0:09", command: "/usr/sbin/cron -f"}
+  - {pid: 404, user: message+, vsz: 8268, rss: 4052, tty: "?", stat: Ss, time: "0:00", command: "/usr/bin/dbus-daemon --system --address=systemd: --nofork --nopidfile --systemd-activation --syslog-only"}
+  - {pid: 421, user: root, vsz: 13352, rss: 7528, tty: "?", stat: Ss, time: "0:00", command: "sshd: /usr/sbin/sshd -D [listener] 0 of 10-100 startups", listen: ["tcp 0.0.0.0:22", "tcp6 :::22"]}
+  - {pid: 430, user: root, vsz: 5480, rss: 1828, tty: ttyS0, stat: Ss+, time: "0:00", command: "/sbin/agetty -o -p -- \\u --keep-baud 115200,57600,38400,9600 ttyS0 vt220"}
+  - {pid: 512, user: proftpd, vsz: 16852, rss: 3468, tty: "?", stat: Ss, time: "0:00", command: "proftpd: (accepting connections)", listen: ["tcp6 :::21"]}
+  - {pid: 598, user: postgres, cpu: 0.1, mem: 0.7, vsz: 215736, rss: 29120, tty: "?", stat: Ss, time: "18:45", command: "/usr/lib/postgresql/13/bin/postgres -D /var/lib/postgresql/13/main -c config_file=/etc/postgresql/13/main/postgresql.conf", listen: ["tcp 127.0.0.1:5432"]}
+  - {pid: 611, user: root, vsz: 55196, rss: 1588, tty: "?", stat: Ss, time: "0:00", command: "nginx: master process /usr/sbin/nginx -g daemon on; master_process on;", listen: ["tcp 0.0.0.0:80", "tcp6 :::80"]}
+  - {pid: 612, user: www-data, vsz: 55864, rss: 5592, tty: "?", stat: S, time: "0:12", command: "nginx: worker process", ppid: 611}
+banners:
+  ssh: SSH-2.0-OpenSSH_8.4p1 Debian-5+deb11u2
+  telnet: "Debian GNU/Linux 11"
+  motd: |
+    Linux db-backup 5.10.0-26-amd64 #1 SMP Debian 5.10.197-1 (2023-09-29) x86_64
+
+    The programs included with the Debian GNU/Linux system are free software;
+    the exact distribution terms for each program are described in the
+    individual files in /usr/share/doc/*/copyright.
+
+    Debian GNU/Linux comes with ABSOLUTELY NO WARRANTY, to the extent
+    permitted by applicable law.
+  ftp: "220 ProFTPD Server (Debian) [172.31.24.117]"
+  ftp_syst: "215 UNIX Type: L8"
+  http: "nginx/1.18.0"
+`,
+
+	"centos-7": `
+hostname: localhost.localdomain
+uptime: 4870h
+os:
+  id: centos
+  id_like: "rhel fedora"
+  name: CentOS Linux
+  version: "7 (Core)"
+  version_id: "7"
+  codename: Core
+  pretty_name: "CentOS Linux 7 (Core)"
+  home_url: "https://www.centos.org/"
+  package_manager: rpm
+kernel:
+  release: 3.10.0-1160.102.1.el7.x86_64
+  version: "#1 SMP Tue Oct 17 15:42:21 UTC 2023"
+  machine: x86_64
+  compiler: "(mockbuild@kbuilder.bsys.centos.org) (gcc version 4.8.5 20150623 (Red Hat 4.8.5-44) (GCC) )"
+cpu:
+  vendor: GenuineIntel
+  model: "Intel(R) Xeon(R) CPU E5-2650 v2 @ 2.60GHz"
+  family: 6
+  model_id: 62
+  stepping: 4
+  cores: 8
+  mhz: 2599.998
+  cache_kb: 20480
+  bogomips: 5199.99
+  flags: "fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush mmx fxsr sse sse2 ss ht syscall nx pdpe1gb rdtscp lm constant_tsc arch_perfmon rep_good nopl xtopology eagerfpu pni pclmulqdq ssse3 cx16 pcid sse4_1 sse4_2 x2apic popcnt tsc_deadline_timer aes xsave avx f16c rdrand hypervisor lahf_lm fsgsbase smep erms xsaveopt"
+memory: {total_mb: 15885, used_mb: 6021, swap_mb: 8063}
+disks:
+  - {device: /dev/mapper/centos-root, type: xfs, mount: /, size_gb: 50.0, used_gb: 18.2}
+  - {device: /dev/sda1, type: xfs, mount: /boot, size_gb: 1.0, used_gb: 0.2}
+  - {device: /dev/mapper/centos-home, type: xfs, mount: /home, size_gb: 441.0, used_gb: 12.9}
+users:
+  - {name: root, uid: 0, gid: 0, gecos: root, home: /root, shell: /bin/bash, password: "$6$TVXgASxrK7Gokqxc$GbtLMTgG2KqV4mqM3zlHYzBzZKS.3wHAsR8OcmLyrKs5.ssL1kCzzVoAccCZ1nO72TaNdS5vPPchcYUlofUZp0"}
+  - {name: bin, uid: 1, gid: 1, gecos: bin, home: /bin, shell: /sbin/nologin}
+  - {name: daemon, uid: 2, gid: 2, gecos: daemon, home: /sbin, shell: /sbin/nologin}
+  - {name: adm, uid: 3, gid: 4, gecos: adm, home: /var/adm, shell: /sbin/nologin}
+  - {name: sync, uid: 5, gid: 0, gecos: sync, home: /sbin, shell: /bin/sync}
+  - {name: shutdown, uid: 6, gid: 0, gecos: shutdown, home: /sbin, shell: /sbin/shutdown}
+  - {name: mail, uid: 8, gid: 12, gecos: mail, home: /var/spool/mail, shell: /sbin/nologin}
+  - {name: ftp, uid: 14, gid: 50, gecos: FTP User, home: /var/ftp, shell: /sbin/nologin}
+  - {name: nobody, uid: 99, gid: 99, gecos: Nobody, home: /, shell: /sbin/nologin}
+  - {name: apache, uid: 48, gid: 48, gecos: Apache, home: /usr/share/httpd, shell: /sbin/nologin}
+  - {name: mysql, uid: 27, gid: 27, gecos: MariaDB Server, home: /var/lib/mysql, shell: /sbin/nologin}
+  - {name: sshd, uid: 74, gid: 74, gecos: Privilege-separated SSH, home: /var/empty/sshd, shell: /sbin/nologin}
+  - {name: centos, uid: 1000, gid: 1000, gecos: Cloud User, home: /home/centos, shell: /bin/bash, password: "$6$jqvAnQwXKaJtOWw4$.XpVOQhrcsNFnuZswSIiz/I4GMoBzE3ZF4PEzhgqV9umrebvqc94MyyFSzpH4uka7fCW8I.D4sut26CGsdqxG1"}
+groups:
+  - {name: root, gid: 0}
+  - {name: bin, gid: 1}
+  - {name: daemon, gid: 2}
+  - {name: adm, gid: 4, members: [centos]}
+  - {name: wheel, gid: 10, members: [centos]}
+  - {name: mail, gid: 12}
+  - {name: mysql, gid: 27}
+  - {name: apache, gid: 48}
+  - {name: ftp, gid: 50}
+  - {name: sshd, gid: 74}
+  - {name: nobody, gid: 99}
+  - {name: centos, gid: 1000}
+interfaces:
+  - {name: eth0, mac: "00:16:3e:5a:21:9c", address: 192.168.10.24/24, address6: "fe80::216:3eff:fe5a:219c/64"}
+packages:
+  - {name: bash, version: 4.2.46-35.el7_9, arch: x86_64}
+  - {name: coreutils, version: 8.22-24.el7_9.2, arch: x86_64}
+  - {name: cronie, version: 1.4.11-25.el7_9, arch: x86_64}
+  - {name: curl, version: 7.29.0-59.el7_9.2, arch: x86_64}
+  - {name: httpd, version: 2.4.6-99.el7.centos.1, arch: x86_64}
+  - {name: mariadb-server, version: 5.5.68-1.el7, arch: x86_64}
+  - {name: openssh-server, version: 7.4p1-23.el7_9, arch: x86_64}
+  - {name: perl, version: 5.16.3-299.el7_9, arch: x86_64}
+  - {name: php, version: 5.4.16-48.el7, arch: x86_64}
+  - {name: python, version: 2.7.5-94.el7_9, arch: x86_64}
+  - {name: sudo, version: 1.8.23-10.el7_9.3, arch: x86_64}
+  - {name: systemd, version: 219-78.el7_9.7, arch: x86_64}
+  - {name: vsftpd, version: 3.0.2-29.el7_9, arch: x86_64}
+  - {name: wget, version: 1.14-18.el7_6.1, arch: x86_64}
+processes:
+  - {pid: 1, user: root, mem: 0.0, vsz: 193916, rss: 6856, tty: "?", stat: Ss, time: "12:41", command: "/usr/lib/systemd/systemd --switched-root --system --deserialize 22"}
+  - {pid: 2, user: root, tty: "?", stat: S, time: "0:01", command: "[kthreadd]"}
+  - {pid: 498, user: root, mem: 0.1, vsz: 39192, rss: 9604, tty: "?", stat: Ss, time: "2:05", command: "/usr/lib/systemd/systemd-journald"}
+  - {pid: 521, user: root, vsz: 47380, rss: 2164, tty: "?", stat: Ss, time: "0:00", command: "/usr/lib/systemd/systemd-udevd"}
+  - {pid: 644, user: root, vsz: 55532, rss: 1112, tty: "?", stat: S<sl, time: "0:47", command: "/sbin/auditd"}
+  - {pid: 671, user: dbus, vsz: 58216, rss: 2456, tty: "?", stat: Ss, time: "0:21", command: "/usr/bin/dbus-daemon --system --address=systemd: --nofork --nopidfile --systemd-activation"}
+  - {pid: 689, user: root, vsz: 126388, rss: 1724, tty: "?", stat: Ss, time: "0:31", command: "/usr/sbin/crond -n"}
+  - {pid: 1022, user: root, vsz: 112940, rss: 4348, tty: "?", stat: Ss, time: "0:03", command: "/usr/sbin/sshd -D", listen: ["tcp 0.0.0.0:22", "tcp6 :::22"]}
+  - {pid: 1025, user: root, vsz: 53288, rss: 840, tty: "?", stat: Ss, time: "0:00", command: "/usr/sbin/vsftpd /etc/vsftpd/vsftpd.conf", listen: ["tcp 0.0.0.0:21"]}
+  - {pid: 1031, user: root, vsz: 216416, rss: 4468, tty: "?", stat: Ssl, time: "3:55", command: "/usr/sbin/rsyslogd -n"}
+  - {pid: 1188, user: mysql, cpu: 0.2, mem: 0.9, vsz: 972764, rss: 149872, tty: "?", stat: Sl, time: "643:10", command: "/usr/libexec/mysqld --basedir=/usr --datadir=/var/lib/mysql --plugin-dir=/usr/lib64/mysql/plugin --log-error=/var/log/mariadb/mariadb.log --pid-file=/var/run/mariadb/mariadb.pid --socket=/var/lib/mysql/mysql.sock", listen: ["tcp 0.0.0.0:3306"]}
+  - {pid: 1290, user: root, vsz: 230372, rss: 5164, tty: "?", stat: Ss, time: "4:09", command: "/usr/sbin/httpd -DFOREGROUND", listen: ["tcp6 :::80"]}
+  - {pid: 1295, user: apache, vsz: 232456, rss: 3116, tty: "?", stat: S, time: "0:00", command: "/usr/sbin/httpd -DFOREGROUND", ppid: 1290}
+banners:
+  ssh: SSH-2.0-OpenSSH_7.4
+  telnet: "CentOS Linux 7 (Core)\nKernel 3.10.0-1160.102.1.el7.x86_64 on an x86_64"
+  motd: ""
+  ftp: "220 (vsFTPd 3.0.2)"
+  ftp_syst: "215 UNIX Type: L8"
+  http: "Apache/2.4.6 (CentOS) PHP/5.4.16"
+`,
+
+	"raspbian-10": `
+hostname: raspberrypi
+uptime: 388h
+os:
+  id: raspbian
+  id_like: debian
+  name: Raspbian GNU/Linux
+  version: "10 (buster)"
+  version_id: "10"
+  codename: buster
+  pretty_name: "Raspbian GNU/Linux 10 (buster)"
+  home_url: "http://www.raspbian.org/"
+  package_manager: dpkg
+kernel:
+  release: 5.10.103-v7l+
+  version: "#1529 SMP Tue Mar 8 12:24:00 GMT 2022"
+  machine: armv7l
+  compiler: "(dom@buildbot) (arm-linux-gnueabihf-gcc-8 (Ubuntu/Linaro 8.4.0-3ubuntu1) 8.4.0, GNU ld (GNU Binutils for Ubuntu) 2.34)"
+cpu:
+  vendor: ARM
+  model: "ARMv7 Processor rev 3 (v7l)"
+  family: 7
+  model_id: 3336
+  stepping: 3
+  cores: 4
+  mhz: 1500
+  bogomips: 108.00
+  flags: "half thumb fastmult vfp edsp neon vfpv3 tls vfpv4 idiva idivt vfpd32 lpae evtstrm crc32"
+memory: {total_mb: 3838, used_mb: 412, swap_mb: 99}
+disks:
+  - {device: /dev/root, type: ext4, mount: /, size_gb: 29.2, used_gb: 6.8}
+  - {device: /dev/mmcblk0p1, type: vfat, mount: /boot, size_gb: 0.25, used_gb: 0.05}
+users:
+  - {name: root, uid: 0, gid: 0, gecos: root, home: /root, shell: /bin/bash}
+  - {name: daemon, uid: 1, gid: 1, gecos: daemon, home: /usr/sbin, shell: /usr/sbin/nologin}
+  - {name: bin, uid: 2, gid: 2, gecos: bin, home: /bin, shell: /usr/sbin/nologin}
+  - {name: sys, uid: 3, gid: 3, gecos: sys, home: /dev, shell: /usr/sbin/nologin}
+  - {name: www-data, uid: 33, gid: 33, gecos: www-data, home: /var/www, shell: /usr/sbin/nologin}
+  - {name: nobody, uid: 65534, gid: 65534, gecos: nobody, home: /nonexistent, shell: /usr/sbin/nologin}
+  - {name: systemd-timesync, uid: 100, gid: 102, gecos: "systemd Time Synchronization,,,", home: /run/systemd, shell: /usr/sbin/nologin}
+  - {name: sshd, uid: 105, gid: 65534, home: /run/sshd, shell: /usr/sbin/nologin}
+  - {name: pi, uid: 1000, gid: 1000, gecos: ",,,", home: /home/pi, shell: /bin/bash, password: "$6$J0gER7QriY1ugkjZ$u8S9jySOUz8ky7cKJgPKYk/Ynt.qhZmwydSWvFX4CdWuNiLHT72LkNn4IavBJyTRiHU7dm3GSrnYAncOZqkoC1"}
+groups:
+  - {name: root, gid: 0}
+  - {name: daemon, gid: 1}
+  - {name: adm, gid: 4, members: [pi]}
+  - {name: dialout, gid: 20, members: [pi]}
+  - {name: cdrom, gid: 24, members: [pi]}
+  - {name: sudo, gid: 27, members: [pi]}
+  - {name: audio, gid: 29, members: [pi]}
+  - {name: www-data, gid: 33}
+  - {name: video, gid: 44, members: [pi]}
+  - {name: plugdev, gid: 46, members: [pi]}
+  - {name: users, gid: 100, members: [pi]}
+  - {name: netdev, gid: 109, members: [pi]}
+  - {name: gpio, gid: 997, members: [pi]}
+  - {name: i2c, gid: 998, members: [pi]}
+  - {name: spi, gid: 999, members: [pi]}
+  - {name: pi, gid: 1000}
+  - {name: nogroup, gid: 65534}
+interfaces:
+  - {name: eth0, mac: "dc:a6:32:1b:7e:44", address: 192.168.1.47/24, address6: "fe80::dea6:32ff:fe1b:7e44/64"}
+  - {name: wlan0, mac: "dc:a6:32:1b:7e:45"}
+packages:
+  - {name: bash, version: 5.0-4, arch: armhf, description: GNU Bourne Again SHell}
+  - {name: busybox, version: "1:1.30.1-4", arch: armhf, description: Tiny utilities for small and embedded systems}
+  - {name: coreutils, version: 8.30-3, arch: armhf, description: GNU core utilities}
+  - {name: curl, version: 7.64.0-4+deb10u2, arch: armhf, description: command line tool for transferring data with URL syntax}
+  - {name: lighttpd, version: 1.4.53-4+deb10u2, arch: armhf, description: fast webserver with minimal memory footprint}
+  - {name: openssh-server, version: "1:7.9p1-10+deb10u2", arch: armhf, description: "secure shell (SSH) server, for secure access from remote machines"}
+  - {name: perl, version: 5.28.1-6+deb10u1, arch: armhf, description: Larry Wall's Practical Extraction and Report Language}
+  - {name: pihole-FTL, version: 5.13, arch: armhf, description: Pi-hole FTL engine}
+  - {name: python3, version: 3.7.3-1, arch: armhf, description: interactive high-level object-oriented language (default python3 version)}
+  - {name: raspberrypi-kernel, version: "1:1.20220308~buster-1", arch: armhf, description: Raspberry Pi bootloader}
+  - {name: sudo, version: 1.8.27-1+deb10u3, arch: armhf, description: Provide limited super user privileges to specific users}
+  - {name: wget, version: 1.20.1-1.1, arch: armhf, description: retrieves files from the web}
+processes:
+  - {pid: 1, user: root, mem: 0.2, vsz: 33764, rss: 8308, tty: "?", stat: Ss, time: "0:38", command: "/sbin/init splash"}
+  - {pid: 2, user: root, tty: "?", stat: S, time: "0:00", command: "[kthreadd]"}
+  - {pid: 134, user: root, mem: 0.3, vsz: 37584, rss: 11860, tty: "?", stat: Ss, time: "0:12", command: "/lib/systemd/systemd-journald"}
+  - {pid: 356, user: root, vsz: 7948, rss: 2172, tty: "?", stat: Ss, time: "0:03", command: "/usr/sbin/cron -f"}
+  - {pid: 372, user: avahi, vsz: 5904, rss: 2548, tty: "?", stat: Ss, time: "0:41", command: "avahi-daemon: running [raspberrypi.local]"}
+  - {pid: 401, user: root, vsz: 10828, rss: 3512, tty: "?", stat: Ss, time: "0:01", command: "/sbin/dhcpcd -q -w"}
+  - {pid: 512, user: root, vsz: 12180, rss: 5996, tty: "?", stat: Ss, time: "0:00", command: "/usr/sbin/sshd -D", listen: ["tcp 0.0.0.0:22", "tcp6 :::22"]}
+  - {pid: 540, user: www-data, vsz: 6972, rss: 3408, tty: "?", stat: Ss, time: "0:19", command: "/usr/sbin/lighttpd -D -f /etc/lighttpd/lighttpd.conf", listen: ["tcp 0.0.0.0:80", "tcp6 :::80"]}
+  - {pid: 611, user: pihole, cpu: 0.3, mem: 0.6, vsz: 89464, rss: 24112, tty: "?", stat: Ssl, time: "57:02", command: "/usr/bin/pihole-FTL -f", listen: ["tcp 0.0.0.0:53", "udp 0.0.0.0:53", "tcp 127.0.0.1:4711"]}
+  - {pid: 655, user: root, vsz: 4600, rss: 1744, tty: tty1, stat: Ss+, time: "0:00", command: "/sbin/agetty -o -p -- \\u --noclear tty1 linux"}
+banners:
+  ssh: SSH-2.0-OpenSSH_7.9p1 Raspbian-10+deb10u2
+  telnet: "Raspbian GNU/Linux 10"
+  motd: |
+    Linux raspberrypi 5.10.103-v7l+ #1529 SMP Tue Mar 8 12:24:00 GMT 2022 armv7l
+
+    The programs included with the Debian GNU/Linux system are free software;
+    the exact distribution terms for each program are described in the
+    individual files in /usr/share/doc/*/copyright.
+
+    Debian GNU/Linux comes with ABSOLUTELY NO WARRANTY, to the extent
+    permitted by applicable law.
+  ftp: "220 (vsFTPd 3.0.3)"
+  ftp_syst: "215 UNIX Type: L8"
+  http: "lighttpd/1.4.53"
+`,
+}
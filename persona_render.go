@@ -0,0 +1,394 @@
+package persona
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Command responde aos comandos que só dependem do perfil (uname, /etc/os-release, /proc, free,
+// /etc/passwd, ip addr, dpkg -l, ...); ok é falso para os demais
+func (p *Profile) Command(command string) (string, bool) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	switch fields[0] {
+	case "uname":
+		return p.Uname(fields[1:]), true
+	case "arch":
+		return p.Kernel.Machine, true
+	case "hostname":
+		if len(fields) > 1 && (fields[1] == "-i" || fields[1] == "-I") {
+			return p.Address(), true
+		}
+		return p.Hostname, true
+	case "lsb_release":
+		return p.LSBRelease(), true
+	case "uptime":
+		return p.UptimeReport(1), true
+	case "free":
+		return p.Free(fields[1:]), true
+	case "df":
+		return p.DF(fields[1:]), true
+	case "nproc":
+		return fmt.Sprint(max(p.CPU.Cores, 1)), true
+	case "lscpu":
+		return p.LSCPU(), true
+	case "ifconfig":
+		return p.IfConfig(), true
+	case "ip":
+		if len(fields) > 1 && (fields[1] == "a" || strings.HasPrefix(fields[1], "addr")) {
+			return p.IPAddr(), true
+		}
+	case "dpkg":
+		if p.OS.PackageManager == "dpkg" && len(fields) > 1 && fields[1] == "-l" {
+			return p.DpkgList(), true
+		}
+	case "rpm":
+		if p.OS.PackageManager == "rpm" && len(fields) > 1 && fields[1] == "-qa" {
+			return p.RPMList(), true
+		}
+	case "cat":
+		if len(fields) == 2 {
+			return p.File(fields[1])
+		}
+	}
+	return "", false
+}
+
+// identityFiles são os caminhos que File pode responder
+var identityFiles = []string{
+	"/etc/hostname", "/etc/os-release", "/usr/lib/os-release", "/etc/issue", "/etc/issue.net",
+	"/etc/passwd", "/etc/group", "/etc/debian_version", "/etc/redhat-release", "/etc/centos-release",
+	"/proc/version", "/proc/cpuinfo", "/proc/meminfo", "/proc/uptime", "/proc/loadavg",
+}
+
+// Files lista os arquivos de identidade que existem neste perfil
+func (p *Profile) Files() []string {
+	var paths []string
+	for _, path := range identityFiles {
+		if _, exists := p.File(path); exists {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// File retorna o conteúdo dos arquivos de identidade do sistema
+func (p *Profile) File(path string) (string, bool) {
+	switch path {
+	case "/etc/hostname":
+		return p.Hostname, true
+	case "/etc/os-release", "/usr/lib/os-release":
+		return p.OSRelease(), true
+	case "/etc/issue":
+		return p.OS.PrettyName + ` \n \l` + "\n", true
+	case "/etc/issue.net":
+		return p.Banners.Telnet, true
+	case "/etc/passwd":
+		return p.Passwd(), true
+	case "/etc/group":
+		return p.GroupFile(), true
+	case "/proc/version":
+		return p.ProcVersion(), true
+	case "/proc/cpuinfo":
+		return p.CPUInfo(), true
+	case "/proc/meminfo":
+		return p.MemInfo(), true
+	case "/proc/uptime":
+		return p.ProcUptime(), true
+	case "/proc/loadavg":
+		return p.ProcLoadAvg(), true
+	case "/etc/debian_version":
+		if p.OS.PackageManager == "dpkg" && p.OS.ID == "ubuntu" {
+			return p.OS.Codename + "/sid", true
+		}
+		if p.OS.PackageManager == "dpkg" {
+			return p.OS.VersionID, true
+		}
+	case "/etc/redhat-release", "/etc/centos-release":
+		if p.OS.PackageManager == "rpm" {
+			return p.OS.PrettyName, true
+		}
+	}
+	return "", false
+}
+
+// Uname imita o uname com as flags -a, -s, -n, -r, -v, -m, -p, -i e -o
+func (p *Profile) Uname(args []string) string {
+	processor := p.Kernel.Machine
+	platform := p.Kernel.Machine
+	if p.Kernel.Machine != "x86_64" {
+		processor, platform = "unknown", "unknown"
+	}
+	values := []struct {
+		flag  byte
+		value string
+	}{
+		{'s', "Linux"}, {'n', p.Hostname}, {'r', p.Kernel.Release}, {'v', p.Kernel.Version},
+		{'m', p.Kernel.Machine}, {'p', processor}, {'i', platform}, {'o', "GNU/Linux"},
+	}
+
+	selected := map[byte]bool{}
+	all := false
+	for _, arg := range args {
+		switch arg {
+		case "--all":
+			all = true
+		case "--kernel-name":
+			selected['s'] = true
+		case "--nodename":
+			selected['n'] = true
+		case "--kernel-release":
+			selected['r'] = true
+		case "--kernel-version":
+			selected['v'] = true
+		case "--machine":
+			selected['m'] = true
+		case "--operating-system":
+			selected['o'] = true
+		default:
+			if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+				return fmt.Sprintf("uname: extra operand '%s'\nTry 'uname --help' for more information.", arg)
+			}
+			for _, flag := range []byte(arg[1:]) {
+				if flag == 'a' {
+					all = true
+				} else {
+					selected[flag] = true
+				}
+			}
+		}
+	}
+	if len(selected) == 0 && !all {
+		selected['s'] = true
+	}
+
+	var out []string
+	for _, v := range values {
+		// -a omite -p e -i quando são "unknown"
+		if all && (v.flag == 'p' || v.flag == 'i') && v.value == "unknown" {
+			continue
+		}
+		if all || selected[v.flag] {
+			out = append(out, v.value)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// ProcVersion é o conteúdo de /proc/version
+func (p *Profile) ProcVersion() string {
+	return fmt.Sprintf("Linux version %s %s %s", p.Kernel.Release, p.Kernel.Compiler, p.Kernel.Version)
+}
+
+// OSRelease é o conteúdo de /etc/os-release
+func (p *Profile) OSRelease() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "PRETTY_NAME=%q\nNAME=%q\n", p.OS.PrettyName, p.OS.Name)
+	if p.OS.VersionID != "" {
+		fmt.Fprintf(&b, "VERSION_ID=%q\n", p.OS.VersionID)
+	}
+	if p.OS.Version != "" {
+		fmt.Fprintf(&b, "VERSION=%q\n", p.OS.Version)
+	}
+	if p.OS.Codename != "" {
+		fmt.Fprintf(&b, "VERSION_CODENAME=%s\n", p.OS.Codename)
+	}
+	fmt.Fprintf(&b, "ID=%s\n", p.OS.ID)
+	if p.OS.IDLike != "" {
+		fmt.Fprintf(&b, "ID_LIKE=%s\n", p.OS.IDLike)
+	}
+	if p.OS.HomeURL != "" {
+		fmt.Fprintf(&b, "HOME_URL=%q\n", p.OS.HomeURL)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// LSBRelease é a saída de lsb_release -a
+func (p *Profile) LSBRelease() string {
+	return fmt.Sprintf("No LSB modules are available.\nDistributor ID:\t%s\nDescription:\t%s\nRelease:\t%s\nCodename:\t%s",
+		p.OS.Name, p.OS.PrettyName, p.OS.VersionID, p.OS.Codename)
+}
+
+// Welcome é a mensagem mostrada logo depois do login
+func (p *Profile) Welcome() string {
+	if p.Banners.MOTD != "" {
+		return strings.TrimRight(p.Banners.MOTD, "\n")
+	}
+	return fmt.Sprintf("Welcome to %s (GNU/Linux %s %s)", p.OS.PrettyName, p.Kernel.Release, p.Kernel.Machine)
+}
+
+// Passwd é o conteúdo de /etc/passwd
+func (p *Profile) Passwd() string {
+	lines := make([]string, 0, len(p.Users))
+	for _, user := range p.Users {
+		lines = append(lines, fmt.Sprintf("%s:x:%d:%d:%s:%s:%s", user.Name, user.UID, user.GID, user.Gecos, user.Home, user.Shell))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Shadow é o conteúdo de /etc/shadow, visível só para root
+func (p *Profile) Shadow() string {
+	lastChange := int(p.BootTime().Unix()/86400) - 30
+	lines := make([]string, 0, len(p.Users))
+	for _, user := range p.Users {
+		hash := user.Password
+		if hash == "" {
+			hash = "*"
+		}
+		lines = append(lines, fmt.Sprintf("%s:%s:%d:0:99999:7:::", user.Name, hash, lastChange))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GroupFile é o conteúdo de /etc/group
+func (p *Profile) GroupFile() string {
+	lines := make([]string, 0, len(p.Groups))
+	for _, group := range p.Groups {
+		lines = append(lines, fmt.Sprintf("%s:x:%d:%s", group.Name, group.GID, strings.Join(group.Members, ",")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ID é a saída de id para o usuário
+func (p *Profile) ID(user User) string {
+	groups := p.GroupsOf(user)
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, fmt.Sprintf("%d(%s)", group.GID, group.Name))
+	}
+	return fmt.Sprintf("uid=%d(%s) gid=%d(%s) groups=%s", user.UID, user.Name, groups[0].GID, groups[0].Name, strings.Join(names, ","))
+}
+
+// IPAddr é a saída de ip addr, com o loopback primeiro
+func (p *Profile) IPAddr() string {
+	var b strings.Builder
+	b.WriteString("1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN group default qlen 1000\n" +
+		"    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00\n" +
+		"    inet 127.0.0.1/8 scope host lo\n" +
+		"       valid_lft forever preferred_lft forever\n" +
+		"    inet6 ::1/128 scope host \n" +
+		"       valid_lft forever preferred_lft forever")
+	for i, iface := range p.Interfaces {
+		fmt.Fprintf(&b, "\n%d: %s: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu %d qdisc fq_codel state UP group default qlen 1000\n", i+2, iface.Name, iface.mtu())
+		fmt.Fprintf(&b, "    link/ether %s brd ff:ff:ff:ff:ff:ff", iface.MAC)
+		if ip, network, err := net.ParseCIDR(iface.Address); err == nil {
+			ones, _ := network.Mask.Size()
+			fmt.Fprintf(&b, "\n    inet %s/%d brd %s scope global dynamic %s\n       valid_lft 3206sec preferred_lft 3206sec", ip, ones, broadcast(network), iface.Name)
+		}
+		if iface.Address6 != "" {
+			fmt.Fprintf(&b, "\n    inet6 %s scope link \n       valid_lft forever preferred_lft forever", iface.Address6)
+		}
+	}
+	return b.String()
+}
+
+// IfConfig é a saída de ifconfig (net-tools)
+func (p *Profile) IfConfig() string {
+	var blocks []string
+	for i, iface := range p.Interfaces {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s: flags=4163<UP,BROADCAST,RUNNING,MULTICAST>  mtu %d\n", iface.Name, iface.mtu())
+		if ip, network, err := net.ParseCIDR(iface.Address); err == nil {
+			fmt.Fprintf(&b, "        inet %s  netmask %s  broadcast %s\n", ip, net.IP(network.Mask), broadcast(network))
+		}
+		if address, _, found := strings.Cut(iface.Address6, "/"); found {
+			fmt.Fprintf(&b, "        inet6 %s  prefixlen 64  scopeid 0x20<link>\n", address)
+		}
+		fmt.Fprintf(&b, "        ether %s  txqueuelen 1000  (Ethernet)\n", iface.MAC)
+		fmt.Fprintf(&b, "        RX packets %d  bytes %d\n", 1843921+i*7919, 1843921*731+i*104729)
+		fmt.Fprintf(&b, "        TX packets %d  bytes %d\n", 1029384+i*6563, 1029384*412+i*86243)
+		blocks = append(blocks, b.String())
+	}
+	blocks = append(blocks, "lo: flags=73<UP,LOOPBACK,RUNNING>  mtu 65536\n"+
+		"        inet 127.0.0.1  netmask 255.0.0.0\n"+
+		"        inet6 ::1  prefixlen 128  scopeid 0x10<host>\n"+
+		"        loop  txqueuelen 1000  (Local Loopback)\n")
+	return strings.TrimSuffix(strings.Join(blocks, "\n"), "\n")
+}
+
+func (iface Interface) mtu() int {
+	if iface.MTU == 0 {
+		return 1500
+	}
+	return iface.MTU
+}
+
+func broadcast(network *net.IPNet) net.IP {
+	ip := network.IP.To4()
+	if ip == nil {
+		return network.IP
+	}
+	out := make(net.IP, len(ip))
+	for i := range ip {
+		out[i] = ip[i] | ^network.Mask[i]
+	}
+	return out
+}
+
+// DpkgList é a saída de dpkg -l
+func (p *Profile) DpkgList() string {
+	nameWidth, versionWidth, archWidth := 14, 14, 12
+	for _, pkg := range p.Packages {
+		nameWidth = max(nameWidth, len(pkg.Name))
+		versionWidth = max(versionWidth, len(pkg.Version))
+		archWidth = max(archWidth, len(pkg.Arch))
+	}
+
+	var b strings.Builder
+	b.WriteString("Desired=Unknown/Install/Remove/Purge/Hold\n" +
+		"| Status=Not/Inst/Conf-files/Unpacked/halF-conf/Half-inst/trig-aWait/Trig-pend\n" +
+		"|/ Err?=(none)/Reinst-required (Status,Err: uppercase=bad)\n")
+	fmt.Fprintf(&b, "||/ %-*s %-*s %-*s Description\n", nameWidth, "Name", versionWidth, "Version", archWidth, "Architecture")
+	fmt.Fprintf(&b, "+++-%s-%s-%s-%s", strings.Repeat("=", nameWidth), strings.Repeat("=", versionWidth), strings.Repeat("=", archWidth), strings.Repeat("=", 40))
+	for _, pkg := range p.Packages {
+		fmt.Fprintf(&b, "\nii  %-*s %-*s %-*s %s", nameWidth, pkg.Name, versionWidth, pkg.Version, archWidth, pkg.Arch, pkg.Description)
+	}
+	return b.String()
+}
+
+// Database é o servidor MySQL ou MariaDB instalado, como o phpMyAdmin mostra em "Server type" e
+// "Server version" (VERSION() e version_comment); ok é falso se o perfil não tem banco de dados
+func (p *Profile) Database() (kind, version string, ok bool) {
+	for _, pkg := range p.Packages {
+		// A época do dpkg (1:10.5.19-0+deb11u2) não faz parte da versão do servidor
+		_, release, found := strings.Cut(pkg.Version, ":")
+		if !found {
+			release = pkg.Version
+		}
+		switch {
+		case strings.HasPrefix(pkg.Name, "mariadb-server"):
+			upstream, _, _ := strings.Cut(release, "-")
+			return "MariaDB", upstream + "-MariaDB - MariaDB Server", true
+		case strings.HasPrefix(pkg.Name, "mysql-server"), strings.HasPrefix(pkg.Name, "mysql-community-server"):
+			return "MySQL", fmt.Sprintf("%s - (%s)", release, p.OS.Name), true
+		}
+	}
+	return "", "", false
+}
+
+// RPMList é a saída de rpm -qa
+func (p *Profile) RPMList() string {
+	lines := make([]string, 0, len(p.Packages))
+	for _, pkg := range p.Packages {
+		lines = append(lines, fmt.Sprintf("%s-%s.%s", pkg.Name, pkg.Version, pkg.Arch))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PSAux é a saída de ps aux com os processos do perfil
+func (p *Profile) PSAux() string {
+	return p.PS([]string{"aux"}, nil)
+}
+
+// psStart formata o START do ps: hora para hoje, mês e dia para dias anteriores
+func psStart(t time.Time) string {
+	if time.Since(t) < 24*time.Hour {
+		return t.Format("15:04")
+	}
+	return t.Format("Jan02")
+}
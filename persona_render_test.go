@@ -0,0 +1,20 @@
+package persona
+
+import "testing"
+
+func TestDatabase(t *testing.T) {
+	for name, want := range map[string][2]string{
+		"ubuntu-22.04": {"MySQL", "8.0.34-0ubuntu0.22.04.1 - (Ubuntu)"},
+		"centos-7":     {"MariaDB", "5.5.68-MariaDB - MariaDB Server"},
+		"debian-11":    {"", ""},
+	} {
+		profile, err := Builtin(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kind, version, ok := profile.Database()
+		if kind != want[0] || version != want[1] || ok != (want[0] != "") {
+			t.Errorf("%s: Database() = %q, %q, %v, esperado %q, %q", name, kind, version, ok, want[0], want[1])
+		}
+	}
+}
@@ -0,0 +1,615 @@
+package persona
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Login é uma sessão de terminal mostrada por w, who e last
+type Login struct {
+	User  string
+	TTY   string // pts/0
+	From  string // Endereço de origem, sem porta
+	At    time.Time
+	Until time.Time // Zero enquanto a sessão está aberta
+	What  string    // Comando em execução, para o w
+}
+
+// Socket é uma linha do netstat e do ss
+type Socket struct {
+	Proto   string // tcp, tcp6, udp ou udp6
+	Local   string
+	Remote  string
+	State   string // LISTEN ou ESTABLISHED; vazio no udp
+	PID     int
+	Program string
+}
+
+// Address é o IPv4 da primeira interface, como em hostname -I
+func (p *Profile) Address() string {
+	for _, iface := range p.Interfaces {
+		if ip, _, err := net.ParseCIDR(iface.Address); err == nil {
+			return ip.String()
+		}
+	}
+	return "127.0.1.1"
+}
+
+// LoadAverage varia devagar em torno da carga dos processos do perfil
+func (p *Profile) LoadAverage() [3]float64 {
+	base := 0.02 * float64(max(p.CPU.Cores, 1))
+	for _, process := range p.Processes {
+		base += process.CPU / 100
+	}
+	t := float64(time.Now().Unix())
+	return [3]float64{
+		base * (1 + 0.6*math.Abs(math.Sin(t/97))),
+		base * (1 + 0.3*math.Abs(math.Sin(t/431))),
+		base * (1 + 0.1*math.Abs(math.Sin(t/1303))),
+	}
+}
+
+// UptimeReport é a saída de uptime, também usada na primeira linha do w
+func (p *Profile) UptimeReport(users int) string {
+	now := time.Now()
+	up := now.Sub(p.BootTime())
+	days, hours, minutes := int(up.Hours())/24, int(up.Hours())%24, int(up.Minutes())%60
+
+	var b strings.Builder
+	fmt.Fprintf(&b, " %s up ", now.Format("15:04:05"))
+	switch {
+	case days == 1:
+		b.WriteString("1 day, ")
+	case days > 1:
+		fmt.Fprintf(&b, "%d days, ", days)
+	}
+	if hours > 0 {
+		fmt.Fprintf(&b, "%2d:%02d, ", hours, minutes)
+	} else {
+		fmt.Fprintf(&b, "%d min, ", minutes)
+	}
+	label := "users"
+	if users == 1 {
+		label = "user"
+	}
+	load := p.LoadAverage()
+	fmt.Fprintf(&b, " %d %s,  load average: %.2f, %.2f, %.2f", users, label, load[0], load[1], load[2])
+	return b.String()
+}
+
+// ProcUptime é o conteúdo de /proc/uptime: segundos ligado e segundos ociosos somando as CPUs
+func (p *Profile) ProcUptime() string {
+	up := time.Since(p.BootTime()).Seconds()
+	return fmt.Sprintf("%.2f %.2f", up, up*float64(max(p.CPU.Cores, 1))*0.97)
+}
+
+// ProcLoadAvg é o conteúdo de /proc/loadavg
+func (p *Profile) ProcLoadAvg() string {
+	load := p.LoadAverage()
+	last := 0
+	for _, process := range p.Processes {
+		last = max(last, process.PID)
+	}
+	return fmt.Sprintf("%.2f %.2f %.2f 1/%d %d", load[0], load[1], load[2], len(p.Processes)+97, last+4211)
+}
+
+// memory retorna os campos do free em KiB
+func (p *Profile) memory() (total, used, free, shared, cache, available int) {
+	total = p.Memory.TotalMB * 1024
+	used = min(p.Memory.UsedMB*1024, total)
+	cache = (total - used) * 3 / 10
+	free = total - used - cache
+	shared = total / 600
+	available = free + cache*9/10
+	return
+}
+
+// Free imita o free com -b, -k (padrão), -m, -g e -h
+func (p *Profile) Free(args []string) string {
+	unit := func(kib int) string { return fmt.Sprint(kib) }
+	for _, arg := range args {
+		switch arg {
+		case "-b", "--bytes":
+			unit = func(kib int) string { return fmt.Sprint(kib * 1024) }
+		case "-m", "--mebi":
+			unit = func(kib int) string { return fmt.Sprint(kib / 1024) }
+		case "-g", "--gibi":
+			unit = func(kib int) string { return fmt.Sprint(kib / 1024 / 1024) }
+		case "-h", "--human":
+			unit = humanFree
+		}
+	}
+
+	total, used, free, shared, cache, available := p.memory()
+	swap := p.Memory.SwapMB * 1024
+	return fmt.Sprintf("%-8s%12s%12s%12s%12s%12s%12s\n", "", "total", "used", "free", "shared", "buff/cache", "available") +
+		fmt.Sprintf("%-8s%12s%12s%12s%12s%12s%12s\n", "Mem:", unit(total), unit(used), unit(free), unit(shared), unit(cache), unit(available)) +
+		fmt.Sprintf("%-8s%12s%12s%12s", "Swap:", unit(swap), unit(0), unit(swap))
+}
+
+// humanFree formata KiB como o free -h (Ki, Mi, Gi)
+func humanFree(kib int) string {
+	if kib == 0 {
+		return "0B"
+	}
+	value, suffix := float64(kib), "Ki"
+	for _, next := range []string{"Mi", "Gi", "Ti"} {
+		if value < 1024 {
+			break
+		}
+		value, suffix = value/1024, next
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%s", value, suffix)
+	}
+	return fmt.Sprintf("%.0f%s", value, suffix)
+}
+
+// MemInfo é o conteúdo de /proc/meminfo
+func (p *Profile) MemInfo() string {
+	total, used, free, shared, cache, available := p.memory()
+	swap := p.Memory.SwapMB * 1024
+	fields := []struct {
+		name  string
+		value int
+	}{
+		{"MemTotal", total}, {"MemFree", free}, {"MemAvailable", available},
+		{"Buffers", cache / 16}, {"Cached", cache - cache/16}, {"SwapCached", 0},
+		{"Active", used * 3 / 4}, {"Inactive", used/4 + cache/2},
+		{"SwapTotal", swap}, {"SwapFree", swap}, {"Dirty", 148}, {"Writeback", 0},
+		{"AnonPages", used / 2}, {"Mapped", cache / 5}, {"Shmem", shared},
+		{"Slab", total / 40}, {"PageTables", total / 400}, {"CommitLimit", total/2 + swap},
+		{"VmallocTotal", 34359738367}, {"HugePages_Total", 0}, {"Hugepagesize", 2048},
+	}
+	lines := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if strings.HasPrefix(field.name, "HugePages_") {
+			lines = append(lines, fmt.Sprintf("%-16s%8d", field.name+":", field.value))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-16s%8d kB", field.name+":", field.value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// filesystem é uma linha do df em KiB
+type filesystem struct {
+	device, mount    string
+	size, used, free int
+}
+
+func (p *Profile) filesystems() []filesystem {
+	gib := func(gb float64) int { return int(gb * 1024 * 1024) }
+	memory := p.Memory.TotalMB * 1024
+	var list []filesystem
+	if p.OS.PackageManager == "dpkg" {
+		list = append(list, filesystem{device: "udev", mount: "/dev", size: memory / 2, free: memory / 2})
+	}
+	list = append(list, filesystem{device: "tmpfs", mount: "/run", size: memory / 10, used: 1180, free: memory/10 - 1180})
+	for _, disk := range p.Disks {
+		size, used := gib(disk.SizeGB), gib(disk.UsedGB)
+		list = append(list, filesystem{device: disk.Device, mount: disk.Mount, size: size, used: used, free: max(size-used, 0)})
+	}
+	list = append(list, filesystem{device: "tmpfs", mount: "/dev/shm", size: memory / 2, free: memory / 2})
+	return list
+}
+
+// DF imita o df, em blocos de 1K ou com -h
+func (p *Profile) DF(args []string) string {
+	human := false
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "h") || arg == "--human-readable" {
+			human = true
+		}
+	}
+
+	list := p.filesystems()
+	width := 14
+	for _, fs := range list {
+		width = max(width, len(fs.device))
+	}
+	percent := func(fs filesystem) string {
+		if fs.used+fs.free == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d%%", (fs.used*100+fs.used+fs.free-1)/(fs.used+fs.free))
+	}
+
+	var b strings.Builder
+	if human {
+		fmt.Fprintf(&b, "%-*s %5s %5s %5s %4s %s", width, "Filesystem", "Size", "Used", "Avail", "Use%", "Mounted on")
+		for _, fs := range list {
+			fmt.Fprintf(&b, "\n%-*s %5s %5s %5s %4s %s", width, fs.device, humanDF(fs.size), humanDF(fs.used), humanDF(fs.free), percent(fs), fs.mount)
+		}
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%-*s %10s %9s %9s %4s %s", width, "Filesystem", "1K-blocks", "Used", "Available", "Use%", "Mounted on")
+	for _, fs := range list {
+		fmt.Fprintf(&b, "\n%-*s %10d %9d %9d %4s %s", width, fs.device, fs.size, fs.used, fs.free, percent(fs), fs.mount)
+	}
+	return b.String()
+}
+
+// humanDF formata KiB como o df -h (K, M, G, T)
+func humanDF(kib int) string {
+	if kib == 0 {
+		return "0"
+	}
+	value, suffix := float64(kib), "K"
+	for _, next := range []string{"M", "G", "T"} {
+		if value < 1024 {
+			break
+		}
+		value, suffix = value/1024, next
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%s", math.Ceil(value*10)/10, suffix)
+	}
+	return fmt.Sprintf("%.0f%s", math.Ceil(value), suffix)
+}
+
+// x86 diz se o perfil é de um processador Intel/AMD
+func (p *Profile) x86() bool {
+	return p.Kernel.Machine == "x86_64" || p.Kernel.Machine == "i686"
+}
+
+// LSCPU é a saída de lscpu
+func (p *Profile) LSCPU() string {
+	cores := max(p.CPU.Cores, 1)
+	type row struct{ name, value string }
+	rows := []row{{"Architecture", p.Kernel.Machine}}
+	if p.x86() {
+		rows = append(rows, row{"CPU op-mode(s)", "32-bit, 64-bit"})
+	}
+	rows = append(rows,
+		row{"Byte Order", "Little Endian"},
+		row{"CPU(s)", fmt.Sprint(cores)},
+		row{"On-line CPU(s) list", fmt.Sprintf("0-%d", cores-1)},
+		row{"Thread(s) per core", "1"},
+		row{"Core(s) per socket", fmt.Sprint(cores)},
+		row{"Socket(s)", "1"},
+		row{"Vendor ID", p.CPU.Vendor},
+	)
+	if p.x86() {
+		rows = append(rows,
+			row{"CPU family", fmt.Sprint(p.CPU.Family)},
+			row{"Model", fmt.Sprint(p.CPU.ModelID)},
+			row{"Model name", p.CPU.Model},
+			row{"Stepping", fmt.Sprint(p.CPU.Stepping)},
+			row{"CPU MHz", fmt.Sprintf("%.3f", p.CPU.MHz)},
+			row{"BogoMIPS", fmt.Sprintf("%.2f", p.CPU.BogoMIPS)},
+			row{"Hypervisor vendor", "KVM"},
+			row{"Virtualization type", "full"},
+		)
+		if p.CPU.CacheKB > 0 {
+			rows = append(rows, row{"L3 cache", fmt.Sprintf("%dK", p.CPU.CacheKB)})
+		}
+	} else {
+		rows = append(rows,
+			row{"Model", fmt.Sprint(p.CPU.Stepping)},
+			row{"Model name", p.CPU.Model},
+			row{"Stepping", fmt.Sprintf("r0p%d", p.CPU.Stepping)},
+			row{"CPU max MHz", fmt.Sprintf("%.4f", p.CPU.MHz)},
+			row{"CPU min MHz", fmt.Sprintf("%.4f", p.CPU.MHz/2.5)},
+			row{"BogoMIPS", fmt.Sprintf("%.2f", p.CPU.BogoMIPS)},
+		)
+	}
+	rows = append(rows, row{"Flags", p.CPU.Flags})
+
+	lines := make([]string, 0, len(rows))
+	for _, r := range rows {
+		lines = append(lines, fmt.Sprintf("%-21s%s", r.name+":", r.value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CPUInfo é o conteúdo de /proc/cpuinfo, um bloco por núcleo
+func (p *Profile) CPUInfo() string {
+	cores := max(p.CPU.Cores, 1)
+	blocks := make([]string, 0, cores)
+	for i := 0; i < cores; i++ {
+		if !p.x86() {
+			blocks = append(blocks, fmt.Sprintf("processor\t: %d\nmodel name\t: %s\nBogoMIPS\t: %.2f\nFeatures\t: %s\n"+
+				"CPU implementer\t: 0x41\nCPU architecture: %d\nCPU variant\t: 0x0\nCPU part\t: 0x%03x\nCPU revision\t: %d",
+				i, p.CPU.Model, p.CPU.BogoMIPS, p.CPU.Flags, p.CPU.Family, p.CPU.ModelID, p.CPU.Stepping))
+			continue
+		}
+		blocks = append(blocks, fmt.Sprintf("processor\t: %[1]d\nvendor_id\t: %[2]s\ncpu family\t: %[3]d\nmodel\t\t: %[4]d\nmodel name\t: %[5]s\n"+
+			"stepping\t: %[6]d\nmicrocode\t: 0x1\ncpu MHz\t\t: %.3[7]f\ncache size\t: %[8]d KB\nphysical id\t: 0\nsiblings\t: %[9]d\n"+
+			"core id\t\t: %[1]d\ncpu cores\t: %[9]d\napicid\t\t: %[1]d\ninitial apicid\t: %[1]d\nfpu\t\t: yes\nfpu_exception\t: yes\n"+
+			"cpuid level\t: 13\nwp\t\t: yes\nflags\t\t: %[10]s\nbogomips\t: %.2[11]f\nclflush size\t: 64\ncache_alignment\t: 64\n"+
+			"address sizes\t: 46 bits physical, 48 bits virtual\npower management:",
+			i, p.CPU.Vendor, p.CPU.Family, p.CPU.ModelID, p.CPU.Model, p.CPU.Stepping, p.CPU.MHz, p.CPU.CacheKB, cores, p.CPU.Flags, p.CPU.BogoMIPS))
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// History são logins antigos do primeiro usuário comum, coerentes com o uptime, para last e "Last login"
+func (p *Profile) History() []Login {
+	user := "root"
+	for _, candidate := range p.Users {
+		if candidate.UID >= 1000 && candidate.UID < 65534 {
+			user = candidate.Name
+			break
+		}
+	}
+	from := "10.0.0.23"
+	if ip := net.ParseIP(p.Address()).To4(); ip != nil {
+		from = net.IPv4(ip[0], ip[1], ip[2], 23).String()
+	}
+
+	var logins []Login
+	for i, back := range []float64{0.62, 0.31, 0.04} {
+		at := startTime.Add(-time.Duration(float64(p.Uptime) * back)).Truncate(time.Minute)
+		logins = append(logins, Login{User: user, TTY: fmt.Sprintf("pts/%d", i%2), From: from, At: at, Until: at.Add(time.Duration(17+i*23) * time.Minute)})
+	}
+	return logins
+}
+
+// LastLogin é o login anterior mais recente do usuário
+func (p *Profile) LastLogin(user string) (Login, bool) {
+	var last Login
+	for _, login := range p.History() {
+		if login.User == user && login.At.After(last.At) {
+			last = login
+		}
+	}
+	return last, !last.At.IsZero()
+}
+
+// W é a saída de w para as sessões abertas
+func (p *Profile) W(logins []Login) string {
+	const row = "\n%-8.8s %-8.8s %-16.16s %-8s %-6s %-6s %-5s %s"
+	var b strings.Builder
+	b.WriteString(p.UptimeReport(len(logins)))
+	fmt.Fprintf(&b, row, "USER", "TTY", "FROM", "LOGIN@", "IDLE", "JCPU", "PCPU", "WHAT")
+	for _, login := range logins {
+		fmt.Fprintf(&b, row, login.User, login.TTY, login.From, loginAt(login.At), "0.00s", "0.02s", "0.00s", login.What)
+	}
+	return b.String()
+}
+
+// loginAt formata o LOGIN@ do w: hora para hoje, dia da semana na última semana, data antes disso
+func loginAt(t time.Time) string {
+	switch since := time.Since(t); {
+	case since < 24*time.Hour && t.Day() == time.Now().Day():
+		return t.Format("15:04")
+	case since < 7*24*time.Hour:
+		return t.Format("Mon15")
+	default:
+		return t.Format("02Jan06")
+	}
+}
+
+// Who é a saída de who
+func (p *Profile) Who(logins []Login) string {
+	lines := make([]string, 0, len(logins))
+	for _, login := range logins {
+		lines = append(lines, fmt.Sprintf("%-8s %-12s %s (%s)", login.User, login.TTY, login.At.Format("2006-01-02 15:04"), login.From))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Last é a saída de last: sessões, o boot atual e o início do wtmp
+func (p *Profile) Last(logins []Login) string {
+	sorted := append([]Login(nil), logins...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].At.After(sorted[j].At) })
+
+	var b strings.Builder
+	for _, login := range sorted {
+		fmt.Fprintf(&b, "%-8.8s %-12.12s %-16.16s %s", login.User, login.TTY, login.From, login.At.Format("Mon Jan _2 15:04"))
+		if login.Until.IsZero() {
+			b.WriteString("   still logged in\n")
+			continue
+		}
+		length := login.Until.Sub(login.At)
+		duration := fmt.Sprintf("(%02d:%02d)", int(length.Hours())%24, int(length.Minutes())%60)
+		if days := int(length.Hours()) / 24; days > 0 {
+			duration = fmt.Sprintf("(%d+%02d:%02d)", days, int(length.Hours())%24, int(length.Minutes())%60)
+		}
+		fmt.Fprintf(&b, " - %s  %s\n", login.Until.Format("15:04"), duration)
+	}
+	boot := p.BootTime()
+	fmt.Fprintf(&b, "%-8s %-12s %-16.16s %s   still running\n\n", "reboot", "system boot", p.Kernel.Release, boot.Format("Mon Jan _2 15:04"))
+	fmt.Fprintf(&b, "wtmp begins %s", boot.Format("Mon Jan _2 15:04:05 2006"))
+	return b.String()
+}
+
+// comm é o nome curto do processo (coluna CMD do ps -e e Program do netstat)
+func (process Process) comm() string {
+	command := process.Command
+	if strings.HasPrefix(command, "[") {
+		return strings.Trim(command, "[]")
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return path.Base(strings.TrimRight(strings.TrimLeft(fields[0], "-@"), ":"))
+}
+
+func (process Process) ppid() int {
+	if process.PPID != 0 || process.PID <= 2 {
+		return process.PPID
+	}
+	return 1
+}
+
+// cpuTime converte o TIME do ps aux ("271:12") para hh:mm:ss
+func (process Process) cpuTime() string {
+	var minutes, seconds int
+	fmt.Sscanf(process.Time, "%d:%d", &minutes, &seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", minutes/60, minutes%60, seconds)
+}
+
+// PS imita o ps: sem opções lista os processos do terminal; aux, -ef e -e/-A/ax listam todos.
+// session são os processos da sessão do atacante, inclusive o próprio ps
+func (p *Profile) PS(args []string, session []Process) string {
+	options := strings.ReplaceAll(strings.Join(args, ""), "-", "")
+	all := append(append([]Process(nil), p.Processes...), session...)
+	boot := p.BootTime()
+	start := func(process Process) time.Time {
+		if process.Start.IsZero() {
+			return boot
+		}
+		return process.Start
+	}
+
+	var b strings.Builder
+	switch {
+	case strings.Contains(options, "u"):
+		b.WriteString("USER         PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND")
+		for _, process := range all {
+			fmt.Fprintf(&b, "\n%-8.8s %7d %4.1f %4.1f %6d %5d %-8s %-4s %5s %6s %s",
+				process.User, process.PID, process.CPU, process.Mem, process.VSZ, process.RSS, process.TTY, process.Stat, psStart(start(process)), process.Time, process.Command)
+		}
+	case strings.Contains(options, "f") && strings.ContainsAny(options, "eA"):
+		b.WriteString("UID          PID    PPID  C STIME TTY          TIME CMD")
+		for _, process := range all {
+			fmt.Fprintf(&b, "\n%-8.8s %7d %7d %2d %5s %-8s %8s %s",
+				process.User, process.PID, process.ppid(), int(process.CPU), psStart(start(process)), process.TTY, process.cpuTime(), process.Command)
+		}
+	case strings.ContainsAny(options, "eAax"):
+		b.WriteString("    PID TTY          TIME CMD")
+		for _, process := range all {
+			fmt.Fprintf(&b, "\n%7d %-8s %8s %s", process.PID, process.TTY, process.cpuTime(), process.comm())
+		}
+	default:
+		b.WriteString("    PID TTY          TIME CMD")
+		for _, process := range session {
+			if process.TTY != "?" {
+				fmt.Fprintf(&b, "\n%7d %-8s %8s %s", process.PID, process.TTY, process.cpuTime(), process.comm())
+			}
+		}
+	}
+	return b.String()
+}
+
+// Sockets são os sockets em escuta declarados nos processos do perfil
+func (p *Profile) Sockets() []Socket {
+	var sockets []Socket
+	for _, process := range p.Processes {
+		for _, listen := range process.Listen {
+			proto, local, found := strings.Cut(listen, " ")
+			if !found {
+				continue
+			}
+			socket := Socket{Proto: proto, Local: local, Remote: "0.0.0.0:*", PID: process.PID, Program: process.comm()}
+			if strings.HasSuffix(proto, "6") {
+				socket.Remote = ":::*"
+			}
+			if strings.HasPrefix(proto, "tcp") {
+				socket.State = "LISTEN"
+			}
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets
+}
+
+// socketFilter lê as flags comuns ao netstat e ao ss (-t, -u, -l, -a, -p)
+func socketFilter(args []string) (tcp, udp, listening, established, programs bool) {
+	flags := ""
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") {
+			flags += arg[1:]
+		}
+	}
+	tcp, udp = strings.Contains(flags, "t"), strings.Contains(flags, "u")
+	if !tcp && !udp {
+		tcp, udp = true, true
+	}
+	all := strings.Contains(flags, "a")
+	listening = all || strings.Contains(flags, "l")
+	established = all || !strings.Contains(flags, "l")
+	return tcp, udp, listening, established, strings.Contains(flags, "p")
+}
+
+func (p *Profile) selectSockets(args []string, extra []Socket) ([]Socket, bool) {
+	tcp, udp, listening, established, programs := socketFilter(args)
+	var selected []Socket
+	for _, socket := range append(p.Sockets(), extra...) {
+		isTCP := strings.HasPrefix(socket.Proto, "tcp")
+		if isTCP && !tcp || !isTCP && !udp {
+			continue
+		}
+		listener := socket.State == "LISTEN" || socket.State == ""
+		if listener && !listening || !listener && !established {
+			continue
+		}
+		selected = append(selected, socket)
+	}
+	return selected, programs
+}
+
+// Netstat imita o netstat -tulnp/-antp; sem root os PIDs dos outros usuários aparecem como "-"
+func (p *Profile) Netstat(args []string, extra []Socket, root bool) string {
+	sockets, programs := p.selectSockets(args, extra)
+	_, _, listening, established, _ := socketFilter(args)
+
+	var b strings.Builder
+	if programs && !root {
+		b.WriteString("(Not all processes could be identified, non-owned process info\n will not be shown, you would have to be root to see it all.)\n")
+	}
+	switch {
+	case listening && established:
+		b.WriteString("Active Internet connections (servers and established)\n")
+	case listening:
+		b.WriteString("Active Internet connections (only servers)\n")
+	default:
+		b.WriteString("Active Internet connections (w/o servers)\n")
+	}
+	b.WriteString("Proto Recv-Q Send-Q Local Address           Foreign Address         State      ")
+	if programs {
+		b.WriteString(" PID/Program name")
+	}
+	for _, socket := range sockets {
+		fmt.Fprintf(&b, "\n%-5s %6d %6d %-23s %-23s %-11s", socket.Proto, 0, 0, socket.Local, socket.Remote, socket.State)
+		if programs {
+			if root {
+				fmt.Fprintf(&b, " %d/%s", socket.PID, socket.Program)
+			} else {
+				b.WriteString(" -")
+			}
+		}
+	}
+	return b.String()
+}
+
+// SS imita o ss -tulnp/-antp
+func (p *Profile) SS(args []string, extra []Socket, root bool) string {
+	sockets, programs := p.selectSockets(args, extra)
+	address := func(addr string) string {
+		if strings.HasPrefix(addr, ":::") {
+			return "[::]:" + addr[3:]
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return addr
+		}
+		switch host {
+		case "0.0.0.0":
+			host = "*"
+		case "::":
+			host = "[::]"
+		}
+		return host + ":" + port
+	}
+
+	var b strings.Builder
+	b.WriteString("Netid State  Recv-Q Send-Q Local Address:Port   Peer Address:Port Process")
+	for _, socket := range sockets {
+		netid := strings.TrimSuffix(socket.Proto, "6")
+		state := map[string]string{"LISTEN": "LISTEN", "ESTABLISHED": "ESTAB", "": "UNCONN"}[socket.State]
+		fmt.Fprintf(&b, "\n%-5s %-6s %-6d %-6d %-20s %-17s", netid, state, 0, 0, address(socket.Local), address(socket.Remote))
+		if programs && root {
+			fmt.Fprintf(&b, " users:((%q,pid=%d,fd=3))", socket.Program, socket.PID)
+		}
+	}
+	return b.String()
+}
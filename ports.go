@@ -1,31 +1,71 @@
 package firewall
 
 import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"myhoneypot/metrics"
 )
 
+// ServiceBanner é o serviço genérico: captura o primeiro payload, classifica o protocolo e responde com o banner da porta
+const ServiceBanner = "banner"
+
 // Port representa uma porta de serviço exposta
 type Port struct {
-	PortNumber  int    // Número da porta
-	Protocol    string // Protocolo associado (ex: TCP, UDP)
-	ServiceName string // Nome do serviço (ex: SSH, FTP)
-	IsOpen      bool   // Indica se a porta está aberta ou fechada
+	PortNumber  int       `json:"port"`                 // Número da porta
+	Protocol    string    `json:"protocol"`             // Protocolo associado (ex: TCP, UDP)
+	ServiceName string    `json:"service"`              // Nome do serviço (ex: ssh, ftp, banner)
+	Banner      string    `json:"banner,omitempty"`     // Banner enviado pelo serviço genérico
+	IsOpen      bool      `json:"open"`                 // Indica se a porta está escutando
+	OpenedAt    time.Time `json:"opened_at"`            // Quando o listener foi aberto
+	Connections int64     `json:"connections"`          // Conexões (ou pacotes, no UDP) desde a abertura
+	Active      int       `json:"active"`               // Conexões em andamento
+	LastError   string    `json:"last_error,omitempty"` // Último erro de bind/accept
 }
 
-// PortManager gerencia as portas expostas e seu status
+// ListenerConfig descreve um grupo de portas e o serviço que responde nelas
+type ListenerConfig struct {
+	Ports    string `yaml:"ports" json:"ports"`       // "8080", "8000-8010" ou "21-23,80,8080"
+	Protocol string `yaml:"protocol" json:"protocol"` // tcp (padrão) ou udp
+	Service  string `yaml:"service" json:"service"`   // TCP: ssh, telnet, ftp ou banner; UDP: dns, ntp, ssdp, snmp, memcached, sip, tftp ou banner
+	Banner   string `yaml:"banner" json:"banner"`     // Banner do serviço genérico (TCP)
+}
+
+// ServiceHandler atende uma conexão já admitida; é responsável por fechá-la
+type ServiceHandler func(conn net.Conn)
+
+// AdmitFunc decide no accept o destino da conexão: ActionAllow, ActionDeny ou ActionTarpit
+type AdmitFunc func(conn net.Conn, protocol string) string
+
+// PortManager gerencia os listeners expostos e seu status
 type PortManager struct {
-	ports           map[int]*Port          // Map de portas e seus status
-	mutex           sync.RWMutex           // Para controle de concorrência
-	openPorts       map[int]*Port          // Portas abertas
-	closedPorts     map[int]*Port          // Portas fechadas
-	allowedIPs      map[string]bool        // IPs permitidos para acessar as portas
-	ipLock          sync.RWMutex           // Para controle de concorrência em IPs
-	logger          *log.Logger            // Logger para registro das operações
-	maxConnAttempts int                    // Tentativas máximas de conexão
+	ports          map[int]*Port             // Map de portas e seus status
+	listeners      map[int]net.Listener      // Listeners abertos
+	udpPorts       map[int]*Port             // Portas UDP e seus status
+	packetConns    map[int]net.PacketConn    // Sockets UDP abertos
+	udpConfig      UDPConfig                 // Limites dos responders UDP
+	services       map[string]ServiceHandler // Emuladores disponíveis por nome
+	mutex          sync.RWMutex              // Para controle de concorrência
+	bindAddr       string                    // Endereço local dos listeners ("" = todas as interfaces)
+	admit          AdmitFunc                 // Decisão de accept; nil usa rules e guard
+	tarpit         *Tarpit                   // Destino das conexões com veredito tarpit
+	recorder       Recorder                  // Destino dos eventos do serviço genérico
+	catchAllConfig CatchAllConfig            // Limites de captura do serviço genérico
+	rules          *RuleEngine               // Regras allow/deny por CIDR, porta e protocolo
+	guard          *Guard                    // Limites de conexão por IP e por rede; criado no primeiro defaultAdmit
+	guardOnce      sync.Once
+	logger         *log.Logger // Logger para registro das operações
 }
 
 // Nova instância do PortManager
@@ -33,48 +73,225 @@ func NewPortManager() *PortManager {
 	// Logger configurado
 	logger := log.New(log.Writer(), "PORT MANAGER: ", log.LstdFlags|log.Lshortfile)
 
-	// Inicializando as portas do honeypot
-	ports := map[int]*Port{
-		22:  {PortNumber: 22, Protocol: "TCP", ServiceName: "SSH", IsOpen: true},
-		23:  {PortNumber: 23, Protocol: "TCP", ServiceName: "Telnet", IsOpen: true},
-		21:  {PortNumber: 21, Protocol: "TCP", ServiceName: "FTP", IsOpen: true},
-		80:  {PortNumber: 80, Protocol: "TCP", ServiceName: "HTTP", IsOpen: true},
-		443: {PortNumber: 443, Protocol: "TCP", ServiceName: "HTTPS", IsOpen: false},
-	}
+	rules, _ := NewRuleEngine(nil)
 
 	return &PortManager{
-		ports:       ports,
-		openPorts:   make(map[int]*Port),
-		closedPorts: make(map[int]*Port),
-		allowedIPs:  make(map[string]bool),
+		ports:       make(map[int]*Port),
+		listeners:   make(map[int]net.Listener),
+		udpPorts:    make(map[int]*Port),
+		packetConns: make(map[int]net.PacketConn),
+		services:    make(map[string]ServiceHandler),
+		rules:       rules,
 		logger:      logger,
 	}
 }
 
-// Adiciona uma porta à lista de portas abertas
-func (pm *PortManager) openPort(portNumber int) {
+// SetBindAddress define o endereço local dos próximos listeners
+func (pm *PortManager) SetBindAddress(addr string) {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
+	pm.bindAddr = addr
+}
 
-	port, exists := pm.ports[portNumber]
-	if exists && !port.IsOpen {
-		port.IsOpen = true
-		pm.openPorts[portNumber] = port
-		pm.logger.Printf("Porta %d aberta para o serviço %s.\n", portNumber, port.ServiceName)
-	}
+// SetAdmission substitui a decisão de accept padrão; tarpit pode ser nil
+func (pm *PortManager) SetAdmission(admit AdmitFunc, tarpit *Tarpit) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.admit = admit
+	pm.tarpit = tarpit
 }
 
-// Fecha uma porta
-func (pm *PortManager) closePort(portNumber int) {
+// SetRecorder define onde o serviço genérico registra os eventos
+func (pm *PortManager) SetRecorder(recorder Recorder) {
 	pm.mutex.Lock()
 	defer pm.mutex.Unlock()
+	pm.recorder = recorder
+}
+
+// SetRules substitui as regras de acesso aplicadas pelo PortManager (usadas também no UDP)
+func (pm *PortManager) SetRules(rules *RuleEngine) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.rules = rules
+}
+
+// SetCatchAll ajusta a captura do serviço genérico
+func (pm *PortManager) SetCatchAll(config CatchAllConfig) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.catchAllConfig = config
+}
+
+// RegisterService associa um emulador a um nome de serviço
+func (pm *PortManager) RegisterService(name string, handler ServiceHandler) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.services[strings.ToLower(name)] = handler
+}
+
+// Bind abre um listener TCP na porta e passa a atendê-la com o serviço informado
+func (pm *PortManager) Bind(portNumber int, service, banner string) error {
+	if portNumber < 1 || portNumber > 65535 {
+		return fmt.Errorf("porta inválida: %d", portNumber)
+	}
+	service = strings.ToLower(service)
+	if service == "" {
+		service = ServiceBanner
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if _, exists := pm.listeners[portNumber]; exists {
+		return fmt.Errorf("porta %d já está aberta", portNumber)
+	}
+	handler, exists := pm.services[service]
+	if !exists {
+		if service != ServiceBanner {
+			return fmt.Errorf("porta %d: serviço desconhecido %q", portNumber, service)
+		}
+		handler = pm.catchAll(service, banner)
+	}
 
 	port, exists := pm.ports[portNumber]
-	if exists && port.IsOpen {
+	if !exists {
+		port = &Port{PortNumber: portNumber, Protocol: "TCP"}
+		pm.ports[portNumber] = port
+	}
+	port.ServiceName = service
+	port.Banner = banner
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(pm.bindAddr, strconv.Itoa(portNumber)))
+	if err != nil {
 		port.IsOpen = false
-		delete(pm.openPorts, portNumber)
-		pm.closedPorts[portNumber] = port
-		pm.logger.Printf("Porta %d fechada para o serviço %s.\n", portNumber, port.ServiceName)
+		port.LastError = err.Error()
+		return fmt.Errorf("erro ao abrir porta %d: %v", portNumber, err)
+	}
+
+	port.IsOpen = true
+	port.OpenedAt = time.Now()
+	port.Connections = 0
+	port.LastError = ""
+	pm.listeners[portNumber] = listener
+	pm.logger.Printf("Porta %d aberta para o serviço %s.\n", portNumber, service)
+
+	go pm.serve(portNumber, service, listener, handler)
+	return nil
+}
+
+// BindListeners abre todas as portas do grupo; as que falharem não impedem as demais
+func (pm *PortManager) BindListeners(config ListenerConfig) error {
+	ports, err := ParsePortRange(config.Ports)
+	if err != nil {
+		return err
+	}
+
+	udp := false
+	switch strings.ToLower(config.Protocol) {
+	case "", "tcp":
+	case "udp":
+		udp = true
+	default:
+		return fmt.Errorf("protocolo inválido: %s (use tcp ou udp)", config.Protocol)
+	}
+
+	var failed []string
+	for _, portNumber := range ports {
+		bind := func() error { return pm.Bind(portNumber, config.Service, config.Banner) }
+		if udp {
+			bind = func() error { return pm.BindUDP(portNumber, config.Service) }
+		}
+		if err := bind(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d de %d portas falharam: %s", len(failed), len(ports), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Unbind fecha o listener da porta; conexões em andamento seguem até terminar
+func (pm *PortManager) Unbind(portNumber int) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	listener, exists := pm.listeners[portNumber]
+	if !exists {
+		return fmt.Errorf("porta %d não está aberta", portNumber)
+	}
+	delete(pm.listeners, portNumber)
+	pm.ports[portNumber].IsOpen = false
+	pm.logger.Printf("Porta %d fechada para o serviço %s.\n", portNumber, pm.ports[portNumber].ServiceName)
+	return listener.Close()
+}
+
+// Ports retorna o estado atual de todas as portas conhecidas, ordenadas pelo número
+func (pm *PortManager) Ports() []Port {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	ports := make([]Port, 0, len(pm.ports)+len(pm.udpPorts))
+	for _, port := range pm.ports {
+		ports = append(ports, *port)
+	}
+	for _, port := range pm.udpPorts {
+		ports = append(ports, *port)
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].PortNumber != ports[j].PortNumber {
+			return ports[i].PortNumber < ports[j].PortNumber
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+	return ports
+}
+
+// Close fecha todos os listeners TCP e UDP
+func (pm *PortManager) Close() {
+	pm.mutex.RLock()
+	ports := make([]int, 0, len(pm.listeners))
+	for portNumber := range pm.listeners {
+		ports = append(ports, portNumber)
+	}
+	udpPorts := make([]int, 0, len(pm.packetConns))
+	for portNumber := range pm.packetConns {
+		udpPorts = append(udpPorts, portNumber)
+	}
+	pm.mutex.RUnlock()
+
+	for _, portNumber := range ports {
+		pm.Unbind(portNumber)
+	}
+	for _, portNumber := range udpPorts {
+		pm.UnbindUDP(portNumber)
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	if pm.guard != nil {
+		pm.guard.Close()
+	}
+}
+
+// Adiciona uma porta à lista de portas abertas, reaproveitando o serviço já associado
+func (pm *PortManager) openPort(portNumber int) {
+	pm.mutex.RLock()
+	service, banner := ServiceBanner, ""
+	if port, exists := pm.ports[portNumber]; exists {
+		service, banner = port.ServiceName, port.Banner
+	}
+	pm.mutex.RUnlock()
+
+	if err := pm.Bind(portNumber, service, banner); err != nil {
+		pm.logger.Printf("%v\n", err)
+	}
+}
+
+// Fecha uma porta
+func (pm *PortManager) closePort(portNumber int) {
+	if err := pm.Unbind(portNumber); err != nil {
+		pm.logger.Printf("%v\n", err)
 	}
 }
 
@@ -90,85 +307,245 @@ func (pm *PortManager) isPortOpen(portNumber int) bool {
 	return false
 }
 
-// Gerencia a conexão de um IP específico
-func (pm *PortManager) manageConnection(ip string, portNumber int) {
-	pm.ipLock.Lock()
-	defer pm.ipLock.Unlock()
+// serve aceita conexões até o listener ser fechado
+func (pm *PortManager) serve(portNumber int, service string, listener net.Listener, handler ServiceHandler) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			pm.mutex.Lock()
+			pm.ports[portNumber].LastError = err.Error()
+			pm.mutex.Unlock()
+			pm.logger.Printf("Erro no accept da porta %d: %v\n", portNumber, err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go pm.manageConnection(conn, portNumber, service, handler)
+	}
+}
+
+// Gerencia a conexão aceita: decide se vai para o serviço, para o tarpit ou é recusada
+func (pm *PortManager) manageConnection(conn net.Conn, portNumber int, service string, handler ServiceHandler) {
+	pm.mutex.RLock()
+	admit, tarpit := pm.admit, pm.tarpit
+	pm.mutex.RUnlock()
 
-	// Verifica se o IP está na lista de permitidos
-	if !pm.allowedIPs[ip] {
-		pm.logger.Printf("Conexão rejeitada: IP %s não permitido.\n", ip)
-		return
+	var action string
+	if admit != nil {
+		action = admit(conn, service)
+	} else {
+		action = pm.defaultAdmit(conn, portNumber, service)
 	}
 
-	// Verifica o status da porta
-	if !pm.isPortOpen(portNumber) {
-		pm.logger.Printf("Conexão rejeitada: Porta %d está fechada.\n", portNumber)
+	switch action {
+	case ActionDeny:
+		conn.Close()
+		return
+	case ActionTarpit:
+		// Sem vaga no tarpit a conexão é simplesmente fechada
+		if tarpit == nil || !tarpit.Hold(conn, service) {
+			conn.Close()
+		}
 		return
 	}
 
-	// Simula a tentativa de conexão
-	attempts := 0
-	for attempts < pm.maxConnAttempts {
-		attempts++
-		pm.logger.Printf("Tentativa %d de %d para o IP %s na porta %d\n", attempts, pm.maxConnAttempts, ip, portNumber)
+	pm.mutex.Lock()
+	port := pm.ports[portNumber]
+	port.Connections++
+	port.Active++
+	pm.mutex.Unlock()
 
-		// Exemplo de banimento após o máximo de tentativas
-		if attempts >= pm.maxConnAttempts {
-			pm.logger.Printf("IP %s bloqueado por tentativas excessivas.\n", ip)
-			// Aqui você pode banir o IP ou tomar outras ações
-		}
+	defer func() {
+		pm.mutex.Lock()
+		port.Active--
+		pm.mutex.Unlock()
+	}()
+	handler(conn)
+}
+
+// defaultAdmit aplica as regras deny e os limites de conexão quando nenhuma AdmitFunc foi definida
+func (pm *PortManager) defaultAdmit(conn net.Conn, portNumber int, service string) string {
+	ip := conn.RemoteAddr().String()
+	switch pm.rules.Match(ip, portNumber, service).Action {
+	case ActionAllow:
+		return ActionAllow
+	case ActionDeny:
+		pm.logger.Printf("Conexão rejeitada: IP %s não permitido na porta %d.\n", ip, portNumber)
+		return ActionDeny
+	}
+
+	verdict := pm.defaultGuard().CheckConnection(ip, service)
+	if verdict.Action == ActionDeny {
+		pm.logger.Printf("Conexão rejeitada: IP %s na porta %d (%s)\n", ip, portNumber, verdict.Reason)
 	}
+	return verdict.Action
+}
+
+// defaultGuard cria na primeira conexão o Guard padrão (3 conexões por minuto por IP), que só
+// é usado quando nenhuma AdmitFunc foi definida
+func (pm *PortManager) defaultGuard() *Guard {
+	pm.guardOnce.Do(func() {
+		guard, _ := NewGuard(GuardConfig{
+			RateLimits: RateLimitConfig{
+				Connections: RateLimitScope{PerIP: RateLimit{Limit: 3, Window: time.Minute}},
+			},
+		}, DefaultBanStore(), nil)
+		pm.mutex.Lock()
+		pm.guard = guard
+		pm.mutex.Unlock()
+	})
+	return pm.guard
 }
 
 // Bloqueia um IP após múltiplas tentativas falhas
 func (pm *PortManager) blockIP(ip string) {
-	pm.ipLock.Lock()
-	defer pm.ipLock.Unlock()
-
-	if !pm.allowedIPs[ip] {
+	if pm.rules.Match(ip, 0, "").Action == ActionDeny {
 		pm.logger.Printf("IP %s já está bloqueado por múltiplas tentativas.\n", ip)
 		return
 	}
 
-	pm.allowedIPs[ip] = false
+	// Prioridade máxima para sobrepor a regra que liberou o IP
+	err := pm.rules.Add(Rule{Name: "blocked", CIDR: ip, Action: ActionDeny, Priority: math.MaxInt32})
+	if err != nil {
+		pm.logger.Printf("Erro ao bloquear IP %s: %v\n", ip, err)
+		return
+	}
+	metrics.FirewallBans.Inc()
 	pm.logger.Printf("IP %s foi bloqueado após múltiplas tentativas falhas.\n", ip)
 }
 
-// Monitoramento de tráfego
-func (pm *PortManager) monitorTraffic(ip string) {
-	// Exemplo de monitoramento de tráfego
-	for portNumber := range pm.openPorts {
-		go pm.manageConnection(ip, portNumber)
+// ParsePortRange interpreta listas de portas e intervalos: "22", "8000-8010", "21-23,80,8080"
+func ParsePortRange(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			first, last = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		}
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("porta inválida: %q", part)
+		}
+		end, err := strconv.Atoi(last)
+		if err != nil {
+			return nil, fmt.Errorf("porta inválida: %q", part)
+		}
+		if start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("intervalo de portas inválido: %q", part)
+		}
+
+		for portNumber := start; portNumber <= end; portNumber++ {
+			if !seen[portNumber] {
+				seen[portNumber] = true
+				ports = append(ports, portNumber)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("nenhuma porta informada")
 	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// Handler expõe o estado das portas e permite abrir e fechar listeners em tempo de execução:
+//
+//	GET    /ports             lista as portas
+//	POST   /ports             abre as portas de um ListenerConfig (JSON)
+//	DELETE /ports/8000-8010   fecha as portas (?protocol=udp para portas UDP)
+//
+// Com token definido, as requisições precisam de "Authorization: Bearer <token>".
+func (pm *PortManager) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+				http.Error(w, "não autorizado", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		spec := strings.Trim(strings.TrimPrefix(r.URL.Path, "/ports"), "/")
+		switch {
+		case r.Method == http.MethodGet && spec == "":
+			pm.writePorts(w, http.StatusOK)
+		case r.Method == http.MethodPost && spec == "":
+			var config ListenerConfig
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&config); err != nil {
+				http.Error(w, fmt.Sprintf("JSON inválido: %v", err), http.StatusBadRequest)
+				return
+			}
+			if err := pm.BindListeners(config); err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			pm.writePorts(w, http.StatusCreated)
+		case r.Method == http.MethodDelete && spec != "":
+			ports, err := ParsePortRange(spec)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			unbind := pm.Unbind
+			if strings.EqualFold(r.URL.Query().Get("protocol"), "udp") {
+				unbind = pm.UnbindUDP
+			}
+			for _, portNumber := range ports {
+				unbind(portNumber)
+			}
+			pm.writePorts(w, http.StatusOK)
+		default:
+			http.Error(w, "método ou caminho não suportado", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (pm *PortManager) writePorts(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(pm.Ports())
 }
 
-// Inicia o monitoramento de todas as portas abertas
-func (pm *PortManager) startMonitoring() {
-	for ip := range pm.allowedIPs {
-		go pm.monitorTraffic(ip)
+// ServeAPI inicia o endpoint HTTP de gerenciamento das portas
+func (pm *PortManager) ServeAPI(addr, token string) error {
+	mux := http.NewServeMux()
+	handler := pm.Handler(token)
+	mux.Handle("/ports", handler)
+	mux.Handle("/ports/", handler)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
+	log.Printf("[INFO] Port manager API listening on %s", addr)
+	return server.ListenAndServe()
 }
 
 // Exemplo de configuração e uso do PortManager
 func main() {
 	// Criação do PortManager
 	portManager := NewPortManager()
-	portManager.maxConnAttempts = 3 // Definindo o número máximo de tentativas
 
-	// Abertura e fechamento de portas
-	portManager.openPort(80)  // Abrir HTTP
-	portManager.openPort(22)  // Abrir SSH
-	portManager.closePort(443) // Fechar HTTPS
-
-	// Monitorando as conexões
-	portManager.allowedIPs["192.168.0.10"] = true
-	portManager.monitorTraffic("192.168.0.10")
-	portManager.startMonitoring()
+	// Abertura de portas com o serviço genérico
+	portManager.Bind(8080, ServiceBanner, "HTTP/1.1 200 OK\r\nServer: Apache/2.4.41\r\n\r\n")
+	portManager.BindListeners(ListenerConfig{Ports: "2323,5900-5902", Service: ServiceBanner})
 
 	// Bloquear um IP por falhas em tentativas de conexão
 	portManager.blockIP("192.168.0.10")
 
 	// Simulando o fechamento de uma porta
-	portManager.closePort(22)
+	portManager.closePort(5901)
+	for _, port := range portManager.Ports() {
+		fmt.Printf("%d/%s %s aberta=%v\n", port.PortNumber, port.Protocol, port.ServiceName, port.IsOpen)
+	}
+	portManager.Close()
 }
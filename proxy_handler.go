@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	socks5 "github.com/armon/go-socks5"
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+)
+
+// Destino dado a cada pedido feito ao proxy
+const (
+	proxyCanned   = "canned"   // Respondido pelo próprio honeypot
+	proxyRelay    = "relay"    // Encaminhado de verdade (destino na lista relay)
+	proxyRejected = "rejected" // Comando não suportado (BIND, UDP ASSOCIATE, URL relativa)
+)
+
+// ProxyConfig configura o honeypot de proxy aberto (SOCKS4/4a, SOCKS5 e HTTP CONNECT)
+type ProxyConfig struct {
+	Relay    []string      `yaml:"relay"`     // Destinos encaminhados de verdade: host, host:porta, *.dominio ou CIDR (vazio = nunca encaminha)
+	MaxBytes int           `yaml:"max_bytes"` // Bytes enviados ao destino guardados no evento (padrão 4096)
+	Timeout  time.Duration `yaml:"timeout"`   // Tempo máximo de cada sessão (padrão 30s)
+}
+
+// ProxyHoneypot finge ser um proxy aberto: registra os destinos pedidos e o que o cliente envia a eles
+type ProxyHoneypot struct {
+	config   ProxyConfig
+	relay    []relayRule
+	recorder firewall.Recorder
+}
+
+// relayRule é uma entrada da lista de destinos encaminhados
+type relayRule struct {
+	network *net.IPNet
+	host    string
+	port    int // 0 = qualquer porta
+}
+
+// NewProxyHoneypot valida a lista de destinos encaminhados
+func NewProxyHoneypot(config ProxyConfig, recorder firewall.Recorder) (*ProxyHoneypot, error) {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 4096
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	p := &ProxyHoneypot{config: config, recorder: recorder}
+	for _, entry := range config.Relay {
+		rule, err := parseRelayRule(entry)
+		if err != nil {
+			return nil, err
+		}
+		p.relay = append(p.relay, rule)
+	}
+	return p, nil
+}
+
+func parseRelayRule(entry string) (relayRule, error) {
+	if _, network, err := net.ParseCIDR(entry); err == nil {
+		return relayRule{network: network}, nil
+	}
+
+	rule := relayRule{host: strings.ToLower(entry)}
+	if host, port, err := net.SplitHostPort(entry); err == nil {
+		number, err := strconv.Atoi(port)
+		if err != nil || number <= 0 || number > 65535 {
+			return rule, fmt.Errorf("destino de relay inválido: %s", entry)
+		}
+		rule.host, rule.port = strings.ToLower(host), number
+	}
+	if rule.host == "" {
+		return rule, fmt.Errorf("destino de relay inválido: %s", entry)
+	}
+	return rule, nil
+}
+
+func (r relayRule) match(host string, port int) bool {
+	if r.port != 0 && r.port != port {
+		return false
+	}
+	if r.network != nil {
+		ip := net.ParseIP(host)
+		return ip != nil && r.network.Contains(ip)
+	}
+	host = strings.ToLower(host)
+	if strings.HasPrefix(r.host, "*.") {
+		return strings.HasSuffix(host, r.host[1:])
+	}
+	return host == r.host
+}
+
+func (p *ProxyHoneypot) relayAllowed(host string, port int) bool {
+	for _, rule := range p.relay {
+		if rule.match(host, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleProxyConnection identifica o protocolo pelo primeiro byte: 0x04 SOCKS4, 0x05 SOCKS5, senão HTTP
+func (p *ProxyHoneypot) HandleProxyConnection(conn net.Conn) {
+	defer conn.Close()
+	defer metrics.TrackConnection("proxy", conn)()
+	conn.SetDeadline(time.Now().Add(p.config.Timeout))
+
+	client := &bufferedConn{Conn: conn, reader: bufio.NewReader(conn)}
+	first, err := client.reader.Peek(1)
+	if err != nil {
+		return
+	}
+	switch first[0] {
+	case 0x04:
+		p.serveSOCKS4(client)
+	case 0x05:
+		p.serveSOCKS5(client)
+	default:
+		p.serveHTTPProxy(client)
+	}
+}
+
+// proxySession guarda o que o cliente pediu ao proxy para o evento PROXY_REQUEST
+type proxySession struct {
+	client   net.Conn
+	protocol string // socks4, socks4a, socks5, http-connect ou http
+	command  string
+	username string
+	password string
+}
+
+// Valid aceita qualquer usuário e senha do SOCKS5, guardando-os na sessão e no store de credenciais
+func (s *proxySession) Valid(user, password string) bool {
+	s.username, s.password = user, password
+	auth.RecordAttempt(auth.Attempt{Protocol: s.protocol, Addr: s.client.RemoteAddr().String(), Username: user, Password: password, Success: true})
+	return true
+}
+
+// serveSOCKS4 atende SOCKS4 e SOCKS4a (go-socks5 só implementa a versão 5)
+func (p *ProxyHoneypot) serveSOCKS4(client *bufferedConn) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(client.reader, header); err != nil {
+		return
+	}
+	userID, err := client.reader.ReadString(0)
+	if err != nil {
+		return
+	}
+
+	s := &proxySession{client: client, protocol: "socks4", command: "CONNECT", username: strings.TrimSuffix(userID, "\x00")}
+	host := net.IP(header[4:8]).String()
+	// SOCKS4a: o IP 0.0.0.x indica que o nome do destino vem depois do user id
+	if header[4] == 0 && header[5] == 0 && header[6] == 0 && header[7] != 0 {
+		name, err := client.reader.ReadString(0)
+		if err != nil {
+			return
+		}
+		host = strings.TrimSuffix(name, "\x00")
+		s.protocol = "socks4a"
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(header[2:4]))))
+
+	if header[1] != 0x01 {
+		s.command = "BIND"
+		p.record(s, address, proxyRejected, nil, nil)
+		client.Write([]byte{0x00, 0x5b, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	target, err := p.dial(s, address)
+	if err != nil {
+		client.Write([]byte{0x00, 0x5b, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	client.Write([]byte{0x00, 0x5a, 0, 0, 0, 0, 0, 0})
+	tunnel(client, target)
+}
+
+// serveSOCKS5 usa o go-socks5 com resolução, regras e discagem do honeypot
+func (p *ProxyHoneypot) serveSOCKS5(client *bufferedConn) {
+	s := &proxySession{client: client, protocol: "socks5", command: "CONNECT"}
+	server, err := socks5.New(&socks5.Config{
+		AuthMethods: []socks5.Authenticator{socks5.NoAuthAuthenticator{}, socks5.UserPassAuthenticator{Credentials: s}},
+		Resolver:    lazyResolver{},
+		Rules:       &proxyRules{proxy: p, session: s},
+		Logger:      log.New(io.Discard, "", 0),
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return p.dial(s, address)
+		},
+	})
+	if err != nil {
+		return
+	}
+	server.ServeConn(client)
+}
+
+// lazyResolver não resolve nomes: o destino segue como FQDN até o dial, sem consultas DNS do sensor
+type lazyResolver struct{}
+
+func (lazyResolver) Resolve(ctx context.Context, name string) (context.Context, net.IP, error) {
+	return ctx, nil, nil
+}
+
+// proxyRules libera apenas CONNECT; BIND e UDP ASSOCIATE são registrados e recusados
+type proxyRules struct {
+	proxy   *ProxyHoneypot
+	session *proxySession
+}
+
+func (r *proxyRules) Allow(ctx context.Context, req *socks5.Request) (context.Context, bool) {
+	if req.Command == socks5.ConnectCommand {
+		return ctx, true
+	}
+	r.session.command = "BIND"
+	if req.Command == socks5.AssociateCommand {
+		r.session.command = "UDP ASSOCIATE"
+	}
+	r.proxy.record(r.session, req.DestAddr.Address(), proxyRejected, nil, nil)
+	return ctx, false
+}
+
+// serveHTTPProxy atende CONNECT e requisições com URL absoluta (GET http://destino/...)
+func (p *ProxyHoneypot) serveHTTPProxy(client *bufferedConn) {
+	request, err := http.ReadRequest(client.reader)
+	if err != nil {
+		return
+	}
+	s := &proxySession{client: client, protocol: "http", command: request.Method}
+	s.username, s.password = proxyAuthorization(request)
+	if s.username != "" {
+		auth.RecordAttempt(auth.Attempt{Protocol: "http-proxy", Addr: client.RemoteAddr().String(), Username: s.username, Password: s.password, Client: request.UserAgent(), Success: true})
+	}
+
+	if request.Method == http.MethodConnect {
+		s.protocol = "http-connect"
+		target, err := p.dial(s, request.Host)
+		if err != nil {
+			io.WriteString(client, "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n")
+			return
+		}
+		defer target.Close()
+		io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+		tunnel(client, target)
+		return
+	}
+
+	if !request.URL.IsAbs() {
+		p.record(s, request.Host+request.URL.RequestURI(), proxyRejected, nil, nil)
+		io.WriteString(client, "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		return
+	}
+	address := request.URL.Host
+	if request.URL.Port() == "" {
+		port := "80"
+		if request.URL.Scheme == "https" {
+			port = "443"
+		}
+		address = net.JoinHostPort(request.URL.Hostname(), port)
+	}
+	target, err := p.dial(s, address)
+	if err != nil {
+		io.WriteString(client, "HTTP/1.1 502 Bad Gateway\r\nContent-Length: 0\r\n\r\n")
+		return
+	}
+	defer target.Close()
+	request.Header.Del("Proxy-Authorization")
+	request.Header.Del("Proxy-Connection")
+	request.Close = true
+	request.Write(target)
+	io.Copy(client, target)
+}
+
+// proxyAuthorization extrai as credenciais do cabeçalho Proxy-Authorization (Basic)
+func proxyAuthorization(r *http.Request) (string, string) {
+	value, found := strings.CutPrefix(r.Header.Get("Proxy-Authorization"), "Basic ")
+	if !found {
+		return "", ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", ""
+	}
+	username, password, _ := strings.Cut(string(decoded), ":")
+	return username, password
+}
+
+// dial conecta ao destino pedido: de verdade se estiver na lista relay, senão a um destino simulado.
+// O que o cliente envia é guardado e registrado quando a conexão com o destino é fechada.
+func (p *ProxyHoneypot) dial(s *proxySession, address string) (net.Conn, error) {
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		p.record(s, address, proxyRejected, nil, err)
+		return nil, err
+	}
+	port, _ := strconv.Atoi(portText)
+
+	mode := proxyCanned
+	var target net.Conn
+	if p.relayAllowed(host, port) {
+		mode = proxyRelay
+		target, err = net.DialTimeout("tcp", address, 10*time.Second)
+		if err != nil {
+			p.record(s, address, mode, nil, err)
+			return nil, err
+		}
+	} else {
+		near, far := net.Pipe()
+		go cannedDestination(far, host, port, p.config.Timeout)
+		target = &pipeConn{Conn: near, local: s.client.LocalAddr()}
+	}
+	metrics.ProxyRequests.Inc(s.protocol, mode)
+
+	return &recordingConn{Conn: target, max: p.config.MaxBytes, done: func(data []byte) {
+		p.record(s, address, mode, data, nil)
+	}}, nil
+}
+
+// record registra o pedido ao proxy com os primeiros bytes que o cliente enviou ao destino
+func (p *ProxyHoneypot) record(s *proxySession, address, mode string, data []byte, failure error) {
+	port := 0
+	if tcpAddr, ok := s.client.LocalAddr().(*net.TCPAddr); ok {
+		port = tcpAddr.Port
+	}
+
+	event := fmt.Sprintf("Proxy %s: %s %s (%s, %s, %d bytes)", s.protocol, s.command, address, mode, firewall.SniffProtocol(data), len(data))
+	level := logging.INFO
+	if mode == proxyRelay {
+		level = logging.WARNING
+	}
+	if failure != nil {
+		event = fmt.Sprintf("Proxy %s: %s %s falhou: %v", s.protocol, s.command, address, failure)
+	}
+
+	entry := logging.LogEntry{
+		IP:       s.client.RemoteAddr().String(),
+		Event:    event,
+		Level:    level,
+		Type:     logging.EventProxyRequest,
+		Protocol: s.protocol,
+		Port:     port,
+		Command:  s.command + " " + address,
+		Username: s.username,
+		Password: s.password,
+	}
+	if len(data) > 0 {
+		entry.Payload = hex.EncodeToString(data)
+	}
+	p.recorder.Record(entry)
+}
+
+// cannedDestination faz o papel do destino quando o proxy não encaminha, respondendo como o serviço
+// esperado para que o atacante acredite que o proxy funciona (SMTP para spam, HTTP para os checkers)
+func cannedDestination(conn net.Conn, host string, port int, timeout time.Duration) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	reader := bufio.NewReader(conn)
+
+	switch port {
+	case 25, 587, 2525:
+		cannedSMTP(conn, reader, host)
+		return
+	}
+
+	// Demais destinos: espera o cliente falar; HTTP recebe uma página, o resto só é lido
+	if _, err := reader.Peek(1); err != nil {
+		return
+	}
+	data, _ := reader.Peek(reader.Buffered())
+	if firewall.SniffProtocol(data) != "http" {
+		reader.Discard(len(data))
+		return
+	}
+	request, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+	io.Copy(io.Discard, request.Body)
+	body := fmt.Sprintf("<html><head><title>%s</title></head><body></body></html>\n", host)
+	fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nServer: nginx\r\nDate: %s\r\nContent-Type: text/html; charset=UTF-8\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+		time.Now().UTC().Format(http.TimeFormat), len(body), body)
+}
+
+// cannedSMTP aceita a mensagem inteira (o spam fica no payload do evento) sem entregá-la
+func cannedSMTP(conn net.Conn, reader *bufio.Reader, host string) {
+	fmt.Fprintf(conn, "220 %s ESMTP Postfix\r\n", host)
+	data := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if data {
+			if strings.TrimRight(line, "\r\n") == "." {
+				data = false
+				io.WriteString(conn, "250 2.0.0 Ok: queued\r\n")
+			}
+			continue
+		}
+
+		verb, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			fmt.Fprintf(conn, "250-%s\r\n250-PIPELINING\r\n250-SIZE 10240000\r\n250 8BITMIME\r\n", host)
+		case "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+			io.WriteString(conn, "250 2.0.0 Ok\r\n")
+		case "DATA":
+			data = true
+			io.WriteString(conn, "354 End data with <CR><LF>.<CR><LF>\r\n")
+		case "QUIT":
+			io.WriteString(conn, "221 2.0.0 Bye\r\n")
+			return
+		default:
+			io.WriteString(conn, "502 5.5.2 Error: command not recognized\r\n")
+		}
+	}
+}
+
+// tunnel copia nos dois sentidos até um dos lados encerrar
+func tunnel(client *bufferedConn, target net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(target, client.reader)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, target)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// bufferedConn preserva os bytes já lidos na identificação do protocolo
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// CloseWrite repassa o fim do destino ao cliente; o go-socks5 só encerra quando os dois sentidos terminam
+func (c *bufferedConn) CloseWrite() error {
+	if conn, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return conn.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+// pipeConn é a ponta do destino simulado; o go-socks5 exige um *net.TCPAddr local na resposta
+type pipeConn struct {
+	net.Conn
+	local net.Addr
+}
+
+func (c *pipeConn) LocalAddr() net.Addr {
+	if tcpAddr, ok := c.local.(*net.TCPAddr); ok {
+		return tcpAddr
+	}
+	return &net.TCPAddr{IP: net.IPv4zero}
+}
+
+// recordingConn guarda os primeiros bytes escritos no destino e os entrega a done no Close
+type recordingConn struct {
+	net.Conn
+	max   int
+	done  func([]byte)
+	mutex sync.Mutex
+	data  []byte
+	once  sync.Once
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	if room := c.max - len(c.data); room > 0 {
+		c.data = append(c.data, b[:min(room, len(b))]...)
+	}
+	c.mutex.Unlock()
+	return c.Conn.Write(b)
+}
+
+func (c *recordingConn) Close() error {
+	c.once.Do(func() {
+		c.mutex.Lock()
+		data := c.data
+		c.mutex.Unlock()
+		c.done(data)
+	})
+	return c.Conn.Close()
+}
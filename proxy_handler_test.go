@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+// proxyRequest é o que o cliente envia pelo túnel; o evento guarda só os primeiros maxBytes
+const (
+	proxyRequest = "GET / HTTP/1.1\r\nHost: destino\r\nConnection: close\r\n\r\n"
+	maxBytes     = 16
+)
+
+type channelRecorder chan logging.LogEntry
+
+func (r channelRecorder) Record(entry logging.LogEntry) { r <- entry }
+
+// startTarget sobe o destino local usado no relay: responde "relayed" a uma requisição HTTP
+func startTarget(t *testing.T) (int, *atomic.Int32) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var accepted atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+			go func() {
+				defer conn.Close()
+				if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+					return
+				}
+				io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 7\r\nConnection: close\r\n\r\nrelayed")
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, &accepted
+}
+
+// startProxy sobe o honeypot de proxy num listener local com 127.0.0.1 e localhost na lista relay
+func startProxy(t *testing.T) (string, channelRecorder) {
+	t.Helper()
+	recorder := make(channelRecorder, 8)
+	proxy, err := NewProxyHoneypot(ProxyConfig{Relay: []string{"127.0.0.1", "localhost"}, MaxBytes: maxBytes, Timeout: 5 * time.Second}, recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go proxy.HandleProxyConnection(conn)
+		}
+	}()
+	return listener.Addr().String(), recorder
+}
+
+// Handshakes de cada protocolo até o túnel aberto
+func socks4Handshake(t *testing.T, conn net.Conn, r *bufio.Reader, host string, port int) {
+	request := []byte{0x04, 0x01, 0, 0}
+	binary.BigEndian.PutUint16(request[2:], uint16(port))
+	if ip := net.ParseIP(host); ip != nil {
+		request = append(append(request, ip.To4()...), "bot\x00"...)
+	} else {
+		request = append(append(request, 0, 0, 0, 1), "bot\x00"+host+"\x00"...)
+	}
+	conn.Write(request)
+
+	reply := make([]byte, 8)
+	if _, err := io.ReadFull(r, reply); err != nil || reply[1] != 0x5a {
+		t.Fatalf("resposta SOCKS4 = %x, %v", reply, err)
+	}
+}
+
+func socks5Handshake(username, password string) func(*testing.T, net.Conn, *bufio.Reader, string, int) {
+	return func(t *testing.T, conn net.Conn, r *bufio.Reader, host string, port int) {
+		method := byte(0x00)
+		if username != "" {
+			method = 0x02
+		}
+		conn.Write([]byte{0x05, 0x01, method})
+		reply := make([]byte, 2)
+		if _, err := io.ReadFull(r, reply); err != nil || reply[1] != method {
+			t.Fatalf("método SOCKS5 = %x, %v", reply, err)
+		}
+		if username != "" {
+			conn.Write(append(append([]byte{0x01, byte(len(username))}, username...), append([]byte{byte(len(password))}, password...)...))
+			if _, err := io.ReadFull(r, reply); err != nil || reply[1] != 0x00 {
+				t.Fatalf("autenticação SOCKS5 = %x, %v", reply, err)
+			}
+		}
+
+		request := []byte{0x05, 0x01, 0x00}
+		if ip := net.ParseIP(host).To4(); ip != nil {
+			request = append(append(request, 0x01), ip...)
+		} else {
+			request = append(append(request, 0x03, byte(len(host))), host...)
+		}
+		request = binary.BigEndian.AppendUint16(request, uint16(port))
+		conn.Write(request)
+
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(r, header); err != nil || header[1] != 0x00 {
+			t.Fatalf("resposta SOCKS5 = %x, %v", header, err)
+		}
+		bound := 4 + 2
+		if header[3] == 0x04 {
+			bound = 16 + 2
+		}
+		io.ReadFull(r, make([]byte, bound))
+	}
+}
+
+func connectHandshake(t *testing.T, conn net.Conn, r *bufio.Reader, host string, port int) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	credentials := base64.StdEncoding.EncodeToString([]byte("proxy:secret"))
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\nProxy-Authorization: Basic %s\r\n\r\n", address, address, credentials)
+
+	response, err := http.ReadResponse(r, &http.Request{Method: http.MethodConnect})
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("CONNECT = %v, %v", response, err)
+	}
+}
+
+func TestProxyTunnels(t *testing.T) {
+	targetPort, accepted := startTarget(t)
+
+	tests := []struct {
+		name      string
+		handshake func(*testing.T, net.Conn, *bufio.Reader, string, int)
+		host      string
+		port      int // 0 = porta do destino local
+		protocol  string
+		username  string
+		password  string
+		relay     bool
+	}{
+		{"socks4 relay", socks4Handshake, "127.0.0.1", 0, "socks4", "bot", "", true},
+		{"socks4 canned", socks4Handshake, "198.51.100.7", 80, "socks4", "bot", "", false},
+		{"socks4a relay", socks4Handshake, "localhost", 0, "socks4a", "bot", "", true},
+		{"socks4a canned", socks4Handshake, "example.com", 80, "socks4a", "bot", "", false},
+		{"socks5 relay", socks5Handshake("", ""), "127.0.0.1", 0, "socks5", "", "", true},
+		{"socks5 canned", socks5Handshake("", ""), "example.com", 80, "socks5", "", "", false},
+		{"socks5 userpass relay", socks5Handshake("user", "pass"), "127.0.0.1", 0, "socks5", "user", "pass", true},
+		{"socks5 userpass canned", socks5Handshake("user", "pass"), "example.com", 8080, "socks5", "user", "pass", false},
+		{"http connect relay", connectHandshake, "127.0.0.1", 0, "http-connect", "proxy", "secret", true},
+		{"http connect canned", connectHandshake, "example.com", 443, "http-connect", "proxy", "secret", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.port == 0 {
+				test.port = targetPort
+			}
+			address := net.JoinHostPort(test.host, strconv.Itoa(test.port))
+			before := accepted.Load()
+			proxyAddr, recorder := startProxy(t)
+
+			conn, err := net.Dial("tcp", proxyAddr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			r := bufio.NewReader(conn)
+			test.handshake(t, conn, r, test.host, test.port)
+
+			io.WriteString(conn, proxyRequest)
+			response, err := http.ReadResponse(r, nil)
+			if err != nil {
+				t.Fatalf("resposta pelo túnel: %v", err)
+			}
+			body, _ := io.ReadAll(response.Body)
+			conn.Close()
+
+			relayed := accepted.Load() > before
+			if relayed != test.relay {
+				t.Fatalf("destino local alcançado = %v, esperado %v", relayed, test.relay)
+			}
+			if test.relay && string(body) != "relayed" {
+				t.Fatalf("corpo do relay = %q", body)
+			}
+			if !test.relay && !strings.Contains(string(body), "<title>"+test.host+"</title>") {
+				t.Fatalf("corpo do destino simulado = %q", body)
+			}
+
+			var entry logging.LogEntry
+			select {
+			case entry = <-recorder:
+			case <-time.After(5 * time.Second):
+				t.Fatal("nenhum PROXY_REQUEST registrado")
+			}
+			mode, level := proxyCanned, logging.INFO
+			if test.relay {
+				mode, level = proxyRelay, logging.WARNING
+			}
+			if entry.Type != logging.EventProxyRequest || entry.Protocol != test.protocol || entry.Level != level {
+				t.Errorf("evento = %+v", entry)
+			}
+			if entry.Command != "CONNECT "+address {
+				t.Errorf("Command = %q, esperado %q", entry.Command, "CONNECT "+address)
+			}
+			if !strings.Contains(entry.Event, "("+mode+", http, ") {
+				t.Errorf("Event = %q, esperado modo %s", entry.Event, mode)
+			}
+			if want := hex.EncodeToString([]byte(proxyRequest[:maxBytes])); entry.Payload != want {
+				t.Errorf("Payload = %q, esperado os primeiros %d bytes %q", entry.Payload, maxBytes, want)
+			}
+			if entry.Username != test.username || entry.Password != test.password {
+				t.Errorf("credenciais = %q/%q, esperado %q/%q", entry.Username, entry.Password, test.username, test.password)
+			}
+		})
+	}
+}
+
+func TestProxyCannedSMTP(t *testing.T) {
+	proxyAddr, recorder := startProxy(t)
+	conn, err := net.Dial("tcp", proxyAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	socks4Handshake(t, conn, r, "203.0.113.25", 25)
+
+	for _, step := range []struct{ send, want string }{
+		{"", "220 203.0.113.25 ESMTP"},
+		{"HELO bot\r\n", "250 "},
+		{"MAIL FROM:<a@b>\r\n", "250 "},
+		{"DATA\r\n", "354 "},
+		{"spam\r\n.\r\n", "250 2.0.0 Ok: queued"},
+		{"QUIT\r\n", "221 "},
+	} {
+		io.WriteString(conn, step.send)
+		line, err := r.ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, step.want) {
+			t.Fatalf("depois de %q: %q, %v (esperado %q)", step.send, line, err, step.want)
+		}
+	}
+	conn.Close()
+
+	select {
+	case entry := <-recorder:
+		if entry.Command != "CONNECT 203.0.113.25:25" || !strings.Contains(entry.Event, "(canned, smtp, ") {
+			t.Fatalf("evento = %+v", entry)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nenhum PROXY_REQUEST registrado")
+	}
+}
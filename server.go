@@ -1,56 +1,290 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"myhoneypot/alerting"
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
 	"myhoneypot/internal/handlers"
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
+	"myhoneypot/session"
 	"net"
-	"sync"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
-const (
-	sshPort    = ":2222"
-	telnetPort = ":23"
-	ftpPort    = ":21"
-)
+// connectionGuard decide no accept se a conexão segue para o serviço
+type connectionGuard struct {
+	rules  *firewall.RuleEngine
+	bans   *firewall.BanStore
+	limits *firewall.Guard
+	tarpit *firewall.Tarpit
+	config firewall.TarpitConfig
+	logger *logging.Logger
+}
 
-func startServer(protocol, port string, handler func(net.Conn)) {
-	listener, err := net.Listen("tcp", port)
-	if err != nil {
-		log.Fatalf("[ERROR] Failed to start %s server on port %s: %v", protocol, port, err)
+// admit aplica as regras de acesso e os banimentos; regras allow liberam scanners internos.
+// Retorna firewall.ActionAllow, firewall.ActionDeny ou firewall.ActionTarpit.
+func (g *connectionGuard) admit(conn net.Conn, protocol string) string {
+	addr := conn.RemoteAddr().String()
+	port := 0
+	if tcpAddr, ok := conn.LocalAddr().(*net.TCPAddr); ok {
+		port = tcpAddr.Port
+	}
+
+	reason := ""
+	switch decision := g.rules.Match(addr, port, protocol); decision.Action {
+	case firewall.ActionAllow:
+		return firewall.ActionAllow
+	case firewall.ActionDeny:
+		reason = "regra " + decision.Rule.Name
+	default:
+		// Banimentos valem para todos os serviços, independente de quem os emitiu
+		ban, banned := g.bans.Lookup(addr)
+		if !banned {
+			// O Guard já emite um evento RATE_LIMITED quando a origem passa a ser limitada
+			verdict := g.limits.CheckConnection(addr, strings.ToLower(protocol))
+			if verdict.Action == firewall.ActionDeny && g.config.RateLimited {
+				return firewall.ActionTarpit
+			}
+			return verdict.Action
+		}
+		if g.config.Banned {
+			return firewall.ActionTarpit
+		}
+		reason = "IP banido: " + ban.Reason
 	}
-	defer listener.Close()
-	log.Printf("[INFO] %s honeypot listening on %s", protocol, port)
 
-	for {
-		conn, err := listener.Accept()
+	g.logger.Record(logging.LogEntry{
+		IP:       addr,
+		Event:    fmt.Sprintf("Conexão %s recusada (%s)", protocol, reason),
+		Level:    logging.WARNING,
+		Type:     logging.EventConnection,
+		Protocol: strings.ToLower(protocol),
+		Port:     port,
+	})
+	return firewall.ActionDeny
+}
+
+// setupSinks conecta os destinos externos de eventos configurados
+func setupSinks(logger *logging.Logger, config *HoneypotConfig) error {
+	if config.Logging.Syslog.Enabled {
+		sink, err := logging.NewSyslogSink(config.Logging.Syslog, nil)
 		if err != nil {
-			log.Printf("[ERROR] Connection error on %s: %v", protocol, err)
-			continue
+			return fmt.Errorf("syslog: %v", err)
 		}
-		go handler(conn) // Lidar com a conexão em uma goroutine
+		logger.AddSink(sink)
+		log.Printf("[INFO] Forwarding events to syslog %s (%s, %s)", config.Logging.Syslog.Address, config.Logging.Syslog.Network, config.Logging.Syslog.Format)
 	}
+
+	if config.Alerting.Enabled {
+		engine, err := alerting.NewEngine(&config.Alerting)
+		if err != nil {
+			return fmt.Errorf("alerting: %v", err)
+		}
+		logger.AddSink(engine)
+		log.Printf("[INFO] Alerting enabled with %d rules", len(config.Alerting.Rules))
+	}
+	return nil
 }
 
 func main() {
-	var wg sync.WaitGroup
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "intel":
+			os.Exit(runIntel(os.Args[2:]))
+		case "creds":
+			os.Exit(runCreds(os.Args[2:]))
+		case "users":
+			os.Exit(runUsers(os.Args[2:]))
+		}
+	}
 
-	wg.Add(3)
+	configFile := flag.String("config", configPath, "Configuration file path")
+	exportLogs := flag.String("export-logs", "", "Export all structured logs as JSONL to this file and exit (see 'export -h' for filters)")
+	flag.Parse()
 
-	go func() {
-		defer wg.Done()
-		startServer("SSH", sshPort, handlers.HandleSSH)
-	}()
+	if *exportLogs != "" {
+		os.Exit(runExport([]string{"-config", *configFile, "-output", *exportLogs}))
+	}
 
-	go func() {
-		defer wg.Done()
-		startServer("Telnet", telnetPort, handlers.HandleTelnet)
-	}()
+	config, err := loadHoneypotConfig(*configFile)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to load configuration: %v", err)
+	}
 
-	go func() {
-		defer wg.Done()
-		startServer("FTP", ftpPort, handlers.HandleFTP)
-	}()
+	logger, err := logging.NewLogger(config.Logging.LogFile, config.Database.File)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to start logger: %v", err)
+	}
+	defer logger.Close()
+
+	if err := setupSinks(logger, config); err != nil {
+		log.Fatalf("[ERROR] Failed to configure event sinks: %v", err)
+	}
+
+	backend, err := firewall.NewBackend(config.Firewall.BackendConfig, nil)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to configure firewall backend: %v", err)
+	}
+	firewall.SetDefaultBackend(backend)
+	log.Printf("[INFO] Firewall backend: %s", backend.Name())
+
+	bans, err := firewall.NewBanStore(logger.DB(), backend, firewall.BanStoreConfig{
+		DefaultDuration: time.Duration(config.Security.BanDuration) * time.Second,
+		Persistent:      config.Security.PersistentBan,
+	})
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to open ban store: %v", err)
+	}
+	defer bans.Close()
+
+	rules, err := firewall.NewRuleEngine(config.Firewall.Rules)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid firewall rules: %v", err)
+	}
+	if config.Firewall.ASNDatabase != "" {
+		table, err := firewall.LoadASNTable(config.Firewall.ASNDatabase)
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to load ASN database: %v", err)
+		}
+		rules.SetASNResolver(table)
+		log.Printf("[INFO] Loaded %d ASN prefixes", table.Len())
+	}
+	bans.SetRules(rules)
+
+	bans.Seed(config.BannedIPs)
+	firewall.SetDefaultBanStore(bans)
+	log.Printf("[INFO] %d IPs banned, %d firewall rules", len(bans.List()), len(rules.Rules()))
+
+	limits, err := firewall.NewGuard(firewall.GuardConfig{
+		BruteForceDetection: config.Security.BruteForceDetection,
+		MaxAttempts:         config.Security.MaxAttempts,
+		BruteForce:          config.Security.BruteForce,
+		RateLimits:          config.Security.RateLimits,
+	}, bans, logger)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid security settings: %v", err)
+	}
+	defer limits.Close()
+	firewall.SetDefaultGuard(limits)
+
+	users, err := auth.OpenUserStore(config.Auth.Users)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to open user database: %v", err)
+	}
+	defer users.Close()
+	auth.SetDefaultUsers(users)
+	log.Printf("[INFO] %d user entries loaded from %s", len(users.Users()), config.Auth.Users.File)
+
+	policies, err := auth.NewPolicies(config.Auth.Policies)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid authentication policies: %v", err)
+	}
+	auth.SetDefaultPolicies(policies)
+
+	credentials, err := auth.NewCredentialStore(logger.DB())
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to open credential store: %v", err)
+	}
+	defer credentials.Close()
+	auth.SetCredentialStore(credentials)
+
+	tarpit := firewall.NewTarpit(config.Security.Tarpit, logger)
+	guard := &connectionGuard{rules: rules, bans: bans, limits: limits, tarpit: tarpit, config: config.Security.Tarpit, logger: logger}
+
+	if config.Metrics.Enabled {
+		metrics.NewGaugeFunc("honeypot_sink_queue_depth", "Eventos aguardando envio nos sinks.", func() float64 {
+			return float64(logger.QueueDepth())
+		})
+		metrics.NewCounterFunc("honeypot_events_dropped_total", "Eventos descartados pelos sinks.", func() float64 {
+			return float64(logger.Dropped())
+		})
+		go func() {
+			if err := metrics.Serve(config.Metrics.Listen); err != nil {
+				log.Printf("[ERROR] Metrics endpoint stopped: %v", err)
+			}
+		}()
+	}
+
+	httpd, err := handlers.NewHTTPHoneypot(config.HTTP, logger)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to configure HTTP honeypot: %v", err)
+	}
+	defer httpd.Close()
+
+	proxy, err := handlers.NewProxyHoneypot(config.Proxy, logger)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to configure proxy honeypot: %v", err)
+	}
+
+	profile, err := persona.Load(config.Persona)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to load persona profile: %v", err)
+	}
+	persona.SetDefault(profile)
+	log.Printf("[INFO] Persona %s loaded: %s (%s), kernel %s", profile.Name, profile.Hostname, profile.OS.PrettyName, profile.Kernel.Release)
+
+	shell, err := handlers.NewShell(config.Simulations, logger)
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid shell simulation settings: %v", err)
+	}
+	if config.SessionPersistence {
+		sessions, err := session.NewStore(logger.DB(), config.SessionStore)
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to open session store: %v", err)
+		}
+		defer sessions.Close()
+		shell.SetStore(sessions)
+		log.Printf("[INFO] Shell state persisted per %s (retention %v)", sessions.Config().Key, sessions.Config().Retention)
+	}
+
+	ports := firewall.NewPortManager()
+	ports.SetBindAddress(config.Ports.BindAddress)
+	ports.SetRecorder(logger)
+	ports.SetCatchAll(config.Ports.CatchAll)
+	ports.SetUDP(config.Ports.UDP)
+	ports.SetRules(rules)
+	ports.SetAdmission(guard.admit, tarpit)
+	ports.RegisterService("ssh", func(conn net.Conn) { handlers.HandleSSHConnection(conn, logger) })
+	ports.RegisterService("telnet", func(conn net.Conn) { handlers.HandleTelnetConnection(conn, logger, shell) })
+	ports.RegisterService("ftp", func(conn net.Conn) { handlers.HandleFTPConnection(conn, logger) })
+	ports.RegisterService("http", httpd.HandleHTTPConnection)
+	ports.RegisterService("https", httpd.HandleHTTPSConnection)
+	ports.RegisterService("proxy", proxy.HandleProxyConnection)
+	defer ports.Close()
+
+	for service, port := range map[string]int{"ssh": config.Ports.SSH, "telnet": config.Ports.Telnet, "ftp": config.Ports.FTP} {
+		if err := ports.Bind(port, service, ""); err != nil {
+			log.Fatalf("[ERROR] Failed to start %s server: %v", service, err)
+		}
+		log.Printf("[INFO] %s honeypot listening on port %d", service, port)
+	}
+	for _, listener := range config.Ports.Listeners {
+		if err := ports.BindListeners(listener); err != nil {
+			log.Printf("[ERROR] Failed to open ports %s: %v", listener.Ports, err)
+		}
+	}
+
+	if config.Ports.API.Enabled {
+		go func() {
+			if err := ports.ServeAPI(config.Ports.API.Listen, config.Ports.API.Token); err != nil {
+				log.Printf("[ERROR] Port manager API stopped: %v", err)
+			}
+		}()
+	}
 
-	wg.Wait()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	log.Printf("[INFO] Shutting down")
 }
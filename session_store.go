@@ -0,0 +1,309 @@
+package session
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/persona"
+)
+
+// Como o atacante é reconhecido quando volta
+const (
+	KeyIP         = "ip"         // Mesmo IP de origem, com qualquer credencial
+	KeyCredential = "credential" // Mesmo usuário e senha, de qualquer IP
+)
+
+// maxVisits limita os logins anteriores guardados por atacante
+const maxVisits = 20
+
+// Config espelha a seção session_store do config.yaml
+type Config struct {
+	Key       string        `yaml:"key"`       // ip ou credential (padrão ip)
+	Retention time.Duration `yaml:"retention"` // Tempo guardado desde a última visita; 0 = para sempre
+	Quota     Quota         `yaml:"quota"`
+}
+
+// Quota limita o sistema de arquivos de cada atacante: acima dela o shell responde "No space left
+// on device" e o Store recusa gravar o estado
+type Quota struct {
+	MaxFiles int   `yaml:"max_files"` // Arquivos e diretórios criados, alterados ou apagados, mais as contas criadas (padrão 1000)
+	MaxBytes int64 `yaml:"max_bytes"` // Soma do conteúdo dos arquivos, downloads incluídos (padrão 16 MiB)
+}
+
+// DefaultQuota vale para os campos não configurados e para o shell sem Store
+var DefaultQuota = Quota{MaxFiles: 1000, MaxBytes: 16 << 20}
+
+// withDefaults completa os campos não configurados com DefaultQuota
+func (q Quota) withDefaults() Quota {
+	if q.MaxFiles <= 0 {
+		q.MaxFiles = DefaultQuota.MaxFiles
+	}
+	if q.MaxBytes <= 0 {
+		q.MaxBytes = DefaultQuota.MaxBytes
+	}
+	return q
+}
+
+// Room é o que ainda cabe no estado: entradas e bytes
+func (q Quota) Room(state *State) (files int, bytes int64) {
+	used, size := state.Usage()
+	return q.MaxFiles - used, q.MaxBytes - size
+}
+
+// Allows informa se gravar size bytes em name mantém o estado dentro da cota
+func (q Quota) Allows(state *State, name string, size int64) bool {
+	files, bytes := q.Room(state)
+	if old, exists := state.Files[name]; exists {
+		files++
+		bytes += int64(len(old.Content))
+	}
+	return files >= 1 && size <= bytes
+}
+
+// File é um arquivo ou diretório criado, alterado ou apagado pelo atacante no shell falso
+type File struct {
+	Content []byte      `json:"content,omitempty"`
+	Dir     bool        `json:"dir,omitempty"`
+	Mode    os.FileMode `json:"mode"` // Só as permissões; o tipo vem de Dir
+	Owner   string      `json:"owner"`
+	ModTime time.Time   `json:"mtime"`
+	Deleted bool        `json:"deleted,omitempty"` // Esconde um arquivo do sistema simulado
+	Source  string      `json:"source,omitempty"`  // URL de onde o arquivo foi baixado com wget ou curl
+}
+
+// Visit é um login anterior do atacante, mostrado pelo last e por "Last login"
+type Visit struct {
+	User  string    `json:"user"`
+	From  string    `json:"from"`
+	TTY   string    `json:"tty"`
+	At    time.Time `json:"at"`
+	Until time.Time `json:"until"`
+}
+
+// State é tudo o que o atacante mudou no sistema simulado
+type State struct {
+	Key       string              `json:"key"`
+	FirstSeen time.Time           `json:"first_seen"`
+	LastSeen  time.Time           `json:"last_seen"`
+	Files     map[string]*File    `json:"files"`   // Caminho absoluto; inclui ~/.bash_history, crontabs e authorized_keys
+	Users     []persona.User      `json:"users"`   // Criados com useradd/adduser
+	Members   map[string][]string `json:"members"` // Membros acrescentados a grupos (usermod -aG, useradd -G)
+	Visits    []Visit             `json:"visits"`
+}
+
+// NewState retorna o estado vazio de um atacante novo
+func NewState(key string) *State {
+	return &State{Key: key, FirstSeen: time.Now(), Files: make(map[string]*File), Members: make(map[string][]string)}
+}
+
+// Usage conta as entradas de Files e as contas criadas, e soma o conteúdo dos arquivos
+func (s *State) Usage() (files int, bytes int64) {
+	for _, file := range s.Files {
+		bytes += int64(len(file.Content))
+	}
+	return len(s.Files) + len(s.Users), bytes
+}
+
+// AddVisit registra um login encerrado, mantendo só os mais recentes
+func (s *State) AddVisit(visit Visit) {
+	s.Visits = append(s.Visits, visit)
+	if len(s.Visits) > maxVisits {
+		s.Visits = s.Visits[len(s.Visits)-maxVisits:]
+	}
+}
+
+// Store guarda o estado de cada atacante na tabela session_state do banco de eventos
+type Store struct {
+	db       *sql.DB // nil mantém os estados apenas em memória
+	config   Config
+	memory   map[string][]byte
+	mu       sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+	logger   *log.Logger
+}
+
+// NewStore valida a configuração, cria a tabela e inicia a limpeza dos estados vencidos
+func NewStore(db *sql.DB, config Config) (*Store, error) {
+	if config.Key == "" {
+		config.Key = KeyIP
+	}
+	if config.Key != KeyIP && config.Key != KeyCredential {
+		return nil, fmt.Errorf("session_store.key desconhecido: %s", config.Key)
+	}
+	if config.Retention < 0 {
+		return nil, fmt.Errorf("session_store.retention inválido: %v", config.Retention)
+	}
+	config.Quota = config.Quota.withDefaults()
+
+	s := &Store{
+		db:     db,
+		config: config,
+		memory: make(map[string][]byte),
+		stop:   make(chan struct{}),
+		logger: log.New(log.Writer(), "SESSIONS: ", log.LstdFlags|log.Lshortfile),
+	}
+	if db != nil {
+		for _, statement := range []string{
+			`CREATE TABLE IF NOT EXISTS session_state (
+				key TEXT PRIMARY KEY,
+				state TEXT NOT NULL,
+				last_seen TEXT NOT NULL
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_session_state_last_seen ON session_state(last_seen)",
+		} {
+			if _, err := db.Exec(statement); err != nil {
+				return nil, fmt.Errorf("erro ao criar tabela de sessões: %v", err)
+			}
+		}
+	}
+
+	go s.janitor()
+	return s, nil
+}
+
+// Config retorna a configuração em uso, já com os padrões
+func (s *Store) Config() Config {
+	return s.config
+}
+
+// Key identifica o atacante conforme session_store.key
+func (s *Store) Key(addr, username, password string) string {
+	if s.config.Key == KeyCredential {
+		return username + ":" + password
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// Load retorna uma cópia do estado guardado; atacantes novos ou vencidos recebem um estado vazio
+func (s *Store) Load(key string) (*State, error) {
+	data, err := s.read(key)
+	if err != nil || data == nil {
+		return NewState(key), err
+	}
+
+	state := NewState(key)
+	if err := json.Unmarshal(data, state); err != nil {
+		return NewState(key), fmt.Errorf("erro ao interpretar o estado de %s: %v", key, err)
+	}
+	if s.expired(state.LastSeen, time.Now()) {
+		return NewState(key), nil
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]*File)
+	}
+	if state.Members == nil {
+		state.Members = make(map[string][]string)
+	}
+	return state, nil
+}
+
+// Save grava o estado; entre duas sessões simultâneas do mesmo atacante vale a última a sair.
+// Estados acima da cota não são gravados e a versão anterior é mantida
+func (s *Store) Save(state *State) error {
+	if files, bytes := state.Usage(); files > s.config.Quota.MaxFiles || bytes > s.config.Quota.MaxBytes {
+		return fmt.Errorf("estado de %s acima da cota, não salvo (%d arquivos, %d bytes)", state.Key, files, bytes)
+	}
+	state.LastSeen = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar o estado de %s: %v", state.Key, err)
+	}
+
+	if s.db == nil {
+		s.mu.Lock()
+		s.memory[state.Key] = data
+		s.mu.Unlock()
+		return nil
+	}
+	_, err = s.db.Exec("INSERT OR REPLACE INTO session_state (key, state, last_seen) VALUES (?, ?, ?)",
+		state.Key, string(data), state.LastSeen.Format(logging.TimestampLayout))
+	if err != nil {
+		return fmt.Errorf("erro ao salvar o estado de %s: %v", state.Key, err)
+	}
+	return nil
+}
+
+func (s *Store) read(key string) ([]byte, error) {
+	if s.db == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.memory[key], nil
+	}
+
+	var data string
+	err := s.db.QueryRow("SELECT state FROM session_state WHERE key = ?", key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler o estado de %s: %v", key, err)
+	}
+	return []byte(data), nil
+}
+
+func (s *Store) expired(lastSeen, now time.Time) bool {
+	return s.config.Retention > 0 && now.Sub(lastSeen) > s.config.Retention
+}
+
+// Purge apaga os estados sem visita dentro de session_store.retention
+func (s *Store) Purge(now time.Time) (int, error) {
+	if s.config.Retention == 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-s.config.Retention)
+
+	if s.db == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		purged := 0
+		for key, data := range s.memory {
+			var state State
+			if json.Unmarshal(data, &state) == nil && state.LastSeen.Before(cutoff) {
+				delete(s.memory, key)
+				purged++
+			}
+		}
+		return purged, nil
+	}
+
+	result, err := s.db.Exec("DELETE FROM session_state WHERE last_seen < ?", cutoff.Format(logging.TimestampLayout))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao apagar estados vencidos: %v", err)
+	}
+	purged, _ := result.RowsAffected()
+	return int(purged), nil
+}
+
+// janitor apaga periodicamente os estados vencidos
+func (s *Store) janitor() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if _, err := s.Purge(time.Now()); err != nil {
+				s.logger.Printf("Erro na limpeza dos estados: %v\n", err)
+			}
+		}
+	}
+}
+
+// Close para a limpeza; o banco continua aberto para quem o passou a NewStore
+func (s *Store) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuotaAllows(t *testing.T) {
+	quota := Quota{MaxFiles: 2, MaxBytes: 10}
+	state := NewState("203.0.113.9")
+	state.Files["/tmp/a"] = &File{Content: []byte("123456")}
+
+	for _, c := range []struct {
+		name  string
+		size  int64
+		allow bool
+	}{
+		{"/tmp/b", 4, true},  // Segunda entrada, 10 bytes no total
+		{"/tmp/b", 5, false}, // Passa dos bytes
+		{"/tmp/a", 10, true}, // Substituir não conta o conteúdo antigo
+	} {
+		if got := quota.Allows(state, c.name, c.size); got != c.allow {
+			t.Errorf("Allows(%s, %d) = %v, esperado %v", c.name, c.size, got, c.allow)
+		}
+	}
+
+	state.Files["/tmp/b"] = &File{Dir: true}
+	if quota.Allows(state, "/tmp/c", 0) {
+		t.Error("Allows aceitou uma terceira entrada com max_files 2")
+	}
+	if !quota.Allows(state, "/tmp/b", 0) {
+		t.Error("Allows recusou regravar uma entrada existente")
+	}
+}
+
+func TestSaveRefusesStateAboveQuota(t *testing.T) {
+	store, err := NewStore(nil, Config{Quota: Quota{MaxFiles: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if quota := store.Config().Quota; quota.MaxBytes != DefaultQuota.MaxBytes {
+		t.Fatalf("max_bytes = %d, esperado o padrão %d", quota.MaxBytes, DefaultQuota.MaxBytes)
+	}
+
+	state := NewState("203.0.113.9")
+	state.Files["/tmp/a"] = &File{Content: []byte("a")}
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save dentro da cota: %v", err)
+	}
+
+	state.Files["/tmp/b"] = &File{Content: []byte("b")}
+	if err := store.Save(state); err == nil || !strings.Contains(err.Error(), "cota") {
+		t.Fatalf("Save acima da cota = %v, esperado erro", err)
+	}
+	// A versão anterior, dentro da cota, continua guardada
+	saved, err := store.Load("203.0.113.9")
+	if err != nil || len(saved.Files) != 1 {
+		t.Fatalf("Load = %d arquivos, %v; esperado o estado anterior", len(saved.Files), err)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"myhoneypot/logging"
+)
+
+// Limites da análise estática de executáveis
+const (
+	minStringLength = 6   // Tamanho mínimo de uma sequência imprimível para contar como string
+	maxStrings      = 200 // Strings guardadas no evento
+	maxIOCs         = 50  // URLs e IPs guardados no evento
+)
+
+// ipPattern acha endereços IPv4 dentro das strings de um executável
+var ipPattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+
+// triage é o resultado da análise estática de um executável do sistema de arquivos falso
+type triage struct {
+	elf      bool
+	arch     string // Nome do uname -m: x86_64, i686, armv7l, aarch64, mips, mipsel...
+	fileType string // Descrição no formato do file(1)
+	packer   string
+	iocs     []string
+	strings  []string
+}
+
+// elfMachines traduz e_machine para o nome do file(1) e o do uname -m
+var elfMachines = map[elf.Machine][2]string{
+	elf.EM_386:     {"Intel 80386", "i686"},
+	elf.EM_X86_64:  {"x86-64", "x86_64"},
+	elf.EM_ARM:     {"ARM", "armv7l"},
+	elf.EM_AARCH64: {"ARM aarch64", "aarch64"},
+	elf.EM_MIPS:    {"MIPS", "mips"},
+	elf.EM_PPC:     {"PowerPC or cisco 4500", "ppc"},
+	elf.EM_PPC64:   {"64-bit PowerPC or cisco 7500", "ppc64"},
+	elf.EM_SPARC:   {"SPARC", "sparc"},
+	elf.EM_SH:      {"Renesas SH", "sh4"},
+	elf.EM_68K:     {"Motorola m68k", "m68k"},
+	elf.EM_RISCV:   {"UCB RISC-V", "riscv64"},
+}
+
+// analyze faz a triagem estática de um executável: cabeçalho ELF, arquitetura, ligação, UPX,
+// strings e os URLs e IPs embutidos nelas. Nada do arquivo é executado. O debug/elf não é
+// protegido contra entradas hostis; um pânico no parse vira "ELF, corrupted" em vez de derrubar o sensor
+func analyze(data []byte) (t triage) {
+	defer func() {
+		if recover() != nil {
+			t.elf, t.arch = false, ""
+			t.fileType = "ELF, corrupted"
+		}
+	}()
+	t.strings, t.iocs = printable(data)
+	if bytes.Contains(data, []byte("UPX!")) {
+		t.packer = "UPX"
+	}
+
+	file, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		t.fileType = "data"
+		if bytes.HasPrefix(data, []byte("\x7fELF")) {
+			t.fileType = "ELF, corrupted"
+		}
+		return t
+	}
+	defer file.Close()
+	t.elf = true
+
+	class := "32-bit"
+	if file.Class == elf.ELFCLASS64 {
+		class = "64-bit"
+	}
+	order := "LSB"
+	if file.Data == elf.ELFDATA2MSB {
+		order = "MSB"
+	}
+	machine, known := elfMachines[file.Machine]
+	if !known {
+		name := strings.ToLower(strings.TrimPrefix(file.Machine.String(), "EM_"))
+		machine = [2]string{name, name}
+	}
+	t.arch = machine[1]
+	switch {
+	case file.Machine == elf.EM_MIPS && order == "LSB":
+		t.arch = "mipsel"
+	case file.Machine == elf.EM_PPC64 && order == "LSB":
+		t.arch = "ppc64le"
+	}
+
+	kind := "executable"
+	linking := "statically linked"
+	for _, prog := range file.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		interp, _ := io.ReadAll(io.LimitReader(prog.Open(), 256))
+		linking = "dynamically linked, interpreter " + strings.TrimRight(string(interp), "\x00")
+	}
+	switch file.Type {
+	case elf.ET_DYN:
+		kind = "shared object"
+		if linking != "statically linked" {
+			kind = "pie executable"
+		}
+	case elf.ET_REL:
+		kind, linking = "relocatable", ""
+	case elf.ET_CORE:
+		kind, linking = "core file", ""
+	}
+
+	parts := []string{fmt.Sprintf("ELF %s %s %s", class, order, kind), machine[0], "version 1 (SYSV)"}
+	if linking != "" {
+		parts = append(parts, linking)
+	}
+	if file.Section(".symtab") == nil {
+		parts = append(parts, "stripped")
+	} else {
+		parts = append(parts, "not stripped")
+	}
+	for _, section := range file.Sections {
+		if strings.HasPrefix(section.Name, "UPX") {
+			t.packer = "UPX"
+		}
+	}
+	if t.packer != "" {
+		parts = append(parts, t.packer+" compressed")
+	}
+	t.fileType = strings.Join(parts, ", ")
+	return t
+}
+
+// printable extrai as sequências de ASCII imprimível (como o strings(1)) e os URLs e IPs nelas
+func printable(data []byte) (found, iocs []string) {
+	seen := make(map[string]bool)
+	add := func(ioc string) {
+		if !seen[ioc] && len(iocs) < maxIOCs {
+			seen[ioc] = true
+			iocs = append(iocs, ioc)
+		}
+	}
+	start := -1
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && data[i] >= 0x20 && data[i] < 0x7f {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minStringLength {
+			text := string(data[start:i])
+			if len(found) < maxStrings {
+				found = append(found, text)
+			}
+			for _, url := range urlPattern.FindAllString(text, -1) {
+				add(url)
+			}
+			for _, ip := range ipPattern.FindAllString(text, -1) {
+				if parsed := net.ParseIP(ip); parsed != nil && !parsed.IsUnspecified() {
+					add(ip)
+				}
+			}
+		}
+		start = -1
+	}
+	return found, iocs
+}
+
+// apply copia a triagem para o evento (o FILE_DOWNLOAD do arquivo ou o BINARY_EXECUTED)
+func (t triage) apply(entry *logging.LogEntry) {
+	entry.FileType = t.fileType
+	entry.Arch = t.arch
+	entry.Packer = t.packer
+	entry.IOCs = strings.Join(t.iocs, ",")
+	entry.Strings = strings.Join(t.strings, "\n")
+}
+
+// runsOn diz se um executável da arquitetura arch roda numa máquina machine (uname -m do perfil);
+// máquinas de 64 bits também rodam os binários de 32 bits da mesma família e ordem de bytes
+func runsOn(arch, machine string) bool {
+	switch {
+	case arch == machine:
+		return true
+	case machine == "x86_64":
+		return arch == "i686"
+	case machine == "aarch64":
+		return arch == "armv7l"
+	}
+	return false
+}
+
+// runBinary emula a execução de um executável: registra a triagem e, se a arquitetura bate com a
+// do perfil, deixa o processo rodando em segundo plano sem saída, como um bot que se desliga do terminal
+func (s *shellSession) runBinary(command, name string, data []byte, args []string) string {
+	t := analyze(data)
+	sum := sha256.Sum256(data)
+	entry := logging.LogEntry{
+		Level:   logging.WARNING,
+		Type:    logging.EventBinary,
+		Command: s.line,
+		Path:    name,
+		SHA256:  hex.EncodeToString(sum[:]),
+		URL:     s.origin(name),
+	}
+	t.apply(&entry)
+
+	machine := s.profile().Kernel.Machine
+	if !t.elf || !runsOn(t.arch, machine) {
+		entry.Event = fmt.Sprintf("Executável %s recusado (%s, máquina %s)", name, t.fileType, machine)
+		s.record(entry)
+		return fmt.Sprintf("bash: %s: cannot execute binary file: Exec format error", command)
+	}
+	entry.Event = fmt.Sprintf("Executável %s iniciado em segundo plano (%s)", name, t.fileType)
+	s.record(entry)
+	s.spawn(s.user(), "?", "Ss", strings.TrimSpace(command+" "+strings.Join(args, " ")))
+	return ""
+}
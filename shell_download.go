@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+)
+
+// Falhas de download, com o texto que wget e curl mostram
+var (
+	errPrivateAddress = errors.New("Connection refused") // O honeypot não alcança a rede interna
+	errUnknownHost    = errors.New("Name or service not known")
+	errTimeout        = errors.New("Connection timed out")
+	errReset          = errors.New("Connection reset by peer")
+)
+
+// download é o resultado de buscar uma URL
+type download struct {
+	url    string
+	host   string // host:porta
+	addr   string // IP que respondeu
+	status int
+	reason string // "200 OK"
+	kind   string // Content-Type
+	data   []byte
+	err    error // Falha de conexão, vira a mensagem do wget ou do curl
+}
+
+// fetch baixa uma URL como o wget ou o curl; com download_files desligado a conexão sempre expira
+func (s *shellSession) fetch(rawURL, userAgent string, follow bool) download {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	result := download{url: rawURL}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		result.err = errInvalidArg
+		return result
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "ftp": "21"}[parsed.Scheme]
+	}
+	result.host = net.JoinHostPort(parsed.Hostname(), port)
+
+	config := s.shell.config
+	if !config.DownloadFiles || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		result.err = errTimeout
+		if net.ParseIP(parsed.Hostname()) == nil {
+			result.err = errUnknownHost
+		}
+		time.Sleep(2 * time.Second)
+		return result
+	}
+
+	client := &http.Client{
+		Timeout: config.DownloadTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{Timeout: config.DownloadTimeout, Control: publicOnly}).DialContext,
+			Proxy:       nil,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !follow || len(via) >= 10 {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.DownloadTimeout)
+	defer cancel()
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			result.addr, _, _ = net.SplitHostPort(info.Conn.RemoteAddr().String())
+		},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.err = errInvalidArg
+		return result
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "*/*")
+	resp, err := client.Do(req)
+	if err != nil {
+		result.err = connectionError(err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.status, result.reason = resp.StatusCode, resp.Status
+	result.kind = resp.Header.Get("Content-Type")
+	result.data, err = io.ReadAll(io.LimitReader(resp.Body, config.DownloadMaxSize+1))
+	if err != nil {
+		result.err = connectionError(err)
+	}
+	return result
+}
+
+// specialPrefixes são os blocos de uso especial (RFC 6890 e registros da IANA) que um download nunca alcança;
+// endereços IPv4 mapeados em IPv6 (::ffff:a.b.c.d) são comparados com os blocos IPv4
+var specialPrefixes = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",       // "Esta rede"
+		"10.0.0.0/8",      // Privada
+		"100.64.0.0/10",   // CGNAT
+		"127.0.0.0/8",     // Loopback
+		"169.254.0.0/16",  // Link-local, inclusive os metadados de nuvem
+		"172.16.0.0/12",   // Privada
+		"192.0.0.0/24",    // Atribuições de protocolo da IETF
+		"192.0.2.0/24",    // Documentação (TEST-NET-1)
+		"192.88.99.0/24",  // Relay 6to4
+		"192.168.0.0/16",  // Privada
+		"198.18.0.0/15",   // Testes de desempenho
+		"198.51.100.0/24", // Documentação (TEST-NET-2)
+		"203.0.113.0/24",  // Documentação (TEST-NET-3)
+		"224.0.0.0/4",     // Multicast
+		"240.0.0.0/4",     // Reservada, inclusive o broadcast 255.255.255.255
+		"::/128",          // Não especificado
+		"::1/128",         // Loopback
+		"64:ff9b::/96",    // Tradução NAT64
+		"64:ff9b:1::/48",  // Tradução NAT64 local
+		"100::/64",        // Descarte
+		"2001::/23",       // Atribuições de protocolo da IETF (Teredo, ORCHID...)
+		"2001:db8::/32",   // Documentação
+		"2002::/16",       // 6to4
+		"fc00::/7",        // Endereços locais únicos
+		"fe80::/10",       // Link-local
+		"ff00::/8",        // Multicast
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// publicOnly recusa conexões para qualquer bloco de uso especial
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return errPrivateAddress
+	}
+	for _, special := range specialPrefixes {
+		if special.Contains(ip) {
+			return errPrivateAddress
+		}
+	}
+	return nil
+}
+
+// connectionError traduz o erro do cliente HTTP para o texto que o wget e o curl mostram
+func connectionError(err error) error {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return errUnknownHost
+	case errors.Is(err, errPrivateAddress), errors.Is(err, syscall.ECONNREFUSED):
+		return errPrivateAddress
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return errTimeout
+	}
+	return errReset
+}
+
+// saveDownload grava o arquivo baixado e registra o evento FILE_DOWNLOAD com o hash; o arquivo conta
+// na cota do estado como qualquer outro, e um download que não cabe nela falha com "No space left on device"
+func (s *shellSession) saveDownload(result download, target string) error {
+	var err error
+	switch {
+	case int64(len(result.data)) > s.shell.config.DownloadMaxSize:
+		err = errNoSpace
+	case target != "":
+		err = s.writeLimited(target, result.data, false, s.shell.config.DownloadMaxSize)
+	}
+	s.recordDownload(result, target)
+	if err == nil && target != "" {
+		s.state.Files[target].Source = result.url
+	}
+	return err
+}
+
+// recordDownload registra o pedido de download, com o hash quando o arquivo chegou e a triagem quando é um executável
+func (s *shellSession) recordDownload(result download, target string) {
+	metrics.Downloads.Inc(s.protocol)
+	entry := logging.LogEntry{
+		Level:   logging.WARNING,
+		Type:    logging.EventDownload,
+		Command: s.line,
+		URL:     result.url,
+		Path:    target,
+	}
+	switch {
+	case result.err != nil:
+		entry.Event = fmt.Sprintf("Download de %s falhou: %v", result.url, result.err)
+	case result.status != http.StatusOK:
+		entry.Event = fmt.Sprintf("Download de %s: HTTP %s", result.url, result.reason)
+	default:
+		sum := sha256.Sum256(result.data)
+		entry.SHA256 = hex.EncodeToString(sum[:])
+		entry.Event = fmt.Sprintf("Arquivo baixado de %s (%d bytes, sha256 %s)", result.url, len(result.data), entry.SHA256)
+		if isBinary(result.data) {
+			analyze(result.data).apply(&entry)
+			entry.Event += ": " + entry.FileType
+		}
+	}
+	s.record(entry)
+}
+
+// origin é a URL de onde o arquivo foi baixado, ou "" se ele não veio de um download
+func (s *shellSession) origin(name string) string {
+	file, err := s.lookup(name)
+	if err != nil {
+		return ""
+	}
+	return file.Source
+}
+
+// userAgent monta o User-Agent da ferramenta com a versão do pacote do perfil (Wget/1.21.2, curl/7.81.0)
+func (s *shellSession) userAgent(program, name string) string {
+	version := map[string]string{"wget": "1.21.2", "curl": "7.81.0"}[program]
+	for _, pkg := range s.profile().Packages {
+		if pkg.Name == program {
+			version, _, _ = strings.Cut(pkg.Version, "-")
+		}
+	}
+	return name + "/" + version
+}
+
+// remoteName é o nome do arquivo que wget e curl -O escolhem a partir da URL
+func remoteName(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		if name := path.Base(parsed.Path); name != "/" && name != "." && name != "" {
+			return name
+		}
+	}
+	return "index.html"
+}
+
+// wget imita o GNU wget: -O arquivo (ou - para a saída padrão), -P diretório e -q; o progresso
+// vai para a saída de erro, como no original
+func (s *shellSession) wget(args []string) string {
+	output, directory, quiet := "", "", false
+	var urls []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch {
+		case arg == "-O" || arg == "--output-document":
+			output, i = value, i+1
+		case strings.HasPrefix(arg, "--output-document="):
+			output = strings.TrimPrefix(arg, "--output-document=")
+		case arg == "-P" || arg == "--directory-prefix":
+			directory, i = value, i+1
+		case arg == "-U" || arg == "--user-agent" || arg == "-t" || arg == "-T" || arg == "--tries" || arg == "--timeout":
+			i++
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 1:
+			// Opções curtas juntas, como -qO- ou -qO arquivo
+			before, after, found := strings.Cut(arg[1:], "O")
+			quiet = quiet || strings.Contains(before, "q")
+			if found {
+				output = after
+				if after == "" {
+					output, i = value, i+1
+				}
+			}
+		case arg == "--quiet":
+			quiet = true
+		case strings.HasPrefix(arg, "-"):
+		default:
+			urls = append(urls, arg)
+		}
+	}
+	if len(urls) == 0 {
+		return "wget: missing URL\nUsage: wget [OPTION]... [URL]...\n\nTry `wget --help' for more options."
+	}
+
+	var stdout strings.Builder
+	for _, rawURL := range urls {
+		result := s.fetch(rawURL, s.userAgent("wget", "Wget"), true)
+		target := ""
+		switch output {
+		case "-":
+		case "":
+			target = s.resolve(path.Join(directory, remoteName(result.url)))
+		default:
+			target = s.resolve(output)
+		}
+		progress := s.wgetProgress(result, target)
+		if result.err != nil || result.status != http.StatusOK {
+			s.recordDownload(result, "")
+			if !quiet {
+				s.stderr(progress)
+			}
+			continue
+		}
+		if err := s.saveDownload(result, target); err != nil {
+			progress = fmt.Sprintf("%s: %v", target, err)
+			quiet = false
+		}
+		if !quiet {
+			s.stderr(progress)
+		}
+		if output == "-" {
+			stdout.Write(result.data)
+		}
+	}
+	return strings.TrimSuffix(stdout.String(), "\n")
+}
+
+// wgetProgress é o relatório do wget: conexão, resposta e arquivo salvo
+func (s *shellSession) wgetProgress(result download, target string) string {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	host, port, _ := net.SplitHostPort(result.host)
+	lines := []string{fmt.Sprintf("--%s--  %s", now, result.url)}
+	connecting := fmt.Sprintf("Connecting to %s:%s", host, port)
+	if net.ParseIP(host) == nil {
+		if errors.Is(result.err, errUnknownHost) {
+			return strings.Join(append(lines, fmt.Sprintf("Resolving %[1]s (%[1]s)... failed: %[2]v.", host, result.err),
+				fmt.Sprintf("wget: unable to resolve host address ‘%s’", host)), "\n")
+		}
+		lines = append(lines, fmt.Sprintf("Resolving %s (%s)... %s", host, host, result.addr))
+		connecting = fmt.Sprintf("Connecting to %s (%s)|%s|:%s", host, host, result.addr, port)
+	}
+	if result.err != nil {
+		return strings.Join(append(lines, fmt.Sprintf("%s... failed: %v.", connecting, result.err)), "\n")
+	}
+	lines = append(lines, connecting+"... connected.",
+		"HTTP request sent, awaiting response... "+result.reason)
+	if result.status != http.StatusOK {
+		return strings.Join(append(lines, fmt.Sprintf("%s ERROR %s.", now, result.reason)), "\n")
+	}
+
+	kind, _, _ := mime.ParseMediaType(result.kind)
+	if kind == "" {
+		kind = "application/octet-stream"
+	}
+	name := "STDOUT"
+	if target != "" {
+		name = "‘" + path.Base(target) + "’"
+	}
+	size := len(result.data)
+	length := fmt.Sprintf("Length: %d [%s]", size, kind)
+	if size >= 1024 {
+		length = fmt.Sprintf("Length: %d (%s) [%s]", size, humanSize(size), kind)
+	}
+	return strings.Join(append(lines,
+		length,
+		"Saving to: "+name,
+		"",
+		fmt.Sprintf("%-20s100%%[===================>] %7s  --.-KB/s    in 0s      ", strings.Trim(name, "‘’"), humanSize(size)),
+		"",
+		fmt.Sprintf("%s (%.1f MB/s) - %s saved [%d/%d]", now, 1+float64(size)/1e6, name, size, size),
+		""), "\n")
+}
+
+// humanSize formata bytes como o wget (812, 1.2K, 3.4M)
+func humanSize(size int) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%d", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1fK", float64(size)/1024)
+	}
+	return fmt.Sprintf("%.1fM", float64(size)/1024/1024)
+}
+
+// curl imita o curl: sem -o/-O o corpo vai para a saída padrão; -s, -S, -f e -L mudam as mensagens
+func (s *shellSession) curl(args []string) string {
+	output, remote, silent, showErrors, fail, follow := "", false, false, false, false, false
+	var urls []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-o" || arg == "--output":
+			if i+1 < len(args) {
+				output, i = args[i+1], i+1
+			}
+		case arg == "--remote-name":
+			remote = true
+		case arg == "--silent":
+			silent = true
+		case arg == "--fail":
+			fail = true
+		case arg == "--location":
+			follow = true
+		case arg == "-A" || arg == "--user-agent" || arg == "-H" || arg == "--header" || arg == "-m" || arg == "--max-time" ||
+			arg == "-X" || arg == "--request" || arg == "-d" || arg == "--data" || arg == "-e" || arg == "--referer":
+			i++
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 1:
+			for j, flag := range arg[1:] {
+				switch flag {
+				case 's':
+					silent = true
+				case 'S':
+					showErrors = true
+				case 'f':
+					fail = true
+				case 'L':
+					follow = true
+				case 'O':
+					remote = true
+				case 'o':
+					// -so arquivo ou -soarquivo
+					output = arg[j+2:]
+					if output == "" && i+1 < len(args) {
+						output, i = args[i+1], i+1
+					}
+				}
+				if flag == 'o' {
+					break
+				}
+			}
+		case strings.HasPrefix(arg, "-"):
+		default:
+			urls = append(urls, arg)
+		}
+	}
+	if len(urls) == 0 {
+		return "curl: try 'curl --help' or 'curl --manual' for more information"
+	}
+
+	var stdout strings.Builder
+	for _, rawURL := range urls {
+		result := s.fetch(rawURL, s.userAgent("curl", "curl"), follow)
+		target := ""
+		switch {
+		case output != "" && output != "-":
+			target = s.resolve(output)
+		case remote:
+			target = s.resolve(remoteName(result.url))
+		}
+		failed := result.err != nil || fail && result.status >= 400
+		if failed {
+			s.recordDownload(result, "")
+			if !silent || showErrors {
+				s.stderr(curlError(result))
+			}
+			continue
+		}
+		if target == "" {
+			s.recordDownload(result, "")
+			stdout.Write(result.data)
+			continue
+		}
+		if err := s.saveDownload(result, target); err != nil {
+			s.stderr("curl: (23) Failure writing output to destination")
+			continue
+		}
+		if !silent {
+			size := len(result.data)
+			s.stderr(fmt.Sprintf("  %% Total    %% Received %% Xferd  Average Speed   Time    Time     Time  Current\n"+
+				"                                 Dload  Upload   Total   Spent    Left  Speed\n"+
+				"100 %5d  100 %5d    0     0  %5d      0 --:--:-- --:--:-- --:--:-- %5d", size, size, size*4, size*4))
+		}
+	}
+	return strings.TrimSuffix(stdout.String(), "\n")
+}
+
+// curlError é a mensagem de erro do curl para a falha do download
+func curlError(result download) string {
+	host, port, _ := net.SplitHostPort(result.host)
+	switch {
+	case result.err == nil:
+		return fmt.Sprintf("curl: (22) The requested URL returned error: %s", result.reason)
+	case errors.Is(result.err, errUnknownHost):
+		return "curl: (6) Could not resolve host: " + host
+	case errors.Is(result.err, errTimeout):
+		return fmt.Sprintf("curl: (28) Failed to connect to %s port %s: Connection timed out", host, port)
+	}
+	return fmt.Sprintf("curl: (7) Failed to connect to %s port %s: %v", host, port, result.err)
+}
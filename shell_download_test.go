@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"myhoneypot/session"
+)
+
+func TestPublicOnly(t *testing.T) {
+	for _, c := range []struct {
+		host    string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"0.0.0.0", false},
+		{"10.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"203.0.113.7", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"ff02::1", false},
+	} {
+		err := publicOnly("tcp", net.JoinHostPort(c.host, "80"), nil)
+		if (err == nil) != c.allowed {
+			t.Errorf("publicOnly(%s) = %v, permitido esperado %v", c.host, err, c.allowed)
+		}
+	}
+}
+
+func TestDownloadQuota(t *testing.T) {
+	s := testSession(t, session.Quota{MaxFiles: 10, MaxBytes: 8})
+	s.shell.config.DownloadMaxSize = 1 << 20
+
+	result := download{url: "http://198.51.100.7/x", status: http.StatusOK, data: []byte("123456")}
+	if err := s.saveDownload(result, "/tmp/a"); err != nil {
+		t.Fatalf("primeiro download: %v", err)
+	}
+	if err := s.saveDownload(result, "/tmp/b"); err != errNoSpace {
+		t.Fatalf("download acima da cota = %v, esperado %v", err, errNoSpace)
+	}
+	if err := s.saveDownload(result, "/tmp/a"); err != nil {
+		t.Fatalf("download sobre o mesmo arquivo: %v", err)
+	}
+	if _, bytes := s.state.Usage(); bytes > 8 {
+		t.Fatalf("estado com %d bytes passou da cota", bytes)
+	}
+}
@@ -0,0 +1,754 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myhoneypot/persona"
+	"myhoneypot/session"
+)
+
+// maxFileSize limita cada arquivo escrito pelo atacante; acima disso o disco "enche"
+const maxFileSize = 1 << 20
+
+// Erros do sistema de arquivos falso, com o texto do strerror
+var (
+	errNotFound   = errors.New("No such file or directory")
+	errDenied     = errors.New("Permission denied")
+	errIsDir      = errors.New("Is a directory")
+	errNotDir     = errors.New("Not a directory")
+	errExists     = errors.New("File exists")
+	errNotPermit  = errors.New("Operation not permitted")
+	errNoSpace    = errors.New("No space left on device")
+	errInvalidArg = errors.New("Invalid argument")
+)
+
+// baseDirs existem em todo perfil, além das homes dos usuários
+var baseDirs = []string{
+	"/bin", "/boot", "/dev", "/dev/shm", "/etc", "/etc/cron.d", "/etc/cron.daily", "/etc/cron.hourly",
+	"/etc/cron.monthly", "/etc/cron.weekly", "/etc/init.d", "/etc/profile.d", "/etc/ssh", "/etc/systemd",
+	"/etc/systemd/system", "/etc/systemd/system/multi-user.target.wants", "/home", "/lib", "/media", "/mnt",
+	"/opt", "/proc", "/root", "/run", "/sbin", "/srv", "/sys", "/tmp", "/usr", "/usr/bin", "/usr/lib",
+	"/usr/local", "/usr/local/bin", "/usr/sbin", "/usr/share", "/var", "/var/log", "/var/spool",
+	"/var/spool/cron", "/var/spool/cron/crontabs", "/var/tmp", "/var/www",
+}
+
+// stickyDirs são graváveis por qualquer usuário
+var stickyDirs = map[string]bool{"/tmp": true, "/var/tmp": true, "/dev/shm": true}
+
+// skeleton são os arquivos de /etc/skel presentes em cada home
+var skeleton = map[string]string{
+	".bash_logout": "# ~/.bash_logout: executed by bash(1) when login shell exits.\n\n" +
+		"# when leaving the console clear the screen to increase privacy\n\n" +
+		"if [ \"$SHLVL\" = 1 ]; then\n    [ -x /usr/bin/clear_console ] && /usr/bin/clear_console -q\nfi\n",
+	".bashrc": "# ~/.bashrc: executed by bash(1) for non-login shells.\n\n" +
+		"# If not running interactively, don't do anything\ncase $- in\n    *i*) ;;\n      *) return;;\nesac\n\n" +
+		"HISTCONTROL=ignoreboth\nshopt -s histappend\nHISTSIZE=1000\nHISTFILESIZE=2000\n\n" +
+		"alias ll='ls -alF'\nalias la='ls -A'\nalias l='ls -CF'\n\n" +
+		"if [ -f ~/.bash_aliases ]; then\n    . ~/.bash_aliases\nfi\n",
+	".profile": "# ~/.profile: executed by the command interpreter for login shells.\n\n" +
+		"if [ -n \"$BASH_VERSION\" ]; then\n    if [ -f \"$HOME/.bashrc\" ]; then\n\t. \"$HOME/.bashrc\"\n    fi\nfi\n\n" +
+		"if [ -d \"$HOME/bin\" ] ; then\n    PATH=\"$HOME/bin:$PATH\"\nfi\n",
+}
+
+// profile é o perfil ativo com as contas criadas pelo atacante
+func (s *shellSession) profile() *persona.Profile {
+	return persona.Current().WithAccounts(s.state.Users, s.state.Members)
+}
+
+// homes são os diretórios home do perfil e do usuário logado, com o dono de cada um
+func (s *shellSession) homes() map[string]string {
+	homes := map[string]string{"/root": "root"}
+	for _, user := range s.profile().Users {
+		if user.UID >= 1000 && strings.HasPrefix(user.Home, "/home/") {
+			homes[user.Home] = user.Name
+		}
+	}
+	account := s.profile().Account(s.users[0])
+	homes[account.Home] = account.Name
+	return homes
+}
+
+// resolve transforma um caminho digitado em absoluto, expandindo ~ e o diretório atual
+func (s *shellSession) resolve(name string) string {
+	switch {
+	case name == "~":
+		name = s.home()
+	case strings.HasPrefix(name, "~/"):
+		name = s.home() + name[1:]
+	case !strings.HasPrefix(name, "/"):
+		name = s.cwd + "/" + name
+	}
+	return path.Clean(name)
+}
+
+// stat procura um caminho nas mudanças do atacante e depois no sistema simulado
+func (s *shellSession) stat(name string) (session.File, bool) {
+	if s.hidden(name) {
+		return session.File{}, false
+	}
+	if file, exists := s.state.Files[name]; exists {
+		return *file, true
+	}
+	return s.baseFile(name)
+}
+
+// hidden diz se o caminho ou um diretório acima dele foi apagado pelo atacante
+func (s *shellSession) hidden(name string) bool {
+	for dir := name; ; dir = path.Dir(dir) {
+		if file, exists := s.state.Files[dir]; exists && file.Deleted {
+			return true
+		}
+		if dir == "/" {
+			return false
+		}
+	}
+}
+
+// baseFile descreve os arquivos e diretórios que existem antes de qualquer mudança
+func (s *shellSession) baseFile(name string) (session.File, bool) {
+	modTime := persona.Current().BootTime()
+	dir := session.File{Dir: true, Mode: 0755, Owner: "root", ModTime: modTime}
+	homes := s.homes()
+
+	switch {
+	case name == "/":
+		return dir, true
+	case stickyDirs[name]:
+		dir.Mode = os.ModeSticky | 0777
+		return dir, true
+	case homes[name] != "":
+		dir.Owner = homes[name]
+		if name == "/root" {
+			dir.Mode = 0700
+		}
+		return dir, true
+	}
+	for _, base := range baseDirs {
+		if base == name {
+			return dir, true
+		}
+	}
+
+	if content, exists := s.baseContent(name); exists {
+		file := session.File{Content: []byte(content), Mode: 0644, Owner: "root", ModTime: modTime}
+		if owner := homes[path.Dir(name)]; owner != "" {
+			file.Owner = owner
+		}
+		if name == "/etc/shadow" {
+			file.Mode = 0640
+		}
+		return file, true
+	}
+	return session.File{}, false
+}
+
+// baseContent é o conteúdo dos arquivos do perfil e do /etc/skel das homes
+func (s *shellSession) baseContent(name string) (string, bool) {
+	p := s.profile()
+	content, exists := p.File(name)
+	switch {
+	case exists:
+	case name == "/etc/shadow":
+		content, exists = p.Shadow(), true
+	case name == "/etc/crontab":
+		content, exists = systemCrontab(p), true
+	case s.homes()[path.Dir(name)] != "":
+		content, exists = skeleton[path.Base(name)]
+	}
+	if exists && content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content, exists
+}
+
+// children lista os nomes dentro de um diretório, em ordem
+func (s *shellSession) children(dir string) []string {
+	candidates := append([]string(nil), baseDirs...)
+	candidates = append(candidates, "/etc/shadow", "/etc/crontab")
+	candidates = append(candidates, s.profile().Files()...)
+	for home := range s.homes() {
+		candidates = append(candidates, home)
+		for name := range skeleton {
+			candidates = append(candidates, home+"/"+name)
+		}
+	}
+	for name := range stickyDirs {
+		candidates = append(candidates, name)
+	}
+	for name := range s.state.Files {
+		candidates = append(candidates, name)
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, candidate := range candidates {
+		if candidate == dir || path.Dir(candidate) != dir || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		if _, exists := s.stat(candidate); exists {
+			names = append(names, path.Base(candidate))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// allowed verifica um bit de permissão (4 leitura, 2 escrita, 1 execução) para o usuário atual
+func (s *shellSession) allowed(file session.File, bit os.FileMode) bool {
+	if s.isRoot() {
+		return true
+	}
+	if file.Owner == s.user() {
+		return file.Mode&(bit<<6) == bit<<6
+	}
+	return file.Mode&bit == bit
+}
+
+// lookup encontra um caminho checando os diretórios no caminho até ele
+func (s *shellSession) lookup(name string) (session.File, error) {
+	if name != "/" {
+		parent, err := s.lookup(path.Dir(name))
+		if err != nil {
+			return session.File{}, err
+		}
+		if !parent.Dir {
+			return session.File{}, errNotDir
+		}
+		if !s.allowed(parent, 1) {
+			return session.File{}, errDenied
+		}
+	}
+	file, exists := s.stat(name)
+	if !exists {
+		return session.File{}, errNotFound
+	}
+	return file, nil
+}
+
+// readFile lê um arquivo para cat, cp e afins
+func (s *shellSession) readFile(name string) ([]byte, error) {
+	file, err := s.lookup(name)
+	switch {
+	case err != nil:
+		return nil, err
+	case file.Dir:
+		return nil, errIsDir
+	case !s.allowed(file, 4):
+		return nil, errDenied
+	}
+	return file.Content, nil
+}
+
+// writable verifica se o usuário pode criar ou apagar entradas no diretório de name
+func (s *shellSession) writable(name string) error {
+	parent, err := s.lookup(path.Dir(name))
+	switch {
+	case err != nil:
+		return err
+	case !parent.Dir:
+		return errNotDir
+	case !s.allowed(parent, 2|1):
+		return errDenied
+	}
+	return nil
+}
+
+// writeFile cria, sobrescreve ou acrescenta a um arquivo
+func (s *shellSession) writeFile(name string, data []byte, appendTo bool) error {
+	return s.writeLimited(name, data, appendTo, maxFileSize)
+}
+
+// writeLimited é o writeFile com outro limite de tamanho, usado pelos downloads
+func (s *shellSession) writeLimited(name string, data []byte, appendTo bool, limit int64) error {
+	file, err := s.lookup(name)
+	switch {
+	case err == errNotFound:
+		if err := s.writable(name); err != nil {
+			return err
+		}
+		file = session.File{Mode: 0644, Owner: s.user()}
+	case err != nil:
+		return err
+	case file.Dir:
+		return errIsDir
+	case !s.allowed(file, 2):
+		return errDenied
+	}
+
+	old := file.Content
+	if appendTo {
+		data = append(append([]byte(nil), old...), data...)
+	}
+	if int64(len(data)) > limit {
+		return errNoSpace
+	}
+	if err := s.fits(name, len(data)); err != nil {
+		return err
+	}
+	file.Content = data
+	file.ModTime = time.Now()
+	file.Deleted = false
+	file.Source = ""
+	s.state.Files[name] = &file
+	s.persisted(name, old, data)
+	return nil
+}
+
+// makeDir cria um diretório; com parents cria os que faltam e aceita os existentes
+func (s *shellSession) makeDir(name string, parents bool) error {
+	if file, err := s.lookup(name); err == nil {
+		if parents && file.Dir {
+			return nil
+		}
+		return errExists
+	}
+	if parents && name != "/" {
+		if _, exists := s.stat(path.Dir(name)); !exists {
+			if err := s.makeDir(path.Dir(name), true); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.writable(name); err != nil {
+		return err
+	}
+	if err := s.fits(name, 0); err != nil {
+		return err
+	}
+	s.state.Files[name] = &session.File{Dir: true, Mode: 0755, Owner: s.user(), ModTime: time.Now()}
+	return nil
+}
+
+// quota é a cota do estado do atacante (session_store.quota)
+func (s *shellSession) quota() session.Quota {
+	if s.shell.store != nil {
+		return s.shell.store.Config().Quota
+	}
+	return session.DefaultQuota
+}
+
+// fits aplica a cota antes de gravar size bytes em name
+func (s *shellSession) fits(name string, size int) error {
+	if !s.quota().Allows(s.state, name, int64(size)) {
+		return errNoSpace
+	}
+	return nil
+}
+
+// remove apaga um caminho; arquivos do sistema simulado viram uma marca de apagado
+func (s *shellSession) remove(name string, recursive bool) error {
+	file, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+	if file.Dir && !recursive {
+		return errIsDir
+	}
+	if name == "/" {
+		return errNotPermit
+	}
+	if err := s.writable(name); err != nil {
+		return err
+	}
+
+	for other := range s.state.Files {
+		if strings.HasPrefix(other, name+"/") {
+			delete(s.state.Files, other)
+		}
+	}
+	delete(s.state.Files, name)
+	if _, exists := s.baseFile(name); exists {
+		s.state.Files[name] = &session.File{Deleted: true, ModTime: time.Now()}
+	}
+	return nil
+}
+
+// cd troca o diretório atual
+func (s *shellSession) cd(args []string) string {
+	target := s.home()
+	if len(args) > 0 {
+		target = args[0]
+	}
+	back := target == "-"
+	if back {
+		target = s.oldpwd
+		if target == "" {
+			return "bash: cd: OLDPWD not set"
+		}
+	}
+	dir := s.resolve(target)
+	file, err := s.lookup(dir)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("bash: cd: %s: %v", target, err)
+	case !file.Dir:
+		return fmt.Sprintf("bash: cd: %s: %v", target, errNotDir)
+	case !s.allowed(file, 1):
+		return fmt.Sprintf("bash: cd: %s: %v", target, errDenied)
+	}
+	s.oldpwd, s.cwd = s.cwd, dir
+	if back {
+		return dir
+	}
+	return ""
+}
+
+// ls lista arquivos e diretórios; entende -l, -a, -A, -d e -1
+func (s *shellSession) ls(args []string) string {
+	var long, all, almost, directory, single bool
+	var names []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			names = append(names, arg)
+			continue
+		}
+		for _, flag := range strings.TrimLeft(arg, "-") {
+			switch flag {
+			case 'l':
+				long = true
+			case 'a':
+				all = true
+			case 'A':
+				almost = true
+			case 'd':
+				directory = true
+			case '1':
+				single = true
+			}
+		}
+	}
+	if len(names) == 0 {
+		names = []string{"."}
+	}
+
+	var errs, files, blocks []string
+	var dirs []string
+	for _, name := range names {
+		full := s.resolve(name)
+		file, err := s.lookup(full)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("ls: cannot access '%s': %v", name, err))
+			continue
+		}
+		if !file.Dir || directory {
+			files = append(files, s.lsEntry(name, file, long))
+			continue
+		}
+		dirs = append(dirs, name)
+	}
+
+	separator := "  "
+	if long || single {
+		separator = "\n"
+	}
+	if len(files) > 0 {
+		blocks = append(blocks, strings.Join(files, separator))
+	}
+	for _, name := range dirs {
+		full := s.resolve(name)
+		file, _ := s.lookup(full)
+		if !s.allowed(file, 4) {
+			errs = append(errs, fmt.Sprintf("ls: cannot open directory '%s': %v", name, errDenied))
+			continue
+		}
+
+		var entries []string
+		total := 0
+		if all {
+			parent, _ := s.stat(path.Dir(full))
+			entries = append(entries, s.lsEntry(".", file, long), s.lsEntry("..", parent, long))
+			total += 8
+		}
+		for _, child := range s.children(full) {
+			if strings.HasPrefix(child, ".") && !all && !almost {
+				continue
+			}
+			entry, _ := s.stat(path.Join(full, child))
+			entries = append(entries, s.lsEntry(child, entry, long))
+			total += blocksOf(entry)
+		}
+
+		block := strings.Join(entries, separator)
+		if long {
+			block = strings.TrimSuffix(fmt.Sprintf("total %d\n%s", total, block), "\n")
+		}
+		if len(names) > 1 {
+			block = name + ":\n" + block
+		}
+		blocks = append(blocks, block)
+	}
+	return strings.Join(append(errs, blocks...), "\n\n")
+}
+
+func (s *shellSession) lsEntry(name string, file session.File, long bool) string {
+	if !long {
+		return name
+	}
+	size, links := len(file.Content), 1
+	if file.Dir {
+		size, links = 4096, 2
+	}
+	stamp := file.ModTime.Format("Jan _2 15:04")
+	if time.Since(file.ModTime) > 180*24*time.Hour {
+		stamp = file.ModTime.Format("Jan _2  2006")
+	}
+	return fmt.Sprintf("%s %d %-5s %-5s %5d %s %s", modeString(file), links, file.Owner, file.Owner, size, stamp, name)
+}
+
+// blocksOf é o número de blocos de 1K que o ls -l soma em "total"
+func blocksOf(file session.File) int {
+	if file.Dir {
+		return 4
+	}
+	return (len(file.Content) + 4095) / 4096 * 4
+}
+
+// modeString formata as permissões como o ls -l (drwxr-xr-x, drwxrwxrwt, ...)
+func modeString(file session.File) string {
+	mode := []byte("-rwxrwxrwx")
+	if file.Dir {
+		mode[0] = 'd'
+	}
+	for i := 0; i < 9; i++ {
+		if file.Mode&(1<<uint(8-i)) == 0 {
+			mode[i+1] = '-'
+		}
+	}
+	if file.Mode&os.ModeSticky != 0 {
+		mode[9] = 't'
+	}
+	return string(mode)
+}
+
+// cat concatena arquivos do sistema de arquivos falso
+func (s *shellSession) cat(args []string) string {
+	var out strings.Builder
+	var errs []string
+	for _, name := range args {
+		if strings.HasPrefix(name, "-") {
+			continue
+		}
+		content, err := s.readFile(s.resolve(name))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cat: %s: %v", name, err))
+			continue
+		}
+		out.Write(content)
+	}
+	if out.Len() > 0 {
+		errs = append([]string{strings.TrimSuffix(out.String(), "\n")}, errs...)
+	}
+	return strings.Join(errs, "\n")
+}
+
+// touch cria arquivos vazios ou atualiza a data dos existentes
+func (s *shellSession) touch(args []string) string {
+	var errs []string
+	for _, name := range args {
+		if strings.HasPrefix(name, "-") {
+			continue
+		}
+		full := s.resolve(name)
+		if err := s.writeFile(full, nil, true); err != nil {
+			errs = append(errs, fmt.Sprintf("touch: cannot touch '%s': %v", name, err))
+		}
+	}
+	return strings.Join(errs, "\n")
+}
+
+// mkdir cria diretórios; entende -p
+func (s *shellSession) mkdir(args []string) string {
+	parents := false
+	var errs []string
+	for _, arg := range args {
+		if arg == "-p" || arg == "--parents" {
+			parents = true
+		}
+	}
+	for _, name := range args {
+		if strings.HasPrefix(name, "-") {
+			continue
+		}
+		if err := s.makeDir(s.resolve(name), parents); err != nil {
+			errs = append(errs, fmt.Sprintf("mkdir: cannot create directory '%s': %v", name, err))
+		}
+	}
+	return strings.Join(errs, "\n")
+}
+
+// rm apaga arquivos; entende -r, -R e -f
+func (s *shellSession) rm(args []string) string {
+	var recursive, force bool
+	var names, errs []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			names = append(names, arg)
+			continue
+		}
+		recursive = recursive || strings.ContainsAny(arg, "rR")
+		force = force || strings.Contains(arg, "f")
+	}
+	if len(names) == 0 && !force {
+		return "rm: missing operand\nTry 'rm --help' for more information."
+	}
+	for _, name := range names {
+		err := s.remove(s.resolve(name), recursive)
+		if err == nil || (force && err == errNotFound) {
+			continue
+		}
+		errs = append(errs, fmt.Sprintf("rm: cannot remove '%s': %v", name, err))
+	}
+	return strings.Join(errs, "\n")
+}
+
+// copyFile copia (ou move) um arquivo; um destino que é diretório recebe o mesmo nome
+func (s *shellSession) copyFile(program string, args []string, move bool) string {
+	var names []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			names = append(names, arg)
+		}
+	}
+	if len(names) < 2 {
+		return fmt.Sprintf("%s: missing destination file operand after '%s'\nTry '%s --help' for more information.",
+			program, strings.Join(names, " "), program)
+	}
+
+	target := names[len(names)-1]
+	targetDir := false
+	if file, err := s.lookup(s.resolve(target)); err == nil && file.Dir {
+		targetDir = true
+	}
+	var errs []string
+	for _, name := range names[:len(names)-1] {
+		source := s.resolve(name)
+		destination := s.resolve(target)
+		if targetDir {
+			destination = path.Join(destination, path.Base(source))
+		}
+
+		file, err := s.lookup(source)
+		if err == nil && file.Dir {
+			err = errIsDir
+		}
+		if err == nil && !s.allowed(file, 4) {
+			err = errDenied
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: cannot stat '%s': %v", program, name, err))
+			continue
+		}
+		// Um arquivo baixado pode passar de maxFileSize; a cópia cabe onde o original coube
+		if err := s.writeLimited(destination, file.Content, false, max(maxFileSize, int64(len(file.Content)))); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: cannot create regular file '%s': %v", program, target, err))
+			continue
+		}
+		copied := s.state.Files[destination]
+		copied.Mode = file.Mode
+		copied.Source = file.Source
+		if move {
+			copied.Owner = file.Owner
+			if err := s.remove(source, false); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: cannot remove '%s': %v", program, name, err))
+			}
+		}
+	}
+	return strings.Join(errs, "\n")
+}
+
+// chmod altera permissões em octal (755) ou simbólicas (+x, u+rwx, go-w)
+func (s *shellSession) chmod(args []string) string {
+	var operands []string
+	for _, arg := range args {
+		if arg != "-R" && arg != "-v" && arg != "-f" {
+			operands = append(operands, arg)
+		}
+	}
+	if len(operands) < 2 {
+		return "chmod: missing operand\nTry 'chmod --help' for more information."
+	}
+
+	spec := operands[0]
+	var errs []string
+	for _, name := range operands[1:] {
+		full := s.resolve(name)
+		file, err := s.lookup(full)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("chmod: cannot access '%s': %v", name, err))
+			continue
+		}
+		if !s.isRoot() && file.Owner != s.user() {
+			errs = append(errs, fmt.Sprintf("chmod: changing permissions of '%s': %v", name, errNotPermit))
+			continue
+		}
+		mode, err := applyMode(file.Mode, spec)
+		if err != nil {
+			return fmt.Sprintf("chmod: invalid mode: '%s'\nTry 'chmod --help' for more information.", spec)
+		}
+		file.Mode = mode
+		file.ModTime = time.Now()
+		s.state.Files[full] = &file
+	}
+	return strings.Join(errs, "\n")
+}
+
+// applyMode calcula o novo modo a partir de um modo octal ou simbólico
+func applyMode(mode os.FileMode, spec string) (os.FileMode, error) {
+	if octal, err := strconv.ParseUint(spec, 8, 32); err == nil {
+		if octal > 07777 {
+			return mode, errInvalidArg
+		}
+		result := os.FileMode(octal & 0777)
+		if octal&01000 != 0 {
+			result |= os.ModeSticky
+		}
+		return result, nil
+	}
+
+	for _, clause := range strings.Split(spec, ",") {
+		op := strings.IndexAny(clause, "+-=")
+		if op < 0 {
+			return mode, errInvalidArg
+		}
+		who, perms := clause[:op], clause[op+1:]
+		if who == "" || strings.Contains(who, "a") {
+			who = "ugo"
+		}
+		var bits os.FileMode
+		for _, perm := range perms {
+			switch perm {
+			case 'r':
+				bits |= 4
+			case 'w':
+				bits |= 2
+			case 'x':
+				bits |= 1
+			default:
+				return mode, errInvalidArg
+			}
+		}
+		var mask, value os.FileMode
+		for _, class := range who {
+			shift := map[rune]uint{'u': 6, 'g': 3, 'o': 0}[class]
+			mask |= 7 << shift
+			value |= bits << shift
+		}
+		switch clause[op] {
+		case '+':
+			mode |= value
+		case '-':
+			mode &^= value
+		case '=':
+			mode = mode&^mask | value
+		}
+	}
+	return mode, nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"myhoneypot/session"
+)
+
+// testSession abre um shell de root com o estado guardado em memória, sujeito a quota
+func testSession(t *testing.T, quota session.Quota) *shellSession {
+	t.Helper()
+	store, err := session.NewStore(nil, session.Config{Quota: quota})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	shell, err := NewShell(SimulationConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shell.SetStore(store)
+
+	server, client := net.Pipe()
+	t.Cleanup(func() { client.Close(); server.Close() })
+	go io.Copy(io.Discard, client)
+	s := shell.newSession(server, "ssh")
+	s.users = []string{"root"}
+	s.state = session.NewState("203.0.113.9")
+	s.cwd = "/root"
+	return s
+}
+
+func TestShellQuota(t *testing.T) {
+	s := testSession(t, session.Quota{MaxFiles: 3, MaxBytes: 8})
+
+	for _, c := range []struct{ command, want string }{
+		{"echo 1234 > /tmp/a", ""},
+		{"echo 12345678 > /tmp/b", "bash: /tmp/b: No space left on device"},
+		{"mkdir /tmp/d", ""},
+		{"echo 12 >> /tmp/a", ""},
+		{"touch /tmp/c", ""},
+		{"touch /tmp/e", "touch: cannot touch '/tmp/e': No space left on device"},
+		{"mkdir /tmp/f", "mkdir: cannot create directory '/tmp/f': No space left on device"},
+		{"useradd bot", "useradd: failure while writing changes to /etc/passwd"},
+		{"rm /tmp/c", ""},
+		{"useradd bot", ""},
+	} {
+		if got := s.execute(c.command); got != c.want {
+			t.Errorf("%s = %q, esperado %q", c.command, got, c.want)
+		}
+	}
+	if files, bytes := s.state.Usage(); files > 3 || bytes > 8 {
+		t.Fatalf("estado com %d entradas e %d bytes passou da cota", files, bytes)
+	}
+}
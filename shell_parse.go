@@ -0,0 +1,585 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// splitWords separa um comando em palavras como o bash: aspas simples são literais, aspas duplas
+// e palavras soltas expandem $VAR, ${VAR}, $(comando) e `comando` com env e run. Fora de aspas o
+// resultado de uma expansão é dividido nos espaços
+func splitWords(command string, env, run func(string) string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	split := func(value string) {
+		if value != "" && isBlank(value[0]) {
+			flush()
+		}
+		for i, field := range strings.Fields(value) {
+			if i > 0 {
+				flush()
+			}
+			word.WriteString(field)
+			inWord = true
+		}
+		if value != "" && isBlank(value[len(value)-1]) {
+			flush()
+		}
+	}
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case isBlank(c):
+			flush()
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				end = len(command) - i - 1
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			for i++; i < len(command) && command[i] != '"'; i++ {
+				switch {
+				case command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) >= 0:
+					i++
+					word.WriteByte(command[i])
+				case command[i] == '$' || command[i] == '`':
+					value, n := expansion(command[i:], env, run)
+					word.WriteString(value)
+					i += n - 1
+				default:
+					word.WriteByte(command[i])
+				}
+			}
+		case c == '\\' && i+1 < len(command):
+			inWord = true
+			i++
+			word.WriteByte(command[i])
+		case c == '$' || c == '`':
+			value, n := expansion(command[i:], env, run)
+			if n == 1 {
+				word.WriteByte('$')
+				inWord = true
+			} else {
+				split(value)
+			}
+			i += n - 1
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+	flush()
+	return words
+}
+
+// expansion é o valor de $VAR, ${VAR}, $1, $?, $(comando) ou `comando` no início de text e
+// quantos bytes ele ocupa; um $ solto vale ele mesmo
+func expansion(text string, env, run func(string) string) (string, int) {
+	switch {
+	case text[0] == '`':
+		end := strings.IndexByte(text[1:], '`')
+		if end < 0 {
+			end = len(text) - 1
+		}
+		return strings.TrimRight(run(text[1:1+end]), "\n"), end + 2
+	case strings.HasPrefix(text, "$("):
+		end := closing(text[1:]) + 1
+		return strings.TrimRight(run(text[2:end]), "\n"), end + 1
+	case strings.HasPrefix(text, "${"):
+		if end := strings.IndexByte(text, '}'); end > 0 {
+			return env(text[2:end]), end + 1
+		}
+	case len(text) > 1 && strings.IndexByte("?$#@*!0123456789", text[1]) >= 0:
+		return env(text[1:2]), 2
+	}
+	end := 1
+	for end < len(text) && (text[end] == '_' || isAlnum(text[end])) {
+		end++
+	}
+	if end == 1 {
+		return "$", 1
+	}
+	return env(text[1:end]), end
+}
+
+// closing encontra o ")" que fecha o "(" em text[0], pulando aspas e grupos internos
+func closing(text string) int {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '\\':
+			i++
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(text) - 1
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// commandName é a primeira palavra do comando, sem expansões, para escolher como executá-lo
+func commandName(command string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(command), " ")
+	return name
+}
+
+// isName diz se text é um nome de variável válido
+func isName(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '_' && !isAlnum(text[i]) || i == 0 && text[i] >= '0' && text[i] <= '9' {
+			return false
+		}
+	}
+	return text != ""
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// splitRedirect tira os redirecionamentos de saída do comando. target é o arquivo da saída padrão
+// (vazio se não houver); 2>arquivo e 2>&1 são descartados
+func splitRedirect(command string) (rest, target string, appendTo bool) {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '\'' || c == '"':
+			quote = c
+			continue
+		case c == '\\':
+			i++
+			continue
+		case c == '(':
+			depth++
+			continue
+		case c == ')':
+			depth--
+			continue
+		case c != '>' || depth > 0:
+			continue
+		}
+
+		start, fd := i, byte('1')
+		if i > 0 && strings.IndexByte("12&", command[i-1]) >= 0 && (i == 1 || command[i-2] == ' ') {
+			start, fd = i-1, command[i-1]
+		}
+		end := i + 1
+		double := end < len(command) && command[end] == '>'
+		if double {
+			end++
+		}
+		for end < len(command) && command[end] == ' ' {
+			end++
+		}
+		wordStart := end
+		for end < len(command) && command[end] != ' ' {
+			end++
+		}
+		word := strings.Trim(command[wordStart:end], `'"`)
+
+		command = command[:start] + command[end:]
+		i = start - 1
+		if fd != '2' && !strings.HasPrefix(word, "&") {
+			target, appendTo = word, double
+		}
+	}
+	return strings.TrimSpace(command), target, appendTo
+}
+
+// splitTop corta command nos operadores ops que estão fora de aspas e parênteses; retorna as partes
+// e o operador antes de cada uma ("" para a primeira). ops deve listar os operadores longos primeiro
+func splitTop(command string, ops ...string) (parts, before []string) {
+	var quote byte
+	depth, start := 0, 0
+	last := ""
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			continue
+		case c == '\\':
+			i++
+			continue
+		case c == '(':
+			depth++
+			continue
+		case c == ')':
+			depth--
+			continue
+		case depth > 0:
+			continue
+		}
+		for _, op := range ops {
+			if !strings.HasPrefix(command[i:], op) {
+				continue
+			}
+			// "|" não corta "||" nem "&" corta "&&" quando o operador longo não foi pedido
+			if next := i + len(op); next < len(command) && len(op) == 1 && command[next] == op[0] {
+				i++
+				break
+			}
+			parts = append(parts, strings.TrimSpace(command[start:i]))
+			before = append(before, last)
+			last, start = op, i+len(op)
+			i += len(op) - 1
+			break
+		}
+	}
+	parts = append(parts, strings.TrimSpace(command[start:]))
+	before = append(before, last)
+	return parts, before
+}
+
+// splitList separa "a; b && c || d" nos comandos da lista
+func splitList(command string) (commands, ops []string) {
+	commands, ops = splitTop(command, "&&", "||", ";")
+	// "a;" termina com um comando vazio que não conta
+	if len(commands) > 1 && commands[len(commands)-1] == "" {
+		commands, ops = commands[:len(commands)-1], ops[:len(ops)-1]
+	}
+	return commands, ops
+}
+
+// splitPipeline separa "a | b | c" nos estágios do pipeline
+func splitPipeline(command string) []string {
+	stages, _ := splitTop(command, "|")
+	return stages
+}
+
+// subshell retorna o conteúdo de "( ... )" quando o comando inteiro é um grupo
+func subshell(command string) (string, bool) {
+	if !strings.HasPrefix(command, "(") || !strings.HasSuffix(command, ")") {
+		return "", false
+	}
+	var quote byte
+	depth := 0
+	for i := 0; i < len(command); i++ {
+		switch c := command[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			// "(a) && (b)" fecha o primeiro grupo antes do fim
+			if depth == 0 && i < len(command)-1 {
+				return "", false
+			}
+		}
+	}
+	return strings.TrimSpace(command[1 : len(command)-1]), true
+}
+
+// statements quebra um script em comandos simples, um por linha ou por ";", com as palavras-chave
+// de blocos (then, do, else, {, }) separadas em itens próprios. Comentários e linhas vazias somem e
+// linhas terminadas em \ continuam na seguinte
+func statements(script string) []string {
+	script = strings.ReplaceAll(strings.ReplaceAll(script, "\r\n", "\n"), "\\\n", "")
+	var out []string
+	for _, line := range strings.Split(script, "\n") {
+		parts, _ := splitTop(stripComment(line), ";")
+		for _, part := range parts {
+			out = append(out, peel(part)...)
+		}
+	}
+	return out
+}
+
+// blockWords abrem um bloco quando começam um comando e precisam de um item próprio
+var blockWords = []string{"then", "do", "else", "{"}
+
+// peel separa as palavras-chave coladas a um comando: "do wget x" vira "do" e "wget x",
+// "f() { echo" vira "function f", "{" e "echo", e "echo }" vira "echo" e "}"
+func peel(part string) []string {
+	part = strings.TrimSpace(part)
+	if part == "" {
+		return nil
+	}
+	if name, rest, ok := functionHeader(part); ok {
+		return append([]string{"function " + name}, peel(rest)...)
+	}
+	for _, keyword := range blockWords {
+		if part == keyword {
+			return []string{part}
+		}
+		if rest, found := strings.CutPrefix(part, keyword+" "); found {
+			return append([]string{keyword}, peel(rest)...)
+		}
+	}
+	if part != "}" && strings.HasSuffix(part, "}") && !strings.HasSuffix(part, "${") &&
+		(strings.HasSuffix(part, " }") || strings.HasSuffix(part, ";}") || strings.HasSuffix(part, "&}")) {
+		return append(peel(strings.TrimSuffix(part, "}")), "}")
+	}
+	return []string{part}
+}
+
+// functionHeader reconhece "nome() resto", "nome () resto" e "function nome resto"
+func functionHeader(part string) (name, rest string, ok bool) {
+	if after, found := strings.CutPrefix(part, "function "); found {
+		name, rest, _ = strings.Cut(strings.TrimSpace(after), " ")
+		name = strings.TrimSuffix(name, "()")
+		return name, rest, name != ""
+	}
+	open := strings.Index(part, "()")
+	if open <= 0 {
+		return "", "", false
+	}
+	name = strings.TrimSpace(part[:open])
+	for i := 0; i < len(name); i++ {
+		if !isAlnum(name[i]) && strings.IndexByte("_:.-", name[i]) < 0 {
+			return "", "", false
+		}
+	}
+	return name, strings.TrimSpace(part[open+2:]), name != ""
+}
+
+// stripComment corta o comentário de uma linha: um # no início de uma palavra fora de aspas
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '\\':
+			i++
+		case c == '#' && (i == 0 || isBlank(line[i-1])):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// compound diz se o comando abre um bloco (if, for, while, until, case ou função) e precisa ser
+// executado como script; open indica que o bloco ainda não foi fechado
+func compound(command string) (isBlock, open bool) {
+	stmts := statements(command)
+	if len(stmts) == 0 {
+		return false, false
+	}
+	keyword, _, _ := strings.Cut(stmts[0], " ")
+	switch keyword {
+	case "if", "for", "while", "until", "case", "function":
+	default:
+		return false, false
+	}
+	depth := 0
+	for _, stmt := range stmts {
+		keyword, _, _ := strings.Cut(stmt, " ")
+		switch keyword {
+		case "if", "for", "while", "until", "select", "case", "{":
+			depth++
+		case "fi", "done", "esac", "}":
+			depth--
+		}
+	}
+	last := stmts[len(stmts)-1]
+	return true, depth > 0 || strings.HasPrefix(last, "function ") || strings.HasSuffix(strings.TrimSpace(command), "\\")
+}
+
+// blockEnd encontra o item que fecha o bloco aberto em stmts[0] (fi, done, esac ou })
+func blockEnd(stmts []string) int {
+	var stack []string
+	for i, stmt := range stmts {
+		keyword, _, _ := strings.Cut(stmt, " ")
+		switch keyword {
+		case "if":
+			stack = append(stack, "fi")
+		case "for", "while", "until", "select":
+			stack = append(stack, "done")
+		case "case":
+			stack = append(stack, "esac")
+		case "{":
+			stack = append(stack, "}")
+		case "fi", "done", "esac", "}":
+			if len(stack) > 0 && stack[len(stack)-1] == keyword {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		if len(stack) == 0 && i > 0 {
+			return i
+		}
+	}
+	return len(stmts) - 1
+}
+
+// echo imita o builtin do bash, com -n, -e e -E
+func echo(args []string) string {
+	newline, escapes := true, false
+	for len(args) > 0 && len(args[0]) > 1 && args[0][0] == '-' && strings.Trim(args[0][1:], "neE") == "" {
+		for _, flag := range args[0][1:] {
+			switch flag {
+			case 'n':
+				newline = false
+			case 'e':
+				escapes = true
+			case 'E':
+				escapes = false
+			}
+		}
+		args = args[1:]
+	}
+	out := strings.Join(args, " ")
+	if escapes {
+		out = unescape(out)
+	}
+	if newline {
+		out += "\n"
+	}
+	return out
+}
+
+// printf imita o builtin do bash para %s, %b, %d, %c e %%; o formato se repete enquanto houver argumentos
+func printf(args []string) string {
+	if len(args) == 0 {
+		return "printf: usage: printf [-v var] format [arguments]"
+	}
+	format, args := unescape(args[0]), args[1:]
+	var out strings.Builder
+	for {
+		consumed := false
+		for i := 0; i < len(format); i++ {
+			if format[i] != '%' || i+1 == len(format) {
+				out.WriteByte(format[i])
+				continue
+			}
+			i++
+			if format[i] == '%' {
+				out.WriteByte('%')
+				continue
+			}
+			arg := ""
+			if len(args) > 0 {
+				arg, args, consumed = args[0], args[1:], true
+			}
+			switch format[i] {
+			case 'b':
+				out.WriteString(unescape(arg))
+			case 'd', 'i':
+				n, _ := strconv.Atoi(arg)
+				out.WriteString(strconv.Itoa(n))
+			case 'c':
+				if arg != "" {
+					out.WriteByte(arg[0])
+				}
+			default:
+				out.WriteString(arg)
+			}
+		}
+		if !consumed || len(args) == 0 {
+			return out.String()
+		}
+	}
+}
+
+// unescape interpreta as sequências de echo -e e printf (\n, \t, \xHH, \0NNN, ...)
+func unescape(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			out.WriteByte(text[i])
+			continue
+		}
+		i++
+		switch c := text[i]; c {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case 'a':
+			out.WriteByte('\a')
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case 'v':
+			out.WriteByte('\v')
+		case 'e':
+			out.WriteByte(0x1b)
+		case '\\':
+			out.WriteByte('\\')
+		case 'x':
+			digits := 0
+			for digits < 2 && i+1+digits < len(text) && isHex(text[i+1+digits]) {
+				digits++
+			}
+			if digits == 0 {
+				out.WriteString(`\x`)
+				continue
+			}
+			value, _ := strconv.ParseUint(text[i+1:i+1+digits], 16, 8)
+			out.WriteByte(byte(value))
+			i += digits
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			start := i
+			if c == '0' {
+				start = i + 1
+			}
+			end := start
+			for end < len(text) && end < start+3 && text[end] >= '0' && text[end] <= '7' {
+				end++
+			}
+			value, _ := strconv.ParseUint("0"+text[start:end], 8, 16)
+			out.WriteByte(byte(value))
+			i = max(end-1, i)
+		default:
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
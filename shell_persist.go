@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/persona"
+	"myhoneypot/session"
+)
+
+// Mecanismos de persistência reconhecidos nos eventos PERSISTENCE
+const (
+	persistCron    = "cron"
+	persistSystemd = "systemd"
+	persistSSHKey  = "ssh_key"
+	persistShellRC = "shell_rc"
+	persistInit    = "init"
+	persistPreload = "preload"
+)
+
+// maxEditorLines limita o que o crontab -e aceita antes de desistir da edição
+const maxEditorLines = 500
+
+// persistenceMechanism classifica um caminho como local de persistência; vazio para os demais
+func persistenceMechanism(name string) string {
+	dir, base := path.Dir(name), path.Base(name)
+	switch {
+	case name == "/etc/crontab", strings.HasPrefix(dir, "/etc/cron."), strings.HasPrefix(name, "/var/spool/cron/"):
+		return persistCron
+	case base == "authorized_keys", base == "authorized_keys2":
+		return persistSSHKey
+	case strings.Contains(name, "/systemd/system/"), strings.Contains(name, "/systemd/user/"):
+		return persistSystemd
+	case name == "/etc/rc.local", dir == "/etc/init.d", strings.HasPrefix(dir, "/etc/rc") && strings.HasSuffix(dir, ".d"):
+		return persistInit
+	case name == "/etc/ld.so.preload":
+		return persistPreload
+	case name == "/etc/profile", name == "/etc/bash.bashrc", name == "/etc/bashrc", dir == "/etc/profile.d",
+		base == ".bashrc", base == ".bash_profile", base == ".bash_login", base == ".profile", base == ".zshrc":
+		return persistShellRC
+	}
+	return ""
+}
+
+// persisted gera os eventos PERSISTENCE quando um local de persistência muda; o conteúdo registrado
+// é só o trecho acrescentado, ou o arquivo inteiro quando ele foi reescrito
+func (s *shellSession) persisted(name string, old, data []byte) {
+	mechanism := persistenceMechanism(name)
+	if mechanism == "" {
+		return
+	}
+	added := data
+	if bytes.HasPrefix(data, old) {
+		added = data[len(old):]
+	}
+	content := strings.TrimSpace(string(added))
+	if content == "" {
+		return
+	}
+
+	if mechanism != persistSSHKey {
+		s.recordPersistence(mechanism, name, content, "", fmt.Sprintf("Persistência via %s em %s", mechanism, name))
+		return
+	}
+	// Uma chave por evento, com o fingerprint que o sshd mostraria no login
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fingerprint, _ := sshFingerprint(line)
+		s.recordPersistence(mechanism, name, line, fingerprint, fmt.Sprintf("Chave SSH autorizada em %s %s", name, fingerprint))
+	}
+}
+
+func (s *shellSession) recordPersistence(mechanism, name, content, fingerprint, event string) {
+	s.record(logging.LogEntry{
+		Event:     event,
+		Level:     logging.WARNING,
+		Type:      logging.EventPersistence,
+		Command:   s.line,
+		Mechanism: mechanism,
+		Path:      name,
+		Content:   content,
+		SSHKey:    fingerprint,
+	})
+}
+
+// sshFingerprint calcula o fingerprint SHA256 de uma linha do authorized_keys, como o ssh-keygen -lf
+func sshFingerprint(line string) (string, bool) {
+	fields := strings.Fields(line)
+	// Opções como command="..." ou from="..." podem vir antes do tipo da chave
+	for i := 0; i+1 < len(fields); i++ {
+		blob, err := base64.StdEncoding.DecodeString(fields[i+1])
+		if err != nil || len(blob) < 4 {
+			continue
+		}
+		// O blob começa com o tipo da chave, prefixado pelo tamanho
+		size := binary.BigEndian.Uint32(blob)
+		if uint64(size)+4 > uint64(len(blob)) || string(blob[4:4+size]) != fields[i] {
+			continue
+		}
+		sum := sha256.Sum256(blob)
+		return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), true
+	}
+	return "", false
+}
+
+// install grava um arquivo como os programas privilegiados (crontab, systemctl), sem checar permissões
+func (s *shellSession) install(name string, data []byte, owner string, mode os.FileMode) error {
+	if err := s.fits(name, len(data)); err != nil {
+		return err
+	}
+	old, _ := s.stat(name)
+	s.state.Files[name] = &session.File{Content: data, Mode: mode, Owner: owner, ModTime: time.Now()}
+	s.persisted(name, old.Content, data)
+	return nil
+}
+
+// systemCrontab é o /etc/crontab instalado pelo cron da distribuição
+func systemCrontab(p *persona.Profile) string {
+	example := "# Example of job definition:\n" +
+		"# .---------------- minute (0 - 59)\n" +
+		"# |  .------------- hour (0 - 23)\n" +
+		"# |  |  .---------- day of month (1 - 31)\n" +
+		"# |  |  |  .------- month (1 - 12) OR jan,feb,mar,apr ...\n" +
+		"# |  |  |  |  .---- day of week (0 - 6) (Sunday=0 or 7) OR sun,mon,tue,wed,thu,fri,sat\n" +
+		"# |  |  |  |  |\n"
+	if p.OS.PackageManager == "rpm" {
+		return "SHELL=/bin/bash\nPATH=/sbin:/bin:/usr/sbin:/usr/bin\nMAILTO=root\n\n" +
+			"# For details see man 4 crontabs\n\n" + example +
+			"# *  *  *  *  * user-name  command to be executed\n\n"
+	}
+	return "# /etc/crontab: system-wide crontab\n" +
+		"# Unlike any other crontab you don't have to run the `crontab'\n" +
+		"# command to install the new version when you edit this file\n" +
+		"# and files in /etc/cron.d. These files also have username fields,\n" +
+		"# that none of the other crontabs do.\n\n" +
+		"SHELL=/bin/sh\nPATH=/usr/local/sbin:/usr/local/bin:/sbin:/bin:/usr/sbin:/usr/bin\n\n" + example +
+		"# *  *  *  *  * user-name command to be executed\n" +
+		"17 *\t* * *\troot    cd / && run-parts --report /etc/cron.hourly\n" +
+		"25 6\t* * *\troot\ttest -x /usr/sbin/anacron || ( cd / && run-parts --report /etc/cron.daily )\n" +
+		"47 6\t* * 7\troot\ttest -x /usr/sbin/anacron || ( cd / && run-parts --report /etc/cron.weekly )\n" +
+		"52 6\t1 * *\troot\ttest -x /usr/sbin/anacron || ( cd / && run-parts --report /etc/cron.monthly )\n#\n"
+}
+
+// crontabPath é o arquivo de spool do crontab de user, que muda entre Debian e Red Hat
+func (s *shellSession) crontabPath(user string) string {
+	if s.profile().OS.PackageManager == "rpm" {
+		return "/var/spool/cron/" + user
+	}
+	return "/var/spool/cron/crontabs/" + user
+}
+
+// crontab imita o crontab(1): -l, -r, -e, -u e instalação a partir de arquivo ou da entrada padrão
+func (s *shellSession) crontab(args []string, input string, piped bool) string {
+	user, action, file := s.user(), "", ""
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-u":
+			if i+1 < len(args) {
+				if !s.isRoot() {
+					return "must be privileged to use -u"
+				}
+				user, i = args[i+1], i+1
+			}
+		case "-l", "-r", "-e", "-i":
+			action = args[i]
+		default:
+			file = args[i]
+		}
+	}
+	if _, exists := s.profile().User(user); !exists && user != s.users[0] {
+		return fmt.Sprintf("crontab: user `%s' unknown", user)
+	}
+
+	spool := s.crontabPath(user)
+	current, exists := s.stat(spool)
+	switch action {
+	case "-l":
+		if !exists {
+			s.stderr("no crontab for " + user)
+			return ""
+		}
+		return strings.TrimSuffix(string(current.Content), "\n")
+	case "-r", "-i":
+		if !exists {
+			return "no crontab for " + user
+		}
+		delete(s.state.Files, spool)
+		return ""
+	case "-e":
+		return s.crontabEdit(user, spool)
+	}
+
+	source := file
+	switch {
+	case file == "-" || file == "" && piped:
+		source = "-"
+	case file == "":
+		// Sem arquivo e sem pipe o crontab esperaria o texto no terminal
+		return ""
+	default:
+		data, err := s.readFile(s.resolve(file))
+		if err != nil {
+			return fmt.Sprintf("%s: %v", file, err)
+		}
+		input = string(data)
+	}
+	if problem := crontabError(source, input); problem != "" {
+		return problem
+	}
+	if err := s.install(spool, []byte(input), user, 0600); err != nil {
+		return fmt.Sprintf("%s: %v", spool, err)
+	}
+	return ""
+}
+
+// crontabEdit imita o crontab -e com o vi: as linhas digitadas entram no fim do crontab até :wq
+func (s *shellSession) crontabEdit(user, spool string) string {
+	current, exists := s.stat(spool)
+	if !exists {
+		s.write("no crontab for " + user + " - using an empty one\n")
+	}
+	content := string(current.Content)
+	for i := 0; i < maxEditorLines; i++ {
+		line, err := s.readLine()
+		if err != nil {
+			return ""
+		}
+		// Tira os ESC de troca de modo e ignora os comandos do vi que só mudam o modo
+		line = strings.ReplaceAll(line, "\x1b", "")
+		switch strings.TrimSpace(line) {
+		case ":q!", ":q":
+			return "crontab: no changes made to crontab"
+		case ":wq", ":x", ":wq!", "ZZ":
+			if content == string(current.Content) {
+				return "crontab: no changes made to crontab"
+			}
+			if problem := crontabError("/tmp/crontab."+s.id[:6]+"/crontab", content); problem != "" {
+				return problem
+			}
+			if err := s.install(spool, []byte(content), user, 0600); err != nil {
+				return fmt.Sprintf("crontab: installing new crontab\n%s: %v", spool, err)
+			}
+			return "crontab: installing new crontab"
+		case "i", "a", "o", "O", "A", "I", "G", "":
+			continue
+		}
+		content += line + "\n"
+	}
+	return "crontab: no changes made to crontab"
+}
+
+// crontabError valida as linhas como o cron: cinco campos de horário (ou @reboot e afins) e o comando
+func crontabError(source, content string) string {
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, _, found := strings.Cut(line, "="); found && !strings.ContainsAny(name, " \t*") {
+			continue
+		}
+		fields := strings.Fields(line)
+		valid := len(fields) >= 6 && strings.Trim(fields[0], "0123456789*/,-") == ""
+		if strings.HasPrefix(line, "@") {
+			valid = len(fields) >= 2
+		}
+		if !valid {
+			return fmt.Sprintf("\"%s\":%d: bad minute\nerrors in crontab file, can't install.", source, i+1)
+		}
+	}
+	return ""
+}
+
+// unitDirs são os diretórios onde o systemctl procura units, do mais para o menos prioritário
+var unitDirs = []string{"/etc/systemd/system", "/lib/systemd/system", "/usr/lib/systemd/system"}
+
+// unit encontra o arquivo de uma unit: primeiro no sistema de arquivos, depois entre os serviços
+// do perfil, que existem sem um arquivo visível
+func (s *shellSession) unit(name string) (string, session.File, bool) {
+	for _, dir := range unitDirs {
+		if file, exists := s.stat(dir + "/" + name); exists && !file.Dir {
+			return dir + "/" + name, file, true
+		}
+	}
+	service := strings.TrimSuffix(name, ".service")
+	for _, process := range s.profile().Processes {
+		command := strings.Fields(process.Command)
+		if len(command) == 0 || process.PID < 100 {
+			continue
+		}
+		if program := path.Base(command[0]); program == service || program == service+"d" {
+			file := session.File{Mode: 0644, Owner: "root", ModTime: persona.Current().BootTime(),
+				Content: []byte(fmt.Sprintf("[Unit]\nDescription=%s\n\n[Service]\nExecStart=%s\n", service, process.Command))}
+			return "/lib/systemd/system/" + name, file, true
+		}
+	}
+	return "", session.File{}, false
+}
+
+// unitValue lê uma chave (Description=, ExecStart=) do arquivo da unit
+func unitValue(file session.File, key string) string {
+	for _, line := range strings.Split(string(file.Content), "\n") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), key+"="); found {
+			return value
+		}
+	}
+	return ""
+}
+
+// systemctl imita enable, disable, start, stop, restart, status, is-enabled, is-active e daemon-reload
+func (s *shellSession) systemctl(args []string) string {
+	verb, now := "", false
+	var units []string
+	for _, arg := range args {
+		switch {
+		case arg == "--now":
+			now = true
+		case strings.HasPrefix(arg, "-"):
+		case verb == "":
+			verb = arg
+		default:
+			if !strings.Contains(arg, ".") {
+				arg += ".service"
+			}
+			units = append(units, arg)
+		}
+	}
+
+	switch verb {
+	case "daemon-reload", "enable", "disable", "start", "stop", "restart", "reload":
+		if !s.isRoot() {
+			return "Failed to " + verb + " unit: Interactive authentication required."
+		}
+	case "status", "is-enabled", "is-active":
+	case "":
+		return ""
+	default:
+		return fmt.Sprintf("Unknown command verb %s.", verb)
+	}
+	if verb == "daemon-reload" {
+		return ""
+	}
+	if len(units) == 0 {
+		return "Too few arguments."
+	}
+
+	var out []string
+	for _, name := range units {
+		unitPath, file, exists := s.unit(name)
+		wants := "/etc/systemd/system/multi-user.target.wants/" + name
+		_, enabled := s.stat(wants)
+		if !exists {
+			switch verb {
+			case "enable", "disable":
+				out = append(out, fmt.Sprintf("Failed to %s unit: Unit file %s does not exist.", verb, name))
+			case "status":
+				out = append(out, fmt.Sprintf("Unit %s could not be found.", name))
+			case "is-enabled", "is-active":
+				out = append(out, "inactive")
+			default:
+				out = append(out, fmt.Sprintf("Failed to %s %s: Unit %s not found.", verb, name, name))
+			}
+			continue
+		}
+		// As units do perfil estão sempre habilitadas e rodando
+		system := !strings.HasPrefix(unitPath, "/etc/") && file.ModTime.Equal(persona.Current().BootTime())
+
+		switch verb {
+		case "enable":
+			if !enabled && !system {
+				if err := s.fits(wants, len(file.Content)); err != nil {
+					out = append(out, fmt.Sprintf("Failed to enable unit: %v", err))
+					continue
+				}
+				s.state.Files[wants] = &session.File{Content: file.Content, Mode: 0777, Owner: "root", ModTime: time.Now()}
+				out = append(out, fmt.Sprintf("Created symlink %s → %s.", wants, unitPath))
+				s.recordPersistence(persistSystemd, unitPath, string(file.Content), "", fmt.Sprintf("Unit %s habilitada via systemctl", name))
+			}
+			if now {
+				s.startUnit(file)
+			}
+		case "disable":
+			if enabled {
+				delete(s.state.Files, wants)
+				out = append(out, "Removed "+wants+".")
+			}
+		case "start", "restart":
+			s.startUnit(file)
+		case "is-enabled":
+			out = append(out, map[bool]string{true: "enabled", false: "disabled"}[enabled || system])
+		case "is-active":
+			out = append(out, map[bool]string{true: "active", false: "inactive"}[s.running(file) || system])
+		case "status":
+			out = append(out, s.unitStatus(name, unitPath, file, enabled || system, system))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// startUnit põe o ExecStart da unit na lista de processos
+func (s *shellSession) startUnit(file session.File) {
+	if command := unitValue(file, "ExecStart"); command != "" && !s.running(file) {
+		s.spawn("root", "?", "Ss", command)
+	}
+}
+
+func (s *shellSession) running(file session.File) bool {
+	command := unitValue(file, "ExecStart")
+	for _, process := range s.processes {
+		if command != "" && process.Command == command {
+			return true
+		}
+	}
+	return false
+}
+
+// unitStatus é a saída do systemctl status de uma unit
+func (s *shellSession) unitStatus(name, unitPath string, file session.File, enabled, system bool) string {
+	state := map[bool]string{true: "enabled", false: "disabled"}[enabled]
+	description := unitValue(file, "Description")
+	if description == "" {
+		description = name
+	}
+	lines := []string{
+		fmt.Sprintf("● %s - %s", name, description),
+		fmt.Sprintf("   Loaded: loaded (%s; %s; vendor preset: enabled)", unitPath, state),
+	}
+
+	since, pid := time.Time{}, 0
+	command := unitValue(file, "ExecStart")
+	for _, process := range s.processes {
+		if command != "" && process.Command == command {
+			since, pid = process.Start, process.PID
+		}
+	}
+	if system {
+		since = persona.Current().BootTime()
+	}
+	if since.IsZero() {
+		return strings.Join(append(lines, "   Active: inactive (dead)"), "\n")
+	}
+	lines = append(lines, fmt.Sprintf("   Active: active (running) since %s; %s ago",
+		since.Format("Mon 2006-01-02 15:04:05 MST"), elapsed(time.Since(since))))
+	if pid > 0 {
+		lines = append(lines, fmt.Sprintf(" Main PID: %d (%s)", pid, path.Base(strings.Fields(command)[0])))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// elapsed formata uma duração como o systemctl status (45s, 12min, 3h 5min, 2 days)
+func elapsed(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dmin", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dmin", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%d days", int(d.Hours()/24))
+}
+
+// enableInit imita update-rc.d e chkconfig habilitando um script de /etc/init.d
+func (s *shellSession) enableInit(program string, args []string) string {
+	names := operands(args)
+	if len(names) == 0 {
+		return "usage: " + program + " <basename> defaults"
+	}
+	name := names[0]
+	if program == "chkconfig" && len(args) > 1 && args[0] == "--add" {
+		name = args[1]
+	}
+	if !s.isRoot() {
+		return program + ": You need root privileges to run this script"
+	}
+	script := "/etc/init.d/" + name
+	file, exists := s.stat(script)
+	if !exists {
+		if program == "chkconfig" {
+			return fmt.Sprintf("error reading information on service %s: No such file or directory", name)
+		}
+		return "update-rc.d: error: unable to read " + script
+	}
+	s.recordPersistence(persistInit, script, string(file.Content), "", fmt.Sprintf("Script %s habilitado via %s", script, program))
+	return ""
+}
+
+// chattr finge mudar atributos (o +i que protege a persistência contra remoção)
+func (s *shellSession) chattr(args []string) string {
+	for _, name := range operands(args) {
+		if strings.HasPrefix(name, "+") {
+			continue
+		}
+		if _, err := s.lookup(s.resolve(name)); err != nil {
+			return fmt.Sprintf("chattr: %v while trying to stat %s", err, name)
+		}
+		if !s.isRoot() {
+			return fmt.Sprintf("chattr: Operation not permitted while setting flags on %s", name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// list executa "a; b && c || d"; sem códigos de saída, todo comando conta como bem-sucedido
+func (s *shellSession) list(commands, ops []string) string {
+	var out []string
+	for i, command := range commands {
+		if ops[i] == "||" || command == "" {
+			continue
+		}
+		if response := s.execute(command); response != "" {
+			out = append(out, response)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// pipeline liga a saída de cada estágio à entrada do próximo
+func (s *shellSession) pipeline(stages []string) string {
+	output := s.stdout(stages[0])
+	for _, stage := range stages[1:] {
+		rest, target, appendTo := splitRedirect(stage)
+		output = s.redirect(s.filter(rest, output), target, appendTo)
+	}
+	return output
+}
+
+// filter executa um estágio do pipeline com input na entrada padrão; quem não lê a entrada a ignora
+func (s *shellSession) filter(command, input string) string {
+	switch commandName(command) {
+	case "", "cat", "tee", "grep", "egrep", "head", "tail", "wc", "sort", "uniq", "crontab",
+		"sh", "bash", "dash", "ash", "python", "python2", "python3", "perl":
+	default:
+		return s.stdout(command)
+	}
+	fields := s.words(command)
+	if len(fields) == 0 {
+		return input
+	}
+	switch fields[0] {
+	case "cat":
+		if len(operands(fields[1:])) == 0 {
+			return input
+		}
+		return withNewline(s.cat(fields[1:]))
+	case "tee":
+		return s.tee(fields[1:], input)
+	case "crontab":
+		return withNewline(s.crontab(fields[1:], input, true))
+	case "sh", "bash", "dash", "ash":
+		return withNewline(s.shellCommand(fields[0], fields[1:], input, true))
+	case "python", "python2", "python3", "perl":
+		return withNewline(s.interpreter(fields[0], fields[1:], input, true))
+	}
+	return withNewline(s.text(fields, input))
+}
+
+// operands são os argumentos que não são opções
+func operands(args []string) []string {
+	var names []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			names = append(names, arg)
+		}
+	}
+	return names
+}
+
+func withNewline(text string) string {
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
+
+// tee copia a entrada para os arquivos e para a saída; -a acrescenta
+func (s *shellSession) tee(args []string, input string) string {
+	appendTo := false
+	var errs []string
+	for _, arg := range args {
+		if arg == "-a" || arg == "--append" {
+			appendTo = true
+		}
+	}
+	for _, name := range operands(args) {
+		if err := s.writeFile(s.resolve(name), []byte(input), appendTo); err != nil {
+			errs = append(errs, fmt.Sprintf("tee: %s: %v", name, err))
+		}
+	}
+	return input + withNewline(strings.Join(errs, "\n"))
+}
+
+// text imita grep, head, tail, wc, sort e uniq sobre arquivos ou sobre a entrada do pipeline
+func (s *shellSession) text(fields []string, input string) string {
+	program, args := fields[0], fields[1:]
+
+	// O padrão do grep e o número do -n não são arquivos
+	var options, names []string
+	pattern := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case (arg == "-n" || arg == "-e" || arg == "-c" && program != "grep" && program != "egrep") && i+1 < len(args):
+			options = append(options, arg+args[i+1])
+			if arg == "-e" {
+				pattern = args[i+1]
+			}
+			i++
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			options = append(options, arg)
+		case (program == "grep" || program == "egrep") && pattern == "" && !hasOption(options, "-e"):
+			pattern = arg
+		default:
+			names = append(names, arg)
+		}
+	}
+
+	var errs []string
+	if len(names) > 0 {
+		var content strings.Builder
+		for _, name := range names {
+			data, err := s.readFile(s.resolve(name))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s: %v", program, name, err))
+				continue
+			}
+			content.Write(data)
+		}
+		input = content.String()
+	}
+
+	var out string
+	switch program {
+	case "grep", "egrep":
+		if pattern == "" && !hasOption(options, "-e") {
+			return "Usage: grep [OPTION]... PATTERNS [FILE]...\nTry 'grep --help' for more information."
+		}
+		out = grep(pattern, options, input)
+	case "head", "tail":
+		out = headTail(program == "tail", options, input)
+	case "wc":
+		out = wordCount(options, input, names)
+	case "sort":
+		out = sortLines(options, input)
+	case "uniq":
+		out = uniqLines(input)
+	}
+	return strings.Join(append(errs, strings.TrimSuffix(out, "\n")), "\n")
+}
+
+func hasOption(options []string, prefix string) bool {
+	for _, option := range options {
+		if strings.HasPrefix(option, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func lines(input string) []string {
+	if input == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(input, "\n"), "\n")
+}
+
+// grep filtra linhas por expressão regular (ou texto literal, se ela não compilar); entende -v, -i e -c
+func grep(pattern string, options []string, input string) string {
+	invert, count := false, false
+	prefix := ""
+	for _, option := range options {
+		if strings.HasPrefix(option, "-e") {
+			continue
+		}
+		invert = invert || strings.Contains(option, "v")
+		count = count || strings.Contains(option, "c")
+		if strings.Contains(option, "i") {
+			prefix = "(?i)"
+		}
+	}
+	match := func(line string) bool { return strings.Contains(line, pattern) }
+	if re, err := regexp.Compile(prefix + pattern); err == nil {
+		match = re.MatchString
+	}
+
+	var matched []string
+	for _, line := range lines(input) {
+		if match(line) != invert {
+			matched = append(matched, line)
+		}
+	}
+	if count {
+		return strconv.Itoa(len(matched))
+	}
+	return strings.Join(matched, "\n")
+}
+
+// headTail mantém as primeiras (ou últimas) N linhas; entende -n N e -N
+func headTail(tail bool, options []string, input string) string {
+	n := 10
+	for _, option := range options {
+		value := strings.TrimPrefix(strings.TrimPrefix(option, "-n"), "-")
+		if parsed, err := strconv.Atoi(strings.TrimPrefix(value, "+")); err == nil {
+			n = parsed
+		}
+	}
+	all := lines(input)
+	n = min(n, len(all))
+	if tail {
+		return strings.Join(all[len(all)-n:], "\n")
+	}
+	return strings.Join(all[:n], "\n")
+}
+
+// wordCount imita o wc com -l, -w e -c
+func wordCount(options []string, input string, names []string) string {
+	counts := map[byte]int{'l': strings.Count(input, "\n"), 'w': len(strings.Fields(input)), 'c': len(input)}
+	var columns []string
+	for _, flag := range []byte("lwc") {
+		if len(options) == 0 || hasFlag(options, flag) {
+			columns = append(columns, strconv.Itoa(counts[flag]))
+		}
+	}
+	out := strings.Join(columns, " ")
+	if len(columns) > 1 {
+		out = ""
+		for _, column := range columns {
+			out += fmt.Sprintf("%*s", max(len(column)+1, 7), column)
+		}
+		out = strings.TrimLeft(out, " ")
+	}
+	if len(names) == 1 {
+		out += " " + names[0]
+	}
+	return out
+}
+
+func hasFlag(options []string, flag byte) bool {
+	for _, option := range options {
+		if !strings.HasPrefix(option, "--") && strings.IndexByte(option, flag) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sortLines ordena as linhas; entende -r e -u
+func sortLines(options []string, input string) string {
+	all := lines(input)
+	sort.Strings(all)
+	if hasFlag(options, 'u') {
+		all = strings.Split(uniqLines(strings.Join(all, "\n")), "\n")
+	}
+	if hasFlag(options, 'r') {
+		for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+			all[i], all[j] = all[j], all[i]
+		}
+	}
+	return strings.Join(all, "\n")
+}
+
+// uniqLines remove linhas repetidas em sequência
+func uniqLines(input string) string {
+	var out []string
+	for i, line := range lines(input) {
+		if i == 0 || line != out[len(out)-1] {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
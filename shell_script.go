@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"myhoneypot/logging"
+)
+
+// Limites da execução de scripts, além de script_max_depth e script_timeout
+const (
+	maxScriptSteps = 5000             // Comandos executados por script, somando loops e funções
+	maxIterations  = 100              // Voltas de um while ou until
+	maxSleep       = 10 * time.Second // Maior pausa real de um sleep
+	maxLoggedCode  = 64 << 10         // Bytes de código guardados no evento SCRIPT_EXECUTED
+)
+
+// coreCommands existem em qualquer perfil, mesmo fora da lista de pacotes
+var coreCommands = []string{
+	"bash", "cat", "chattr", "chmod", "cp", "crontab", "curl", "df", "echo", "free", "grep", "head", "id", "kill",
+	"last", "ls", "mkdir", "mv", "netstat", "nohup", "passwd", "perl", "printf", "ps", "rm", "sh", "sleep", "sort",
+	"ss", "su", "sudo", "systemctl", "tail", "tee", "touch", "uname", "uniq", "useradd", "usermod", "w", "wc",
+	"wget", "who", "whoami",
+}
+
+// urlPattern acha URLs no código de scripts que não são interpretados
+var urlPattern = regexp.MustCompile(`https?://[^\s'"()<>;|]+`)
+
+// printPattern acha os textos literais impressos por print em Python e Perl
+var printPattern = regexp.MustCompile(`print\s*\(?\s*(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)')`)
+
+// script interpreta um shell script linha a linha com os mesmos comandos do terminal. Como num
+// subshell, cd, variáveis e funções do script não sobram para o terminal
+func (s *shellSession) script(code, name string, args []string) string {
+	s.recordScript("sh", name, code)
+	cwd, oldpwd := s.cwd, s.oldpwd
+	vars, functions := s.vars, s.functions
+	s.vars, s.functions = maps.Clone(vars), maps.Clone(functions)
+	defer func() {
+		s.cwd, s.oldpwd = cwd, oldpwd
+		s.vars, s.functions = vars, functions
+	}()
+	return s.call(statements(code), name, args)
+}
+
+// call executa um script ou uma função com seus argumentos posicionais ($0, $1, ...)
+func (s *shellSession) call(stmts []string, name string, args []string) string {
+	if s.depth >= s.shell.config.ScriptMaxDepth {
+		return "bash: fork: retry: Resource temporarily unavailable"
+	}
+	if s.depth == 0 {
+		s.deadline = time.Now().Add(s.shell.config.ScriptTimeout)
+		s.steps = 0
+	}
+	s.depth++
+	previous := s.args
+	s.args = append([]string{name}, args...)
+	defer func() {
+		s.depth--
+		s.args = previous
+		s.stop = false
+	}()
+	return s.block(stmts, name)
+}
+
+// recordScript registra o código executado com o hash e a URL de onde ele veio (ou a primeira que ele cita)
+func (s *shellSession) recordScript(language, name, code string) {
+	sum := sha256.Sum256([]byte(code))
+	entry := logging.LogEntry{
+		Event:   fmt.Sprintf("Script %s executado: %s (%d bytes)", language, name, len(code)),
+		Level:   logging.WARNING,
+		Type:    logging.EventScript,
+		Command: s.line,
+		Path:    name,
+		SHA256:  hex.EncodeToString(sum[:]),
+		URL:     s.origin(name),
+		Content: code,
+	}
+	if entry.URL == "" {
+		entry.URL = urlPattern.FindString(code)
+	}
+	if len(code) > maxLoggedCode {
+		entry.Content = code[:maxLoggedCode]
+	}
+	s.record(entry)
+}
+
+// budget conta um comando e diz se o script ainda pode executá-lo
+func (s *shellSession) budget() bool {
+	s.steps++
+	return !s.stop && s.steps <= maxScriptSteps && time.Now().Before(s.deadline)
+}
+
+// block executa uma lista de comandos, tratando if, for, while, until, case e definições de função
+func (s *shellSession) block(stmts []string, source string) string {
+	var out strings.Builder
+	for i := 0; i < len(stmts) && s.budget(); i++ {
+		keyword, _, _ := strings.Cut(stmts[i], " ")
+		switch keyword {
+		case "if", "for", "while", "until", "case":
+			end := i + blockEnd(stmts[i:])
+			out.WriteString(s.compound(stmts[i:end+1], source))
+			i = end
+		case "function":
+			// O corpo vai do "{" seguinte até o "}" correspondente
+			start, end := i+1, i+1
+			if start < len(stmts) && stmts[start] == "{" {
+				end = start + blockEnd(stmts[start:])
+			}
+			s.functions[strings.TrimPrefix(stmts[i], "function ")] = stmts[min(start+1, end):end]
+			i = end
+		case "{", "}", "then", "do", "else", "fi", "done", "esac":
+		default:
+			s.logScriptCommand(stmts[i], source)
+			out.WriteString(withNewline(s.execute(stmts[i])))
+		}
+	}
+	return out.String()
+}
+
+// logScriptCommand registra cada comando executado por um script
+func (s *shellSession) logScriptCommand(command, source string) {
+	s.record(logging.LogEntry{
+		Event:   fmt.Sprintf("executed (%s): %s", source, command),
+		Level:   logging.INFO,
+		Type:    logging.EventCommand,
+		Command: command,
+		Path:    source,
+	})
+}
+
+// compound executa um bloco if, for, while, until ou case inteiro, do cabeçalho ao fi/done/esac
+func (s *shellSession) compound(stmts []string, source string) string {
+	keyword, condition, _ := strings.Cut(stmts[0], " ")
+	inner := stmts[1 : len(stmts)-1]
+	parts := splitBlock(inner)
+	var out strings.Builder
+
+	switch keyword {
+	case "for":
+		name, list, hasList := strings.Cut(condition, " in ")
+		words := s.args[min(1, len(s.args)):]
+		if hasList {
+			words = s.words(list)
+		}
+		// for ((i=0; i<n; i++)) não é avaliado: o corpo roda uma vez
+		if strings.HasPrefix(condition, "((") {
+			name, words = "", []string{""}
+		}
+		for _, word := range words {
+			if name != "" {
+				s.vars[strings.TrimSpace(name)] = word
+			}
+			out.WriteString(s.block(parts.do, source))
+			if s.stop || !time.Now().Before(s.deadline) {
+				break
+			}
+		}
+	case "while", "until":
+		for i := 0; i < maxIterations && !s.stop && time.Now().Before(s.deadline); i++ {
+			if s.test(condition, &out) == (keyword == "until") {
+				break
+			}
+			out.WriteString(s.block(parts.do, source))
+		}
+	case "if":
+		branches := append([]branch{{condition, parts.then}}, parts.elifs...)
+		taken := false
+		for _, b := range branches {
+			if s.test(b.condition, &out) {
+				out.WriteString(s.block(b.stmts, source))
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			out.WriteString(s.block(parts.otherwise, source))
+		}
+	case "case":
+		word, _, _ := strings.Cut(condition, " in")
+		value := strings.Join(s.words(word), " ")
+		out.WriteString(s.block(caseBranch(inner, value), source))
+	}
+	return out.String()
+}
+
+// branch é um elif e os comandos dele
+type branch struct {
+	condition string
+	stmts     []string
+}
+
+// blockParts é o corpo de um bloco dividido pelas palavras-chave do nível de cima
+type blockParts struct {
+	do, then, otherwise []string
+	elifs               []branch
+}
+
+func splitBlock(stmts []string) blockParts {
+	var parts blockParts
+	var target *[]string
+	for i := 0; i < len(stmts); i++ {
+		keyword, rest, _ := strings.Cut(stmts[i], " ")
+		switch keyword {
+		case "do":
+			target = &parts.do
+			continue
+		case "then":
+			target = &parts.then
+			if n := len(parts.elifs); n > 0 {
+				target = &parts.elifs[n-1].stmts
+			}
+			continue
+		case "else":
+			target = &parts.otherwise
+			continue
+		case "elif":
+			parts.elifs = append(parts.elifs, branch{condition: rest})
+			target = nil
+			continue
+		}
+		end := i
+		switch keyword {
+		case "if", "for", "while", "until", "case", "{":
+			end = i + blockEnd(stmts[i:])
+		}
+		if target != nil {
+			*target = append(*target, stmts[i:end+1]...)
+		}
+		i = end
+	}
+	return parts
+}
+
+// caseBranch escolhe os comandos do primeiro padrão do case que casa com value ("x86_64|amd64)", "*)")
+func caseBranch(stmts []string, value string) []string {
+	var chosen []string
+	expectPattern, active, matched := true, false, false
+	for _, stmt := range stmts {
+		if expectPattern {
+			patterns, rest, found := strings.Cut(stmt, ")")
+			if !found {
+				continue
+			}
+			active = false
+			for _, pattern := range strings.Split(strings.TrimPrefix(strings.TrimSpace(patterns), "("), "|") {
+				if ok, _ := path.Match(strings.Trim(strings.TrimSpace(pattern), `"'`), value); ok && !matched {
+					active, matched = true, true
+				}
+			}
+			stmt, expectPattern = strings.TrimSpace(rest), false
+		}
+		if command, done := strings.CutSuffix(stmt, ";;"); done {
+			stmt, expectPattern = strings.TrimSpace(command), true
+		}
+		if active && stmt != "" {
+			chosen = append(chosen, stmt)
+		}
+	}
+	return chosen
+}
+
+// test avalia a condição de if, while e until. Sem códigos de saída, só [ ], test, command -v,
+// which, true, false, read e ! têm resultado; os outros comandos executam e contam como sucesso
+func (s *shellSession) test(condition string, out *strings.Builder) bool {
+	condition = strings.TrimSpace(condition)
+	if negated, found := strings.CutPrefix(condition, "! "); found {
+		return !s.test(negated, out)
+	}
+	rest, _, _ := splitRedirect(condition)
+	fields := s.words(rest)
+	if len(fields) == 0 {
+		return true
+	}
+	switch fields[0] {
+	case "true", ":":
+		return true
+	case "false", "read":
+		return false
+	case "[", "[[", "test":
+		args := fields[1:]
+		if n := len(args); n > 0 && (args[n-1] == "]" || args[n-1] == "]]") {
+			args = args[:n-1]
+		}
+		return s.condition(args)
+	case "command", "which", "type", "hash":
+		names := operands(fields[1:])
+		return len(names) > 0 && s.installed(names[len(names)-1])
+	}
+	out.WriteString(withNewline(s.execute(condition)))
+	return true
+}
+
+// condition avalia as expressões do [ ]: testes de arquivo, de texto e de números
+func (s *shellSession) condition(args []string) bool {
+	if len(args) > 0 && args[0] == "!" {
+		return !s.condition(args[1:])
+	}
+	switch len(args) {
+	case 0:
+		return false
+	case 1:
+		return args[0] != ""
+	case 2:
+		if args[0] == "-z" || args[0] == "-n" {
+			return (args[1] == "") == (args[0] == "-z")
+		}
+		file, err := s.lookup(s.resolve(args[1]))
+		exists := err == nil
+		switch args[0] {
+		case "-e", "-a", "-L", "-h":
+			return exists
+		case "-f":
+			return exists && !file.Dir
+		case "-d":
+			return exists && file.Dir
+		case "-s":
+			return exists && len(file.Content) > 0
+		case "-r":
+			return exists && s.allowed(file, 4)
+		case "-w":
+			return exists && s.allowed(file, 2)
+		case "-x":
+			return exists && s.allowed(file, 1)
+		}
+		return true
+	}
+	a, op, b := args[0], args[1], args[2]
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+	switch op {
+	case "=", "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "-eq":
+		return x == y
+	case "-ne":
+		return x != y
+	case "-gt":
+		return x > y
+	case "-ge":
+		return x >= y
+	case "-lt":
+		return x < y
+	case "-le":
+		return x <= y
+	}
+	return true
+}
+
+// installed diz se um comando existe no sistema simulado
+func (s *shellSession) installed(name string) bool {
+	if strings.Contains(name, "/") {
+		file, err := s.lookup(s.resolve(name))
+		return err == nil && !file.Dir
+	}
+	if _, exists := s.functions[name]; exists {
+		return true
+	}
+	for _, command := range coreCommands {
+		if command == name {
+			return true
+		}
+	}
+	for _, pkg := range s.profile().Packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// which imita which e command -v: o caminho de cada comando encontrado
+func (s *shellSession) which(args []string) string {
+	var out []string
+	for _, name := range operands(args) {
+		switch {
+		case strings.Contains(name, "/") && s.installed(name):
+			out = append(out, name)
+		case s.functions[name] != nil:
+			out = append(out, name)
+		case s.installed(name):
+			out = append(out, "/usr/bin/"+name)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// sleep pausa de verdade, até maxSleep e até o fim do prazo do script em execução
+func (s *shellSession) sleep(args []string) string {
+	var total time.Duration
+	for _, arg := range operands(args) {
+		unit := time.Second
+		switch {
+		case strings.HasSuffix(arg, "m"):
+			unit = time.Minute
+		case strings.HasSuffix(arg, "h"):
+			unit = time.Hour
+		case strings.HasSuffix(arg, "d"):
+			unit = 24 * time.Hour
+		}
+		value, err := strconv.ParseFloat(strings.TrimRight(arg, "smhd"), 64)
+		if err != nil {
+			return fmt.Sprintf("sleep: invalid time interval '%s'\nTry 'sleep --help' for more information.", arg)
+		}
+		total += time.Duration(value * float64(unit))
+	}
+	delay := min(total, maxSleep)
+	if s.depth > 0 {
+		delay = min(delay, time.Until(s.deadline))
+	}
+	time.Sleep(max(delay, 0))
+	return ""
+}
+
+// shellCommand imita sh e bash: -c código, um arquivo de script ou o script vindo do pipe
+func (s *shellSession) shellCommand(program string, args []string, input string, piped bool) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-c":
+			if i+1 >= len(args) {
+				return program + ": -c: option requires an argument"
+			}
+			name := program
+			if i+2 < len(args) {
+				name = args[i+2]
+			}
+			return s.script(args[i+1], name, args[min(i+3, len(args)):])
+		case strings.HasPrefix(args[i], "-"):
+			continue
+		default:
+			name := s.resolve(args[i])
+			code, err := s.readFile(name)
+			if err != nil {
+				return fmt.Sprintf("%s: %s: %v", program, args[i], err)
+			}
+			if isBinary(code) {
+				return fmt.Sprintf("%s: %s: cannot execute binary file", program, args[i])
+			}
+			return s.script(string(code), name, args[i+1:])
+		}
+	}
+	if piped {
+		return s.script(input, program, nil)
+	}
+	// Sem script, o atacante só abriu outro shell interativo
+	return ""
+}
+
+// source imita source e ".": o script roda no próprio shell
+func (s *shellSession) source(program string, args []string) string {
+	if len(args) == 0 {
+		return "bash: " + program + ": filename argument required"
+	}
+	name := s.resolve(args[0])
+	code, err := s.readFile(name)
+	if err != nil {
+		return fmt.Sprintf("bash: %s: %v", args[0], err)
+	}
+	s.recordScript("sh", name, string(code))
+	return s.call(statements(string(code)), name, args[1:])
+}
+
+// runFile executa um arquivo pelo caminho (./x.sh, /tmp/x): o shebang escolhe o interpretador
+func (s *shellSession) runFile(command string, args []string) string {
+	name := s.resolve(command)
+	file, err := s.lookup(name)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("bash: %s: %v", command, err)
+	case file.Dir:
+		return fmt.Sprintf("bash: %s: Is a directory", command)
+	case !s.allowed(file, 1) || !s.allowed(file, 4):
+		return fmt.Sprintf("bash: %s: Permission denied", command)
+	case isBinary(file.Content):
+		return s.runBinary(command, name, file.Content, args)
+	}
+
+	code := string(file.Content)
+	if first, _, _ := strings.Cut(code, "\n"); strings.HasPrefix(first, "#!") {
+		interpreter := strings.Fields(strings.TrimPrefix(first, "#!"))
+		if len(interpreter) > 1 && path.Base(interpreter[0]) == "env" {
+			interpreter = interpreter[1:]
+		}
+		if len(interpreter) > 0 {
+			if program := path.Base(interpreter[0]); program != "sh" && program != "bash" && program != "dash" && program != "ash" {
+				return s.interpreter(program, append([]string{name}, args...), "", false)
+			}
+		}
+	}
+	return s.script(code, name, args)
+}
+
+// isBinary diz se o conteúdo não é texto: ELF e afins não são interpretados como script
+func isBinary(content []byte) bool {
+	head := content[:min(len(content), 512)]
+	return bytes.HasPrefix(content, []byte("\x7fELF")) || bytes.IndexByte(head, 0) >= 0
+}
+
+// interpreter registra código Python ou Perl (-c, -e, arquivo ou pipe) sem executá-lo e devolve
+// uma saída plausível: os print literais, a recusa de conexão de um reverse shell ou nada
+func (s *shellSession) interpreter(program string, args []string, input string, piped bool) string {
+	version := s.interpreterVersion(program)
+	if version == "" {
+		return "bash: " + program + ": command not found"
+	}
+
+	code, name := "", ""
+	for i := 0; i < len(args) && name == ""; i++ {
+		switch {
+		case args[i] == "-V" || args[i] == "--version" || args[i] == "-v":
+			if program == "perl" {
+				return "\nThis is perl 5, version " + version
+			}
+			return "Python " + version
+		case (args[i] == "-c" || args[i] == "-e" || args[i] == "-E") && i+1 < len(args):
+			code, name = args[i+1], args[i]
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			data, err := s.readFile(s.resolve(args[i]))
+			if err != nil {
+				if program == "perl" {
+					return fmt.Sprintf("Can't open perl script \"%s\": %v", args[i], err)
+				}
+				return fmt.Sprintf("%s: can't open file '%s': [Errno 2] %v", program, args[i], err)
+			}
+			code, name = string(data), s.resolve(args[i])
+		}
+	}
+	if name == "" {
+		if !piped {
+			// O interpretador interativo ficaria esperando; o atacante volta ao prompt
+			return ""
+		}
+		code, name = input, "-"
+	}
+	s.recordScript(program, name, code)
+
+	switch {
+	case strings.Contains(code, "socket") && strings.Contains(code, "connect"):
+		time.Sleep(time.Second)
+		if program == "perl" {
+			return ""
+		}
+		file := "<string>"
+		if name != "-c" {
+			file = name
+		}
+		if strings.HasPrefix(version, "2.") {
+			return fmt.Sprintf("Traceback (most recent call last):\n  File \"%s\", line 1, in <module>\n  File \"/usr/lib64/python2.7/socket.py\", line 228, in meth\n    return getattr(self._sock,name)(*args)\nsocket.error: [Errno 111] Connection refused", file)
+		}
+		return fmt.Sprintf("Traceback (most recent call last):\n  File \"%s\", line 1, in <module>\nConnectionRefusedError: [Errno 111] Connection refused", file)
+	}
+	var out strings.Builder
+	for _, match := range printPattern.FindAllStringSubmatch(code, -1) {
+		text := unescape(match[1] + match[2])
+		if program != "perl" {
+			text += "\n"
+		}
+		out.WriteString(text)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// interpreterVersion é a versão do Python ou Perl instalada no perfil; vazio quando ele não existe.
+// Para o Perl vem no formato do perl -v: "34, subversion 0 (v5.34.0)"
+func (s *shellSession) interpreterVersion(program string) string {
+	name := map[string]string{"python": "python", "python2": "python", "python3": "python3", "perl": "perl"}[program]
+	for _, pkg := range s.profile().Packages {
+		if name != "" && pkg.Name == name {
+			version, _, _ := strings.Cut(pkg.Version, "-")
+			if parts := strings.Split(version, "."); program == "perl" && len(parts) == 3 {
+				return fmt.Sprintf("%s, subversion %s (v%s)", parts[1], parts[2], version)
+			}
+			return version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+
+	"myhoneypot/persona"
+	"myhoneypot/session"
+)
+
+func TestInterpreterVersionFollowsPersona(t *testing.T) {
+	defer persona.SetDefault(persona.Current())
+	s := testSession(t, session.DefaultQuota)
+
+	for _, c := range []struct {
+		profile, command, want string
+	}{
+		{"ubuntu-22.04", "perl -v", "\nThis is perl 5, version 34, subversion 0 (v5.34.0)"},
+		{"centos-7", "perl -v", "\nThis is perl 5, version 16, subversion 3 (v5.16.3)"},
+		{"raspbian-10", "perl -v", "\nThis is perl 5, version 28, subversion 1 (v5.28.1)"},
+		{"centos-7", "python -V", "Python 2.7.5"},
+		{"centos-7", "python3 -V", "bash: python3: command not found"},
+	} {
+		profile, err := persona.Builtin(c.profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		persona.SetDefault(profile)
+		if got := s.execute(c.command); got != c.want {
+			t.Errorf("%s em %s = %q, esperado %q", c.command, c.profile, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"myhoneypot/logging"
+	"myhoneypot/persona"
+	"myhoneypot/session"
+)
+
+// historyFileSize é o HISTFILESIZE padrão: linhas mantidas em ~/.bash_history
+const historyFileSize = 2000
+
+// restore carrega o que o atacante deixou no sistema em visitas anteriores
+func (s *shellSession) restore(username, password string) {
+	s.state = session.NewState("")
+	store := s.shell.store
+	if store == nil {
+		return
+	}
+
+	state, err := store.Load(store.Key(s.ip, username, password))
+	if err != nil {
+		log.Printf("Erro ao restaurar a sessão de %s: %v", s.ip, err)
+	}
+	s.state = state
+	if len(state.Visits) == 0 && len(state.Files) == 0 {
+		return
+	}
+	s.record(logging.LogEntry{
+		Event: fmt.Sprintf("Atacante recorrente (visto desde %s): %d visitas, %d arquivos alterados restaurados",
+			state.FirstSeen.Format("2006-01-02 15:04:05"), len(state.Visits), len(state.Files)),
+		Level:    logging.INFO,
+		Type:     logging.EventConnection,
+		Username: username,
+	})
+}
+
+// save grava o histórico do bash e o estado da sessão ao sair
+func (s *shellSession) save() {
+	if s.histfile {
+		// Como o bash do login, grava na home do usuário do login mesmo depois de su ou sudo -i
+		users := s.users
+		s.users = s.users[:1]
+		file := s.home() + "/.bash_history"
+		lines := s.history
+		if len(lines) > historyFileSize {
+			lines = lines[len(lines)-historyFileSize:]
+		}
+		content := ""
+		if len(lines) > 0 {
+			content = strings.Join(lines, "\n") + "\n"
+		}
+		if err := s.writeFile(file, []byte(content), false); err == nil {
+			s.state.Files[file].Mode = 0600
+		}
+		s.users = users
+	}
+
+	s.state.AddVisit(session.Visit{User: s.users[0], From: s.host(), TTY: s.tty, At: s.loginAt, Until: time.Now()})
+	if s.shell.store == nil {
+		return
+	}
+	if err := s.shell.store.Save(s.state); err != nil {
+		log.Printf("Erro ao salvar a sessão de %s: %v", s.ip, err)
+	}
+}
+
+// loadHistory lê ~/.bash_history como o bash faz ao iniciar
+func (s *shellSession) loadHistory() {
+	s.histfile = true
+	content, err := s.readFile(s.home() + "/.bash_history")
+	if err != nil || len(content) == 0 {
+		return
+	}
+	s.history = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// previousLogins são as visitas anteriores do atacante, da mais recente para a mais antiga
+func (s *shellSession) previousLogins() []persona.Login {
+	var logins []persona.Login
+	for i := len(s.state.Visits) - 1; i >= 0; i-- {
+		visit := s.state.Visits[i]
+		logins = append(logins, persona.Login{User: visit.User, TTY: visit.TTY, From: visit.From, At: visit.At, Until: visit.Until})
+	}
+	return logins
+}
+
+// historyCommand imita o builtin history: lista numerada, -c limpa
+func (s *shellSession) historyCommand(args []string) string {
+	if len(args) > 0 && args[0] == "-c" {
+		s.history = nil
+		return ""
+	}
+	lines := make([]string, len(s.history))
+	for i, command := range s.history {
+		lines[i] = fmt.Sprintf("%5d  %s", i+1, command)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// env é o valor das variáveis expandidas pelo shell: as definidas pelo atacante, os parâmetros
+// do script em execução e as variáveis de ambiente
+func (s *shellSession) env(name string) string {
+	if value, exists := s.vars[name]; exists {
+		return value
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < len(s.args) {
+			return s.args[n]
+		}
+		if n == 0 {
+			return "-bash"
+		}
+		return ""
+	}
+	switch name {
+	case "?":
+		return "0"
+	case "$":
+		return strconv.Itoa(s.shellPID)
+	case "#":
+		return strconv.Itoa(max(len(s.args)-1, 0))
+	case "@", "*":
+		return strings.Join(s.args[min(1, len(s.args)):], " ")
+	case "HOME":
+		return s.home()
+	case "USER", "LOGNAME":
+		return s.user()
+	case "PWD":
+		return s.cwd
+	case "OLDPWD":
+		return s.oldpwd
+	case "SHELL":
+		return s.profile().Account(s.user()).Shell
+	case "HOSTNAME":
+		return s.hostname
+	case "PATH":
+		return "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	case "HISTFILE":
+		if s.histfile {
+			return s.home() + "/.bash_history"
+		}
+	case "UID":
+		return strconv.Itoa(s.profile().Account(s.user()).UID)
+	}
+	return ""
+}
+
+// useradd cria um usuário; entende -m, -d, -s, -c, -u, -p e -G
+func (s *shellSession) useradd(program string, args []string) string {
+	if !s.isRoot() {
+		return program + ": Permission denied.\n" + program + ": cannot lock /etc/passwd; try again later."
+	}
+
+	user := persona.User{Shell: "/bin/sh"}
+	var createHome, interactive, explicitUID bool
+	var groups []string
+	if program == "adduser" {
+		user.Shell, createHome, interactive = "/bin/bash", true, true
+	}
+	for i := 0; i < len(args); i++ {
+		value := ""
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch args[i] {
+		case "-m", "--create-home":
+			createHome = true
+		case "--disabled-password", "--disabled-login":
+			interactive = false
+		case "-d", "--home", "--home-dir":
+			user.Home, i = value, i+1
+		case "-s", "--shell":
+			user.Shell, i = value, i+1
+		case "-c", "--comment", "--gecos":
+			user.Gecos, i = value, i+1
+		case "-u", "--uid":
+			user.UID, _ = strconv.Atoi(value)
+			explicitUID, i = true, i+1
+		case "-p", "--password":
+			user.Password, i = value, i+1
+		case "-G", "--groups":
+			groups, i = strings.Split(value, ","), i+1
+		case "-g", "--gid", "--ingroup":
+			i++
+		default:
+			if !strings.HasPrefix(args[i], "-") {
+				user.Name = args[i]
+			}
+		}
+	}
+	if user.Name == "" {
+		if program == "adduser" {
+			return "adduser: Only one or two names allowed."
+		}
+		return "Usage: useradd [options] LOGIN"
+	}
+
+	p := s.profile()
+	if _, exists := p.User(user.Name); exists {
+		return fmt.Sprintf("%s: user '%s' already exists", program, user.Name)
+	}
+	if missing := s.missingGroup(groups); missing != "" {
+		return fmt.Sprintf("%s: group '%s' does not exist", program, missing)
+	}
+	if !explicitUID {
+		user.UID = 1000
+		for _, existing := range p.Users {
+			if existing.UID >= user.UID && existing.UID < 60000 {
+				user.UID = existing.UID + 1
+			}
+		}
+	}
+	user.GID = user.UID
+	if user.Home == "" {
+		user.Home = "/home/" + user.Name
+	}
+	// A conta, a home e o /etc/skel copiado entram na cota do estado
+	entries, size := 1, int64(0)
+	if createHome {
+		entries += 1 + len(skeleton)
+		for _, content := range skeleton {
+			size += int64(len(content))
+		}
+	}
+	if files, bytes := s.quota().Room(s.state); files < entries || bytes < size {
+		return fmt.Sprintf("%s: failure while writing changes to /etc/passwd", program)
+	}
+	s.state.Users = append(s.state.Users, user)
+	for _, group := range groups {
+		s.state.Members[group] = append(s.state.Members[group], user.Name)
+	}
+	if createHome {
+		s.state.Files[user.Home] = &session.File{Dir: true, Mode: 0755, Owner: user.Name, ModTime: time.Now()}
+		for name, content := range skeleton {
+			s.state.Files[path.Join(user.Home, name)] = &session.File{Content: []byte(content), Mode: 0644, Owner: user.Name, ModTime: time.Now()}
+		}
+	}
+
+	s.record(logging.LogEntry{
+		Event:    fmt.Sprintf("Usuário %s criado via %s (uid %d, grupos %v)", user.Name, program, user.UID, groups),
+		Level:    logging.WARNING,
+		Type:     logging.EventPrivilegeEscalation,
+		Username: user.Name,
+		Password: user.Password,
+		Command:  strings.Join(append([]string{program}, args...), " "),
+	})
+	if !interactive {
+		return ""
+	}
+
+	s.write(fmt.Sprintf("Adding user `%[1]s' ...\nAdding new group `%[1]s' (%[2]d) ...\n"+
+		"Adding new user `%[1]s' (%[2]d) with group `%[1]s' ...\nCreating home directory `%[3]s' ...\n"+
+		"Copying files from `/etc/skel' ...\n", user.Name, user.UID, user.Home))
+	return s.passwd([]string{user.Name})
+}
+
+// usermod acrescenta grupos a um usuário (usermod -aG grupo usuario)
+func (s *shellSession) usermod(args []string) string {
+	if !s.isRoot() {
+		return "usermod: Permission denied.\nusermod: cannot lock /etc/passwd; try again later."
+	}
+	var groups []string
+	name := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-G" || args[i] == "-aG" || args[i] == "--groups":
+			if i+1 < len(args) {
+				groups = strings.Split(args[i+1], ",")
+				i++
+			}
+		case !strings.HasPrefix(args[i], "-"):
+			name = args[i]
+		}
+	}
+	if _, exists := s.profile().User(name); !exists {
+		return fmt.Sprintf("usermod: user '%s' does not exist", name)
+	}
+	if missing := s.missingGroup(groups); missing != "" {
+		return fmt.Sprintf("usermod: group '%s' does not exist", missing)
+	}
+	for _, group := range groups {
+		s.state.Members[group] = append(s.state.Members[group], name)
+	}
+	s.record(logging.LogEntry{
+		Event:    fmt.Sprintf("Usuário %s adicionado aos grupos %v via usermod", name, groups),
+		Level:    logging.WARNING,
+		Type:     logging.EventPrivilegeEscalation,
+		Username: name,
+		Command:  strings.Join(append([]string{"usermod"}, args...), " "),
+	})
+	return ""
+}
+
+// missingGroup retorna o primeiro grupo da lista que não existe no perfil
+func (s *shellSession) missingGroup(groups []string) string {
+	for _, name := range groups {
+		found := false
+		for _, group := range s.profile().Groups {
+			found = found || group.Name == name
+		}
+		if !found {
+			return name
+		}
+	}
+	return ""
+}
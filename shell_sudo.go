@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"myhoneypot/auth"
+	"myhoneypot/logging"
+	"myhoneypot/metrics"
+)
+
+// sudoTimeout é o tempo em que o sudo lembra a senha, como o timestamp_timeout padrão
+const sudoTimeout = 15 * time.Minute
+
+// sudo emula sudo -l, sudo -i/-s/su e sudo <comando>
+func (s *shellSession) sudo(args []string) string {
+	if len(args) == 0 {
+		return "usage: sudo -h | -K | -k | -V\n" +
+			"usage: sudo -v [-ABkNnS] [-g group] [-h host] [-p prompt] [-u user]\n" +
+			"usage: sudo -l [-ABkNnS] [-g group] [-h host] [-p prompt] [-U user] [-u user] [command [arg ...]]\n" +
+			"usage: sudo [-ABbEHkNnPS] [-C num] [-D directory] [-g group] [-h host] [-p prompt] [-R directory] [-T timeout] [-u user] [VAR=value] [-i | -s] [command [arg ...]]"
+	}
+
+	command := strings.Join(append([]string{"sudo"}, args...), " ")
+	if !s.isRoot() && time.Now().After(s.sudoUntil) {
+		granted := false
+		for attempt := 1; attempt <= s.shell.config.SudoAttempts && !granted; attempt++ {
+			s.write(fmt.Sprintf("[sudo] password for %s: ", s.user()))
+			password, err := s.readPassword()
+			if err != nil {
+				return ""
+			}
+			if granted = s.escalate("sudo", command, s.user(), password); !granted {
+				time.Sleep(2 * time.Second)
+				if attempt < s.shell.config.SudoAttempts {
+					s.write("Sorry, try again.\n")
+				}
+			}
+		}
+		if !granted {
+			return fmt.Sprintf("sudo: %d incorrect password attempts", s.shell.config.SudoAttempts)
+		}
+		s.sudoUntil = time.Now().Add(sudoTimeout)
+	}
+
+	target := "root"
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-l", "--list":
+			return fmt.Sprintf("Matching Defaults entries for %[1]s on %[2]s:\n"+
+				"    env_reset, mail_badpass, secure_path=/usr/local/sbin\\:/usr/local/bin\\:/usr/sbin\\:/usr/bin\\:/sbin\\:/bin\\:/snap/bin, use_pty\n\n"+
+				"User %[1]s may run the following commands on %[2]s:\n"+
+				"    (ALL : ALL) ALL", s.users[0], s.hostname)
+		case "-i", "-s", "--login", "--shell":
+			s.users = append(s.users, target)
+			return ""
+		case "-u", "--user":
+			if len(args) > 1 {
+				target = args[1]
+				args = args[1:]
+			}
+		case "-k", "-K", "-v":
+			return ""
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return ""
+	}
+
+	switch args[0] {
+	case "su", "bash", "sh", "-bash", "/bin/bash", "/bin/sh":
+		s.users = append(s.users, target)
+		return ""
+	}
+
+	// Executa um único comando como o usuário alvo
+	s.users = append(s.users, target)
+	defer func() { s.users = s.users[:len(s.users)-1] }()
+	return s.execute(strings.Join(args, " "))
+}
+
+// su troca para outro usuário (root por padrão) pedindo a senha dele
+func (s *shellSession) su(args []string) string {
+	target := "root"
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			target = arg
+			break
+		}
+	}
+
+	if !s.isRoot() {
+		s.write("Password: ")
+		password, err := s.readPassword()
+		if err != nil {
+			return ""
+		}
+		if !s.escalate("su", strings.Join(append([]string{"su"}, args...), " "), target, password) {
+			// Atraso do pam_faildelay
+			time.Sleep(3 * time.Second)
+			return "su: Authentication failure"
+		}
+	}
+	s.users = append(s.users, target)
+	return ""
+}
+
+// passwd emula a troca de senha; a senha atual e as novas são registradas
+func (s *shellSession) passwd(args []string) string {
+	target := s.user()
+	if len(args) > 0 && !strings.HasPrefix(args[len(args)-1], "-") {
+		target = args[len(args)-1]
+	}
+	if target != s.user() && !s.isRoot() {
+		return fmt.Sprintf("passwd: You may not view or modify password information for %s.", target)
+	}
+
+	command := strings.Join(append([]string{"passwd"}, args...), " ")
+	if !s.isRoot() {
+		s.write(fmt.Sprintf("Changing password for %s.\nCurrent password: ", target))
+		password, err := s.readPassword()
+		if err != nil {
+			return ""
+		}
+		if !s.escalate("passwd", command, target, password) {
+			time.Sleep(2 * time.Second)
+			return "passwd: Authentication token manipulation error\npasswd: password unchanged"
+		}
+	}
+
+	s.write("New password: ")
+	newPassword, err := s.readPassword()
+	if err != nil {
+		return ""
+	}
+	s.write("Retype new password: ")
+	retyped, err := s.readPassword()
+	if err != nil {
+		return ""
+	}
+	s.record(logging.LogEntry{
+		Event:    fmt.Sprintf("Nova senha definida para %s via passwd", target),
+		Level:    logging.WARNING,
+		Type:     logging.EventPrivilegeEscalation,
+		Username: target,
+		Password: newPassword,
+		Command:  command,
+	})
+	if newPassword != retyped {
+		return "Sorry, passwords do not match.\npasswd: Authentication token manipulation error\npasswd: password unchanged"
+	}
+	return "passwd: password updated successfully"
+}
+
+// escalate decide uma senha digitada em sudo, su ou passwd conforme sudo_outcome e a registra
+func (s *shellSession) escalate(program, command, username, password string) bool {
+	attempt := auth.Attempt{Protocol: program, Addr: s.ip, Username: username, Password: password, Client: s.protocol}
+	var granted bool
+	switch s.shell.config.SudoOutcome {
+	case SudoPolicy:
+		granted = auth.Check(attempt)
+	default:
+		attempt.Success = s.shell.config.SudoOutcome == SudoGrant
+		granted = attempt.Success
+		auth.RecordAttempt(attempt)
+	}
+
+	metrics.AuthAttempts.Inc(program)
+	result := "recusada"
+	if granted {
+		metrics.AuthSuccesses.Inc(program)
+		result = "aceita"
+	}
+	s.record(logging.LogEntry{
+		Event:    fmt.Sprintf("Senha de %s digitada em %s (%s)", username, program, result),
+		Level:    logging.WARNING,
+		Type:     logging.EventPrivilegeEscalation,
+		Username: username,
+		Password: password,
+		Command:  command,
+	})
+	return granted
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"myhoneypot/persona"
+)
+
+// startProcesses cria os processos do login (sshd ou in.telnetd/login) e o bash do usuário
+func (s *shellSession) startProcesses(username string) {
+	highest := 0
+	for _, process := range persona.Current().Processes {
+		highest = max(highest, process.PID)
+	}
+	s.nextPID = highest + 1000 + rand.Intn(3000)
+
+	switch s.protocol {
+	case "telnet":
+		s.spawn("root", "?", "Ss", "in.telnetd: "+s.host())
+		s.spawn("root", s.tty, "Ss", "login -- "+username)
+	default:
+		s.spawn("root", "?", "Ss", "sshd: "+username+" [priv]")
+		s.spawn(username, "?", "S", "sshd: "+username+"@"+s.tty)
+	}
+	s.shellPID = s.spawn(username, s.tty, "Ss", "-bash").PID
+}
+
+// spawn registra um processo da sessão; o pai é o processo anterior ou o shell
+func (s *shellSession) spawn(user, tty, stat, command string) persona.Process {
+	s.nextPID += 1 + rand.Intn(4)
+	process := persona.Process{
+		PID:     s.nextPID,
+		PPID:    s.shellPID,
+		User:    user,
+		VSZ:     8000 + rand.Intn(12000),
+		RSS:     3000 + rand.Intn(4000),
+		TTY:     tty,
+		Stat:    stat,
+		Time:    "0:00",
+		Start:   time.Now(),
+		Command: command,
+	}
+	if process.PPID == 0 && len(s.processes) > 0 {
+		process.PPID = s.processes[len(s.processes)-1].PID
+	}
+	s.processes = append(s.processes, process)
+	return process
+}
+
+// psProcesses são os processos da sessão mais o próprio ps em execução
+func (s *shellSession) psProcesses(command string) []persona.Process {
+	s.nextPID++
+	return append(append([]persona.Process(nil), s.processes...), persona.Process{
+		PID: s.nextPID, PPID: s.shellPID, User: s.user(), VSZ: 10072, RSS: 3348,
+		TTY: s.tty, Stat: "R+", Time: "0:00", Start: time.Now(), Command: command,
+	})
+}
+
+// host é o IP de origem da sessão, sem a porta
+func (s *shellSession) host() string {
+	if host, _, err := net.SplitHostPort(s.ip); err == nil {
+		return host
+	}
+	return s.ip
+}
+
+// logins é a sessão atual como w, who e last a mostram
+func (s *shellSession) logins(what string) []persona.Login {
+	return []persona.Login{{User: s.users[0], TTY: s.tty, From: s.host(), At: s.loginAt, What: what}}
+}
+
+// sockets é a conexão do próprio atacante, que o netstat e o ss precisam mostrar
+func (s *shellSession) sockets() []persona.Socket {
+	port := "22"
+	if addr, ok := s.conn.LocalAddr().(*net.TCPAddr); ok {
+		port = strconv.Itoa(addr.Port)
+	}
+	socket := persona.Socket{
+		Proto:  "tcp",
+		Local:  net.JoinHostPort(persona.Current().Address(), port),
+		Remote: s.ip,
+		State:  "ESTABLISHED",
+	}
+	if len(s.processes) > 0 {
+		socket.PID = s.processes[0].PID
+		socket.Program, _, _ = strings.Cut(s.processes[0].Command, ":")
+	}
+	return []persona.Socket{socket}
+}
+
+// background emula "comando &" e nohup: o processo fica no ps e o comando roda na hora (scripts e
+// downloads continuam sendo capturados); com nohup a saída vai para nohup.out. sleep não espera
+func (s *shellSession) background(command string, job bool) string {
+	program, nohup := strings.CutPrefix(command, "nohup ")
+	program = strings.TrimSpace(program)
+	process := s.spawn(s.user(), s.tty, "S", program)
+
+	var out []string
+	if job {
+		s.jobs = append(s.jobs, process.PID)
+		out = append(out, fmt.Sprintf("[%d] %d", len(s.jobs), process.PID))
+	}
+	if nohup {
+		out = append(out, "nohup: ignoring input and appending output to 'nohup.out'")
+	}
+	if commandName(program) == "sleep" {
+		return strings.Join(out, "\n")
+	}
+	output := s.stdout(program)
+	switch {
+	case nohup:
+		s.writeFile(s.resolve("nohup.out"), []byte(output), true)
+	case output != "":
+		out = append(out, strings.TrimSuffix(output, "\n"))
+	}
+	return strings.Join(out, "\n")
+}
+
+// kill encerra processos da sessão; os do sistema exigem root e nunca somem do ps
+func (s *shellSession) kill(args []string) string {
+	var out []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if job, isJob := strings.CutPrefix(arg, "%"); isJob {
+			n, err := strconv.Atoi(job)
+			if err != nil || n < 1 || n > len(s.jobs) || !s.killSession(s.jobs[n-1]) {
+				out = append(out, fmt.Sprintf("bash: kill: %s: no such job", arg))
+			}
+			continue
+		}
+		pid, err := strconv.Atoi(arg)
+		if err != nil {
+			out = append(out, fmt.Sprintf("bash: kill: %s: arguments must be process or job IDs", arg))
+			continue
+		}
+		if s.killSession(pid) {
+			continue
+		}
+		if s.systemProcess(pid) {
+			if !s.isRoot() {
+				out = append(out, fmt.Sprintf("bash: kill: (%d) - Operation not permitted", pid))
+			}
+			continue
+		}
+		out = append(out, fmt.Sprintf("bash: kill: (%d) - No such process", pid))
+	}
+	return strings.Join(out, "\n")
+}
+
+func (s *shellSession) killSession(pid int) bool {
+	for i, process := range s.processes {
+		// O shell e o login não morrem: derrubariam a própria sessão
+		if process.PID == pid && pid > s.shellPID {
+			s.processes = append(s.processes[:i], s.processes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// systemProcess diz se pid existe fora dos jobs do atacante
+func (s *shellSession) systemProcess(pid int) bool {
+	for _, process := range persona.Current().Processes {
+		if process.PID == pid {
+			return true
+		}
+	}
+	for _, process := range s.processes {
+		if process.PID == pid {
+			return true
+		}
+	}
+	return false
+}
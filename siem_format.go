@@ -0,0 +1,376 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatos de saída suportados pelos sinks
+const (
+	FormatJSON = "json"
+	FormatCEF  = "cef"
+	FormatLEEF = "leef"
+)
+
+// sdID identifica o bloco de structured data do RFC 5424 (PEN de exemplo do RFC 5612)
+const sdID = "honeypot@32473"
+
+// SIEMFormatter converte eventos para os formatos aceitos pelos SIEMs
+type SIEMFormatter struct {
+	Vendor   string // Campo "Device Vendor" do CEF / "Vendor" do LEEF
+	Product  string
+	Version  string
+	AppName  string // APP-NAME do cabeçalho syslog
+	Hostname string // HOSTNAME do cabeçalho syslog
+	Facility int    // Facility syslog (16 = local0)
+}
+
+// NewSIEMFormatter cria um formatador com valores padrão
+func NewSIEMFormatter() *SIEMFormatter {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &SIEMFormatter{
+		Vendor:   "Gpot",
+		Product:  "Honeypot",
+		Version:  "2.0.0",
+		AppName:  "honeypot",
+		Hostname: hostname,
+		Facility: 16,
+	}
+}
+
+// Message formata o corpo do evento no formato pedido
+func (f *SIEMFormatter) Message(format string, entry LogEntry) (string, error) {
+	switch format {
+	case FormatJSON, "":
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return "", fmt.Errorf("erro ao serializar evento: %v", err)
+		}
+		return string(data), nil
+	case FormatCEF:
+		return f.CEF(entry), nil
+	case FormatLEEF:
+		return f.LEEF(entry), nil
+	default:
+		return "", fmt.Errorf("formato desconhecido: %s", format)
+	}
+}
+
+// Syslog monta uma mensagem RFC 5424 completa com o corpo já formatado
+func (f *SIEMFormatter) Syslog(entry LogEntry, msg string) string {
+	pri := f.Facility*8 + syslogSeverity(entry.Level)
+	msgID := entry.Type
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		pri,
+		entryTime(entry).Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(f.Hostname, 255),
+		syslogHeaderField(f.AppName, 48),
+		os.Getpid(),
+		syslogHeaderField(msgID, 32),
+		structuredData(entry),
+		msg,
+	)
+}
+
+// CEF formata o evento no padrão ArcSight Common Event Format
+func (f *SIEMFormatter) CEF(entry LogEntry) string {
+	host, port := splitAddr(entry.IP)
+
+	ext := []string{
+		"rt=" + strconv.FormatInt(entryTime(entry).UnixMilli(), 10),
+		"src=" + cefValue(host),
+	}
+	if port != "" {
+		ext = append(ext, "spt="+port)
+	}
+	if entry.Port != 0 {
+		ext = append(ext, "dpt="+strconv.Itoa(entry.Port))
+	}
+	if entry.Protocol != "" {
+		ext = append(ext, "proto="+transport(entry), "app="+cefValue(entry.Protocol))
+	}
+	if entry.Username != "" {
+		ext = append(ext, "suser="+cefValue(entry.Username))
+	}
+	if entry.Password != "" {
+		ext = append(ext, "cs1Label=password", "cs1="+cefValue(entry.Password))
+	}
+	if entry.Command != "" {
+		ext = append(ext, "cs2Label=command", "cs2="+cefValue(entry.Command))
+	}
+	if entry.Session != "" {
+		ext = append(ext, "cs3Label=sessionId", "cs3="+cefValue(entry.Session))
+	}
+	if entry.URL != "" {
+		ext = append(ext, "request="+cefValue(entry.URL))
+	}
+	if entry.SHA256 != "" {
+		ext = append(ext, "fileHash="+cefValue(entry.SHA256))
+	}
+	if entry.SSHKey != "" {
+		ext = append(ext, "cs4Label=sshKeyFingerprint", "cs4="+cefValue(entry.SSHKey))
+	}
+	if entry.Payload != "" {
+		ext = append(ext, "cs5Label=payload", "cs5="+cefValue(entry.Payload))
+	}
+	if entry.UserAgent != "" {
+		ext = append(ext, "requestClientApplication="+cefValue(entry.UserAgent))
+	}
+	if entry.Mechanism != "" {
+		ext = append(ext, "flexString1Label=mechanism", "flexString1="+cefValue(entry.Mechanism))
+	}
+	if entry.Path != "" {
+		ext = append(ext, "filePath="+cefValue(entry.Path))
+	}
+	if entry.Content != "" {
+		ext = append(ext, "cs6Label=content", "cs6="+cefValue(entry.Content))
+	}
+	if entry.FileType != "" {
+		ext = append(ext, "fileType="+cefValue(entry.FileType))
+	}
+	if entry.IOCs != "" {
+		ext = append(ext, "flexString2Label=iocs", "flexString2="+cefValue(entry.IOCs))
+	}
+	if entry.Event != "" {
+		ext = append(ext, "msg="+cefValue(entry.Event))
+	}
+
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefHeader(f.Vendor),
+		cefHeader(f.Product),
+		cefHeader(f.Version),
+		cefHeader(eventID(entry)),
+		cefHeader(eventName(entry)),
+		cefSeverity(entry.Level),
+		strings.Join(ext, " "),
+	)
+}
+
+// LEEF formata o evento no padrão IBM QRadar LEEF 2.0 (delimitador TAB)
+func (f *SIEMFormatter) LEEF(entry LogEntry) string {
+	host, port := splitAddr(entry.IP)
+
+	attrs := []string{
+		"devTime=" + entryTime(entry).Format("Jan 02 2006 15:04:05"),
+		"devTimeFormat=MMM dd yyyy HH:mm:ss",
+		"src=" + leefValue(host),
+		"sev=" + strconv.Itoa(cefSeverity(entry.Level)),
+		"cat=" + leefValue(eventID(entry)),
+	}
+	if port != "" {
+		attrs = append(attrs, "srcPort="+port)
+	}
+	if entry.Port != 0 {
+		attrs = append(attrs, "dstPort="+strconv.Itoa(entry.Port))
+	}
+	if entry.Protocol != "" {
+		attrs = append(attrs, "proto="+transport(entry), "application="+leefValue(entry.Protocol))
+	}
+	if entry.Username != "" {
+		attrs = append(attrs, "usrName="+leefValue(entry.Username))
+	}
+	if entry.Password != "" {
+		attrs = append(attrs, "password="+leefValue(entry.Password))
+	}
+	if entry.Command != "" {
+		attrs = append(attrs, "command="+leefValue(entry.Command))
+	}
+	if entry.Session != "" {
+		attrs = append(attrs, "sessionId="+leefValue(entry.Session))
+	}
+	if entry.URL != "" {
+		attrs = append(attrs, "url="+leefValue(entry.URL))
+	}
+	if entry.SHA256 != "" {
+		attrs = append(attrs, "fileHash="+leefValue(entry.SHA256))
+	}
+	if entry.SSHKey != "" {
+		attrs = append(attrs, "sshKeyFingerprint="+leefValue(entry.SSHKey))
+	}
+	if entry.Payload != "" {
+		attrs = append(attrs, "payload="+leefValue(entry.Payload))
+	}
+	if entry.UserAgent != "" {
+		attrs = append(attrs, "userAgent="+leefValue(entry.UserAgent))
+	}
+	if entry.Mechanism != "" {
+		attrs = append(attrs, "mechanism="+leefValue(entry.Mechanism))
+	}
+	if entry.Path != "" {
+		attrs = append(attrs, "filePath="+leefValue(entry.Path))
+	}
+	if entry.Content != "" {
+		attrs = append(attrs, "content="+leefValue(entry.Content))
+	}
+	if entry.FileType != "" {
+		attrs = append(attrs, "fileType="+leefValue(entry.FileType))
+	}
+	if entry.Arch != "" {
+		attrs = append(attrs, "arch="+leefValue(entry.Arch))
+	}
+	if entry.Packer != "" {
+		attrs = append(attrs, "packer="+leefValue(entry.Packer))
+	}
+	if entry.IOCs != "" {
+		attrs = append(attrs, "iocs="+leefValue(entry.IOCs))
+	}
+	if entry.Event != "" {
+		attrs = append(attrs, "msg="+leefValue(entry.Event))
+	}
+
+	return fmt.Sprintf("LEEF:2.0|%s|%s|%s|%s|x09|%s",
+		leefHeader(f.Vendor),
+		leefHeader(f.Product),
+		leefHeader(f.Version),
+		leefHeader(eventID(entry)),
+		strings.Join(attrs, "\t"),
+	)
+}
+
+// structuredData monta o bloco SD-ELEMENT com os campos principais do evento
+func structuredData(entry LogEntry) string {
+	params := []struct{ name, value string }{
+		{"type", entry.Type},
+		{"protocol", entry.Protocol},
+		{"src", entry.IP},
+		{"session", entry.Session},
+		{"username", entry.Username},
+		{"command", entry.Command},
+		{"url", entry.URL},
+		{"sha256", entry.SHA256},
+		{"ssh_key", entry.SSHKey},
+		{"payload", entry.Payload},
+		{"user_agent", entry.UserAgent},
+		{"mechanism", entry.Mechanism},
+		{"path", entry.Path},
+		{"content", entry.Content},
+		{"file_type", entry.FileType},
+		{"arch", entry.Arch},
+		{"packer", entry.Packer},
+		{"iocs", entry.IOCs},
+	}
+
+	var b strings.Builder
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, ` %s="%s"`, p.name, sdValue(p.value))
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return "[" + sdID + b.String() + "]"
+}
+
+// entryTime converte o timestamp do evento, usando o horário atual se inválido
+func entryTime(entry LogEntry) time.Time {
+	t, err := time.ParseInLocation(TimestampLayout, entry.Timestamp, time.Local)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// splitAddr separa IP e porta de endereços no formato de RemoteAddr()
+func splitAddr(addr string) (string, string) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, ""
+	}
+	return host, port
+}
+
+// transport é o protocolo de transporte do evento: os pacotes dos serviços UDP chegam como
+// UDP_PACKET, todo o resto vem de uma conexão TCP
+func transport(entry LogEntry) string {
+	if entry.Type == EventUDPPacket {
+		return "UDP"
+	}
+	return "TCP"
+}
+
+func eventID(entry LogEntry) string {
+	if entry.Type != "" {
+		return entry.Type
+	}
+	return string(entry.Level)
+}
+
+func eventName(entry LogEntry) string {
+	if entry.Event != "" {
+		return entry.Event
+	}
+	return eventID(entry)
+}
+
+// syslogSeverity mapeia o nível do log para a severidade do RFC 5424
+func syslogSeverity(level LogLevel) int {
+	switch level {
+	case CRITICAL:
+		return 2
+	case ERROR:
+		return 3
+	case WARNING:
+		return 4
+	default:
+		return 6
+	}
+}
+
+// cefSeverity mapeia o nível do log para a escala 0-10 do CEF/LEEF
+func cefSeverity(level LogLevel) int {
+	switch level {
+	case CRITICAL:
+		return 10
+	case ERROR:
+		return 8
+	case WARNING:
+		return 6
+	default:
+		return 3
+	}
+}
+
+// syslogHeaderField garante um campo de cabeçalho sem espaços e com tamanho máximo
+func syslogHeaderField(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= 32 || r >= 127 {
+			return -1
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > max {
+		value = value[:max]
+	}
+	return value
+}
+
+var (
+	sdEscaper         = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	cefHeaderEscaper  = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueEscaper   = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefHeaderEscaper = strings.NewReplacer(`|`, `\|`, "\r", " ", "\n", " ")
+	leefValueEscaper  = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+func sdValue(value string) string    { return sdEscaper.Replace(value) }
+func cefHeader(value string) string  { return cefHeaderEscaper.Replace(value) }
+func cefValue(value string) string   { return cefValueEscaper.Replace(value) }
+func leefHeader(value string) string { return leefHeaderEscaper.Replace(value) }
+func leefValue(value string) string  { return leefValueEscaper.Replace(value) }
@@ -0,0 +1,159 @@
+package logging
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFormatter() *SIEMFormatter {
+	return &SIEMFormatter{Vendor: "Gpot", Product: "Honeypot", Version: "2.0.0", AppName: "honeypot", Hostname: "sensor", Facility: 16}
+}
+
+func TestCEF(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: "2025-04-07 12:10:45",
+		IP:        "203.0.113.9:51234",
+		Event:     "Login | falhou",
+		Level:     CRITICAL,
+		Type:      EventFailedLogin,
+		Protocol:  "ssh",
+		Port:      22,
+		Username:  "root",
+		Password:  `a=b\c`,
+		Command:   "echo 1\necho 2",
+		SHA256:    "abc123",
+		Path:      "/tmp/x",
+	}
+	got := testFormatter().CEF(entry)
+
+	rt, _ := time.ParseInLocation(TimestampLayout, entry.Timestamp, time.Local)
+	prefix := `CEF:0|Gpot|Honeypot|2.0.0|FAILED_LOGIN|Login \| falhou|10|`
+	if !strings.HasPrefix(got, prefix) {
+		t.Fatalf("cabeçalho CEF = %q, esperado prefixo %q", got, prefix)
+	}
+	for _, want := range []string{
+		"rt=" + strconv.FormatInt(rt.UnixMilli(), 10),
+		"src=203.0.113.9",
+		"spt=51234",
+		"dpt=22",
+		"proto=TCP",
+		"app=ssh",
+		"suser=root",
+		`cs1Label=password cs1=a\=b\\c`,
+		`cs2Label=command cs2=echo 1\necho 2`,
+		"fileHash=abc123",
+		"filePath=/tmp/x",
+		`msg=Login | falhou`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("CEF sem %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\n") {
+		t.Errorf("CEF com quebra de linha literal: %q", got)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	entry := LogEntry{IP: "198.51.100.4:123", Level: INFO, Type: EventUDPPacket, Protocol: "ntp", Port: 123}
+	if got := testFormatter().CEF(entry); !strings.Contains(got, "proto=UDP app=ntp") {
+		t.Errorf("CEF de UDP_PACKET = %q, esperado proto=UDP", got)
+	}
+	if got := testFormatter().LEEF(entry); !strings.Contains(got, "proto=UDP\tapplication=ntp") {
+		t.Errorf("LEEF de UDP_PACKET = %q, esperado proto=UDP", got)
+	}
+}
+
+func TestCEFHeaderEscaping(t *testing.T) {
+	got := testFormatter().CEF(LogEntry{Event: "a|b\\c\nd", Level: INFO})
+	if want := `|INFO|a\|b\\c d|3|`; !strings.Contains(got, want) {
+		t.Fatalf("CEF = %q, esperado %q no cabeçalho", got, want)
+	}
+}
+
+func TestLEEF(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: "2025-04-07 12:10:45",
+		IP:        "[2001:db8::1]:4444",
+		Event:     "Comando\texecutado",
+		Level:     WARNING,
+		Type:      EventCommand,
+		Protocol:  "telnet|x",
+		Username:  "admin",
+		Command:   "ls\t-la\nid",
+		Mechanism: "cron",
+		FileType:  "ELF 32-bit MSB executable, MIPS",
+		Arch:      "mips",
+	}
+	got := testFormatter().LEEF(entry)
+
+	if prefix := "LEEF:2.0|Gpot|Honeypot|2.0.0|COMMAND_EXECUTED|x09|"; !strings.HasPrefix(got, prefix) {
+		t.Fatalf("cabeçalho LEEF = %q, esperado prefixo %q", got, prefix)
+	}
+	attrs := strings.Split(strings.SplitN(got, "|x09|", 2)[1], "\t")
+	want := map[string]string{
+		"devTime":     "Apr 07 2025 12:10:45",
+		"src":         "2001:db8::1",
+		"srcPort":     "4444",
+		"sev":         "6",
+		"cat":         "COMMAND_EXECUTED",
+		"proto":       "TCP",
+		"application": "telnet|x",
+		"usrName":     "admin",
+		"mechanism":   "cron",
+		"fileType":    "ELF 32-bit MSB executable, MIPS",
+		"arch":        "mips",
+		"msg":         "Comando executado",
+	}
+	found := make(map[string]string)
+	for _, attr := range attrs {
+		key, value, _ := strings.Cut(attr, "=")
+		found[key] = value
+	}
+	for key, value := range want {
+		if found[key] != value {
+			t.Errorf("LEEF %s = %q, esperado %q", key, found[key], value)
+		}
+	}
+	// TAB e quebra de linha dentro de um valor não podem criar atributos novos
+	if strings.ContainsAny(found["command"], "\t\n") || found["command"] == "" {
+		t.Errorf("comando LEEF mal escapado: %q", found["command"])
+	}
+}
+
+func TestStructuredData(t *testing.T) {
+	got := structuredData(LogEntry{Type: EventDownload, URL: `http://x/"a]`, Content: `c:\tmp`})
+	want := `[honeypot@32473 type="FILE_DOWNLOAD" url="http://x/\"a\]" content="c:\\tmp"]`
+	if got != want {
+		t.Fatalf("structuredData = %q, esperado %q", got, want)
+	}
+	if got := structuredData(LogEntry{}); got != "-" {
+		t.Fatalf("structuredData vazio = %q, esperado \"-\"", got)
+	}
+}
+
+func TestSyslogHeader(t *testing.T) {
+	f := testFormatter()
+	f.Hostname = "my sensor"
+	got := f.Syslog(LogEntry{Level: CRITICAL}, "corpo")
+	// facility 16 * 8 + severidade 2; espaços somem do HOSTNAME e o MSGID vazio vira "-"
+	if !strings.HasPrefix(got, "<130>1 ") || !strings.Contains(got, " mysensor honeypot ") || !strings.HasSuffix(got, " - - corpo") {
+		t.Fatalf("Syslog = %q", got)
+	}
+}
+
+func TestMessageFormats(t *testing.T) {
+	f := testFormatter()
+	entry := LogEntry{Event: "x", Level: INFO}
+	for format, prefix := range map[string]string{"": "{", FormatJSON: "{", FormatCEF: "CEF:0|", FormatLEEF: "LEEF:2.0|"} {
+		msg, err := f.Message(format, entry)
+		if err != nil || !strings.HasPrefix(msg, prefix) {
+			t.Errorf("Message(%q) = %q, %v", format, msg, err)
+		}
+	}
+	if _, err := f.Message("xml", entry); err == nil {
+		t.Error("Message(xml) deveria falhar")
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"time"
 
 	"golang.org/x/crypto/ssh"
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 	"yourproject/internal/logs"  // Log personalizado
 	"yourproject/internal/network"  // Lógica de rede separada
 )
@@ -28,8 +32,31 @@ func NewSSHServerConfig(listenAddr string, privateKey []byte) (*SSHServerConfig,
 
 	// Cria o servidor SSH com configurações básicas
 	serverConfig := &ssh.ServerConfig{
+		// Versão anunciada pelo perfil ativo (ex.: SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.4)
+		ServerVersion: persona.Current().Banners.SSH,
 		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if verdict := firewall.CheckAuth(c.RemoteAddr().String(), "ssh"); verdict.Action == firewall.ActionDeny {
+				return nil, fmt.Errorf("too many authentication attempts")
+			}
+			metrics.AuthAttempts.Inc("ssh")
+			logs.Info(fmt.Sprintf("Public key offered by %s (%s): %s", c.RemoteAddr(), c.User(), ssh.FingerprintSHA256(key)))
 			// Aqui podemos simular uma autenticação
+			firewall.AuthFailed(c.RemoteAddr().String(), "ssh", c.User())
+			return nil, fmt.Errorf("unauthorized access")
+		},
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			addr := c.RemoteAddr().String()
+			if verdict := firewall.CheckAuth(addr, "ssh"); verdict.Action == firewall.ActionDeny {
+				return nil, fmt.Errorf("too many authentication attempts")
+			}
+			metrics.AuthAttempts.Inc("ssh")
+			// A política do protocolo decide quais senhas abrem a sessão
+			if auth.Check(auth.Attempt{Protocol: "ssh", Addr: addr, Username: c.User(), Password: string(password), Client: string(c.ClientVersion())}) {
+				metrics.AuthSuccesses.Inc("ssh")
+				logs.Info(fmt.Sprintf("Password accepted for %s from %s", c.User(), addr))
+				return nil, nil
+			}
+			firewall.AuthFailed(addr, "ssh", c.User())
 			return nil, fmt.Errorf("unauthorized access")
 		},
 	}
@@ -71,6 +98,8 @@ func (cfg *SSHServerConfig) Start() error {
 
 // handleSSHConnection trata a conexão SSH, realizando a autenticação e comandos.
 func handleSSHConnection(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	defer metrics.TrackConnection("ssh", conn)()
+
 	// Realiza o handshake SSH
 	sshConn, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
 	if err != nil {
@@ -107,7 +136,7 @@ func handleChannel(ch ssh.NewChannel) {
 	defer channel.Close()
 
 	// Simula uma interação de shell, retornando uma mensagem de boas-vindas
-	channel.Write([]byte("Welcome to the honeypot! Type 'exit' to quit.\n"))
+	channel.Write([]byte(persona.Current().Welcome() + "\n\n"))
 
 	// Aguardar comandos do cliente (nesse caso, apenas simula um shell simples)
 	handleCommands(channel)
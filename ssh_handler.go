@@ -3,21 +3,23 @@ package handlers
 import (
 	"net"
 	"myhoneypot/logging"
+	"myhoneypot/metrics"
 )
 
 func HandleSSHConnection(conn net.Conn, logger *logging.Logger) {
 	defer conn.Close()
+	defer metrics.TrackConnection("ssh", conn)()
 
 	ip := conn.RemoteAddr().String()
 
-	// Verificar se o IP já está banido
-	if logger.IsIPBanned(ip) {
-		logger.Log(ip, "Conexão SSH recusada (IP banido)", WARNING)
-		return
-	}
-
 	// Logando tentativa de conexão
-	logger.Log(ip, "Tentativa de login via SSH", INFO)
+	logger.Record(logging.LogEntry{
+		IP:       ip,
+		Event:    "Tentativa de login via SSH",
+		Level:    logging.INFO,
+		Type:     logging.EventConnection,
+		Protocol: "ssh",
+	})
 
 	// Lógica do honeypot...
 }
@@ -32,28 +34,27 @@ import (
 	"math/rand"
 	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // Para salvar logs no SQLite
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 	"yourproject/internal/logs"
 )
 
 const (
 	sshPort        = "0.0.0.0:22"
-	fakeUsername   = "admin"
-	fakePassword   = "admin"
 	timeoutSeconds = 120
 )
 
 var fakeSSHResponses = map[string]string{
 	"ls":         "bin  boot  dev  etc  home  lib  lib64  media  mnt  opt  proc  root  run  sbin  srv  sys  tmp  usr  var",
 	"whoami":     "admin",
-	"uname -a":   "Linux honeypot 5.15.0-58-generic x86_64 GNU/Linux",
 	"id":         "uid=0(root) gid=0(root) groups=0(root)",
 	"pwd":        "/home/admin",
-	"cat /etc/passwd": "root:x:0:0:root:/root:/bin/bash\nadmin:x:1000:1000:Admin,,,:/home/admin:/bin/bash",
 	"exit":       "Connection closed by remote host.",
 }
 
@@ -81,22 +82,31 @@ func StartSSHServer() {
 
 func handleSSHConnection(conn net.Conn) {
 	defer conn.Close()
+	defer metrics.TrackConnection("ssh", conn)()
 	clientAddr := conn.RemoteAddr().String()
 	logs.Info(fmt.Sprintf("New SSH connection from %s", clientAddr))
 
-	conn.Write([]byte("SSH-2.0-OpenSSH_8.2p1 Ubuntu-4ubuntu0.5\r\n"))
+	conn.Write([]byte(persona.Current().Banners.SSH + "\r\n"))
+
+	if verdict := firewall.CheckAuth(clientAddr, "ssh"); verdict.Action == firewall.ActionDeny {
+		logs.Warn(fmt.Sprintf("Login from %s refused: %s", clientAddr, verdict.Reason))
+		return
+	}
 
 	username, password := fakeSSHLogin(conn)
+	metrics.AuthAttempts.Inc("ssh")
 	if username == "" || password == "" {
 		logs.Warn(fmt.Sprintf("Failed SSH login attempt from %s", clientAddr))
 		logToFile(fmt.Sprintf("Failed login from %s", clientAddr))
 		saveToDatabase(clientAddr, "FAILED_LOGIN", "")
+		firewall.AuthFailed(clientAddr, "ssh", "")
 		return
 	}
 
 	logs.Info(fmt.Sprintf("Successful SSH login from %s with user: %s", clientAddr, username))
 	logToFile(fmt.Sprintf("Successful login from %s - Username: %s", clientAddr, username))
 	saveToDatabase(clientAddr, "SUCCESSFUL_LOGIN", username)
+	metrics.AuthSuccesses.Inc("ssh")
 
 	handleFakeSSHCommands(conn, clientAddr)
 }
@@ -105,12 +115,12 @@ func fakeSSHLogin(conn net.Conn) (string, string) {
 	conn.Write([]byte("login as: "))
 	username := readLine(conn)
 
-	conn.Write([]byte(username + "@honeypot's password: "))
+	conn.Write([]byte(username + "@" + persona.Current().Hostname + "'s password: "))
 	password := readLine(conn)
 
-	if username == fakeUsername && password == fakePassword {
-		conn.Write([]byte("Welcome to Ubuntu 20.04 LTS (GNU/Linux 5.15.0-58-generic x86_64)\r\n\n"))
-		conn.Write([]byte(username + "@honeypot:~$ "))
+	if auth.Accept("ssh", conn.RemoteAddr().String(), username, password) {
+		conn.Write([]byte(strings.ReplaceAll(persona.Current().Welcome(), "\n", "\r\n") + "\r\n\r\n"))
+		conn.Write([]byte(username + "@" + persona.Current().Hostname + ":~$ "))
 		return username, password
 	}
 
@@ -128,6 +138,7 @@ func handleFakeSSHCommands(conn net.Conn, clientAddr string) {
 			continue
 		}
 
+		metrics.Commands.Inc("ssh")
 		logs.Info(fmt.Sprintf("SSH command from %s: %s", clientAddr, command))
 		logToFile(fmt.Sprintf("SSH command from %s: %s", clientAddr, command))
 		saveToDatabase(clientAddr, "COMMAND_EXECUTED", command)
@@ -135,13 +146,15 @@ func handleFakeSSHCommands(conn net.Conn, clientAddr string) {
 		simulateCommandLatency(command)
 		detectSuspiciousCommand(command, clientAddr)
 
-		if response, exists := fakeSSHResponses[command]; exists {
+		if response, exists := persona.Current().Command(command); exists {
+			conn.Write([]byte(strings.ReplaceAll(response, "\n", "\r\n") + "\r\n"))
+		} else if response, exists := fakeSSHResponses[command]; exists {
 			conn.Write([]byte(response + "\r\n"))
 		} else {
 			conn.Write([]byte("bash: " + command + ": command not found\r\n"))
 		}
 
-		conn.Write([]byte("admin@honeypot:~$ "))
+		conn.Write([]byte("admin@" + persona.Current().Hostname + ":~$ "))
 	}
 }
 
@@ -183,15 +196,14 @@ func detectSuspiciousCommand(command, clientAddr string) {
 	}
 }
 
-func blockSuspiciousIP(ip string) {
-	cmd := exec.Command("sudo", "iptables", "-A", "INPUT", "-s", ip, "-j", "DROP")
-	err := cmd.Run()
+func blockSuspiciousIP(addr string) {
+	err := firewall.BanAddr(addr, "suspicious command", "SUSPICIOUS_COMMAND")
 	if err != nil {
-		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
-		logToFile(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
+		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
+		logToFile(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
 	} else {
-		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", ip))
-		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", ip))
+		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", addr))
+		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", addr))
 	}
 }
 
@@ -0,0 +1,263 @@
+package logging
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SyslogConfig configura o envio de eventos para um coletor syslog
+type SyslogConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	Network           string        `yaml:"network"` // udp, tcp ou tls
+	Address           string        `yaml:"address"`
+	Format            string        `yaml:"format"` // json, cef ou leef
+	Facility          int           `yaml:"facility"`
+	AppName           string        `yaml:"app_name"`
+	BufferSize        int           `yaml:"buffer_size"`        // Eventos mantidos enquanto o coletor está fora
+	ReconnectInterval time.Duration `yaml:"reconnect_interval"` // Espera entre tentativas de reconexão
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	TLSCAFile         string        `yaml:"tls_ca_file"`
+	TLSSkipVerify     bool          `yaml:"tls_skip_verify"`
+}
+
+// SyslogSink envia eventos no formato RFC 5424 via UDP, TCP ou TLS
+type SyslogSink struct {
+	config    SyslogConfig
+	formatter *SIEMFormatter
+	tlsConfig *tls.Config
+	queue     chan string
+	conn      net.Conn
+	dropped   uint64
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	logger    *log.Logger
+}
+
+// NewSyslogSink cria o sink e inicia a goroutine de envio
+func NewSyslogSink(config SyslogConfig, formatter *SIEMFormatter) (*SyslogSink, error) {
+	if config.Address == "" {
+		return nil, errors.New("endereço do coletor syslog não configurado")
+	}
+	config.Network = strings.ToLower(config.Network)
+	if config.Network == "" {
+		config.Network = "udp"
+	}
+	if config.Network != "udp" && config.Network != "tcp" && config.Network != "tls" {
+		return nil, fmt.Errorf("transporte syslog desconhecido: %s", config.Network)
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 10000
+	}
+	if config.ReconnectInterval <= 0 {
+		config.ReconnectInterval = 5 * time.Second
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 10 * time.Second
+	}
+
+	if formatter == nil {
+		formatter = NewSIEMFormatter()
+	}
+	if config.Facility != 0 {
+		formatter.Facility = config.Facility
+	}
+	if config.AppName != "" {
+		formatter.AppName = config.AppName
+	}
+
+	// Valida o formato antes de aceitar eventos
+	if _, err := formatter.Message(config.Format, LogEntry{}); err != nil {
+		return nil, err
+	}
+
+	sink := &SyslogSink{
+		config:    config,
+		formatter: formatter,
+		queue:     make(chan string, config.BufferSize),
+		done:      make(chan struct{}),
+		logger:    log.New(log.Writer(), "SYSLOG: ", log.LstdFlags|log.Lshortfile),
+	}
+
+	if config.Network == "tls" {
+		tlsConfig, err := buildTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		sink.tlsConfig = tlsConfig
+	}
+
+	sink.wg.Add(1)
+	go sink.run()
+	return sink, nil
+}
+
+// Send formata o evento e o coloca na fila de envio sem bloquear o serviço
+func (s *SyslogSink) Send(entry LogEntry) error {
+	msg, err := s.formatter.Message(s.config.Format, entry)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.queue <- s.formatter.Syslog(entry, msg):
+		return nil
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+		return errors.New("fila do syslog cheia, evento descartado")
+	}
+}
+
+// QueueDepth retorna quantos eventos aguardam envio
+func (s *SyslogSink) QueueDepth() int {
+	return len(s.queue)
+}
+
+// Dropped retorna quantos eventos foram descartados por falta de espaço na fila ou por não
+// terem sido entregues até o Close
+func (s *SyslogSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close tenta enviar o que resta na fila e encerra a conexão
+func (s *SyslogSink) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+	return nil
+}
+
+// run consome a fila, reconectando ao coletor sempre que a escrita falhar
+func (s *SyslogSink) run() {
+	defer s.wg.Done()
+	defer s.disconnect()
+
+	for {
+		select {
+		case msg := <-s.queue:
+			if !s.deliver(msg) {
+				// Fechado com o coletor fora do ar: a mensagem em mãos e a fila se perdem
+				atomic.AddUint64(&s.dropped, uint64(len(s.queue)+1))
+				return
+			}
+		case <-s.done:
+			s.flush()
+			return
+		}
+	}
+}
+
+// deliver insiste no envio até conseguir ou até o sink ser fechado
+func (s *SyslogSink) deliver(msg string) bool {
+	for {
+		err := s.write(msg)
+		if err == nil {
+			return true
+		}
+		s.logger.Printf("Falha ao enviar para %s (%s): %v\n", s.config.Address, s.config.Network, err)
+		s.disconnect()
+
+		select {
+		case <-time.After(s.config.ReconnectInterval):
+		case <-s.done:
+			return false
+		}
+	}
+}
+
+// flush envia os eventos pendentes uma única vez, sem aguardar reconexões
+func (s *SyslogSink) flush() {
+	for {
+		select {
+		case msg := <-s.queue:
+			if err := s.write(msg); err != nil {
+				atomic.AddUint64(&s.dropped, uint64(len(s.queue)+1))
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (s *SyslogSink) write(msg string) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	// TCP e TLS usam octet-counting (RFC 5425/6587); UDP envia um datagrama por mensagem
+	frame := msg
+	if s.config.Network != "udp" {
+		frame = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.config.WriteTimeout))
+	_, err := s.conn.Write([]byte(frame))
+	return err
+}
+
+func (s *SyslogSink) connect() error {
+	dialer := &net.Dialer{Timeout: s.config.WriteTimeout}
+
+	var conn net.Conn
+	var err error
+	if s.config.Network == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.config.Address, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(s.config.Network, s.config.Address)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	s.logger.Printf("Conectado ao coletor %s (%s)\n", s.config.Address, s.config.Network)
+	return nil
+}
+
+func (s *SyslogSink) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// buildTLSConfig carrega a CA do coletor, se informada
+func buildTLSConfig(config SyslogConfig) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("endereço syslog inválido: %v", err)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: config.TLSSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler CA do syslog: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("nenhum certificado válido em %s", config.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,268 @@
+package logging
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc5424 casa o cabeçalho gerado para um evento WARNING de facility local0 (PRI 132)
+var rfc5424 = regexp.MustCompile(`^<132>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) sensor honeypot \d+ FAILED_LOGIN \[honeypot@32473 [^\]]*\] \{.*\}$`)
+
+func testEntry(n int) LogEntry {
+	return LogEntry{
+		Timestamp: "2025-04-07 12:10:45",
+		IP:        "203.0.113.9:51234",
+		Event:     fmt.Sprintf("Tentativa %d", n),
+		Level:     WARNING,
+		Type:      EventFailedLogin,
+		Protocol:  "ssh",
+		Username:  "root",
+		Password:  "toor",
+	}
+}
+
+func testSink(t *testing.T, config SyslogConfig) *SyslogSink {
+	t.Helper()
+	formatter := NewSIEMFormatter()
+	formatter.Hostname = "sensor"
+	config.Format = FormatJSON
+	config.ReconnectInterval = 20 * time.Millisecond
+	config.WriteTimeout = time.Second
+	sink, err := NewSyslogSink(config, formatter)
+	if err != nil {
+		t.Fatalf("NewSyslogSink: %v", err)
+	}
+	t.Cleanup(func() { sink.Close() })
+	return sink
+}
+
+// readFrame lê uma mensagem com octet-counting ("<tamanho> <mensagem>")
+func readFrame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	size, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatalf("lendo o tamanho do frame: %v", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(size, " "))
+	if err != nil {
+		t.Fatalf("tamanho do frame inválido %q", size)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		t.Fatalf("lendo %d bytes do frame: %v", n, err)
+	}
+	return string(msg)
+}
+
+func acceptOne(t *testing.T, listener net.Listener) *bufio.Reader {
+	t.Helper()
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	t.Cleanup(func() { conn.Close() })
+	return bufio.NewReader(conn)
+}
+
+func checkMessage(t *testing.T, msg string, n int) {
+	t.Helper()
+	if !rfc5424.MatchString(msg) {
+		t.Fatalf("mensagem fora do RFC 5424: %q", msg)
+	}
+	if want := fmt.Sprintf(`"event":"Tentativa %d"`, n); !strings.Contains(msg, want) {
+		t.Fatalf("mensagem %q sem %s", msg, want)
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sink := testSink(t, SyslogConfig{Network: "udp", Address: conn.LocalAddr().String()})
+
+	for i := 1; i <= 2; i++ {
+		if err := sink.Send(testEntry(i)); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	buf := make([]byte, 64<<10)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for i := 1; i <= 2; i++ {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom: %v", err)
+		}
+		// Um datagrama por mensagem, sem octet-counting
+		checkMessage(t, string(buf[:n]), i)
+	}
+}
+
+func TestSyslogTCPOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	sink := testSink(t, SyslogConfig{Network: "tcp", Address: listener.Addr().String()})
+
+	for i := 1; i <= 3; i++ {
+		sink.Send(testEntry(i))
+	}
+	r := acceptOne(t, listener)
+	for i := 1; i <= 3; i++ {
+		checkMessage(t, readFrame(t, r), i)
+	}
+}
+
+func TestSyslogTLS(t *testing.T) {
+	cert, caFile := testCertificate(t)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	sink := testSink(t, SyslogConfig{Network: "tls", Address: listener.Addr().String(), TLSCAFile: caFile})
+
+	sink.Send(testEntry(1))
+	sink.Send(testEntry(2))
+	r := acceptOne(t, listener)
+	checkMessage(t, readFrame(t, r), 1)
+	checkMessage(t, readFrame(t, r), 2)
+}
+
+func TestSyslogBuffersWhileCollectorIsDown(t *testing.T) {
+	// Reserva um endereço e fecha o listener: o coletor começa fora do ar
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	sink := testSink(t, SyslogConfig{Network: "tcp", Address: address, BufferSize: 10})
+	for i := 1; i <= 3; i++ {
+		if err := sink.Send(testEntry(i)); err != nil {
+			t.Fatalf("Send com o coletor fora: %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if depth := sink.QueueDepth(); depth < 2 {
+		t.Fatalf("QueueDepth = %d, eventos deveriam aguardar o coletor", depth)
+	}
+
+	listener, err = net.Listen("tcp", address)
+	if err != nil {
+		t.Skipf("não foi possível reabrir %s: %v", address, err)
+	}
+	defer listener.Close()
+	r := acceptOne(t, listener)
+	for i := 1; i <= 3; i++ {
+		checkMessage(t, readFrame(t, r), i)
+	}
+	if dropped := sink.Dropped(); dropped != 0 {
+		t.Fatalf("Dropped = %d, nenhum evento deveria ser perdido", dropped)
+	}
+}
+
+func TestSyslogCountsQueueLostOnClose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	sink := testSink(t, SyslogConfig{Network: "tcp", Address: address, BufferSize: 10})
+	for i := 1; i <= 4; i++ {
+		if err := sink.Send(testEntry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	sink.Close()
+	if dropped := sink.Dropped(); dropped != 4 {
+		t.Fatalf("Dropped = %d, esperado 4: a mensagem em envio e a fila se perderam no Close", dropped)
+	}
+}
+
+func TestSyslogDropsWhenBufferIsFull(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	sink := testSink(t, SyslogConfig{Network: "tcp", Address: address, BufferSize: 1})
+	var failed int
+	for i := 1; i <= 5; i++ {
+		if sink.Send(testEntry(i)) != nil {
+			failed++
+		}
+	}
+	if failed == 0 || sink.Dropped() != uint64(failed) {
+		t.Fatalf("failed = %d, Dropped = %d: a fila cheia deveria descartar e contar", failed, sink.Dropped())
+	}
+}
+
+func TestNewSyslogSinkRejectsBadConfig(t *testing.T) {
+	for _, config := range []SyslogConfig{
+		{Network: "udp"},
+		{Network: "sctp", Address: "127.0.0.1:514"},
+		{Network: "udp", Address: "127.0.0.1:514", Format: "xml"},
+	} {
+		if sink, err := NewSyslogSink(config, nil); err == nil {
+			sink.Close()
+			t.Errorf("NewSyslogSink(%+v) deveria falhar", config)
+		}
+	}
+}
+
+// testCertificate gera um certificado autoassinado para 127.0.0.1 e grava a CA num arquivo
+func testCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "collector"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
@@ -8,30 +8,26 @@ import (
 	"math/rand"
 	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // Para salvar logs em SQLite opcionalmente
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 	"yourproject/internal/logs"
 )
 
 const (
 	telnetPort      = "0.0.0.0:2323"
-	fakeUsername    = "admin"
-	fakePassword    = "admin"
-	bannerMessage   = "Unauthorized access is prohibited.\n"
 	timeoutDuration = 120 * time.Second
 )
 
 var fakeCommandResponses = map[string]string{
 	"whoami":          "admin",
-	"uname -a":        "Linux honeypot 5.10.0-kali #1 SMP Debian 5.10.46-4kali2 x86_64 GNU/Linux",
 	"ls":              "bin  boot  dev  etc  home  lib  lib64  media  mnt  opt  proc  root  sbin  srv  tmp  usr  var",
 	"pwd":             "/home/admin",
-	"ifconfig":        "eth0: flags=4163<UP,BROADCAST,RUNNING,MULTICAST>  mtu 1500\ninet 192.168.1.10  netmask 255.255.255.0  broadcast 192.168.1.255",
-	"ps aux":          "USER       PID  %CPU %MEM    TIME+  COMMAND\nadmin      1342  0.1  0.5   20000   1024 ?        Ss   00:00   0:00 /bin/bash",
-	"cat /etc/passwd": "root:x:0:0:root:/root:/bin/bash\nadmin:x:1000:1000:Admin User:/home/admin:/bin/bash",
 	"ls /tmp":         "/tmp/.rootkit /tmp/backdoor /tmp/hacktool",
 	"exit":            "Connection closed.\n",
 }
@@ -60,35 +56,44 @@ func StartTelnetServer() {
 
 func handleTelnetConnection(conn net.Conn) {
 	defer conn.Close()
+	defer metrics.TrackConnection("telnet", conn)()
 	clientAddr := conn.RemoteAddr().String()
 	logs.Info(fmt.Sprintf("New Telnet connection from %s", clientAddr))
 
-	conn.Write([]byte(bannerMessage))
+	conn.Write([]byte(persona.Current().Banners.Telnet + "\n"))
+
+	if verdict := firewall.CheckAuth(clientAddr, "telnet"); verdict.Action == firewall.ActionDeny {
+		logs.Warn(fmt.Sprintf("Login from %s refused: %s", clientAddr, verdict.Reason))
+		return
+	}
 
 	username, password := fakeLogin(conn)
+	metrics.AuthAttempts.Inc("telnet")
 	if username == "" || password == "" {
 		logs.Warn(fmt.Sprintf("Failed login attempt from %s", clientAddr))
 		logToFile(fmt.Sprintf("Failed login attempt from %s", clientAddr))
 		saveToDatabase(clientAddr, "FAILED_LOGIN", "")
+		firewall.AuthFailed(clientAddr, "telnet", "")
 		return
 	}
 
 	logs.Info(fmt.Sprintf("Successful Telnet login from %s with user: %s", clientAddr, username))
 	logToFile(fmt.Sprintf("Successful login from %s - Username: %s", clientAddr, username))
 	saveToDatabase(clientAddr, "SUCCESSFUL_LOGIN", username)
+	metrics.AuthSuccesses.Inc("telnet")
 
 	handleFakeShell(conn, clientAddr)
 }
 
 func fakeLogin(conn net.Conn) (string, string) {
-	conn.Write([]byte("login: "))
+	conn.Write([]byte(persona.Current().Hostname + " login: "))
 	username := readLine(conn)
 
 	conn.Write([]byte("Password: "))
 	password := readLine(conn)
 
-	if username == fakeUsername && password == fakePassword {
-		conn.Write([]byte("\nWelcome to the system.\n"))
+	if auth.Accept("telnet", conn.RemoteAddr().String(), username, password) {
+		conn.Write([]byte("\n" + persona.Current().Welcome() + "\n"))
 		return username, password
 	}
 
@@ -111,6 +116,7 @@ func handleFakeShell(conn net.Conn, clientAddr string) {
 			continue
 		}
 
+		metrics.Commands.Inc("telnet")
 		logs.Info(fmt.Sprintf("Telnet command from %s: %s", clientAddr, command))
 		logToFile(fmt.Sprintf("Telnet command from %s: %s", clientAddr, command))
 		saveToDatabase(clientAddr, "COMMAND_EXECUTED", command)
@@ -118,7 +124,11 @@ func handleFakeShell(conn net.Conn, clientAddr string) {
 		simulateCommandLatency(command)
 		detectSuspiciousCommand(command, clientAddr)
 
-		if response, exists := fakeCommandResponses[command]; exists {
+		if command == "ps aux" {
+			conn.Write([]byte(persona.Current().PSAux() + "\n"))
+		} else if response, exists := persona.Current().Command(command); exists {
+			conn.Write([]byte(response + "\n"))
+		} else if response, exists := fakeCommandResponses[command]; exists {
 			conn.Write([]byte(response + "\n"))
 			if command == "exit" {
 				return
@@ -173,15 +183,14 @@ func detectSuspiciousCommand(command, clientAddr string) {
 	}
 }
 
-func blockSuspiciousIP(ip string) {
-	cmd := exec.Command("sudo", "iptables", "-A", "INPUT", "-s", ip, "-j", "DROP")
-	err := cmd.Run()
+func blockSuspiciousIP(addr string) {
+	err := firewall.BanAddr(addr, "suspicious command", "SUSPICIOUS_COMMAND")
 	if err != nil {
-		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
-		logToFile(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
+		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
+		logToFile(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
 	} else {
-		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", ip))
-		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", ip))
+		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", addr))
+		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", addr))
 	}
 }
 
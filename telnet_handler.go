@@ -1,25 +1,91 @@
 package handlers
 
 import (
+	"fmt"
 	"net"
+	"time"
+
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
 	"myhoneypot/logging"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 )
 
-func HandleTelnetConnection(conn net.Conn, logger *logging.Logger) {
+// telnetLoginAttempts é o número de logins pedidos antes de desconectar, como o login(1)
+const telnetLoginAttempts = 3
+
+// HandleTelnetConnection pede usuário e senha e, se a política aceitar, abre o shell falso
+func HandleTelnetConnection(conn net.Conn, logger *logging.Logger, shell *Shell) {
 	defer conn.Close()
+	defer metrics.TrackConnection("telnet", conn)()
 
 	ip := conn.RemoteAddr().String()
 
-	if logger.IsIPBanned(ip) {
-		logger.Log(ip, "Conexão Telnet recusada (IP banido)", WARNING)
-		return
+	logger.Record(logging.LogEntry{
+		IP:       ip,
+		Event:    "Tentativa de login via Telnet",
+		Level:    logging.INFO,
+		Type:     logging.EventConnection,
+		Protocol: "telnet",
+	})
+
+	// Simulação de resposta falsa para enganar invasores
+	session := shell.newSession(conn, "telnet")
+	if issue := persona.Current().Banners.Telnet; issue != "" {
+		session.write(issue + "\n")
 	}
+	for attempt := 0; attempt < telnetLoginAttempts; attempt++ {
+		session.write(session.hostname + " login: ")
+		username, err := session.readLine()
+		if err != nil {
+			return
+		}
+		session.write("Password: ")
+		password, err := session.readPassword()
+		if err != nil {
+			return
+		}
 
-	logger.Log(ip, "Tentativa de login via Telnet", INFO)
+		start := time.Now()
+		if verdict := firewall.CheckAuth(ip, "telnet"); verdict.Action == firewall.ActionDeny {
+			return
+		}
+		metrics.AuthAttempts.Inc("telnet")
+		entry := logging.LogEntry{
+			IP:       ip,
+			Event:    fmt.Sprintf("Login recusado via Telnet para %s", username),
+			Level:    logging.WARNING,
+			Type:     logging.EventFailedLogin,
+			Protocol: "telnet",
+			Session:  session.id,
+			Username: username,
+			Password: password,
+		}
+		accepted := auth.Accept("telnet", ip, username, password)
+		metrics.ObserveLatency("telnet", "auth", start)
+		if accepted {
+			metrics.AuthSuccesses.Inc("telnet")
+			entry.Event = fmt.Sprintf("Login aceito via Telnet para %s", username)
+			entry.Level = logging.INFO
+			entry.Type = logging.EventSuccessfulLogin
+			logger.Record(entry)
+
+			session.login(username, password)
+			session.run()
+			return
+		}
+		logger.Record(entry)
+		if verdict := firewall.AuthFailed(ip, "telnet", username); verdict.Action == firewall.ActionDeny {
+			return
+		}
 
-	// Simulação de resposta falsa para enganar invasores
-	conn.Write([]byte("Login: "))
+		// Atraso do login(1) depois de uma senha errada
+		time.Sleep(2 * time.Second)
+		session.write("\nLogin incorrect\n")
+	}
 }
+
 package cmd
 
 import (
@@ -29,29 +95,27 @@ import (
 	"log"
 	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"myhoneypot/auth"
+	"myhoneypot/firewall"
+	"myhoneypot/metrics"
+	"myhoneypot/persona"
 	"yourproject/internal/logs"
 )
 
 const (
 	telnetPort      = "0.0.0.0:23"
-	fakeUsername    = "admin"
-	fakePassword    = "admin"
 	timeoutSeconds  = 120
-	telnetBanner    = "\r\nWelcome to the Telnet honeypot. Unauthorized access is prohibited.\r\n"
 )
 
 var fakeTelnetResponses = map[string]string{
 	"ls":         "bin  boot  dev  etc  home  lib  lib64  media  mnt  opt  proc  root  run  sbin  srv  sys  tmp  usr  var",
 	"whoami":     "admin",
-	"uname -a":   "Linux honeypot 5.15.0-58-generic x86_64 GNU/Linux",
 	"id":         "uid=0(root) gid=0(root) groups=0(root)",
 	"pwd":        "/home/admin",
-	"cat /etc/passwd": "root:x:0:0:root:/root:/bin/bash\nadmin:x:1000:1000:Admin,,,:/home/admin:/bin/bash",
 	"exit":       "Connection closed by remote host.",
 }
 
@@ -79,22 +143,31 @@ func StartTelnetServer() {
 
 func handleTelnetConnection(conn net.Conn) {
 	defer conn.Close()
+	defer metrics.TrackConnection("telnet", conn)()
 	clientAddr := conn.RemoteAddr().String()
 	logs.Info(fmt.Sprintf("New Telnet connection from %s", clientAddr))
 
-	conn.Write([]byte(telnetBanner))
+	conn.Write([]byte("\r\n" + strings.ReplaceAll(persona.Current().Banners.Telnet, "\n", "\r\n") + "\r\n"))
+
+	if verdict := firewall.CheckAuth(clientAddr, "telnet"); verdict.Action == firewall.ActionDeny {
+		logs.Warn(fmt.Sprintf("Login from %s refused: %s", clientAddr, verdict.Reason))
+		return
+	}
 
 	username, password := fakeTelnetLogin(conn)
+	metrics.AuthAttempts.Inc("telnet")
 	if username == "" || password == "" {
 		logs.Warn(fmt.Sprintf("Failed Telnet login attempt from %s", clientAddr))
 		logToFile(fmt.Sprintf("Failed login from %s", clientAddr))
 		saveToDatabase(clientAddr, "FAILED_LOGIN", "")
+		firewall.AuthFailed(clientAddr, "telnet", "")
 		return
 	}
 
 	logs.Info(fmt.Sprintf("Successful Telnet login from %s with user: %s", clientAddr, username))
 	logToFile(fmt.Sprintf("Successful login from %s - Username: %s", clientAddr, username))
 	saveToDatabase(clientAddr, "SUCCESSFUL_LOGIN", username)
+	metrics.AuthSuccesses.Inc("telnet")
 
 	handleFakeTelnetCommands(conn, clientAddr)
 }
@@ -102,7 +175,7 @@ func handleTelnetConnection(conn net.Conn) {
 func fakeTelnetLogin(conn net.Conn) (string, string) {
 	scanner := bufio.NewScanner(conn)
 
-	conn.Write([]byte("login: "))
+	conn.Write([]byte(persona.Current().Hostname + " login: "))
 	scanner.Scan()
 	username := scanner.Text()
 
@@ -110,8 +183,8 @@ func fakeTelnetLogin(conn net.Conn) (string, string) {
 	scanner.Scan()
 	password := scanner.Text()
 
-	if username == fakeUsername && password == fakePassword {
-		conn.Write([]byte("Welcome to Telnet!\r\n"))
+	if auth.Accept("telnet", conn.RemoteAddr().String(), username, password) {
+		conn.Write([]byte(strings.ReplaceAll(persona.Current().Welcome(), "\n", "\r\n") + "\r\n"))
 		return username, password
 	}
 
@@ -129,19 +202,22 @@ func handleFakeTelnetCommands(conn net.Conn, clientAddr string) {
 			continue
 		}
 
+		metrics.Commands.Inc("telnet")
 		logs.Info(fmt.Sprintf("Telnet command from %s: %s", clientAddr, command))
 		logToFile(fmt.Sprintf("Telnet command from %s: %s", clientAddr, command))
 		saveToDatabase(clientAddr, "COMMAND_EXECUTED", command)
 
 		detectSuspiciousCommand(command, clientAddr)
 
-		if response, exists := fakeTelnetResponses[command]; exists {
+		if response, exists := persona.Current().Command(command); exists {
+			conn.Write([]byte(strings.ReplaceAll(response, "\n", "\r\n") + "\r\n"))
+		} else if response, exists := fakeTelnetResponses[command]; exists {
 			conn.Write([]byte(response + "\r\n"))
 		} else {
 			conn.Write([]byte("bash: " + command + ": command not found\r\n"))
 		}
 
-		conn.Write([]byte("admin@honeypot:~$ "))
+		conn.Write([]byte("admin@" + persona.Current().Hostname + ":~$ "))
 	}
 }
 
@@ -169,15 +245,14 @@ func detectSuspiciousCommand(command, clientAddr string) {
 	}
 }
 
-func blockSuspiciousIP(ip string) {
-	cmd := exec.Command("sudo", "iptables", "-A", "INPUT", "-s", ip, "-j", "DROP")
-	err := cmd.Run()
+func blockSuspiciousIP(addr string) {
+	err := firewall.BanAddr(addr, "suspicious command", "SUSPICIOUS_COMMAND")
 	if err != nil {
-		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
-		logToFile(fmt.Sprintf("Failed to block IP %s: %v", ip, err))
+		logs.Warn(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
+		logToFile(fmt.Sprintf("Failed to block IP %s: %v", addr, err))
 	} else {
-		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", ip))
-		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", ip))
+		logs.Info(fmt.Sprintf("Blocked suspicious IP: %s", addr))
+		logToFile(fmt.Sprintf("Blocked suspicious IP: %s", addr))
 	}
 }
 
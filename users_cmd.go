@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"myhoneypot/auth"
+)
+
+// runUsers implementa o subcomando "users": lista e altera o banco de usuários.
+// Um sensor rodando com auth.users.watch recarrega as mudanças sozinho.
+func runUsers(args []string) int {
+	fs := flag.NewFlagSet("users", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: honeypot users [flags] list | rehash | add <username> | edit <username> | remove <username>")
+		fs.PrintDefaults()
+	}
+	configFile := fs.String("config", configPath, "Configuration file path")
+	backend := fs.String("backend", "", "User database backend: json or sqlite (defaults to auth.users.backend from the config)")
+	file := fs.String("file", "", "User database file (defaults to auth.users.file from the config)")
+	password := fs.String("password", "", "Password for add/edit: plain text (stored as bcrypt), a $2/$5/$6 hash, '*' or '!password'")
+	status := fs.String("status", "active", "Account status for add/edit: active or inactive")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	action, username := fs.Arg(0), fs.Arg(1)
+	if action == "" || (action != "list" && action != "rehash" && username == "") {
+		fs.Usage()
+		return 2
+	}
+	if (action == "add" || action == "edit") && *password == "" {
+		log.Printf("[ERROR] --password is required for %s", action)
+		return 2
+	}
+
+	config := auth.UsersConfig{Backend: *backend, File: *file}
+	if *backend == "" || *file == "" {
+		honeypotConfig, err := loadHoneypotConfig(*configFile)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load configuration: %v", err)
+			return 1
+		}
+		if config.Backend == "" {
+			config.Backend = honeypotConfig.Auth.Users.Backend
+		}
+		if config.File == "" {
+			config.File = honeypotConfig.Auth.Users.File
+		}
+	}
+
+	store, err := auth.OpenUserStore(config)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return 1
+	}
+	defer store.Close()
+
+	switch action {
+	case "list":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "USERNAME\tSTATUS\tPASSWORD")
+		for _, user := range store.Users() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", user.Username, user.Status, user.Password)
+		}
+		if err := w.Flush(); err != nil {
+			log.Printf("[ERROR] %v", err)
+			return 1
+		}
+		return 0
+	case "rehash":
+		changed, err := store.Rehash()
+		if err != nil {
+			log.Printf("[ERROR] Failed to rehash passwords: %v", err)
+			return 1
+		}
+		log.Printf("[INFO] rehash: %d plain-text passwords stored as bcrypt in %s", changed, config.File)
+		return 0
+	case "add":
+		err = store.Add(username, *password, *status)
+	case "edit":
+		err = store.Edit(username, *password, *status)
+	case "remove":
+		err = store.Remove(username)
+	default:
+		log.Printf("[ERROR] Unknown action: %s", action)
+		return 2
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to %s %s: %v", action, username, err)
+		return 1
+	}
+	log.Printf("[INFO] %s %s: saved to %s", action, username, config.File)
+	return 0
+}